@@ -4,18 +4,22 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata" // business timezones must load on hosts without a zoneinfo database
 
 	"github.com/go-chi/cors"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/internal/handler"
 	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
 	"github.com/joshuaolumoye/pos-backend/internal/middleware"
 	"github.com/joshuaolumoye/pos-backend/internal/repository"
 	"github.com/joshuaolumoye/pos-backend/internal/usecase"
 	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -113,16 +117,94 @@ func main() {
 	authRepo := &repository.AuthRepo{}
 
 	saleRepo := repository.NewSaleRepo(db)
+	settingsRepo := &repository.SettingsRepo{DB: db}
+	taxRepo := &repository.TaxRepo{DB: db}
+	promotionRepo := &repository.PromotionRepo{DB: db}
+	customerRepo := &repository.CustomerRepo{DB: db}
+	shiftRepo := &repository.ShiftRepo{DB: db}
 	saleUC := &usecase.SaleUsecase{
 		SaleRepo:       saleRepo,
 		ProductRepo:    productRepo,
+		SettingsRepo:   settingsRepo,
+		TaxRepo:        taxRepo,
+		PromotionRepo:  promotionRepo,
+		CustomerRepo:   customerRepo,
+		ShiftRepo:      shiftRepo,
+		StaffRepo:      staffRepo,
+		BusinessRepo:   businessRepo,
 		NotificationUC: notificationUC,
 	}
 
 	businessUC := &usecase.BusinessUsecase{BusinessRepo: businessRepo, BranchRepo: branchRepo}
 	branchUC := &usecase.BranchUsecase{BranchRepo: branchRepo}
 	staffUC := &usecase.StaffUsecase{StaffRepo: staffRepo}
-	productUC := &usecase.ProductUsecase{ProductRepo: productRepo}
+	reservationRepo := &repository.ReservationRepo{DB: db}
+	productUC := &usecase.ProductUsecase{ProductRepo: productRepo, ReservationRepo: reservationRepo}
+	rolePermissionRepo := &repository.RolePermissionRepo{DB: db}
+	rbacUC := &usecase.RBACUsecase{RolePermissionRepo: rolePermissionRepo}
+	settingsUC := &usecase.SettingsUsecase{SettingsRepo: settingsRepo}
+	refundUC := &usecase.RefundUsecase{
+		RefundRepo:   &repository.RefundRepo{DB: db},
+		SaleRepo:     saleRepo,
+		SettingsRepo: settingsRepo,
+		ShiftRepo:    shiftRepo,
+	}
+	stockMovementRepo := &repository.StockMovementRepo{DB: db}
+	stockUC := &usecase.StockUsecase{
+		StockMovementRepo: stockMovementRepo,
+		ProductRepo:       productRepo,
+		LotRepo:           &repository.LotRepo{DB: db},
+	}
+	// Products created before the stock ledger existed get an opening balance
+	if seeded, err := stockMovementRepo.SeedOpeningBalances(); err != nil {
+		utils.Logger.Fatal("Seeding stock ledger failed", utils.ZapError(err))
+	} else if seeded > 0 {
+		utils.Logger.Info("Seeded stock ledger opening balances", zap.Int("products", seeded))
+	}
+	transferUC := &usecase.TransferUsecase{
+		TransferRepo: &repository.TransferRepo{DB: db},
+		ProductRepo:  productRepo,
+		BranchRepo:   branchRepo,
+	}
+	supplierRepo := &repository.SupplierRepo{DB: db}
+	supplierUC := &usecase.SupplierUsecase{SupplierRepo: supplierRepo}
+	purchaseOrderUC := &usecase.PurchaseOrderUsecase{
+		PurchaseOrderRepo: &repository.PurchaseOrderRepo{DB: db},
+		SupplierRepo:      supplierRepo,
+		ProductRepo:       productRepo,
+		SaleRepo:          saleRepo,
+	}
+	recallUC := &usecase.RecallUsecase{RecallRepo: &repository.RecallRepo{DB: db}}
+	taxUC := &usecase.TaxUsecase{TaxRepo: taxRepo, SettingsRepo: settingsRepo, ProductRepo: productRepo}
+	promotionUC := &usecase.PromotionUsecase{
+		PromotionRepo: promotionRepo,
+		ProductRepo:   productRepo,
+		BranchRepo:    branchRepo,
+	}
+	customerUC := &usecase.CustomerUsecase{CustomerRepo: customerRepo}
+	loyaltyUC := &usecase.LoyaltyUsecase{
+		LoyaltyRepo:  &repository.LoyaltyRepo{DB: db},
+		CustomerRepo: customerRepo,
+		SettingsRepo: settingsRepo,
+	}
+	shiftUC := &usecase.ShiftUsecase{ShiftRepo: shiftRepo, BranchRepo: branchRepo}
+	zReportUC := &usecase.ZReportUsecase{
+		ZReportRepo:  &repository.ZReportRepo{DB: db},
+		SettingsRepo: settingsRepo,
+		BranchRepo:   branchRepo,
+	}
+	creditUC := &usecase.CreditUsecase{
+		CreditRepo:   &repository.CreditRepo{DB: db},
+		CustomerRepo: customerRepo,
+	}
+	syncRepo := &repository.SyncRepo{DB: db}
+	syncUC := &usecase.SyncUsecase{
+		SyncRepo:    syncRepo,
+		SaleRepo:    saleRepo,
+		ProductRepo: productRepo,
+		SaleUC:      saleUC,
+		ProductUC:   productUC,
+	}
 
 	handler.BusinessUC = businessUC
 	handler.BranchUC = branchUC
@@ -130,6 +212,67 @@ func main() {
 	handler.ProductUC = productUC
 	handler.AuthRepo = authRepo
 	handler.SaleUC = saleUC
+	handler.SyncUC = syncUC
+	handler.RBACUC = rbacUC
+	handler.SettingsUC = settingsUC
+	handler.RefundUC = refundUC
+	handler.StockUC = stockUC
+	handler.TransferUC = transferUC
+	handler.SupplierUC = supplierUC
+	handler.PurchaseOrderUC = purchaseOrderUC
+	handler.RecallUC = recallUC
+	handler.TaxUC = taxUC
+	handler.PromotionUC = promotionUC
+	handler.CustomerUC = customerUC
+	handler.LoyaltyUC = loyaltyUC
+	handler.CreditUC = creditUC
+	handler.ShiftUC = shiftUC
+	handler.ZReportUC = zReportUC
+	handler.HeldCartUC = &usecase.HeldCartUsecase{
+		HeldCartRepo: &repository.HeldCartRepo{DB: db},
+		ProductRepo:  productRepo,
+		BranchRepo:   branchRepo,
+		SaleUC:       saleUC,
+	}
+	reservationUC := &usecase.ReservationUsecase{
+		ReservationRepo: reservationRepo,
+		ProductRepo:     productRepo,
+		BranchRepo:      branchRepo,
+	}
+	handler.ReservationUC = reservationUC
+	handler.ReceiptUC = &usecase.ReceiptUsecase{
+		SaleRepo:     saleRepo,
+		BusinessRepo: businessRepo,
+		BranchRepo:   branchRepo,
+		StaffRepo:    staffRepo,
+		ProductRepo:  productRepo,
+		CustomerRepo: customerRepo,
+		SettingsRepo: settingsRepo,
+	}
+	middleware.RBAC = rbacUC
+	idempotencyUC := &usecase.IdempotencyUsecase{IdempotencyRepo: &repository.IdempotencyRepo{DB: db}}
+	middleware.Idempotency = idempotencyUC
+
+	// Periodically verify that the stock ledger still sums to on-hand quantities
+	reconcileInterval := time.Hour
+	if v := os.Getenv("STOCK_RECONCILE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			reconcileInterval = d
+		}
+	}
+	go stockUC.RunReconciliationJob(reconcileInterval)
+
+	// Close reservations whose hold has lapsed
+	expiryInterval := time.Minute
+	if v := os.Getenv("RESERVATION_EXPIRY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			expiryInterval = d
+		}
+	}
+	go reservationUC.RunExpiryJob(expiryInterval)
+
+	// Forget idempotency keys once their responses are past replaying
+	go idempotencyUC.RunCleanupJob(time.Hour)
 
 	r := chi.NewRouter()
 	r.Use(middleware.LoggingMiddleware)
@@ -138,8 +281,8 @@ func main() {
 	corsOptions := cors.Options{
 		AllowedOrigins:   []string{"http://localhost:8081"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", middleware.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}
@@ -151,40 +294,158 @@ func main() {
 	r.Post("/api/staff/login", handler.StaffLoginHandler)
 	r.Get("/health", handler.HealthCheckHandler)
 
-	// Protected endpoints
-	r.Group(func(protected chi.Router) {
-		protected.Use(middleware.AuthMiddleware)
+	// Route policy table: every protected endpoint with the permission it requires.
+	// An empty permission only requires a valid token.
+	// POST endpoints take no query params; GET/PUT/DELETE may use them for filters and resource IDs.
+	protectedRoutes := []struct {
+		method     string
+		pattern    string
+		handler    http.HandlerFunc
+		permission domain.Permission
+		noQuery    bool
+	}{
+		{http.MethodPost, "/api/branch/create", handler.CreateBranchHandler, domain.PermBranchCreate, true},
+		{http.MethodPost, "/api/staff/create", handler.CreateStaffHandler, domain.PermStaffManage, true},
+		{http.MethodPost, "/api/product/add", middleware.Idempotent(handler.AddProductHandler), domain.PermProductCreate, true},
+		{http.MethodPost, "/api/sales/create", middleware.Idempotent(handler.CreateSaleHandler), domain.PermSaleCreate, true},
+		{http.MethodPost, "/api/sync", handler.SyncDataHandler, domain.PermSync, true},
+		{http.MethodPost, "/api/auth/refresh", handler.RefreshTokenHandler, "", true},
+		{http.MethodPost, "/api/product/{id}/stock-adjustments", handler.AdjustStockHandler, domain.PermProductUpdate, true},
+		{http.MethodPost, "/api/product/{id}/lots", handler.ReceiveLotHandler, domain.PermProductUpdate, true},
+		{http.MethodPost, "/api/transfers", handler.CreateTransferHandler, domain.PermTransferManage, true},
+		{http.MethodPost, "/api/transfers/{id}/dispatch", handler.DispatchTransferHandler, domain.PermTransferManage, true},
+		{http.MethodPost, "/api/transfers/{id}/receive", handler.ReceiveTransferHandler, domain.PermTransferReceive, true},
+		{http.MethodPost, "/api/transfers/{id}/cancel", handler.CancelTransferHandler, domain.PermTransferManage, true},
+		{http.MethodPost, "/api/suppliers", handler.CreateSupplierHandler, domain.PermSupplierManage, true},
+		{http.MethodPost, "/api/purchase-orders", handler.CreatePurchaseOrderHandler, domain.PermPurchaseManage, true},
+		{http.MethodPost, "/api/purchase-orders/{id}/send", handler.SendPurchaseOrderHandler, domain.PermPurchaseManage, true},
+		{http.MethodPost, "/api/purchase-orders/{id}/cancel", handler.CancelPurchaseOrderHandler, domain.PermPurchaseManage, true},
+		{http.MethodPost, "/api/purchase-orders/{id}/receive", handler.ReceiveGoodsHandler, domain.PermPurchaseReceive, true},
+		{http.MethodPost, "/api/recalls", handler.CreateRecallHandler, domain.PermRecallManage, true},
+		{http.MethodPost, "/api/recalls/{id}/close", handler.CloseRecallHandler, domain.PermRecallManage, true},
+		{http.MethodPost, "/api/tax-rates", handler.CreateTaxRateHandler, domain.PermSettingsManage, true},
+		{http.MethodPost, "/api/promotions", handler.CreatePromotionHandler, domain.PermPromotionManage, true},
+		{http.MethodPost, "/api/promotions/{id}/end", handler.EndPromotionHandler, domain.PermPromotionManage, true},
+		{http.MethodPost, "/api/customers", handler.CreateCustomerHandler, domain.PermCustomerManage, true},
+		{http.MethodPost, "/api/credit-accounts", handler.CreateCreditAccountHandler, domain.PermCreditManage, true},
+		{http.MethodPost, "/api/credit-accounts/{id}/repayments", handler.RecordRepaymentHandler, domain.PermCreditRepay, true},
+		{http.MethodPost, "/api/shifts", handler.OpenShiftHandler, domain.PermShiftOperate, true},
+		{http.MethodPost, "/api/shifts/{id}/cash-movements", handler.RecordCashMovementHandler, domain.PermShiftOperate, true},
+		{http.MethodPost, "/api/shifts/{id}/close", handler.CloseShiftHandler, domain.PermShiftOperate, true},
+		{http.MethodPost, "/api/z-reports", handler.CloseZReportHandler, domain.PermZReportClose, true},
+		{http.MethodPost, "/api/held-carts", handler.HoldCartHandler, domain.PermSaleCreate, true},
+		{http.MethodPost, "/api/held-carts/{id}/checkout", handler.CheckoutHeldCartHandler, domain.PermSaleCreate, true},
+		{http.MethodPost, "/api/reservations", handler.CreateReservationHandler, domain.PermReservationManage, true},
+		{http.MethodPost, "/api/reservations/{id}/release", handler.ReleaseReservationHandler, domain.PermReservationManage, true},
+		{http.MethodPost, "/api/sales/{id}/void", handler.VoidSaleHandler, domain.PermSaleVoid, true},
+		{http.MethodPost, "/api/sales/{id}/refunds", handler.CreateRefundHandler, domain.PermRefundCreate, true},
+		{http.MethodPost, "/api/refunds/{id}/approve", handler.ApproveRefundHandler, domain.PermRefundApprove, true},
+		{http.MethodPost, "/api/refunds/{id}/reject", handler.RejectRefundHandler, domain.PermRefundApprove, true},
 
-		// POST endpoints - NO query params allowed
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/branch/create", handler.CreateBranchHandler)
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/staff/create", handler.CreateStaffHandler)
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/product/add", handler.AddProductHandler)
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/sales/create", handler.CreateSaleHandler)
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/sync", handler.SyncDataHandler)
-		protected.With(middleware.NoQueryParamsMiddleware).Post("/api/auth/refresh", handler.RefreshTokenHandler)
-
-		// GET endpoints - query params allowed
-		protected.Get("/api/branches", handler.GetBranchesHandler)
-		protected.Get("/api/staff", handler.GetStaffListHandler)
-		protected.Get("/api/staff/details", handler.GetStaffByIDHandler)
-		protected.Get("/api/products", handler.GetProductsHandler)
-		protected.Get("/api/product/{id}", handler.GetProductHandler)
+		{http.MethodGet, "/api/branches", handler.GetBranchesHandler, domain.PermBranchView, false},
+		{http.MethodGet, "/api/staff", handler.GetStaffListHandler, domain.PermStaffView, false},
+		{http.MethodGet, "/api/staff/details", handler.GetStaffByIDHandler, domain.PermStaffView, false},
+		{http.MethodGet, "/api/products", handler.GetProductsHandler, domain.PermProductView, false},
+		{http.MethodGet, "/api/product/{id}", handler.GetProductHandler, domain.PermProductView, false},
+		{http.MethodGet, "/api/product/{id}/movements", handler.GetProductMovementsHandler, domain.PermProductView, false},
+		{http.MethodGet, "/api/product/{id}/lots", handler.GetProductLotsHandler, domain.PermProductView, false},
+		{http.MethodGet, "/api/stock/reconciliation", handler.GetStockReconciliationHandler, domain.PermProductView, false},
+		{http.MethodGet, "/api/transfers", handler.GetTransfersHandler, domain.PermTransferView, false},
+		{http.MethodGet, "/api/transfers/discrepancies", handler.GetTransferDiscrepanciesHandler, domain.PermTransferView, false},
+		{http.MethodGet, "/api/transfers/{id}", handler.GetTransferHandler, domain.PermTransferView, false},
+		{http.MethodGet, "/api/suppliers", handler.GetSuppliersHandler, domain.PermSupplierView, false},
+		{http.MethodGet, "/api/suppliers/{id}", handler.GetSupplierHandler, domain.PermSupplierView, false},
+		{http.MethodPut, "/api/suppliers/{id}", handler.UpdateSupplierHandler, domain.PermSupplierManage, false},
+		{http.MethodDelete, "/api/suppliers/{id}", handler.DeleteSupplierHandler, domain.PermSupplierManage, false},
+		{http.MethodGet, "/api/purchase-orders", handler.GetPurchaseOrdersHandler, domain.PermPurchaseView, false},
+		{http.MethodGet, "/api/purchase-orders/{id}", handler.GetPurchaseOrderHandler, domain.PermPurchaseView, false},
+		{http.MethodGet, "/api/reports/tenders", handler.GetTenderReportHandler, domain.PermDashboardView, false},
+		{http.MethodGet, "/api/reports/discounts", handler.GetDiscountReportHandler, domain.PermDashboardView, false},
+		{http.MethodGet, "/api/reports/sales", handler.GetSalesReportHandler, domain.PermDashboardView, false},
+		{http.MethodGet, "/api/reports/reorder", handler.GetReorderReportHandler, domain.PermPurchaseView, false},
+		{http.MethodGet, "/api/recalls", handler.GetRecallsHandler, domain.PermRecallView, false},
+		{http.MethodGet, "/api/recalls/{id}/report", handler.GetRecallReportHandler, domain.PermRecallView, false},
+		{http.MethodGet, "/api/tax-rates", handler.GetTaxRatesHandler, domain.PermProductView, false},
+		{http.MethodPut, "/api/tax-rates/{id}", handler.UpdateTaxRateHandler, domain.PermSettingsManage, false},
+		{http.MethodDelete, "/api/tax-rates/{id}", handler.DeleteTaxRateHandler, domain.PermSettingsManage, false},
+		{http.MethodGet, "/api/tax-rules", handler.GetTaxRulesHandler, domain.PermProductView, false},
+		{http.MethodPut, "/api/tax-rules", handler.SaveTaxRuleHandler, domain.PermSettingsManage, false},
+		{http.MethodDelete, "/api/tax-rules/{id}", handler.DeleteTaxRuleHandler, domain.PermSettingsManage, false},
+		{http.MethodGet, "/api/reports/tax", handler.GetTaxReportHandler, domain.PermDashboardView, false},
+		{http.MethodGet, "/api/promotions", handler.GetPromotionsHandler, domain.PermPromotionView, false},
+		{http.MethodGet, "/api/promotions/{id}", handler.GetPromotionHandler, domain.PermPromotionView, false},
+		{http.MethodPut, "/api/promotions/{id}", handler.UpdatePromotionHandler, domain.PermPromotionManage, false},
+		{http.MethodGet, "/api/reports/promotions", handler.GetPromotionReportHandler, domain.PermDashboardView, false},
+		{http.MethodGet, "/api/customers", handler.GetCustomersHandler, domain.PermCustomerView, false},
+		{http.MethodGet, "/api/customers/lookup", handler.LookupCustomerHandler, domain.PermCustomerView, false},
+		{http.MethodGet, "/api/customers/{id}", handler.GetCustomerHandler, domain.PermCustomerView, false},
+		{http.MethodPut, "/api/customers/{id}", handler.UpdateCustomerHandler, domain.PermCustomerManage, false},
+		{http.MethodDelete, "/api/customers/{id}", handler.DeleteCustomerHandler, domain.PermCustomerManage, false},
+		{http.MethodGet, "/api/customers/{id}/history", handler.GetCustomerHistoryHandler, domain.PermCustomerView, false},
+		{http.MethodGet, "/api/customers/{id}/loyalty", handler.GetLoyaltyStatementHandler, domain.PermCustomerView, false},
+		{http.MethodGet, "/api/credit-accounts", handler.GetCreditAccountsHandler, domain.PermCreditView, false},
+		{http.MethodGet, "/api/credit-accounts/{id}", handler.GetCreditAccountHandler, domain.PermCreditView, false},
+		{http.MethodPut, "/api/credit-accounts/{id}", handler.UpdateCreditAccountHandler, domain.PermCreditManage, false},
+		{http.MethodGet, "/api/reports/debtors-ageing", handler.GetDebtorsAgeingHandler, domain.PermCreditView, false},
+		{http.MethodGet, "/api/shifts", handler.GetShiftsHandler, domain.PermShiftManage, false},
+		{http.MethodGet, "/api/shifts/current", handler.GetCurrentShiftHandler, domain.PermShiftOperate, false},
+		{http.MethodGet, "/api/shifts/{id}", handler.GetShiftHandler, domain.PermShiftOperate, false},
+		{http.MethodGet, "/api/z-reports", handler.GetZReportsHandler, domain.PermZReportView, false},
+		{http.MethodGet, "/api/z-reports/preview", handler.PreviewZReportHandler, domain.PermZReportView, false},
+		{http.MethodGet, "/api/z-reports/{id}", handler.GetZReportHandler, domain.PermZReportView, false},
 
 		// Notification endpoints
-		protected.Get("/api/notifications", handler.ListNotificationsHandler)
-		protected.Put("/api/notifications/{id}/read", handler.MarkNotificationReadHandler)
-		protected.Put("/api/notifications/read", handler.BatchMarkNotificationsReadHandler)
-		protected.Get("/api/search/products", handler.GetProductNotificationsHandler)
+		{http.MethodGet, "/api/notifications", handler.ListNotificationsHandler, domain.PermNotificationView, false},
+		{http.MethodPut, "/api/notifications/{id}/read", handler.MarkNotificationReadHandler, domain.PermNotificationView, false},
+		{http.MethodPut, "/api/notifications/read", handler.BatchMarkNotificationsReadHandler, domain.PermNotificationView, false},
+		{http.MethodGet, "/api/search/products", handler.GetProductNotificationsHandler, domain.PermProductView, false},
 
 		// Dashboard stats endpoint
-		protected.Get("/api/dashboard/stats", handler.GetDashboardStatsHandler)
-
-		// PUT/DELETE endpoints - query params allowed for resource IDs
-		protected.Put("/api/branch/update", handler.UpdateBranchHandler)
-		protected.Delete("/api/branch/delete", handler.DeleteBranchHandler)
-		protected.Put("/api/staff/update", handler.UpdateStaffHandler)
-		protected.Put("/api/product/{id}", handler.UpdateProductHandler)
-		protected.Delete("/api/product/delete", handler.DeleteProductHandler)
+		{http.MethodGet, "/api/dashboard/stats", handler.GetDashboardStatsHandler, domain.PermDashboardView, false},
+
+		{http.MethodPut, "/api/branch/update", handler.UpdateBranchHandler, domain.PermBranchUpdate, false},
+		{http.MethodDelete, "/api/branch/delete", handler.DeleteBranchHandler, domain.PermBranchDelete, false},
+		{http.MethodPut, "/api/staff/update", handler.UpdateStaffHandler, domain.PermStaffManage, false},
+		{http.MethodPut, "/api/product/{id}", handler.UpdateProductHandler, domain.PermProductUpdate, false},
+		{http.MethodDelete, "/api/product/delete", handler.DeleteProductHandler, domain.PermProductDelete, false},
+
+		// Role permission management
+		{http.MethodGet, "/api/roles/permissions", handler.GetRolePermissionsHandler, domain.PermRoleManage, false},
+		{http.MethodPut, "/api/roles/{role}/permissions", handler.UpdateRolePermissionsHandler, domain.PermRoleManage, false},
+		{http.MethodDelete, "/api/roles/{role}/permissions", handler.ResetRolePermissionsHandler, domain.PermRoleManage, false},
+
+		// Sales
+		{http.MethodGet, "/api/sales", handler.ListSalesHandler, domain.PermSaleView, false},
+		{http.MethodGet, "/api/sales/{id}", handler.GetSaleHandler, domain.PermSaleView, false},
+		{http.MethodGet, "/api/sales/{id}/receipt", handler.GetReceiptHandler, domain.PermSaleView, false},
+		{http.MethodGet, "/api/held-carts", handler.GetHeldCartsHandler, domain.PermSaleCreate, false},
+		{http.MethodGet, "/api/held-carts/{id}", handler.ResumeHeldCartHandler, domain.PermSaleCreate, false},
+		{http.MethodPut, "/api/held-carts/{id}", handler.UpdateHeldCartHandler, domain.PermSaleCreate, false},
+		{http.MethodDelete, "/api/held-carts/{id}", handler.DiscardHeldCartHandler, domain.PermSaleCreate, false},
+		{http.MethodGet, "/api/reservations", handler.GetReservationsHandler, domain.PermReservationView, false},
+		{http.MethodGet, "/api/reservations/{id}", handler.GetReservationHandler, domain.PermReservationView, false},
+
+		// Refunds
+		{http.MethodGet, "/api/sales/{id}/refunds", handler.GetSaleRefundsHandler, domain.PermSaleView, false},
+		{http.MethodGet, "/api/refunds/pending", handler.GetPendingRefundsHandler, domain.PermRefundApprove, false},
+
+		// Business settings
+		{http.MethodGet, "/api/settings", handler.GetSettingsHandler, domain.PermSettingsManage, false},
+		{http.MethodPut, "/api/settings", handler.UpdateSettingsHandler, domain.PermSettingsManage, false},
+	}
+
+	// Protected endpoints
+	r.Group(func(protected chi.Router) {
+		protected.Use(middleware.AuthMiddleware)
+
+		for _, route := range protectedRoutes {
+			chain := []func(http.Handler) http.Handler{}
+			if route.noQuery {
+				chain = append(chain, middleware.NoQueryParamsMiddleware)
+			}
+			chain = append(chain, middleware.RBACMiddleware(route.permission))
+			protected.With(chain...).Method(route.method, route.pattern, route.handler)
+		}
 	})
 
 	utils.Logger.Info("POS Backend starting on :8080...")
@@ -1,6 +1,22 @@
 package domain
 
+// User types carried in auth tokens
+const (
+	UserTypeOwner = "owner"
+	UserTypeStaff = "staff"
+)
+
+// AuthIdentity is who a token was issued to. For owners UserID and BusinessID are both the
+// business ID and BranchID is empty; for staff UserID is the staff UUID.
+type AuthIdentity struct {
+	UserID     string `json:"user_id"`
+	BusinessID string `json:"business_id"`
+	BranchID   string `json:"branch_id,omitempty"`
+	UserType   string `json:"user_type"`
+	Role       string `json:"role"`
+}
+
 type AuthRepository interface {
-	CreateToken(userID string, role string) (accessToken string, refreshToken string, err error)
-	ValidateToken(token string) (userID string, role string, err error)
+	CreateToken(identity *AuthIdentity) (accessToken string, refreshToken string, err error)
+	ValidateToken(token string) (*AuthIdentity, error)
 }
@@ -0,0 +1,98 @@
+package domain
+
+import "errors"
+
+// Credit ledger entry types
+const (
+	CreditEntryCharge    = "charge"
+	CreditEntryRepayment = "repayment"
+	// CreditEntryReversal takes a voided credit sale, or the refunded part of one, off the account
+	CreditEntryReversal = "reversal"
+)
+
+var (
+	ErrCreditAccountNotFound = errors.New("credit account not found")
+	// ErrCreditLimitExceeded is returned when a credit sale would take a debtor over their limit
+	ErrCreditLimitExceeded = errors.New("credit limit exceeded")
+)
+
+// CreditAccount lets a customer buy on credit up to CreditLimit. Balance is what they owe.
+type CreditAccount struct {
+	ID          string  `json:"id"`
+	BusinessID  string  `json:"business_id"`
+	CustomerID  string  `json:"customer_id"`
+	CreditLimit float64 `json:"credit_limit"`
+	Balance     float64 `json:"balance"`
+	// Suspended accounts take repayments but no new credit sales
+	Active    bool   `json:"active"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	// Filled in for listings
+	CustomerName  string `json:"customer_name,omitempty"`
+	CustomerPhone string `json:"customer_phone,omitempty"`
+}
+
+// CreditEntry is one movement on a credit account. Amount is positive for charges and
+// negative for repayments and reversals. Outstanding is the part of a charge not yet repaid;
+// repayments settle the oldest charges first.
+type CreditEntry struct {
+	ID          string  `json:"id"`
+	AccountID   string  `json:"account_id"`
+	BusinessID  string  `json:"business_id"`
+	BranchID    string  `json:"branch_id,omitempty"`
+	Type        string  `json:"type"`
+	Amount      float64 `json:"amount"`
+	Outstanding float64 `json:"outstanding"`
+	SaleID      *string `json:"sale_id,omitempty"`
+	// How a repayment was made, e.g. cash or transfer
+	Method     string `json:"method,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+	Note       string `json:"note,omitempty"`
+	RecordedBy string `json:"recorded_by"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// DebtorAgeing splits what one debtor owes by how long the unpaid charges have been outstanding
+type DebtorAgeing struct {
+	AccountID     string  `json:"account_id"`
+	CustomerID    string  `json:"customer_id"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerPhone string  `json:"customer_phone,omitempty"`
+	CreditLimit   float64 `json:"credit_limit"`
+	Current       float64 `json:"current"`      // 0-30 days
+	Days31To60    float64 `json:"days_31_60"`   // 31-60 days
+	Days61To90    float64 `json:"days_61_90"`   // 61-90 days
+	Over90Days    float64 `json:"over_90_days"` // more than 90 days
+	Total         float64 `json:"total"`
+}
+
+// Add puts an outstanding amount into the bucket for its age in days
+func (a *DebtorAgeing) Add(amount float64, ageDays int) {
+	switch {
+	case ageDays <= 30:
+		a.Current += amount
+	case ageDays <= 60:
+		a.Days31To60 += amount
+	case ageDays <= 90:
+		a.Days61To90 += amount
+	default:
+		a.Over90Days += amount
+	}
+	a.Total += amount
+}
+
+type CreditRepository interface {
+	CreateAccount(a *CreditAccount) error
+	GetAccountByID(id string) (*CreditAccount, error)
+	GetAccountByCustomerID(customerID string) (*CreditAccount, error)
+	GetAccountsByBusinessID(businessID string) ([]*CreditAccount, error)
+	// UpdateAccount saves the limit and status; the balance only changes through the ledger
+	UpdateAccount(a *CreditAccount) error
+	// RecordRepayment reduces the balance and settles the oldest outstanding charges; a
+	// repayment cannot exceed the balance
+	RecordRepayment(entry *CreditEntry) (*CreditAccount, error)
+	GetEntries(accountID string, limit, offset int) ([]*CreditEntry, error)
+	// GetOutstandingCharges returns the business's charges not yet fully repaid, oldest first
+	GetOutstandingCharges(businessID string) ([]*CreditEntry, error)
+}
@@ -0,0 +1,38 @@
+package domain
+
+type Customer struct {
+	ID         string `json:"id"`
+	BusinessID string `json:"business_id"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// CustomerLifetime sums up a customer's non-voided purchases. LifetimeValue is what they
+// spent less completed refunds.
+type CustomerLifetime struct {
+	SalesCount      int     `json:"sales_count"`
+	TotalSpent      float64 `json:"total_spent"`
+	TotalRefunded   float64 `json:"total_refunded"`
+	LifetimeValue   float64 `json:"lifetime_value"`
+	AverageSale     float64 `json:"average_sale"`
+	FirstPurchaseAt *int64  `json:"first_purchase_at,omitempty"`
+	LastPurchaseAt  *int64  `json:"last_purchase_at,omitempty"`
+}
+
+type CustomerRepository interface {
+	CreateCustomer(c *Customer) error
+	GetCustomerByID(id string) (*Customer, error)
+	// GetCustomerByPhone finds a business's customer by normalised phone number
+	GetCustomerByPhone(businessID, phone string) (*Customer, error)
+	// GetCustomersByBusinessID lists customers by name, optionally matching search against name, phone or email
+	GetCustomersByBusinessID(businessID, search string, limit, offset int) ([]*Customer, error)
+	UpdateCustomer(c *Customer) error
+	DeleteCustomer(id string) error
+	// GetCustomerSales returns a customer's sales with their items, newest first, optionally in one branch
+	GetCustomerSales(customerID, branchID string, limit, offset int) ([]*Sale, error)
+	GetCustomerLifetime(customerID, branchID string) (*CustomerLifetime, error)
+}
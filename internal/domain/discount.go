@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeFixed   = "fixed"
+)
+
+var (
+	// ErrInvalidDiscount is returned for malformed discounts or discounts given without a reason
+	ErrInvalidDiscount = errors.New("invalid discount")
+	// ErrDiscountLimit is returned when a discount exceeds what the user's role may give
+	ErrDiscountLimit = errors.New("discount exceeds your limit")
+)
+
+// DefaultMaxDiscountPercent is the largest discount, as a percentage of each line's list
+// price, a role may give until the business configures its own limits. Owners are unlimited.
+var DefaultMaxDiscountPercent = map[StaffRole]float64{
+	RoleManager:   50,
+	RoleCashier:   10,
+	RoleInventory: 0,
+}
+
+// Discount is a percentage or a fixed amount taken off a line or the whole basket
+type Discount struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// CashierDiscountTotal is the discount one cashier gave over a period
+type CashierDiscountTotal struct {
+	CashierID      string  `json:"cashier_id"`
+	CashierName    string  `json:"cashier_name"`
+	SalesCount     int     `json:"sales_count"`
+	GrossAmount    float64 `json:"gross_amount"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+// SalePricing is the business policy a sale is priced under
+type SalePricing struct {
+	// Largest discount the seller may give, as a percentage of each line's list value
+	DiscountLimit float64
+	// Tax policy; nil prices the sale without tax
+	Tax *TaxPolicy
+	// Promotions running when the sale was made
+	Promotions []*Promotion
+}
+
+// PriceSale applies pricing.Promotions, then the line discounts and then the basket discount to
+// items whose ListPrice is set, then tax at each item's TaxRate under pricing.Tax. The basket
+// discount is spread over the lines in proportion to their value so every SaleItem carries the
+// price actually paid, which refunds rely on. Each line's discount, not counting promotions,
+// must stay within pricing.DiscountLimit percent of its list value, and any discount needs
+// sale.DiscountReason. Sets the items' UnitPrice, Subtotal, PromotionID, PromotionAmount,
+// DiscountAmount and TaxAmount and the sale's GrossAmount, PromotionAmount, DiscountAmount,
+// TaxAmount, TaxMode and TotalAmount.
+func PriceSale(sale *Sale, items []SaleItem, pricing *SalePricing) error {
+	gross := make([]int64, len(items))
+	net := make([]int64, len(items))
+	for i, it := range items {
+		gross[i] = toKobo(it.ListPrice * float64(it.Quantity))
+	}
+	promo := applyPromotions(pricing.Promotions, items, gross)
+	var grossTotal, promoTotal, netTotal int64
+	for i, it := range items {
+		off, err := discountKobo(it.Discount, gross[i]-promo[i])
+		if err != nil {
+			return err
+		}
+		net[i] = gross[i] - promo[i] - off
+		items[i].PromotionAmount = fromKobo(promo[i])
+		grossTotal += gross[i]
+		promoTotal += promo[i]
+		netTotal += net[i]
+	}
+
+	basketOff, err := discountKobo(sale.Discount, netTotal)
+	if err != nil {
+		return err
+	}
+	// Spread the basket discount by line value; rounding leftovers go to the last line that can take them
+	remaining := basketOff
+	for i := range items {
+		if netTotal == 0 || remaining == 0 {
+			break
+		}
+		share := basketOff * net[i] / netTotal
+		if i == len(items)-1 || share > remaining {
+			share = remaining
+		}
+		if share > net[i] {
+			share = net[i]
+		}
+		net[i] -= share
+		remaining -= share
+	}
+	for i := len(items) - 1; i >= 0 && remaining > 0; i-- {
+		take := remaining
+		if take > net[i] {
+			take = net[i]
+		}
+		net[i] -= take
+		remaining -= take
+	}
+
+	var discountTotal int64
+	for i := range items {
+		off := gross[i] - promo[i] - net[i]
+		if off > 0 && float64(off)*100 > pricing.DiscountLimit*float64(gross[i]) {
+			return fmt.Errorf("%w: %.0f%% off product %s, the most you may give is %.0f%%",
+				ErrDiscountLimit, float64(off)*100/float64(gross[i]), items[i].ProductID, pricing.DiscountLimit)
+		}
+		items[i].DiscountAmount = fromKobo(off)
+		discountTotal += off
+	}
+	if discountTotal > 0 && sale.DiscountReason == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidDiscount)
+	}
+
+	var taxTotal, total int64
+	if pricing.Tax != nil {
+		taxTotal = applyTax(pricing.Tax, items, net)
+		sale.TaxMode = pricing.Tax.Mode
+	}
+	for i := range items {
+		items[i].Subtotal = fromKobo(net[i])
+		items[i].UnitPrice = items[i].Subtotal / float64(items[i].Quantity)
+		total += net[i]
+	}
+	sale.GrossAmount = fromKobo(grossTotal)
+	sale.PromotionAmount = fromKobo(promoTotal)
+	sale.DiscountAmount = fromKobo(discountTotal)
+	sale.TaxAmount = fromKobo(taxTotal)
+	sale.TotalAmount = fromKobo(total)
+	return nil
+}
+
+// discountKobo returns how much d takes off an amount, in kobo
+func discountKobo(d *Discount, amount int64) (int64, error) {
+	if d == nil || d.Value == 0 {
+		return 0, nil
+	}
+	if d.Value < 0 {
+		return 0, fmt.Errorf("%w: value cannot be negative", ErrInvalidDiscount)
+	}
+	switch d.Type {
+	case DiscountTypePercent:
+		if d.Value > 100 {
+			return 0, fmt.Errorf("%w: percentage cannot exceed 100", ErrInvalidDiscount)
+		}
+		return toKobo(fromKobo(amount) * d.Value / 100), nil
+	case DiscountTypeFixed:
+		off := toKobo(d.Value)
+		if off > amount {
+			return 0, fmt.Errorf("%w: %.2f is more than the amount it applies to", ErrInvalidDiscount, d.Value)
+		}
+		return off, nil
+	default:
+		return 0, fmt.Errorf("%w: type must be percent or fixed", ErrInvalidDiscount)
+	}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// priceLines prices items on a sale with the given basket discount and describes each line as
+// "subtotal -discount +tax", followed by the sale's "= total (tax)"
+func priceLines(t *testing.T, basket *Discount, items []SaleItem, pricing SalePricing) string {
+	t.Helper()
+	sale := &Sale{Discount: basket, DiscountReason: "damaged box"}
+	if err := PriceSale(sale, items, &pricing); err != nil {
+		t.Fatalf("PriceSale() error = %v", err)
+	}
+	var lines []string
+	for _, it := range items {
+		lines = append(lines, fmt.Sprintf("%.2f -%.2f +%.2f", it.Subtotal, it.DiscountAmount, it.TaxAmount))
+	}
+	return fmt.Sprintf("%s = %.2f (%.2f)", strings.Join(lines, ", "), sale.TotalAmount, sale.TaxAmount)
+}
+
+func TestPriceSaleTax(t *testing.T) {
+	inclusive := SalePricing{Tax: &TaxPolicy{Mode: TaxModeInclusive}}
+	exclusive := SalePricing{Tax: &TaxPolicy{Mode: TaxModeExclusive}}
+
+	tests := []struct {
+		name    string
+		items   []SaleItem
+		pricing SalePricing
+		want    string
+	}{
+		{"no tax policy", []SaleItem{{ListPrice: 100, Quantity: 2, TaxRate: 7.5}, {ListPrice: 50, Quantity: 1}}, SalePricing{}, "200.00 -0.00 +0.00, 50.00 -0.00 +0.00 = 250.00 (0.00)"},
+		{"inclusive tax is carved out of the price", []SaleItem{{ListPrice: 107.5, Quantity: 1, TaxRate: 7.5}}, inclusive, "107.50 -0.00 +7.50 = 107.50 (7.50)"},
+		{"inclusive tax rounds the net amount to the kobo", []SaleItem{{ListPrice: 100, Quantity: 1, TaxRate: 7.5}}, inclusive, "100.00 -0.00 +6.98 = 100.00 (6.98)"},
+		{"exclusive tax is added on top", []SaleItem{{ListPrice: 100, Quantity: 1, TaxRate: 7.5}}, exclusive, "107.50 -0.00 +7.50 = 107.50 (7.50)"},
+		{"exclusive tax rounds half up to the kobo", []SaleItem{{ListPrice: 33.33, Quantity: 1, TaxRate: 7.5}, {ListPrice: 10, Quantity: 1}}, exclusive, "35.83 -0.00 +2.50, 10.00 -0.00 +0.00 = 45.83 (2.50)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceLines(t, nil, tt.items, tt.pricing); got != tt.want {
+				t.Errorf("priced %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceSaleDiscounts(t *testing.T) {
+	fixed := func(v float64) *Discount { return &Discount{Type: DiscountTypeFixed, Value: v} }
+	percent := func(v float64) *Discount { return &Discount{Type: DiscountTypePercent, Value: v} }
+
+	tests := []struct {
+		name    string
+		basket  *Discount
+		items   []SaleItem
+		pricing SalePricing
+		want    string
+	}{
+		{"basket discount is spread by line value", fixed(10), []SaleItem{{ListPrice: 30, Quantity: 2}, {ListPrice: 40, Quantity: 1}}, SalePricing{DiscountLimit: 100}, "54.00 -6.00 +0.00, 36.00 -4.00 +0.00 = 90.00 (0.00)"},
+		{"basket rounding goes to the last line", fixed(10), []SaleItem{{ListPrice: 10, Quantity: 1}, {ListPrice: 10, Quantity: 1}, {ListPrice: 10, Quantity: 1}}, SalePricing{DiscountLimit: 100}, "6.67 -3.33 +0.00, 6.67 -3.33 +0.00, 6.66 -3.34 +0.00 = 20.00 (0.00)"},
+		{"line discount at the limit", nil, []SaleItem{{ListPrice: 50, Quantity: 2, Discount: percent(10)}}, SalePricing{DiscountLimit: 10}, "90.00 -10.00 +0.00 = 90.00 (0.00)"},
+		{"discounts come off before exclusive tax", nil, []SaleItem{{ListPrice: 100, Quantity: 1, TaxRate: 7.5, Discount: percent(10)}}, SalePricing{DiscountLimit: 10, Tax: &TaxPolicy{Mode: TaxModeExclusive}}, "96.75 -10.00 +6.75 = 96.75 (6.75)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceLines(t, tt.basket, tt.items, tt.pricing); got != tt.want {
+				t.Errorf("priced %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceSaleRejectsDiscounts(t *testing.T) {
+	over := &Sale{DiscountReason: "promo"}
+	items := []SaleItem{{ListPrice: 50, Quantity: 2, Discount: &Discount{Type: DiscountTypePercent, Value: 20}}}
+	if err := PriceSale(over, items, &SalePricing{DiscountLimit: 10}); !errors.Is(err, ErrDiscountLimit) {
+		t.Errorf("line discount over the limit: error = %v, want %v", err, ErrDiscountLimit)
+	}
+
+	// The limit applies to each line's share of a basket discount too
+	basket := &Sale{Discount: &Discount{Type: DiscountTypeFixed, Value: 15}, DiscountReason: "promo"}
+	items = []SaleItem{{ListPrice: 50, Quantity: 1}, {ListPrice: 50, Quantity: 1}}
+	if err := PriceSale(basket, items, &SalePricing{DiscountLimit: 5}); !errors.Is(err, ErrDiscountLimit) {
+		t.Errorf("basket discount over the limit: error = %v, want %v", err, ErrDiscountLimit)
+	}
+
+	unexplained := &Sale{Discount: &Discount{Type: DiscountTypeFixed, Value: 5}}
+	if err := PriceSale(unexplained, []SaleItem{{ListPrice: 50, Quantity: 1}}, &SalePricing{DiscountLimit: 100}); !errors.Is(err, ErrInvalidDiscount) {
+		t.Errorf("discount without a reason: error = %v, want %v", err, ErrInvalidDiscount)
+	}
+
+	tooBig := &Sale{Discount: &Discount{Type: DiscountTypeFixed, Value: 60}, DiscountReason: "promo"}
+	if err := PriceSale(tooBig, []SaleItem{{ListPrice: 50, Quantity: 1}}, &SalePricing{DiscountLimit: 100}); !errors.Is(err, ErrInvalidDiscount) {
+		t.Errorf("discount larger than the basket: error = %v, want %v", err, ErrInvalidDiscount)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "errors"
+
+// Held cart warning types, reported when a cart is resumed
+const (
+	HeldCartWarningPriceChanged      = "price_changed"
+	HeldCartWarningInsufficientStock = "insufficient_stock"
+	HeldCartWarningUnavailable       = "product_unavailable"
+)
+
+var ErrHeldCartNotFound = errors.New("held cart not found")
+
+// HeldCart is a basket parked at a branch while the till serves someone else. It does not
+// reserve stock; any till in the branch can resume it and turn it into a sale.
+type HeldCart struct {
+	ID             string         `json:"id"`
+	BusinessID     string         `json:"business_id"`
+	BranchID       string         `json:"branch_id"`
+	Label          string         `json:"label"`
+	HeldBy         string         `json:"held_by"`
+	CustomerID     *string        `json:"customer_id,omitempty"`
+	CustomerPhone  string         `json:"customer_phone,omitempty"`
+	Items          []HeldCartItem `json:"items"`
+	Discount       *Discount      `json:"discount,omitempty"`
+	DiscountReason string         `json:"discount_reason,omitempty"`
+	CreatedAt      int64          `json:"created_at"`
+	UpdatedAt      int64          `json:"updated_at"`
+}
+
+// HeldCartItem is a line of a held cart with the product's name and price when it was held
+type HeldCartItem struct {
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+	UnitPrice   float64   `json:"unit_price"`
+	Discount    *Discount `json:"discount,omitempty"`
+}
+
+// HeldCartWarning tells the till what changed about a product since its cart was held
+type HeldCartWarning struct {
+	Type         string  `json:"type"`
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	HeldPrice    float64 `json:"held_price,omitempty"`
+	CurrentPrice float64 `json:"current_price,omitempty"`
+	Quantity     int     `json:"quantity,omitempty"`
+	InStock      int     `json:"in_stock,omitempty"`
+}
+
+type HeldCartRepository interface {
+	CreateHeldCart(c *HeldCart) error
+	GetHeldCartByID(id string) (*HeldCart, error)
+	// GetHeldCarts lists a business's held carts, oldest first, optionally in one branch
+	GetHeldCarts(businessID, branchID string) ([]*HeldCart, error)
+	// UpdateHeldCart saves the cart's label, customer, discount and items
+	UpdateHeldCart(c *HeldCart) error
+	// DeleteHeldCart removes a cart, failing with ErrHeldCartNotFound if it is already gone
+	DeleteHeldCart(id string) error
+}
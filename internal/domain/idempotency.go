@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long a request's response is kept to replay to retries with the same key
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyLease is how long a running request holds its key. A claim older than this
+// without a response was abandoned, e.g. by a crash, and a retry may take it over.
+const IdempotencyLease = time.Minute
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 255 characters")
+	// ErrIdempotencyKeyReused is returned when a key comes back with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key has already been used for a different request")
+	// ErrIdempotencyKeyInFlight is returned when a retry arrives while the first request is still running
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyRecord remembers a request sent with an Idempotency-Key and, once it has
+// succeeded, the response to replay. StatusCode is 0 while the request is running, which
+// it has been doing since ClaimedAt.
+type IdempotencyRecord struct {
+	ID          string
+	BusinessID  string
+	Key         string
+	UserID      string
+	Endpoint    string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Response    string
+	ClaimedAt   int64
+	CreatedAt   int64
+}
+
+type IdempotencyRepository interface {
+	// ClaimIdempotencyKey saves rec as running and returns nil, or returns the record that
+	// already holds the key. Records created before expiredBefore no longer hold their key.
+	ClaimIdempotencyKey(rec *IdempotencyRecord, expiredBefore int64) (*IdempotencyRecord, error)
+	// TakeOverIdempotencyKey re-claims a key at claimedAt if its request has still not
+	// answered and was claimed before abandonedBefore, reporting whether it did
+	TakeOverIdempotencyKey(businessID, key string, claimedAt, abandonedBefore int64) (bool, error)
+	// SaveIdempotentResponse stores the response of a claimed key's request
+	SaveIdempotentResponse(businessID, key string, statusCode int, contentType, response string) error
+	// DeleteIdempotencyKey frees a key so the request can be retried
+	DeleteIdempotencyKey(businessID, key string) error
+	// DeleteIdempotencyKeysBefore removes records created before the given time and returns how many
+	DeleteIdempotencyKeysBefore(before int64) (int, error)
+}
@@ -0,0 +1,49 @@
+package domain
+
+// ProductLot is a batch of a product received together, with its own expiry and cost.
+// A product's quantity in stock may also include unlotted units (stock from before lots
+// were tracked, or received without a batch number); those are sold after the lots.
+type ProductLot struct {
+	ID               string  `json:"id"`
+	BusinessID       string  `json:"business_id"`
+	BranchID         string  `json:"branch_id"`
+	ProductID        string  `json:"product_id"`
+	BatchNumber      string  `json:"batch_number"`
+	ExpiryDate       *int64  `json:"expiry_date,omitempty"`
+	Quantity         int     `json:"quantity"`
+	ReceivedQuantity int     `json:"received_quantity"`
+	CostPrice        float64 `json:"cost_price"`
+	SupplierID       *string `json:"supplier_id,omitempty"`
+	ReceivedAt       int64   `json:"received_at"`
+	Expired          bool    `json:"expired"`
+}
+
+// SaleItemLot records how many units of a sale item came out of which lot, for recalls
+type SaleItemLot struct {
+	ID               string `json:"id"`
+	SaleID           string `json:"sale_id"`
+	SaleItemID       string `json:"sale_item_id"`
+	LotID            string `json:"lot_id"`
+	ProductID        string `json:"product_id"`
+	BatchNumber      string `json:"batch_number"`
+	ExpiryDate       *int64 `json:"expiry_date,omitempty"`
+	Quantity         int    `json:"quantity"`
+	ReturnedQuantity int    `json:"returned_quantity"`
+}
+
+// LotReceipt is stock arriving as a batch
+type LotReceipt struct {
+	ProductID   string
+	BatchNumber string
+	ExpiryDate  *int64
+	Quantity    int
+	CostPrice   float64
+	SupplierID  *string
+	Change      StockChange
+}
+
+type LotRepository interface {
+	// ReceiveLot adds the units to the product's stock and to the lot with the same batch number, creating it if needed
+	ReceiveLot(receipt *LotReceipt) (*ProductLot, error)
+	GetLotsByProduct(productID string, includeEmpty bool) ([]*ProductLot, error)
+}
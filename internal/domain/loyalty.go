@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"math"
+)
+
+// Loyalty ledger entry types. Earned points are spent and expire oldest first.
+const (
+	LoyaltyEntryEarn   = "earn"
+	LoyaltyEntryRedeem = "redeem"
+	LoyaltyEntryExpire = "expire"
+	// LoyaltyEntryReversal undoes the points earned or redeemed on a voided or refunded sale
+	LoyaltyEntryReversal = "reversal"
+)
+
+// Loyalty defaults used until a business configures its programme
+const (
+	DefaultLoyaltyEarnRate   = 0.01 // one point per ₦100
+	DefaultLoyaltyPointValue = 1.0
+	DefaultLoyaltyExpiryDays = 365
+)
+
+// ErrInsufficientPoints is returned when a customer redeems more points than they hold
+var ErrInsufficientPoints = errors.New("insufficient loyalty points")
+
+// LoyaltyPolicy is how a business's customers earn and redeem points
+type LoyaltyPolicy struct {
+	// Points earned per currency unit spent
+	EarnRate float64
+	// Currency value of one point when redeemed
+	PointValue float64
+	// Categories whose sales earn no points
+	ExcludedCategories map[string]bool
+	// Days after which earned points lapse; 0 means never
+	ExpiryDays int
+}
+
+// PointsFor returns how many points pay for amount, rounding up to a whole point
+func (p *LoyaltyPolicy) PointsFor(amount float64) int {
+	value := toKobo(p.PointValue)
+	if value <= 0 {
+		return 0
+	}
+	return int((toKobo(amount) + value - 1) / value)
+}
+
+// PointsEarned returns the points a sale earns. Only lines outside the excluded categories
+// count, and only the share of the sale not paid for with points.
+func (p *LoyaltyPolicy) PointsEarned(sale *Sale, items []SaleItem) int {
+	if sale.TotalAmount <= 0 {
+		return 0
+	}
+	var eligible, redeemed float64
+	for _, it := range items {
+		if !p.ExcludedCategories[it.Category] {
+			eligible += it.Subtotal
+		}
+	}
+	for _, pay := range sale.Payments {
+		if pay.Method == PaymentMethodLoyalty {
+			redeemed += pay.Amount
+		}
+	}
+	eligible *= (sale.TotalAmount - redeemed) / sale.TotalAmount
+	return int(math.Floor(eligible*p.EarnRate + 1e-9))
+}
+
+// ExpiresAt returns when points earned at the given time lapse, or nil if they never do
+func (p *LoyaltyPolicy) ExpiresAt(earnedAt int64) *int64 {
+	if p.ExpiryDays <= 0 {
+		return nil
+	}
+	t := earnedAt + int64(p.ExpiryDays)*24*60*60
+	return &t
+}
+
+// LoyaltyEntry is one movement on a customer's points balance. Points is negative for
+// redemptions, expiries and clawbacks.
+type LoyaltyEntry struct {
+	ID         string  `json:"id"`
+	BusinessID string  `json:"business_id"`
+	CustomerID string  `json:"customer_id"`
+	SaleID     *string `json:"sale_id,omitempty"`
+	Type       string  `json:"type"`
+	Points     int     `json:"points"`
+	// When the earned points lapse
+	ExpiresAt *int64 `json:"expires_at,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// LoyaltyStatement is a customer's points balance and their earn/burn history, newest first
+type LoyaltyStatement struct {
+	CustomerID   string          `json:"customer_id"`
+	Balance      int             `json:"balance"`
+	BalanceValue float64         `json:"balance_value"`
+	Entries      []*LoyaltyEntry `json:"entries"`
+}
+
+type LoyaltyRepository interface {
+	// GetLoyaltyBalance records any points that have lapsed and returns the customer's balance
+	GetLoyaltyBalance(customerID string) (int, error)
+	GetLoyaltyEntries(customerID string, limit, offset int) ([]*LoyaltyEntry, error)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodTransfer = "transfer"
+	PaymentMethodCard     = "card"
+	// PaymentMethodSplit is stored as a sale's payment method when it was paid with several tenders
+	PaymentMethodSplit = "split"
+	// PaymentMethodLoyalty pays with the customer's loyalty points; accepted whenever the programme is enabled
+	PaymentMethodLoyalty = "loyalty"
+	// PaymentMethodCredit charges the sale to the customer's credit account; accepted for any customer with an active account
+	PaymentMethodCredit = "credit"
+)
+
+// DefaultPaymentMethods are accepted until a business configures its own list
+var DefaultPaymentMethods = []string{PaymentMethodCash, PaymentMethodTransfer, PaymentMethodCard}
+
+// ErrInvalidPayment is returned when a sale's tenders are not acceptable
+var ErrInvalidPayment = errors.New("invalid payment")
+
+// SalePayment is one tender used to pay for a sale. Amount is what the tender
+// contributed to the sale; for cash, Tendered may be higher and the difference
+// was handed back as change.
+type SalePayment struct {
+	ID        string  `json:"id"`
+	SaleID    string  `json:"sale_id"`
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	Tendered  float64 `json:"tendered"`
+	Reference string  `json:"reference,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+// Tenders returns how the sale was paid. Sales recorded before split payments have no
+// payment rows and count as one tender of their payment method.
+func (s *Sale) Tenders() []SalePayment {
+	if len(s.Payments) > 0 || s.PaymentMethod == "" {
+		return s.Payments
+	}
+	return []SalePayment{{SaleID: s.ID, Method: s.PaymentMethod, Amount: s.TotalAmount}}
+}
+
+// TenderTotal is the revenue taken through one payment method
+type TenderTotal struct {
+	Method     string  `json:"method"`
+	Amount     float64 `json:"amount"`
+	SalesCount int     `json:"sales_count"`
+}
+
+// SettlePayments checks the tenders against the sale total and returns the change due.
+// Tenders must cover the total; only cash may exceed it, and the excess (which cannot be
+// more than the cash tendered) is given back as change. Each payment's Amount is set to
+// what it contributed to the sale. A single tender without an amount pays the total exactly,
+// for clients that only send a payment method.
+func SettlePayments(total float64, payments []SalePayment) (float64, error) {
+	if len(payments) == 0 {
+		return 0, fmt.Errorf("%w: at least one payment is required", ErrInvalidPayment)
+	}
+	if len(payments) == 1 && payments[0].Tendered == 0 {
+		payments[0].Tendered = total
+	}
+
+	totalKobo := toKobo(total)
+	var paidKobo, cashKobo int64
+	for _, p := range payments {
+		if p.Tendered <= 0 {
+			return 0, fmt.Errorf("%w: payment amounts must be greater than 0", ErrInvalidPayment)
+		}
+		paidKobo += toKobo(p.Tendered)
+		if p.Method == PaymentMethodCash {
+			cashKobo += toKobo(p.Tendered)
+		}
+	}
+	if paidKobo < totalKobo {
+		return 0, fmt.Errorf("%w: payments of %.2f do not cover the sale total of %.2f", ErrInvalidPayment, fromKobo(paidKobo), total)
+	}
+	changeKobo := paidKobo - totalKobo
+	if changeKobo > cashKobo {
+		return 0, fmt.Errorf("%w: only cash payments can exceed the sale total", ErrInvalidPayment)
+	}
+
+	// Change comes out of the cash tenders, last one first
+	remaining := changeKobo
+	for i := len(payments) - 1; i >= 0; i-- {
+		amount := toKobo(payments[i].Tendered)
+		if payments[i].Method == PaymentMethodCash && remaining > 0 {
+			take := remaining
+			if take > amount {
+				take = amount
+			}
+			amount -= take
+			remaining -= take
+		}
+		payments[i].Amount = fromKobo(amount)
+		payments[i].Tendered = fromKobo(toKobo(payments[i].Tendered))
+	}
+	return fromKobo(changeKobo), nil
+}
+
+func toKobo(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromKobo(v int64) float64 {
+	return float64(v) / 100
+}
@@ -0,0 +1,149 @@
+package domain
+
+import "errors"
+
+// ErrForbidden is returned when a user's role does not grant the permission a request needs
+var ErrForbidden = errors.New("forbidden")
+
+// Permission is a single action a role may be granted
+type Permission string
+
+const (
+	PermBranchView        Permission = "branch.view"
+	PermBranchCreate      Permission = "branch.create"
+	PermBranchUpdate      Permission = "branch.update"
+	PermBranchDelete      Permission = "branch.delete"
+	PermStaffView         Permission = "staff.view"
+	PermStaffManage       Permission = "staff.manage"
+	PermProductView       Permission = "product.view"
+	PermProductCreate     Permission = "product.create"
+	PermProductUpdate     Permission = "product.update"
+	PermProductDelete     Permission = "product.delete"
+	PermTransferView      Permission = "transfer.view"
+	PermTransferManage    Permission = "transfer.manage"
+	PermTransferReceive   Permission = "transfer.receive"
+	PermSupplierView      Permission = "supplier.view"
+	PermSupplierManage    Permission = "supplier.manage"
+	PermPurchaseView      Permission = "purchase.view"
+	PermPurchaseManage    Permission = "purchase.manage"
+	PermPurchaseReceive   Permission = "purchase.receive"
+	PermRecallView        Permission = "recall.view"
+	PermRecallManage      Permission = "recall.manage"
+	PermPromotionView     Permission = "promotion.view"
+	PermPromotionManage   Permission = "promotion.manage"
+	PermCustomerView      Permission = "customer.view"
+	PermCustomerManage    Permission = "customer.manage"
+	PermCreditView        Permission = "credit.view"
+	PermCreditManage      Permission = "credit.manage"
+	PermCreditRepay       Permission = "credit.repay"
+	PermShiftOperate      Permission = "shift.operate"
+	PermShiftManage       Permission = "shift.manage"
+	PermZReportView       Permission = "zreport.view"
+	PermZReportClose      Permission = "zreport.close"
+	PermReservationView   Permission = "reservation.view"
+	PermReservationManage Permission = "reservation.manage"
+	PermSaleView          Permission = "sale.view"
+	PermSaleCreate        Permission = "sale.create"
+	PermSaleVoid          Permission = "sale.void"
+	PermRefundCreate      Permission = "refund.create"
+	PermRefundApprove     Permission = "refund.approve"
+	PermSync              Permission = "sync"
+	PermNotificationView  Permission = "notification.view"
+	PermDashboardView     Permission = "dashboard.view"
+	PermRoleManage        Permission = "role.manage"
+	PermSettingsManage    Permission = "settings.manage"
+)
+
+// AllPermissions lists every permission that can be granted to a role
+var AllPermissions = []Permission{
+	PermBranchView, PermBranchCreate, PermBranchUpdate, PermBranchDelete,
+	PermStaffView, PermStaffManage,
+	PermProductView, PermProductCreate, PermProductUpdate, PermProductDelete,
+	PermTransferView, PermTransferManage, PermTransferReceive,
+	PermSupplierView, PermSupplierManage,
+	PermPurchaseView, PermPurchaseManage, PermPurchaseReceive,
+	PermRecallView, PermRecallManage,
+	PermPromotionView, PermPromotionManage,
+	PermCustomerView, PermCustomerManage,
+	PermCreditView, PermCreditManage, PermCreditRepay,
+	PermShiftOperate, PermShiftManage,
+	PermZReportView, PermZReportClose,
+	PermReservationView, PermReservationManage,
+	PermSaleView, PermSaleCreate, PermSaleVoid, PermSync,
+	PermRefundCreate, PermRefundApprove,
+	PermNotificationView, PermDashboardView,
+	PermRoleManage, PermSettingsManage,
+}
+
+// DefaultRolePermissions is used for any role a business has not customised.
+// Owners always hold every permission and cannot be restricted.
+var DefaultRolePermissions = map[StaffRole][]Permission{
+	RoleManager: {
+		PermBranchView, PermBranchUpdate,
+		PermStaffView, PermStaffManage,
+		PermProductView, PermProductCreate, PermProductUpdate, PermProductDelete,
+		PermTransferView, PermTransferManage, PermTransferReceive,
+		PermSupplierView, PermSupplierManage,
+		PermPurchaseView, PermPurchaseManage, PermPurchaseReceive,
+		PermRecallView,
+		PermPromotionView, PermPromotionManage,
+		PermCustomerView, PermCustomerManage,
+		PermCreditView, PermCreditManage, PermCreditRepay,
+		PermShiftOperate, PermShiftManage,
+		PermZReportView, PermZReportClose,
+		PermReservationView, PermReservationManage,
+		PermSaleView, PermSaleCreate, PermSaleVoid, PermSync,
+		PermRefundCreate, PermRefundApprove,
+		PermNotificationView, PermDashboardView,
+	},
+	RoleCashier: {
+		PermBranchView,
+		PermProductView,
+		PermPromotionView,
+		PermCustomerView, PermCustomerManage,
+		PermCreditView, PermCreditRepay,
+		PermShiftOperate,
+		PermReservationView, PermReservationManage,
+		PermSaleView, PermSaleCreate, PermSaleVoid, PermSync,
+		PermRefundCreate,
+		PermNotificationView, PermDashboardView,
+	},
+	RoleInventory: {
+		PermBranchView,
+		PermProductView, PermProductCreate, PermProductUpdate,
+		PermTransferView, PermTransferManage, PermTransferReceive,
+		PermSupplierView,
+		PermPurchaseView, PermPurchaseReceive,
+		PermRecallView,
+		PermReservationView,
+		PermSync,
+		PermNotificationView, PermDashboardView,
+	},
+}
+
+// IsValidPermission reports whether p is a known permission
+func IsValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions is a business's customised permission set for one role
+type RolePermissions struct {
+	BusinessID  string       `json:"business_id"`
+	Role        StaffRole    `json:"role"`
+	Permissions []Permission `json:"permissions"`
+	UpdatedBy   string       `json:"updated_by"`
+	UpdatedAt   int64        `json:"updated_at"`
+}
+
+type RolePermissionRepository interface {
+	// GetRolePermissions returns nil when the business has not customised the role
+	GetRolePermissions(businessID string, role StaffRole) (*RolePermissions, error)
+	GetRolePermissionsByBusinessID(businessID string) ([]*RolePermissions, error)
+	SaveRolePermissions(rp *RolePermissions) error
+	DeleteRolePermissions(businessID string, role StaffRole) error
+}
@@ -24,13 +24,16 @@ type Product struct {
 type ProductRepository interface {
 	CreateProduct(product *Product) error
 	GetProductByID(productID string) (*Product, error)
+	GetProductByBarcode(businessID, branchID, barcode string) (*Product, error)
 	GetProductsByBusinessID(businessID string) ([]*Product, error)
 	GetProductsByBranchID(businessID, branchID string) ([]*Product, error)
 	UpdateProduct(product *Product) error
 	DeleteProduct(productID string) error
 	SearchProducts(businessID, searchTerm string) ([]*Product, error)
 	GetLowStockProducts(businessID string) ([]*Product, error)
-	UpdateProductStock(productID string, quantity int) error
+	UpdateProductStock(productID string, quantity int, change StockChange) error
+	AdjustProductStock(productID, businessID string, delta int, change StockChange) (int, error)
+	GetProductsUpdatedSince(businessID, branchID string, since int64) ([]*Product, error)
 	GetProductsByBranch(branchID string) ([]*Product, error) // Keep for backward compatibility
 	QueryProductsNotification(businessID, op string, stock int, expiry int64, lowStock int, limit, offset int, expired bool) ([]*Product, error)
 	GetAllProductsPaginated(businessID string, limit, offset int) ([]*Product, error)
@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// PromotionBuyXGetY gives GetQuantity units free for every BuyQuantity bought; the cheapest units go free
+	PromotionBuyXGetY = "buy_x_get_y"
+	// PromotionBundle sells any BundleQuantity matching units together for BundlePrice
+	PromotionBundle = "bundle"
+	// PromotionPriceOverride sells matching products at Price, typically during a daily window (happy hour)
+	PromotionPriceOverride = "price_override"
+	// PromotionCategoryPercent takes Percent off every product in Category
+	PromotionCategoryPercent = "category_percent"
+)
+
+var ErrPromotionNotFound = errors.New("promotion not found")
+
+// Promotion is a pricing rule applied automatically when a sale is rung up. It matches the
+// products in ProductIDs or, when none are listed, every product in Category. It runs from
+// StartsAt until EndsAt, and only between DailyStart and DailyEnd ("HH:MM") when those are set.
+// Promotions without a branch apply in every branch.
+type Promotion struct {
+	ID             string   `json:"id"`
+	BusinessID     string   `json:"business_id"`
+	BranchID       *string  `json:"branch_id,omitempty"`
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	ProductIDs     []string `json:"product_ids"`
+	Category       *string  `json:"category,omitempty"`
+	BuyQuantity    int      `json:"buy_quantity,omitempty"`
+	GetQuantity    int      `json:"get_quantity,omitempty"`
+	BundleQuantity int      `json:"bundle_quantity,omitempty"`
+	BundlePrice    float64  `json:"bundle_price,omitempty"`
+	Price          float64  `json:"price,omitempty"`
+	Percent        float64  `json:"percent,omitempty"`
+	StartsAt       int64    `json:"starts_at"`
+	EndsAt         *int64   `json:"ends_at,omitempty"`
+	DailyStart     string   `json:"daily_start,omitempty"`
+	DailyEnd       string   `json:"daily_end,omitempty"`
+	Active         bool     `json:"active"`
+	CreatedBy      string   `json:"created_by"`
+	CreatedAt      int64    `json:"created_at"`
+	UpdatedAt      int64    `json:"updated_at"`
+}
+
+// Matches reports whether the promotion covers a product
+func (p *Promotion) Matches(productID, category string) bool {
+	if len(p.ProductIDs) > 0 {
+		for _, id := range p.ProductIDs {
+			if id == productID {
+				return true
+			}
+		}
+		return false
+	}
+	return p.Category != nil && *p.Category == category
+}
+
+// RunsAt reports whether the promotion is in effect at t. The daily window is read in t's
+// location and may run past midnight.
+func (p *Promotion) RunsAt(t time.Time) bool {
+	if !p.Active || t.Unix() < p.StartsAt || (p.EndsAt != nil && t.Unix() >= *p.EndsAt) {
+		return false
+	}
+	if p.DailyStart == "" || p.DailyEnd == "" {
+		return true
+	}
+	start, err1 := time.Parse("15:04", p.DailyStart)
+	end, err2 := time.Parse("15:04", p.DailyEnd)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// PromotionTotal is what one promotion gave away over a period
+type PromotionTotal struct {
+	PromotionID     string  `json:"promotion_id"`
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	SalesCount      int     `json:"sales_count"`
+	Quantity        int     `json:"quantity"`
+	Revenue         float64 `json:"revenue"`
+	PromotionAmount float64 `json:"promotion_amount"`
+}
+
+type PromotionRepository interface {
+	CreatePromotion(p *Promotion) error
+	GetPromotionByID(id string) (*Promotion, error)
+	GetPromotionsByBusinessID(businessID string, activeOnly bool) ([]*Promotion, error)
+	// GetRunningPromotions returns the active promotions of a business that apply to the branch
+	// and whose date range covers at; daily windows are left to Promotion.RunsAt
+	GetRunningPromotions(businessID, branchID string, at int64) ([]*Promotion, error)
+	UpdatePromotion(p *Promotion) error
+	// GetPromotionTotals totals the sale lines each promotion priced on non-voided sales made in [from, to)
+	GetPromotionTotals(businessID, branchID string, from, to int64) ([]PromotionTotal, error)
+}
+
+// applyPromotions works out the promotion saving on each line, in kobo. Each line gets at most
+// one promotion: the promotion saving the most on the lines still free is applied first, and so
+// on until no promotion saves anything more. Sets the items' PromotionID.
+func applyPromotions(promotions []*Promotion, items []SaleItem, gross []int64) []int64 {
+	off := make([]int64, len(items))
+	claimed := make([]bool, len(items))
+	remaining := append([]*Promotion(nil), promotions...)
+	for len(remaining) > 0 {
+		best, bestTotal := -1, int64(0)
+		var bestOff map[int]int64
+		for n, p := range remaining {
+			saving := promotionSaving(p, items, gross, claimed)
+			var total int64
+			for _, v := range saving {
+				total += v
+			}
+			if total > bestTotal {
+				best, bestTotal, bestOff = n, total, saving
+			}
+		}
+		if best < 0 {
+			break
+		}
+		p := remaining[best]
+		for i, v := range bestOff {
+			off[i] = v
+			claimed[i] = true
+			id := p.ID
+			items[i].PromotionID = &id
+		}
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return off
+}
+
+// promotionSaving returns what p takes off each unclaimed line it covers, in kobo
+func promotionSaving(p *Promotion, items []SaleItem, gross []int64, claimed []bool) map[int]int64 {
+	var lines []int
+	for i, it := range items {
+		if !claimed[i] && it.Quantity > 0 && gross[i] > 0 && p.Matches(it.ProductID, it.Category) {
+			lines = append(lines, i)
+		}
+	}
+	saving := map[int]int64{}
+	if len(lines) == 0 {
+		return saving
+	}
+	unitPrice := func(i int) float64 { return float64(gross[i]) / float64(items[i].Quantity) }
+
+	switch p.Type {
+	case PromotionPriceOverride:
+		price := toKobo(p.Price)
+		for _, i := range lines {
+			if v := gross[i] - price*int64(items[i].Quantity); v > 0 {
+				saving[i] = v
+			}
+		}
+	case PromotionCategoryPercent:
+		for _, i := range lines {
+			if v := int64(math.Round(float64(gross[i]) * p.Percent / 100)); v > 0 {
+				saving[i] = v
+			}
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return saving
+		}
+		units := 0
+		for _, i := range lines {
+			units += items[i].Quantity
+		}
+		free := units / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		// The cheapest units go free
+		sort.SliceStable(lines, func(a, b int) bool { return unitPrice(lines[a]) < unitPrice(lines[b]) })
+		for _, i := range lines {
+			if free == 0 {
+				break
+			}
+			take := min(free, items[i].Quantity)
+			saving[i] = gross[i] * int64(take) / int64(items[i].Quantity)
+			free -= take
+		}
+	case PromotionBundle:
+		if p.BundleQuantity <= 0 {
+			return saving
+		}
+		units := 0
+		for _, i := range lines {
+			units += items[i].Quantity
+		}
+		bundles := units / p.BundleQuantity
+		if bundles == 0 {
+			return saving
+		}
+		// Bundle the dearest units, which saves the customer the most
+		sort.SliceStable(lines, func(a, b int) bool { return unitPrice(lines[a]) > unitPrice(lines[b]) })
+		left := bundles * p.BundleQuantity
+		value := map[int]int64{}
+		var bundled int64
+		for _, i := range lines {
+			if left == 0 {
+				break
+			}
+			take := min(left, items[i].Quantity)
+			value[i] = gross[i] * int64(take) / int64(items[i].Quantity)
+			bundled += value[i]
+			left -= take
+		}
+		total := bundled - toKobo(p.BundlePrice)*int64(bundles)
+		if total <= 0 {
+			return saving
+		}
+		// Spread the saving over the bundled lines by value; rounding leftovers go to the last
+		spread := int64(0)
+		for n, i := range lines {
+			if _, ok := value[i]; !ok {
+				continue
+			}
+			share := total * value[i] / bundled
+			if n == len(value)-1 {
+				share = total - spread
+			}
+			saving[i] = share
+			spread += share
+		}
+	}
+	return saving
+}
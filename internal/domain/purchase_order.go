@@ -0,0 +1,92 @@
+package domain
+
+// Purchase order statuses
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+	ID         string              `json:"id"`
+	BusinessID string              `json:"business_id"`
+	BranchID   string              `json:"branch_id"`
+	SupplierID string              `json:"supplier_id"`
+	Status     string              `json:"status"`
+	Note       string              `json:"note,omitempty"`
+	ExpectedAt *int64              `json:"expected_at,omitempty"`
+	TotalCost  float64             `json:"total_cost"`
+	CreatedBy  string              `json:"created_by"`
+	SentAt     *int64              `json:"sent_at,omitempty"`
+	CreatedAt  int64               `json:"created_at"`
+	UpdatedAt  int64               `json:"updated_at"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+}
+
+type PurchaseOrderLine struct {
+	ID               string  `json:"id"`
+	PurchaseOrderID  string  `json:"purchase_order_id"`
+	ProductID        string  `json:"product_id"`
+	ProductName      string  `json:"product_name"`
+	QuantityOrdered  int     `json:"quantity_ordered"`
+	QuantityReceived int     `json:"quantity_received"`
+	UnitCost         float64 `json:"unit_cost"`
+}
+
+// GoodsReceivedNote records one delivery against a purchase order
+type GoodsReceivedNote struct {
+	ID              string                  `json:"id"`
+	PurchaseOrderID string                  `json:"purchase_order_id"`
+	BusinessID      string                  `json:"business_id"`
+	BranchID        string                  `json:"branch_id"`
+	ReceivedBy      string                  `json:"received_by"`
+	Note            string                  `json:"note,omitempty"`
+	CreatedAt       int64                   `json:"created_at"`
+	Items           []GoodsReceivedNoteItem `json:"items"`
+}
+
+type GoodsReceivedNoteItem struct {
+	ID        string `json:"id"`
+	GRNID     string `json:"grn_id"`
+	LineID    string `json:"line_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	// Actual cost per unit on the delivery; the product's cost price becomes the weighted average
+	UnitCost float64 `json:"unit_cost"`
+	// When set the units are received into this lot
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  *int64 `json:"expiry_date,omitempty"`
+}
+
+// ReorderSuggestion is a line of the suggested-reorder report
+type ReorderSuggestion struct {
+	ProductID         string  `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	BranchID          string  `json:"branch_id"`
+	QuantityInStock   int     `json:"quantity_in_stock"`
+	LowStockThreshold int     `json:"low_stock_threshold"`
+	UnitsSold         int     `json:"units_sold"`
+	DailyVelocity     float64 `json:"daily_velocity"`
+	// Days the current stock lasts at the current velocity; -1 when nothing has sold
+	DaysOfCover       float64 `json:"days_of_cover"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+	CostPrice         float64 `json:"cost_price"`
+	// Units ordered on open purchase orders and not yet received, already taken off the suggestion
+	OnOrder int `json:"on_order"`
+}
+
+type PurchaseOrderRepository interface {
+	CreatePurchaseOrder(po *PurchaseOrder) error
+	GetPurchaseOrderByID(id string) (*PurchaseOrder, error)
+	ListPurchaseOrders(businessID, branchID, supplierID, status string) ([]*PurchaseOrder, error)
+	// UpdatePurchaseOrderStatus moves the order to status if it is currently in one of from
+	UpdatePurchaseOrderStatus(id, status string, from []string) (*PurchaseOrder, error)
+	// ReceiveGoods books a delivery: it increments stock, updates cost prices and the order status in one transaction
+	ReceiveGoods(grn *GoodsReceivedNote) (*PurchaseOrder, error)
+	GetGoodsReceivedNotes(purchaseOrderID string) ([]*GoodsReceivedNote, error)
+	// GetQuantitiesOnOrder returns, per product, the units ordered and not yet received on
+	// purchase orders that are neither received nor cancelled
+	GetQuantitiesOnOrder(businessID, branchID string) (map[string]int, error)
+}
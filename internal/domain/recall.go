@@ -0,0 +1,122 @@
+package domain
+
+import "errors"
+
+// ErrProductRecalled is returned when a sale or transfer asks for stock that is under an active recall
+var ErrProductRecalled = errors.New("product is under recall")
+
+const (
+	RecallStatusActive = "active"
+	RecallStatusClosed = "closed"
+)
+
+// Recall blocks the sale of every product registered under a NAFDAC number, optionally
+// narrowed to one barcode, one batch and/or an expiry window. Matching is done against
+// the current stock, so products created later (e.g. by a transfer) are covered too.
+type Recall struct {
+	ID              string  `json:"id"`
+	BusinessID      string  `json:"business_id"`
+	NAFDACRegNumber string  `json:"nafdac_reg_number"`
+	BarcodeValue    *string `json:"barcode_value,omitempty"`
+	BatchNumber     *string `json:"batch_number,omitempty"`
+	ExpiryFrom      *int64  `json:"expiry_from,omitempty"`
+	ExpiryTo        *int64  `json:"expiry_to,omitempty"`
+	Reason          string  `json:"reason"`
+	Reference       string  `json:"reference,omitempty"`
+	Status          string  `json:"status"`
+	CreatedBy       string  `json:"created_by"`
+	CreatedAt       int64   `json:"created_at"`
+	ClosedBy        *string `json:"closed_by,omitempty"`
+	ClosedAt        *int64  `json:"closed_at,omitempty"`
+}
+
+// CoversWholeProduct reports whether every unit of a matching product is recalled,
+// rather than only the lots within the batch or expiry window
+func (r *Recall) CoversWholeProduct() bool {
+	return r.BatchNumber == nil && r.ExpiryFrom == nil && r.ExpiryTo == nil
+}
+
+// CoversLot reports whether a lot of a matching product falls under the recall
+func (r *Recall) CoversLot(batchNumber string, expiry *int64) bool {
+	if r.BatchNumber != nil && *r.BatchNumber != batchNumber {
+		return false
+	}
+	return r.CoversExpiry(expiry)
+}
+
+// CoversExpiry reports whether an expiry date falls inside the recall's window.
+// Stock without an expiry date is only covered when no window is set.
+func (r *Recall) CoversExpiry(expiry *int64) bool {
+	if r.ExpiryFrom == nil && r.ExpiryTo == nil {
+		return true
+	}
+	if expiry == nil {
+		return false
+	}
+	if r.ExpiryFrom != nil && *expiry < *r.ExpiryFrom {
+		return false
+	}
+	if r.ExpiryTo != nil && *expiry > *r.ExpiryTo {
+		return false
+	}
+	return true
+}
+
+// RecallStockLine is recalled stock still held by a branch
+type RecallStockLine struct {
+	BranchID     string  `json:"branch_id"`
+	BranchName   string  `json:"branch_name"`
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	BarcodeValue *string `json:"barcode_value,omitempty"`
+	// Empty for unlotted stock
+	LotID       string `json:"lot_id,omitempty"`
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  *int64 `json:"expiry_date,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+// RecallSaleLine is recalled stock that has already been sold
+type RecallSaleLine struct {
+	SaleID      string `json:"sale_id"`
+	SaleItemID  string `json:"sale_item_id"`
+	BranchID    string `json:"branch_id"`
+	BranchName  string `json:"branch_name"`
+	CashierID   string `json:"cashier_id"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  *int64 `json:"expiry_date,omitempty"`
+	// Units sold, net of units returned through refunds
+	Quantity int   `json:"quantity"`
+	SoldAt   int64 `json:"sold_at"`
+}
+
+// RecallBranchSummary totals a recall's exposure in one branch
+type RecallBranchSummary struct {
+	BranchID    string `json:"branch_id"`
+	BranchName  string `json:"branch_name"`
+	UnitsOnHand int    `json:"units_on_hand"`
+	UnitsSold   int    `json:"units_sold"`
+	SalesCount  int    `json:"sales_count"`
+}
+
+// RecallReport lists where a recall's stock is held and which sales dispensed it
+type RecallReport struct {
+	Recall      *Recall               `json:"recall"`
+	GeneratedAt int64                 `json:"generated_at"`
+	Branches    []RecallBranchSummary `json:"branches"`
+	Stock       []RecallStockLine     `json:"stock"`
+	Sales       []RecallSaleLine      `json:"sales"`
+}
+
+type RecallRepository interface {
+	CreateRecall(recall *Recall) error
+	GetRecallByID(id string) (*Recall, error)
+	GetRecallsByBusinessID(businessID, status string) ([]*Recall, error)
+	CloseRecall(id, closedBy string, closedAt int64) error
+	// GetRecallStock lists matching stock on hand, optionally for one branch
+	GetRecallStock(recall *Recall, branchID string) ([]RecallStockLine, error)
+	// GetRecallSales lists non-voided sales that dispensed matching stock, optionally for one branch
+	GetRecallSales(recall *Recall, branchID string) ([]RecallSaleLine, error)
+}
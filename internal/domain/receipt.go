@@ -0,0 +1,262 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Receipt formats
+const (
+	ReceiptFormatJSON   = "json"
+	ReceiptFormatText   = "text"
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatPDF    = "pdf"
+)
+
+// Characters per line on 58mm and 80mm thermal paper
+const (
+	ReceiptWidth58mm = 32
+	ReceiptWidth80mm = 48
+)
+
+// Receipt is a sale as printed for the customer. Date is the local time of the sale in
+// the business's timezone.
+type Receipt struct {
+	ReceiptNumber   int              `json:"receipt_number"`
+	SaleID          string           `json:"sale_id"`
+	Status          string           `json:"status"`
+	BusinessName    string           `json:"business_name"`
+	BusinessPhone   string           `json:"business_phone,omitempty"`
+	BranchID        string           `json:"branch_id"`
+	BranchName      string           `json:"branch_name"`
+	BranchAddress   string           `json:"branch_address"`
+	CashierName     string           `json:"cashier_name"`
+	CustomerName    string           `json:"customer_name,omitempty"`
+	Currency        string           `json:"currency"`
+	Date            string           `json:"date"`
+	Timezone        string           `json:"timezone"`
+	Items           []ReceiptItem    `json:"items"`
+	GrossAmount     float64          `json:"gross_amount"`
+	PromotionAmount float64          `json:"promotion_amount"`
+	DiscountAmount  float64          `json:"discount_amount"`
+	TaxAmount       float64          `json:"tax_amount"`
+	TotalAmount     float64          `json:"total_amount"`
+	Payments        []ReceiptPayment `json:"payments"`
+	ChangeDue       float64          `json:"change_due"`
+	PointsEarned    int              `json:"points_earned,omitempty"`
+	PointsRedeemed  int              `json:"points_redeemed,omitempty"`
+}
+
+// ReceiptItem is one line of a receipt at its list price, before promotions and discounts
+type ReceiptItem struct {
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Amount    float64 `json:"amount"`
+}
+
+// ReceiptPayment is one tender on a receipt; Tendered is what the customer handed over
+type ReceiptPayment struct {
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	Tendered  float64 `json:"tendered"`
+	Reference string  `json:"reference,omitempty"`
+}
+
+// ReceiptLine is one printed line, already padded to the paper width
+type ReceiptLine struct {
+	Text string
+	Bold bool
+}
+
+// Number is the receipt number as printed
+func (r *Receipt) Number() string {
+	return fmt.Sprintf("%06d", r.ReceiptNumber)
+}
+
+// Lines lays the receipt out for paper width characters wide
+func (r *Receipt) Lines(width int) []ReceiptLine {
+	var lines []ReceiptLine
+	center := func(s string, bold bool) {
+		for _, part := range wrapText(s, width) {
+			pad := (width - utf8.RuneCountInString(part)) / 2
+			lines = append(lines, ReceiptLine{Text: strings.Repeat(" ", pad) + part, Bold: bold})
+		}
+	}
+	pair := func(left, right string, bold bool) {
+		gap := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+		if gap < 1 {
+			for _, part := range wrapText(left, width) {
+				lines = append(lines, ReceiptLine{Text: part, Bold: bold})
+			}
+			left, gap = "", width-utf8.RuneCountInString(right)
+		}
+		lines = append(lines, ReceiptLine{Text: left + strings.Repeat(" ", max(gap, 0)) + right, Bold: bold})
+	}
+	rule := func() {
+		lines = append(lines, ReceiptLine{Text: strings.Repeat("-", width)})
+	}
+
+	center(r.BusinessName, true)
+	if r.BranchName != "" && r.BranchName != r.BusinessName {
+		center(r.BranchName, false)
+	}
+	center(r.BranchAddress, false)
+	if r.BusinessPhone != "" {
+		center("Tel: "+r.BusinessPhone, false)
+	}
+	rule()
+	pair("Receipt", r.Number(), false)
+	pair("Date", r.Date, false)
+	pair("Cashier", r.CashierName, false)
+	if r.CustomerName != "" {
+		pair("Customer", r.CustomerName, false)
+	}
+	rule()
+	for _, it := range r.Items {
+		for _, part := range wrapText(it.Name, width) {
+			lines = append(lines, ReceiptLine{Text: part})
+		}
+		pair(fmt.Sprintf("  %d x %s", it.Quantity, FormatMoney(it.UnitPrice)), FormatMoney(it.Amount), false)
+	}
+	rule()
+	pair("Subtotal", FormatMoney(r.GrossAmount), false)
+	if r.PromotionAmount > 0 {
+		pair("Promotions", "-"+FormatMoney(r.PromotionAmount), false)
+	}
+	if r.DiscountAmount > 0 {
+		pair("Discount", "-"+FormatMoney(r.DiscountAmount), false)
+	}
+	total := "TOTAL"
+	if r.Currency != "" {
+		total += " (" + r.Currency + ")"
+	}
+	pair(total, FormatMoney(r.TotalAmount), true)
+	if r.TaxAmount > 0 {
+		pair("Incl. tax", FormatMoney(r.TaxAmount), false)
+	}
+	rule()
+	for _, p := range r.Payments {
+		pair(paymentLabel(p.Method), FormatMoney(math.Max(p.Tendered, p.Amount)), false)
+		if p.Reference != "" {
+			pair("  Ref", p.Reference, false)
+		}
+	}
+	if r.ChangeDue > 0 {
+		pair("Change", FormatMoney(r.ChangeDue), false)
+	}
+	if r.PointsEarned > 0 || r.PointsRedeemed > 0 {
+		rule()
+		if r.PointsRedeemed > 0 {
+			pair("Points redeemed", fmt.Sprintf("%d", r.PointsRedeemed), false)
+		}
+		if r.PointsEarned > 0 {
+			pair("Points earned", fmt.Sprintf("%d", r.PointsEarned), false)
+		}
+	}
+	rule()
+	if r.Status == SaleStatusVoided {
+		center("*** VOIDED ***", true)
+	}
+	center("Thank you for your patronage", false)
+	return lines
+}
+
+// Text renders the receipt as plain text for paper width characters wide
+func (r *Receipt) Text(width int) string {
+	var b strings.Builder
+	for _, l := range r.Lines(width) {
+		b.WriteString(strings.TrimRight(l.Text, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ESCPOS renders the receipt as an ESC/POS byte stream for a thermal printer, ending with
+// a paper cut. Characters outside ASCII are printed as '?'.
+func (r *Receipt) ESCPOS(width int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0x1B, 0x40}) // ESC @: initialise
+	for _, l := range r.Lines(width) {
+		if l.Bold {
+			b.Write([]byte{0x1B, 0x45, 1}) // ESC E 1: bold on
+		}
+		for _, c := range strings.TrimRight(l.Text, " ") {
+			if c < 0x20 || c > 0x7E {
+				c = '?'
+			}
+			b.WriteByte(byte(c))
+		}
+		if l.Bold {
+			b.Write([]byte{0x1B, 0x45, 0}) // ESC E 0: bold off
+		}
+		b.WriteByte('\n')
+	}
+	b.Write([]byte{0x1B, 0x64, 4})       // ESC d 4: feed past the cutter
+	b.Write([]byte{0x1D, 0x56, 0x42, 0}) // GS V B 0: partial cut
+	return b.Bytes()
+}
+
+// FormatMoney formats an amount with two decimals and thousands separators, e.g. 12,500.00
+func FormatMoney(v float64) string {
+	s := fmt.Sprintf("%.2f", math.Abs(v))
+	whole, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	if v < 0 && s != "0.00" {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String() + frac
+}
+
+func paymentLabel(method string) string {
+	switch method {
+	case PaymentMethodLoyalty:
+		return "Loyalty points"
+	case PaymentMethodCredit:
+		return "On account"
+	}
+	if method == "" {
+		return "Payment"
+	}
+	return strings.ToUpper(method[:1]) + method[1:]
+}
+
+// wrapText breaks s into lines of at most width characters at spaces, splitting words
+// longer than a line
+func wrapText(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for utf8.RuneCountInString(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			r := []rune(word)
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
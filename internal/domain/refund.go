@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidRefundPayment is returned when a refund is paid back through methods or amounts
+// that do not match how the sale was paid
+var ErrInvalidRefundPayment = errors.New("invalid refund payment")
+
+// Sale statuses
+const (
+	SaleStatusCompleted         = "completed"
+	SaleStatusPartiallyRefunded = "partially_refunded"
+	SaleStatusRefunded          = "refunded"
+	SaleStatusVoided            = "voided"
+)
+
+// Refund statuses
+const (
+	RefundStatusPendingApproval = "pending_approval"
+	RefundStatusCompleted       = "completed"
+	RefundStatusRejected        = "rejected"
+)
+
+// Refund reason codes
+const (
+	RefundReasonDefective    = "defective"
+	RefundReasonDamaged      = "damaged"
+	RefundReasonExpired      = "expired"
+	RefundReasonWrongItem    = "wrong_item"
+	RefundReasonChangedMind  = "changed_mind"
+	RefundReasonPricingError = "pricing_error"
+	RefundReasonOther        = "other"
+)
+
+// What happens to returned goods
+const (
+	RefundDispositionRestock  = "restock"
+	RefundDispositionWriteOff = "write_off"
+)
+
+// IsValidRefundReason reports whether code is a known refund reason code
+func IsValidRefundReason(code string) bool {
+	switch code {
+	case RefundReasonDefective, RefundReasonDamaged, RefundReasonExpired, RefundReasonWrongItem,
+		RefundReasonChangedMind, RefundReasonPricingError, RefundReasonOther:
+		return true
+	}
+	return false
+}
+
+type Refund struct {
+	ID            string       `json:"id"`
+	BusinessID    string       `json:"business_id"`
+	BranchID      string       `json:"branch_id"`
+	SaleID        string       `json:"sale_id"`
+	RequestedBy   string       `json:"requested_by"`
+	ApprovedBy    *string      `json:"approved_by,omitempty"`
+	ReasonCode    string       `json:"reason_code"`
+	Note          string       `json:"note,omitempty"`
+	PaymentMethod string       `json:"payment_method"`
+	TotalAmount   float64      `json:"total_amount"`
+	Status        string       `json:"status"`
+	CreatedAt     int64        `json:"created_at"`
+	ResolvedAt    *int64       `json:"resolved_at,omitempty"`
+	ShiftID       *string      `json:"shift_id,omitempty"`
+	Items         []RefundItem `json:"items"`
+	// How TotalAmount is paid back; PaymentMethod is split when there is more than one method
+	Payments []RefundPayment `json:"payments"`
+}
+
+// RefundPayment is the part of a refund paid back through one payment method
+type RefundPayment struct {
+	ID       string  `json:"id"`
+	RefundID string  `json:"refund_id"`
+	Method   string  `json:"method"`
+	Amount   float64 `json:"amount"`
+}
+
+type RefundItem struct {
+	ID          string  `json:"id"`
+	RefundID    string  `json:"refund_id"`
+	SaleItemID  string  `json:"sale_item_id"`
+	ProductID   string  `json:"product_id"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+	Disposition string  `json:"disposition"`
+}
+
+// RefundAmount returns what returning quantity more units of the line pays back. It is worked
+// out on the running total, so returning every unit pays back exactly Subtotal however many
+// refunds it takes.
+func (it *SaleItem) RefundAmount(quantity int) float64 {
+	if it.Quantity <= 0 {
+		return 0
+	}
+	subtotal := toKobo(it.Subtotal)
+	before := subtotal * int64(it.RefundedQuantity) / int64(it.Quantity)
+	after := subtotal * int64(it.RefundedQuantity+quantity) / int64(it.Quantity)
+	return fromKobo(after - before)
+}
+
+// CheckRefundPayments reports whether payments may pay back a refund of amount on a sale taken
+// with tenders, after the earlier refunds of the sale paid back prior. A sale paid with one
+// method may be refunded through another, but never onto credit or points it was not paid
+// with; a credit sale only goes back onto the customer's account and a sale paid with points
+// only back as points. A split sale is refunded through its own methods, each up to what the
+// sale took in it, so the parts paid on credit or with points cannot be paid out as money.
+func CheckRefundPayments(tenders []SalePayment, prior, payments []RefundPayment, amount float64) error {
+	taken := map[string]int64{}
+	for _, t := range tenders {
+		taken[t.Method] += toKobo(t.Amount)
+	}
+	refunded := map[string]int64{}
+	var refundedTotal int64
+	for _, p := range prior {
+		refunded[p.Method] += toKobo(p.Amount)
+		refundedTotal += toKobo(p.Amount)
+	}
+
+	paying := map[string]int64{}
+	var total int64
+	for _, p := range payments {
+		if toKobo(p.Amount) <= 0 {
+			return fmt.Errorf("%w: payment amounts must be greater than 0", ErrInvalidRefundPayment)
+		}
+		paying[p.Method] += toKobo(p.Amount)
+		total += toKobo(p.Amount)
+	}
+	if total != toKobo(amount) {
+		return fmt.Errorf("%w: payments of %.2f do not match the refund of %.2f", ErrInvalidRefundPayment, fromKobo(total), amount)
+	}
+
+	if len(taken) == 1 {
+		var method string
+		for m := range taken {
+			method = m
+		}
+		for m := range paying {
+			if m != method && method == PaymentMethodCredit {
+				return fmt.Errorf("%w: a sale paid on credit can only be refunded to the customer's credit account", ErrInvalidRefundPayment)
+			}
+			if m != method && method == PaymentMethodLoyalty {
+				return fmt.Errorf("%w: a sale paid with points can only be refunded as points", ErrInvalidRefundPayment)
+			}
+			if m != method && (m == PaymentMethodCredit || m == PaymentMethodLoyalty) {
+				return fmt.Errorf("%w: the sale was not paid with %s", ErrInvalidRefundPayment, m)
+			}
+		}
+		if left := taken[method] - refundedTotal; total > left {
+			return fmt.Errorf("%w: only %.2f of the sale is left to refund", ErrInvalidRefundPayment, fromKobo(left))
+		}
+		return nil
+	}
+	for m, amount := range paying {
+		if _, ok := taken[m]; !ok {
+			return fmt.Errorf("%w: the sale was not paid with %s", ErrInvalidRefundPayment, m)
+		}
+		if left := taken[m] - refunded[m]; amount > left {
+			return fmt.Errorf("%w: only %.2f paid with %s is left to refund", ErrInvalidRefundPayment, fromKobo(left), m)
+		}
+	}
+	return nil
+}
+
+type RefundRepository interface {
+	// CreateRefund stores the refund; completed refunds are applied to the sale and stock in the same transaction
+	CreateRefund(refund *Refund) error
+	// ApproveRefund applies a pending refund and marks it completed, paid out of shiftID's
+	// drawer when set
+	ApproveRefund(refundID, approverID string, shiftID *string) (*Refund, error)
+	RejectRefund(refundID, approverID, note string) (*Refund, error)
+	GetRefundByID(id string) (*Refund, error)
+	GetRefundsBySaleID(saleID string) ([]*Refund, error)
+	GetPendingRefunds(businessID, branchID string) ([]*Refund, error)
+}
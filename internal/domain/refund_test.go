@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSaleItemRefundAmount(t *testing.T) {
+	// Three units for 10.00 cannot be split evenly; refunding them one at a time must still
+	// pay back exactly the line total
+	item := SaleItem{Quantity: 3, Subtotal: 10}
+	var paid float64
+	for i, want := range []float64{3.33, 3.33, 3.34} {
+		got := item.RefundAmount(1)
+		if got != want {
+			t.Errorf("unit %d refunds %.2f, want %.2f", i+1, got, want)
+		}
+		paid += got
+		item.RefundedQuantity++
+	}
+	if toKobo(paid) != toKobo(item.Subtotal) {
+		t.Errorf("refunds add up to %.2f, want %.2f", paid, item.Subtotal)
+	}
+
+	if got := (&SaleItem{Quantity: 3, Subtotal: 10, RefundedQuantity: 1}).RefundAmount(2); got != 6.67 {
+		t.Errorf("rest of a partly refunded line refunds %.2f, want 6.67", got)
+	}
+	if got := (&SaleItem{}).RefundAmount(1); got != 0 {
+		t.Errorf("empty line refunds %.2f, want 0", got)
+	}
+}
+
+func TestCheckRefundPayments(t *testing.T) {
+	cash := []SalePayment{{Method: PaymentMethodCash, Amount: 100}}
+	credit := []SalePayment{{Method: PaymentMethodCredit, Amount: 100}}
+	points := []SalePayment{{Method: PaymentMethodLoyalty, Amount: 100}}
+	split := []SalePayment{{Method: PaymentMethodCash, Amount: 40}, {Method: PaymentMethodCredit, Amount: 60}}
+	pay := func(method string, amount float64) []RefundPayment {
+		return []RefundPayment{{Method: method, Amount: amount}}
+	}
+
+	tests := []struct {
+		name     string
+		tenders  []SalePayment
+		prior    []RefundPayment
+		payments []RefundPayment
+		amount   float64
+		err      error
+	}{
+		{"same method", cash, nil, pay(PaymentMethodCash, 100), 100, nil},
+		{"another money method", cash, nil, pay(PaymentMethodTransfer, 100), 100, nil},
+		{"what is left after earlier refunds", cash, pay(PaymentMethodCard, 70), pay(PaymentMethodCash, 30), 30, nil},
+		{"more than is left after earlier refunds", cash, pay(PaymentMethodCash, 70), pay(PaymentMethodCash, 40), 40, ErrInvalidRefundPayment},
+		{"onto credit the sale was not paid with", cash, nil, pay(PaymentMethodCredit, 10), 10, ErrInvalidRefundPayment},
+		{"as points the sale was not paid with", cash, nil, pay(PaymentMethodLoyalty, 10), 10, ErrInvalidRefundPayment},
+		{"credit sale back to credit", credit, nil, pay(PaymentMethodCredit, 50), 50, nil},
+		{"credit sale paid out as cash", credit, nil, pay(PaymentMethodCash, 50), 50, ErrInvalidRefundPayment},
+		{"points sale back as points", points, nil, pay(PaymentMethodLoyalty, 50), 50, nil},
+		{"points sale paid out as cash", points, nil, pay(PaymentMethodCash, 50), 50, ErrInvalidRefundPayment},
+		{"split sale through its own methods", split, nil, append(pay(PaymentMethodCash, 40), pay(PaymentMethodCredit, 60)...), 100, nil},
+		{"split sale over a method's share", split, nil, pay(PaymentMethodCash, 50), 50, ErrInvalidRefundPayment},
+		{"split sale over a share already refunded", split, pay(PaymentMethodCash, 30), pay(PaymentMethodCash, 20), 20, ErrInvalidRefundPayment},
+		{"split sale through a method it was not paid with", split, nil, pay(PaymentMethodCard, 10), 10, ErrInvalidRefundPayment},
+		{"payments short of the refund", cash, nil, pay(PaymentMethodCash, 40), 50, ErrInvalidRefundPayment},
+		{"zero payment", split, nil, append(pay(PaymentMethodCash, 40), pay(PaymentMethodCredit, 0)...), 40, ErrInvalidRefundPayment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRefundPayments(tt.tenders, tt.prior, tt.payments, tt.amount)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("CheckRefundPayments() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import "errors"
+
+// Reservation statuses. An active reservation holds its units back from other sales until
+// it expires; it is fulfilled when the customer's sale is rung up against it.
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusFulfilled = "fulfilled"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrReservationNotActive is returned when a reservation has already been fulfilled,
+	// released or has expired
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	// ErrReservationMismatch is returned when a sale collecting a reservation does not carry
+	// exactly the products and quantities that were reserved
+	ErrReservationMismatch = errors.New("sale does not match the reservation")
+)
+
+// Reservation sets stock aside at a branch for a layaway or phone order. Reserved units
+// cannot be sold to anyone else until the reservation is fulfilled, released or expires.
+type Reservation struct {
+	ID            string            `json:"id"`
+	BusinessID    string            `json:"business_id"`
+	BranchID      string            `json:"branch_id"`
+	Reference     string            `json:"reference"`
+	CustomerID    *string           `json:"customer_id,omitempty"`
+	CustomerPhone string            `json:"customer_phone,omitempty"`
+	Note          string            `json:"note,omitempty"`
+	Status        string            `json:"status"`
+	ExpiresAt     int64             `json:"expires_at"`
+	CreatedBy     string            `json:"created_by"`
+	SaleID        *string           `json:"sale_id,omitempty"`
+	ReleasedBy    *string           `json:"released_by,omitempty"`
+	ClosedAt      *int64            `json:"closed_at,omitempty"`
+	CreatedAt     int64             `json:"created_at"`
+	UpdatedAt     int64             `json:"updated_at"`
+	Items         []ReservationItem `json:"items"`
+}
+
+type ReservationItem struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// HoldsStock reports whether the reservation still keeps its units from other sales at now
+func (r *Reservation) HoldsStock(now int64) bool {
+	return r.Status == ReservationStatusActive && r.ExpiresAt > now
+}
+
+type ReservationRepository interface {
+	// CreateReservation saves the reservation, failing with ErrInsufficientStock if any
+	// product does not have enough unreserved units on hand
+	CreateReservation(r *Reservation) error
+	GetReservationByID(id string) (*Reservation, error)
+	// GetReservations lists a business's reservations, newest first, optionally in one
+	// branch and with one status
+	GetReservations(businessID, branchID, status string, limit, offset int) ([]*Reservation, error)
+	// ReleaseReservation gives an active reservation's units back to the shelf
+	ReleaseReservation(id, releasedBy string, releasedAt int64) (*Reservation, error)
+	// ExpireReservations marks every active reservation that lapsed before now as expired
+	// and returns how many there were
+	ExpireReservations(now int64) (int, error)
+	// GetReservedQuantities returns the units held by reservations at now for each product
+	GetReservedQuantities(productIDs []string, now int64) (map[string]int, error)
+}
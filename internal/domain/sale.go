@@ -1,30 +1,150 @@
 package domain
 
+import "errors"
+
+// ErrInsufficientStock is returned when a sale asks for more units than are on hand
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrSaleExists is returned when a sale is recorded under an ID that is already taken
+var ErrSaleExists = errors.New("sale id already in use")
+
 type Sale struct {
-	ID            string     `json:"id"`
-	BusinessID    string     `json:"business_id"`
-	BranchID      string     `json:"branch_id"`
-	CashierID     string     `json:"cashier_id"`
-	TotalAmount   float64    `json:"total_amount"`
-	PaymentMethod string     `json:"payment_method"`
-	Status        string     `json:"status"`
-	CreatedAt     int64      `json:"created_at"`
-	Items         []SaleItem `json:"items"`
+	ID            string  `json:"id"`
+	BusinessID    string  `json:"business_id"`
+	BranchID      string  `json:"branch_id"`
+	ReceiptNumber int     `json:"receipt_number,omitempty"`
+	CashierID     string  `json:"cashier_id"`
+	CustomerID    *string `json:"customer_id,omitempty"`
+	ShiftID       *string `json:"shift_id,omitempty"`
+	// Reservation the sale collected, whose units it was allowed to take
+	ReservationID *string `json:"reservation_id,omitempty"`
+	// Set on sales rung up offline, whose units were handed over before the server could
+	// check the stock: they are sold even if reserved or sold out in the meantime
+	AllowOversell bool    `json:"-"`
+	TotalAmount   float64 `json:"total_amount"`
+	PaymentMethod string  `json:"payment_method"`
+	Status        string  `json:"status"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+	VoidedBy      *string `json:"voided_by,omitempty"`
+	VoidReason    string  `json:"void_reason,omitempty"`
+	VoidedAt      *int64  `json:"voided_at,omitempty"`
+	// Cash handed back to the customer
+	ChangeDue float64 `json:"change_due"`
+	// List value of the items before promotions and discounts, and the totals each took off
+	GrossAmount     float64 `json:"gross_amount"`
+	PromotionAmount float64 `json:"promotion_amount"`
+	DiscountAmount  float64 `json:"discount_amount"`
+	// Basket discount, applied after line discounts
+	Discount       *Discount `json:"discount,omitempty"`
+	DiscountReason string    `json:"discount_reason,omitempty"`
+	// Tax included in TotalAmount, and the price mode it was worked out in
+	TaxAmount float64 `json:"tax_amount"`
+	TaxMode   string  `json:"tax_mode,omitempty"`
+	// Loyalty points the customer earned and spent on the sale
+	PointsEarned   int           `json:"points_earned"`
+	PointsRedeemed int           `json:"points_redeemed"`
+	Payments       []SalePayment `json:"payments"`
+	Items          []SaleItem    `json:"items"`
 }
 
 type SaleItem struct {
-	ID        string  `json:"id"`
-	SaleID    string  `json:"sale_id"`
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
+	ID        string `json:"id"`
+	SaleID    string `json:"sale_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	// Selling price before promotions and discounts; UnitPrice is what was actually charged per unit
+	ListPrice float64 `json:"list_price"`
 	UnitPrice float64 `json:"unit_price"`
 	Subtotal  float64 `json:"subtotal"`
-	CreatedAt int64   `json:"created_at"`
+	// Promotion that priced the line and what it took off
+	PromotionID     *string   `json:"promotion_id,omitempty"`
+	PromotionAmount float64   `json:"promotion_amount"`
+	Discount        *Discount `json:"discount,omitempty"`
+	DiscountAmount  float64   `json:"discount_amount"`
+	// Tax included in Subtotal
+	TaxTreatment string  `json:"tax_treatment,omitempty"`
+	TaxRate      float64 `json:"tax_rate"`
+	TaxAmount    float64 `json:"tax_amount"`
+	// Average cost of the units taken from stock
+	UnitCost float64 `json:"-"`
+	// Units already returned through completed refunds
+	RefundedQuantity int   `json:"refunded_quantity"`
+	CreatedAt        int64 `json:"created_at"`
+	// Units an offline sale sold beyond the stock on hand, which left the product below zero
+	OversoldQuantity int `json:"oversold_quantity,omitempty"`
+	// Lots the units were taken from, earliest expiry first
+	Lots []SaleItemLot `json:"lots,omitempty"`
+	// Product category, used to match promotions
+	Category string `json:"-"`
 }
 
 type SaleRepository interface {
-	CreateSale(sale *Sale, items []SaleItem) (string, float64, error)
-	GetTotalSalesToday(businessID, branchID string) (int, error)
+	// CreateSale stores a sale whose items are priced and whose Payments are settled, and in one
+	// transaction takes the stock, redeems and credits its loyalty points (the earned points lapse
+	// at pointsExpireAt, nil for never) and charges its credit tenders to the customer's account.
+	// Units a sale with AllowOversell cannot take from stock are set as the items' OversoldQuantity.
+	// A sale whose ID is already stored fails with ErrSaleExists and changes nothing.
+	CreateSale(sale *Sale, items []SaleItem, pointsExpireAt *int64) (string, float64, error)
+	GetSaleByID(id string) (*Sale, error)
+	// ListSales returns a business's sales matching the filter with their items and payments,
+	// newest first
+	ListSales(businessID string, filter SaleFilter) ([]*Sale, error)
+	// AssignReceiptNumber gives a sale recorded before receipts were numbered the branch's next
+	// receipt number, returning the number it already has otherwise
+	AssignReceiptNumber(saleID string) (int, error)
+	// VoidSale puts every item back into stock, reopens the reservation the sale collected and
+	// marks the sale voided in one transaction
+	VoidSale(saleID, voidedBy, reason string, voidedAt int64) (*Sale, error)
+	GetSalesUpdatedSince(businessID, branchID string, since int64) ([]*Sale, error)
+	// GetTotalSalesSince counts the non-voided sales made at or after since
+	GetTotalSalesSince(businessID, branchID string, since int64) (int, error)
 	GetTotalRevenue(businessID, branchID string) (float64, error)
 	GetRecentSales(businessID, branchID string, limit int) ([]*Sale, error)
+	// GetUnitsSoldSince returns units sold per product since the given time, net of refunds and excluding voided sales
+	GetUnitsSoldSince(businessID, branchID string, since int64) (map[string]int, error)
+	// GetRevenueByTender totals non-voided sales made in [from, to) per payment method, net of
+	// the completed refunds paid back through each method
+	GetRevenueByTender(businessID, branchID string, from, to int64) ([]TenderTotal, error)
+	// GetDiscountsByCashier totals the discounts on non-voided sales made in [from, to) per cashier
+	GetDiscountsByCashier(businessID, branchID string, from, to int64) ([]CashierDiscountTotal, error)
+	// GetSaleTotals totals the items of each non-voided sale matching the filter; with a
+	// category set, only that category's items count and sales without any are left out
+	GetSaleTotals(businessID string, filter SaleReportFilter) ([]SaleTotal, error)
+}
+
+// SaleFilter narrows a sale listing; empty fields match everything. PaymentMethod matches any
+// tender of a split sale. A listing continues after the sale at (AfterCreatedAt, AfterID).
+type SaleFilter struct {
+	BranchID       string
+	CashierID      string
+	PaymentMethod  string
+	Status         string
+	ProductID      string
+	From           int64
+	To             int64
+	MinAmount      *float64
+	MaxAmount      *float64
+	AfterCreatedAt int64
+	AfterID        string
+	Limit          int
+}
+
+// SaleReportFilter narrows the sales report to sales made in [From, To); empty fields match everything
+type SaleReportFilter struct {
+	From      int64
+	To        int64
+	BranchID  string
+	CashierID string
+	Category  string
+}
+
+// SaleTotal is what one sale took, as counted by the sales report
+type SaleTotal struct {
+	SaleID      string
+	CreatedAt   int64
+	ItemsSold   int
+	Revenue     float64
+	TaxAmount   float64
+	CostOfGoods float64
 }
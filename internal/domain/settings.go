@@ -0,0 +1,113 @@
+package domain
+
+import "time"
+
+// DefaultTimezone is used for business days until a business sets its own timezone
+const DefaultTimezone = "Africa/Lagos"
+
+// BusinessSettings holds per-business configuration. A business that never saved
+// its settings gets DefaultBusinessSettings.
+type BusinessSettings struct {
+	BusinessID string `json:"business_id"`
+	// Refunds above this amount need approval from a user holding refund.approve
+	RefundApprovalLimit float64 `json:"refund_approval_limit"`
+	// Payment methods cashiers may take, e.g. cash, transfer, card
+	PaymentMethods []string `json:"payment_methods"`
+	// Largest discount each staff role may give, as a percentage; roles not listed use DefaultMaxDiscountPercent
+	MaxDiscountPercent map[StaffRole]float64 `json:"max_discount_percent"`
+	// Whether selling prices include tax (inclusive) or have it added at the till (exclusive)
+	TaxMode string `json:"tax_mode"`
+	// Loyalty programme: points earned per currency unit, the value of a point when redeemed,
+	// categories that earn nothing, and days until earned points lapse (0 = never)
+	LoyaltyEnabled            bool     `json:"loyalty_enabled"`
+	LoyaltyEarnRate           float64  `json:"loyalty_earn_rate"`
+	LoyaltyPointValue         float64  `json:"loyalty_point_value"`
+	LoyaltyExcludedCategories []string `json:"loyalty_excluded_categories"`
+	LoyaltyExpiryDays         int      `json:"loyalty_expiry_days"`
+	// Reject sales from cashiers who have not opened a shift
+	RequireOpenShift bool `json:"require_open_shift"`
+	// IANA timezone that sets where the business's days start and end, e.g. Africa/Lagos
+	Timezone  string `json:"timezone"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// DefaultBusinessSettings returns the settings used until a business customises them
+func DefaultBusinessSettings(businessID string) *BusinessSettings {
+	return &BusinessSettings{
+		BusinessID:          businessID,
+		RefundApprovalLimit: 0,
+		PaymentMethods:      DefaultPaymentMethods,
+		TaxMode:             TaxModeInclusive,
+		LoyaltyEarnRate:     DefaultLoyaltyEarnRate,
+		LoyaltyPointValue:   DefaultLoyaltyPointValue,
+		LoyaltyExpiryDays:   DefaultLoyaltyExpiryDays,
+		Timezone:            DefaultTimezone,
+	}
+}
+
+// Location returns the business's timezone, falling back to UTC if it cannot be loaded
+func (s *BusinessSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// StartOfDay returns midnight of t's day in the business's timezone
+func (s *BusinessSettings) StartOfDay(t time.Time) time.Time {
+	local := t.In(s.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+// LoyaltyPolicy returns the business's loyalty programme, or nil if it has none
+func (s *BusinessSettings) LoyaltyPolicy() *LoyaltyPolicy {
+	if !s.LoyaltyEnabled {
+		return nil
+	}
+	p := &LoyaltyPolicy{
+		EarnRate:           s.LoyaltyEarnRate,
+		PointValue:         s.LoyaltyPointValue,
+		ExcludedCategories: map[string]bool{},
+		ExpiryDays:         s.LoyaltyExpiryDays,
+	}
+	for _, c := range s.LoyaltyExcludedCategories {
+		p.ExcludedCategories[c] = true
+	}
+	return p
+}
+
+// DiscountLimit returns the largest discount, as a percentage, a role may give. Owners are unlimited.
+func (s *BusinessSettings) DiscountLimit(role StaffRole) float64 {
+	if role == RoleOwner {
+		return 100
+	}
+	if limit, ok := s.MaxDiscountPercent[role]; ok {
+		return limit
+	}
+	return DefaultMaxDiscountPercent[role]
+}
+
+// AcceptsPaymentMethod reports whether method is on the business's payment method list,
+// is loyalty points while the loyalty programme is enabled, or is credit (which also needs
+// a debtor account)
+func (s *BusinessSettings) AcceptsPaymentMethod(method string) bool {
+	if method == PaymentMethodLoyalty {
+		return s.LoyaltyEnabled
+	}
+	if method == PaymentMethodCredit {
+		return true
+	}
+	for _, m := range s.PaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+type SettingsRepository interface {
+	GetSettings(businessID string) (*BusinessSettings, error)
+	SaveSettings(s *BusinessSettings) error
+}
@@ -0,0 +1,100 @@
+package domain
+
+import "errors"
+
+// Shift statuses
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+)
+
+// Cash movement types: cash put into the drawer (e.g. topping up change) or taken out
+// (petty cash, safe drops)
+const (
+	CashMovementIn  = "cash_in"
+	CashMovementOut = "cash_out"
+)
+
+var (
+	ErrShiftNotFound = errors.New("shift not found")
+	// ErrNoOpenShift is returned when a cashier sells without an open shift and the business requires one
+	ErrNoOpenShift = errors.New("no open shift")
+	// ErrShiftAlreadyOpen is returned when a cashier opens a second shift before closing the first
+	ErrShiftAlreadyOpen = errors.New("a shift is already open")
+	ErrShiftClosed      = errors.New("shift is closed")
+)
+
+// Shift is one cashier's session on a till, from the opening float to the cash count at close.
+// ExpectedCash, CountedCash and Variance are set when the shift closes; a negative variance
+// means the drawer was short.
+type Shift struct {
+	ID           string   `json:"id"`
+	BusinessID   string   `json:"business_id"`
+	BranchID     string   `json:"branch_id"`
+	CashierID    string   `json:"cashier_id"`
+	Status       string   `json:"status"`
+	OpeningFloat float64  `json:"opening_float"`
+	OpenedAt     int64    `json:"opened_at"`
+	ClosedAt     *int64   `json:"closed_at,omitempty"`
+	ClosedBy     *string  `json:"closed_by,omitempty"`
+	ExpectedCash *float64 `json:"expected_cash,omitempty"`
+	CountedCash  *float64 `json:"counted_cash,omitempty"`
+	Variance     *float64 `json:"variance,omitempty"`
+	ClosingNote  string   `json:"closing_note,omitempty"`
+	// Filled in when the shift is fetched on its own
+	Summary   *ShiftCashSummary `json:"summary,omitempty"`
+	Movements []*CashMovement   `json:"movements,omitempty"`
+}
+
+// CashMovement is cash added to or removed from a shift's drawer outside of a sale
+type CashMovement struct {
+	ID         string  `json:"id"`
+	ShiftID    string  `json:"shift_id"`
+	BusinessID string  `json:"business_id"`
+	BranchID   string  `json:"branch_id"`
+	Type       string  `json:"type"`
+	Amount     float64 `json:"amount"`
+	Reason     string  `json:"reason"`
+	RecordedBy string  `json:"recorded_by"`
+	CreatedAt  int64   `json:"created_at"`
+}
+
+// ShiftCashSummary is the cash that went through a shift's drawer. Cash sales are the cash
+// tenders of the shift's sales that were not voided, net of change; cash refunds are those
+// paid out of the shift's drawer, and debt repayments those the cashier took in cash at the
+// branch while the shift was open.
+type ShiftCashSummary struct {
+	SalesCount     int     `json:"sales_count"`
+	SalesTotal     float64 `json:"sales_total"`
+	OpeningFloat   float64 `json:"opening_float"`
+	CashSales      float64 `json:"cash_sales"`
+	CashRefunds    float64 `json:"cash_refunds"`
+	CashRepayments float64 `json:"cash_repayments"`
+	CashIn         float64 `json:"cash_in"`
+	CashOut        float64 `json:"cash_out"`
+	ExpectedCash   float64 `json:"expected_cash"`
+}
+
+// Expected works out the cash that should be in the drawer and stores it in ExpectedCash
+func (s *ShiftCashSummary) Expected() float64 {
+	kobo := toKobo(s.OpeningFloat) + toKobo(s.CashSales) - toKobo(s.CashRefunds) +
+		toKobo(s.CashRepayments) + toKobo(s.CashIn) - toKobo(s.CashOut)
+	s.ExpectedCash = fromKobo(kobo)
+	return s.ExpectedCash
+}
+
+type ShiftRepository interface {
+	// OpenShift fails with ErrShiftAlreadyOpen if the cashier already has an open shift
+	OpenShift(s *Shift) error
+	GetShiftByID(id string) (*Shift, error)
+	// GetOpenShift returns the cashier's open shift, or ErrNoOpenShift
+	GetOpenShift(cashierID string) (*Shift, error)
+	GetShifts(businessID, branchID, status string, limit, offset int) ([]*Shift, error)
+	// AddCashMovement fails with ErrShiftClosed once the shift has closed
+	AddCashMovement(m *CashMovement) error
+	GetCashMovements(shiftID string) ([]*CashMovement, error)
+	// GetCashSummary totals the cash through an open shift so far, or a closed shift as it closed
+	GetCashSummary(s *Shift) (*ShiftCashSummary, error)
+	// CloseShift counts the drawer and records the variance against the expected cash
+	CloseShift(id, closedBy string, counted float64, note string, closedAt int64) (*Shift, error)
+}
@@ -0,0 +1,61 @@
+package domain
+
+// Stock movement types
+const (
+	StockMovementOpening     = "opening"
+	StockMovementSale        = "sale"
+	StockMovementVoid        = "void"
+	StockMovementRefund      = "refund"
+	StockMovementReceipt     = "receipt"
+	StockMovementAdjustment  = "adjustment"
+	StockMovementTransferIn  = "transfer_in"
+	StockMovementTransferOut = "transfer_out"
+	StockMovementWriteOff    = "write_off"
+)
+
+// StockMovement is one immutable entry in the stock ledger. Quantity is the signed
+// change; the movements of a product always sum to its quantity in stock.
+type StockMovement struct {
+	ID             string `json:"id"`
+	BusinessID     string `json:"business_id"`
+	BranchID       string `json:"branch_id"`
+	ProductID      string `json:"product_id"`
+	Type           string `json:"type"`
+	Quantity       int    `json:"quantity"`
+	QuantityBefore int    `json:"quantity_before"`
+	QuantityAfter  int    `json:"quantity_after"`
+	ActorID        string `json:"actor_id"`
+	ReferenceType  string `json:"reference_type,omitempty"`
+	ReferenceID    string `json:"reference_id,omitempty"`
+	Note           string `json:"note,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// StockChange describes why a stock level is changing, for the ledger entry it produces
+type StockChange struct {
+	Type          string
+	ActorID       string
+	ReferenceType string
+	ReferenceID   string
+	Note          string
+}
+
+// StockDiscrepancy is a product whose ledger does not add up to its quantity in stock, or
+// whose stock is below zero because offline sales oversold it
+type StockDiscrepancy struct {
+	ProductID       string `json:"product_id"`
+	BusinessID      string `json:"business_id"`
+	BranchID        string `json:"branch_id"`
+	ProductName     string `json:"product_name"`
+	QuantityInStock int    `json:"quantity_in_stock"`
+	LedgerQuantity  int    `json:"ledger_quantity"`
+}
+
+type StockMovementRepository interface {
+	GetMovementsByProduct(productID string, limit, offset int) ([]*StockMovement, error)
+	// SeedOpeningBalances records an opening movement for every product that has no ledger entries yet
+	SeedOpeningBalances() (int, error)
+	// FindDiscrepancies compares the ledger with on-hand stock and picks out stock below zero;
+	// an empty businessID checks every business
+	FindDiscrepancies(businessID string) ([]*StockDiscrepancy, error)
+}
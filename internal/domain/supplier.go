@@ -0,0 +1,21 @@
+package domain
+
+type Supplier struct {
+	ID          string `json:"id"`
+	BusinessID  string `json:"business_id"`
+	Name        string `json:"name"`
+	ContactName string `json:"contact_name,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Address     string `json:"address,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+type SupplierRepository interface {
+	CreateSupplier(s *Supplier) error
+	GetSupplierByID(id string) (*Supplier, error)
+	GetSuppliersByBusinessID(businessID string) ([]*Supplier, error)
+	UpdateSupplier(s *Supplier) error
+	DeleteSupplier(id string) error
+}
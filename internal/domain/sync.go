@@ -0,0 +1,34 @@
+package domain
+
+// Sync operation outcomes reported back to the client per record
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+)
+
+// Sync operation types
+const (
+	SyncOpSale            = "sale"
+	SyncOpProductEdit     = "product_edit"
+	SyncOpStockAdjustment = "stock_adjustment"
+)
+
+// SyncOperation records the final outcome of a client-generated operation so
+// that retried uploads are answered with the same result instead of being
+// applied twice.
+type SyncOperation struct {
+	ID         string `json:"id"`
+	BusinessID string `json:"business_id"`
+	DeviceID   string `json:"device_id"`
+	OpType     string `json:"op_type"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type SyncRepository interface {
+	// GetOperation finds a recorded operation; client IDs are only unique per operation type
+	GetOperation(businessID, opType, opID string) (*SyncOperation, error)
+	RecordOperation(op *SyncOperation) error
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// TaxModeInclusive means selling prices already include tax
+	TaxModeInclusive = "inclusive"
+	// TaxModeExclusive means tax is added on top of selling prices
+	TaxModeExclusive = "exclusive"
+)
+
+const (
+	TaxTreatmentStandard  = "standard"
+	TaxTreatmentZeroRated = "zero_rated"
+	TaxTreatmentExempt    = "exempt"
+)
+
+// DefaultVATRate is the Nigerian VAT rate used until a business configures its own default rate
+const DefaultVATRate = 7.5
+
+var ErrTaxRuleNotFound = errors.New("tax rule not found")
+
+// TaxRate is a named percentage a business charges; the default rate applies to every
+// product without a tax rule
+type TaxRate struct {
+	ID         string  `json:"id"`
+	BusinessID string  `json:"business_id"`
+	Name       string  `json:"name"`
+	Rate       float64 `json:"rate"`
+	IsDefault  bool    `json:"is_default"`
+	CreatedAt  int64   `json:"created_at"`
+	UpdatedAt  int64   `json:"updated_at"`
+}
+
+// TaxRule sets the tax treatment of one product or of every product in a category.
+// A product's own rule wins over its category's. Standard-rated rules may name a
+// specific rate; otherwise the business's default rate applies.
+type TaxRule struct {
+	ID         string  `json:"id"`
+	BusinessID string  `json:"business_id"`
+	ProductID  *string `json:"product_id,omitempty"`
+	Category   *string `json:"category,omitempty"`
+	Treatment  string  `json:"treatment"`
+	TaxRateID  *string `json:"tax_rate_id,omitempty"`
+	CreatedAt  int64   `json:"created_at"`
+	UpdatedAt  int64   `json:"updated_at"`
+}
+
+// TaxPolicy is everything needed to tax a sale for one business
+type TaxPolicy struct {
+	Mode          string
+	DefaultRate   float64
+	Rates         map[string]float64 // by tax rate ID
+	ProductRules  map[string]*TaxRule
+	CategoryRules map[string]*TaxRule
+}
+
+// NewTaxPolicy builds a business's tax policy. Without a default rate, standard-rated
+// products are taxed at DefaultVATRate.
+func NewTaxPolicy(mode string, rates []*TaxRate, rules []*TaxRule) *TaxPolicy {
+	p := &TaxPolicy{
+		Mode:          mode,
+		DefaultRate:   DefaultVATRate,
+		Rates:         map[string]float64{},
+		ProductRules:  map[string]*TaxRule{},
+		CategoryRules: map[string]*TaxRule{},
+	}
+	for _, r := range rates {
+		p.Rates[r.ID] = r.Rate
+		if r.IsDefault {
+			p.DefaultRate = r.Rate
+		}
+	}
+	for _, r := range rules {
+		if r.ProductID != nil {
+			p.ProductRules[*r.ProductID] = r
+		} else if r.Category != nil {
+			p.CategoryRules[*r.Category] = r
+		}
+	}
+	return p
+}
+
+// Resolve returns the tax treatment and rate (percent) for a product
+func (p *TaxPolicy) Resolve(productID, category string) (string, float64) {
+	rule := p.ProductRules[productID]
+	if rule == nil {
+		rule = p.CategoryRules[category]
+	}
+	if rule == nil {
+		return TaxTreatmentStandard, p.DefaultRate
+	}
+	if rule.Treatment != TaxTreatmentStandard {
+		return rule.Treatment, 0
+	}
+	if rule.TaxRateID != nil {
+		if rate, ok := p.Rates[*rule.TaxRateID]; ok {
+			return TaxTreatmentStandard, rate
+		}
+	}
+	return TaxTreatmentStandard, p.DefaultRate
+}
+
+// TaxSummaryLine totals the tax on sales at one treatment and rate, net of refunds
+type TaxSummaryLine struct {
+	Treatment string  `json:"treatment"`
+	Rate      float64 `json:"rate"`
+	// Value of the sales before tax
+	NetAmount float64 `json:"net_amount"`
+	TaxAmount float64 `json:"tax_amount"`
+}
+
+type TaxRepository interface {
+	CreateTaxRate(rate *TaxRate) error
+	GetTaxRateByID(id string) (*TaxRate, error)
+	GetTaxRatesByBusinessID(businessID string) ([]*TaxRate, error)
+	// UpdateTaxRate saves the rate; making it the default clears the flag on the business's other rates
+	UpdateTaxRate(rate *TaxRate) error
+	DeleteTaxRate(id string) error
+	// SaveTaxRule creates or replaces the rule for the rule's product or category
+	SaveTaxRule(rule *TaxRule) error
+	GetTaxRuleByID(id string) (*TaxRule, error)
+	GetTaxRulesByBusinessID(businessID string) ([]*TaxRule, error)
+	DeleteTaxRule(id string) error
+	// GetTaxSummary totals tax on non-voided sales made in [from, to), net of refunded units.
+	// Sales recorded before tax was tracked are left out.
+	GetTaxSummary(businessID, branchID string, from, to int64) ([]TaxSummaryLine, error)
+}
+
+// applyTax adds tax to priced lines. In inclusive mode the tax is carved out of the
+// line total; in exclusive mode it is added on top. Line totals and unit prices end up
+// as what the customer pays.
+func applyTax(policy *TaxPolicy, items []SaleItem, net []int64) int64 {
+	var taxTotal int64
+	for i := range items {
+		rate := items[i].TaxRate
+		var tax int64
+		if rate > 0 {
+			if policy.Mode == TaxModeExclusive {
+				tax = int64(math.Round(float64(net[i]) * rate / 100))
+				net[i] += tax
+			} else {
+				tax = net[i] - int64(math.Round(float64(net[i])*100/(100+rate)))
+			}
+		}
+		items[i].TaxAmount = fromKobo(tax)
+		taxTotal += tax
+	}
+	return taxTotal
+}
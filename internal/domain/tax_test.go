@@ -0,0 +1,39 @@
+package domain
+
+import "testing"
+
+func TestTaxPolicyResolve(t *testing.T) {
+	food, drinks := "food", "drinks"
+	bread, juice := "product-bread", "product-juice"
+	reduced, missing := "rate-reduced", "rate-missing"
+	policy := NewTaxPolicy(TaxModeInclusive,
+		[]*TaxRate{{ID: reduced, Rate: 5}},
+		[]*TaxRule{
+			{Category: &food, Treatment: TaxTreatmentExempt},
+			{Category: &drinks, Treatment: TaxTreatmentStandard, TaxRateID: &reduced},
+			{ProductID: &bread, Treatment: TaxTreatmentStandard},
+			{ProductID: &juice, Treatment: TaxTreatmentStandard, TaxRateID: &missing},
+		})
+
+	tests := []struct {
+		name      string
+		productID string
+		category  string
+		treatment string
+		rate      float64
+	}{
+		{"no rule takes the default rate", "product-soap", "household", TaxTreatmentStandard, DefaultVATRate},
+		{"category rule", "product-rice", food, TaxTreatmentExempt, 0},
+		{"category rule with its own rate", "product-soda", drinks, TaxTreatmentStandard, 5},
+		{"product rule overrides its category", bread, food, TaxTreatmentStandard, DefaultVATRate},
+		{"unknown rate falls back to the default", juice, drinks, TaxTreatmentStandard, DefaultVATRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			treatment, rate := policy.Resolve(tt.productID, tt.category)
+			if treatment != tt.treatment || rate != tt.rate {
+				t.Errorf("Resolve() = %s %.2f, want %s %.2f", treatment, rate, tt.treatment, tt.rate)
+			}
+		})
+	}
+}
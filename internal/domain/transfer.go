@@ -0,0 +1,84 @@
+package domain
+
+// Transfer statuses. Stock leaves the source branch on dispatch and arrives at the
+// destination as it is received; until then it is in transit.
+const (
+	TransferStatusDraft             = "draft"
+	TransferStatusDispatched        = "dispatched"
+	TransferStatusPartiallyReceived = "partially_received"
+	TransferStatusReceived          = "received"
+	TransferStatusCancelled         = "cancelled"
+)
+
+type Transfer struct {
+	ID           string         `json:"id"`
+	BusinessID   string         `json:"business_id"`
+	FromBranchID string         `json:"from_branch_id"`
+	ToBranchID   string         `json:"to_branch_id"`
+	Status       string         `json:"status"`
+	Note         string         `json:"note,omitempty"`
+	CreatedBy    string         `json:"created_by"`
+	DispatchedBy *string        `json:"dispatched_by,omitempty"`
+	DispatchedAt *int64         `json:"dispatched_at,omitempty"`
+	ReceivedBy   *string        `json:"received_by,omitempty"`
+	ReceivedAt   *int64         `json:"received_at,omitempty"`
+	CreatedAt    int64          `json:"created_at"`
+	UpdatedAt    int64          `json:"updated_at"`
+	Items        []TransferItem `json:"items"`
+}
+
+type TransferItem struct {
+	ID               string  `json:"id"`
+	TransferID       string  `json:"transfer_id"`
+	SourceProductID  string  `json:"source_product_id"`
+	DestProductID    string  `json:"dest_product_id"`
+	BarcodeValue     *string `json:"barcode_value,omitempty"`
+	ProductName      string  `json:"product_name"`
+	Quantity         int     `json:"quantity"`
+	QuantityReceived int     `json:"quantity_received"`
+	// Units dispatched but not yet received
+	InTransit int `json:"in_transit"`
+	// Units that never arrived once the transfer was closed
+	Discrepancy int `json:"discrepancy"`
+	// Batches the units were dispatched from; units sent from unlotted stock are not listed
+	Lots []TransferItemLot `json:"lots,omitempty"`
+}
+
+// TransferItemLot is the part of a transfer item taken from one lot at the source branch.
+// It is received into the destination's lot with the same batch number, expiry and cost.
+type TransferItemLot struct {
+	ID               string  `json:"id"`
+	LotID            string  `json:"lot_id"`
+	BatchNumber      string  `json:"batch_number"`
+	ExpiryDate       *int64  `json:"expiry_date,omitempty"`
+	CostPrice        float64 `json:"cost_price"`
+	Quantity         int     `json:"quantity"`
+	QuantityReceived int     `json:"quantity_received"`
+	// Units of the batch that never arrived once the transfer was closed
+	WrittenOff int `json:"written_off"`
+}
+
+// HasDiscrepancy reports whether a closed transfer received less than was dispatched
+func (t *Transfer) HasDiscrepancy() bool {
+	for _, it := range t.Items {
+		if it.Discrepancy > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type TransferRepository interface {
+	CreateTransfer(t *Transfer) error
+	GetTransferByID(id string) (*Transfer, error)
+	// ListTransfers filters by status when set and, when branchID is set, by transfers into or out of it
+	ListTransfers(businessID, branchID, status string) ([]*Transfer, error)
+	// DispatchTransfer deducts the stock from the source branch, recording the lots it came
+	// out of, and marks the transfer dispatched
+	DispatchTransfer(id, userID string) (*Transfer, error)
+	// ReceiveTransfer adds received quantities (keyed by item ID) to the destination branch,
+	// into lots matching the ones dispatched. When close is set the transfer is marked received
+	// and anything still in transit becomes a discrepancy, written off batch by batch.
+	ReceiveTransfer(id, userID string, received map[string]int, close bool) (*Transfer, error)
+	CancelTransfer(id string) (*Transfer, error)
+}
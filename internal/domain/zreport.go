@@ -0,0 +1,111 @@
+package domain
+
+import "errors"
+
+// Z-report line kinds: the breakdowns printed on an end-of-day report
+const (
+	ZReportLineTender   = "tender"
+	ZReportLineCategory = "category"
+	ZReportLineCashier  = "cashier"
+	ZReportLineHour     = "hour"
+	ZReportLineItem     = "item"
+)
+
+var (
+	ErrZReportNotFound = errors.New("z-report not found")
+	// ErrZReportStale is returned when sales or refunds landed while a Z-report was being closed
+	ErrZReportStale = errors.New("sales changed while closing the z-report, try again")
+)
+
+// ZReport is a branch's closing report. It covers every sale and completed refund at the
+// branch not on an earlier Z-report, so offline sales synced late land on the next one.
+// Once closed a Z-report never changes; Number runs 1, 2, 3... per branch. Listings leave
+// out the breakdowns.
+type ZReport struct {
+	ID         string `json:"id,omitempty"`
+	BusinessID string `json:"business_id"`
+	BranchID   string `json:"branch_id"`
+	Number     int    `json:"number"`
+	// Local date the report was closed, YYYY-MM-DD in Timezone
+	BusinessDate string `json:"business_date"`
+	Timezone     string `json:"timezone"`
+	// Close of the previous Z-report (0 for the first) and of this one
+	From        int64   `json:"from"`
+	To          int64   `json:"to"`
+	SalesCount  int     `json:"sales_count"`
+	VoidedCount int     `json:"voided_count"`
+	ItemsSold   int     `json:"items_sold"`
+	GrossSales  float64 `json:"gross_sales"`
+	Promotions  float64 `json:"promotions"`
+	Discounts   float64 `json:"discounts"`
+	NetSales    float64 `json:"net_sales"`
+	TaxAmount   float64 `json:"tax_amount"`
+	CostOfGoods float64 `json:"cost_of_goods"`
+	// Takings on the goods customers kept, less their tax and cost
+	GrossProfit float64        `json:"gross_profit"`
+	RefundCount int            `json:"refund_count"`
+	Refunds     float64        `json:"refunds"`
+	Tenders     []*ZReportLine `json:"tenders,omitempty"`
+	Categories  []*ZReportLine `json:"categories,omitempty"`
+	Cashiers    []*ZReportLine `json:"cashiers,omitempty"`
+	Hours       []*ZReportLine `json:"hours,omitempty"`
+	Items       []*ZReportLine `json:"items,omitempty"`
+	ClosedBy    string         `json:"closed_by,omitempty"`
+	ClosedAt    int64          `json:"closed_at,omitempty"`
+}
+
+// ZReportLine is one row of a Z-report breakdown. Key is the payment method, category,
+// cashier ID, hour of day ("00"-"23") or product ID; Label is the cashier or product name.
+type ZReportLine struct {
+	Kind       string  `json:"-"`
+	Key        string  `json:"key"`
+	Label      string  `json:"label,omitempty"`
+	SalesCount int     `json:"sales_count,omitempty"`
+	Quantity   int     `json:"quantity,omitempty"`
+	Amount     float64 `json:"amount"`
+	Cost       float64 `json:"cost,omitempty"`
+}
+
+// ZReportSale is a sale not yet on a Z-report
+type ZReportSale struct {
+	ID              string
+	CashierID       string
+	CashierName     string
+	Status          string
+	GrossAmount     float64
+	PromotionAmount float64
+	DiscountAmount  float64
+	TaxAmount       float64
+	TotalAmount     float64
+	CreatedAt       int64
+}
+
+// ZReportItem is what was sold of one product on sales not yet on a Z-report, net of
+// refunds, with the tax included in Amount and the cost of the units taken from stock
+type ZReportItem struct {
+	ProductID   string
+	ProductName string
+	Category    string
+	Quantity    int
+	Amount      float64
+	TaxAmount   float64
+	Cost        float64
+}
+
+type ZReportRepository interface {
+	// GetUnreportedSales returns the branch's sales made before until that are not on a Z-report, voided ones included
+	GetUnreportedSales(branchID string, until int64) ([]*ZReportSale, error)
+	// GetUnreportedItems totals the items on the branch's unreported, non-voided sales per product
+	GetUnreportedItems(branchID string, until int64) ([]*ZReportItem, error)
+	// GetUnreportedTenders totals the branch's unreported, non-voided sales per payment method
+	GetUnreportedTenders(branchID string, until int64) ([]TenderTotal, error)
+	// GetUnreportedRefunds counts and totals the branch's completed refunds not on a Z-report
+	GetUnreportedRefunds(branchID string, until int64) (int, float64, error)
+	// GetLastZReport returns the branch's latest Z-report without its lines, or ErrZReportNotFound
+	GetLastZReport(branchID string) (*ZReport, error)
+	// CreateZReport numbers the report, marks its sales and refunds as reported and saves it with
+	// its lines. Fails with ErrZReportStale if the sales or refunds no longer match the report.
+	CreateZReport(r *ZReport) error
+	GetZReportByID(id string) (*ZReport, error)
+	GetZReports(businessID, branchID string, limit, offset int) ([]*ZReport, error)
+}
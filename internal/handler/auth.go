@@ -4,8 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"strconv"
-
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/pkg/dto"
 	"github.com/joshuaolumoye/pos-backend/pkg/utils"
 	"go.uber.org/zap"
@@ -24,53 +23,54 @@ func RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate the refresh token
-	userID, role, err := AuthRepo.ValidateToken(req.RefreshToken)
+	identity, err := AuthRepo.ValidateToken(req.RefreshToken)
 	if err != nil {
 		utils.Logger.Error("Invalid refresh token", zap.Error(err))
 		http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
 		return
 	}
 
-	utils.Logger.Info("Refresh token validated", zap.String("userID", userID), zap.String("role", role))
+	utils.Logger.Info("Refresh token validated",
+		zap.String("userID", identity.UserID),
+		zap.String("userType", identity.UserType),
+		zap.String("role", identity.Role))
+
+	// Re-read the account so role or branch changes since login take effect
+	if identity.UserType == domain.UserTypeStaff {
+		staff, err := StaffUC.StaffRepo.GetStaffByID(identity.UserID)
+		if err != nil || staff.BusinessID != identity.BusinessID {
+			utils.Logger.Error("Failed to fetch staff for refresh", zap.String("userID", identity.UserID))
+			http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+		identity.BranchID = staff.BranchID
+		identity.Role = string(staff.Role)
+	} else {
+		if _, err := BusinessUC.BusinessRepo.GetBusinessByID(identity.BusinessID); err != nil {
+			utils.Logger.Error("Failed to fetch business", zap.Error(err))
+			http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+	}
 
 	// Generate new tokens
-	newAccessToken, newRefreshToken, err := AuthRepo.CreateToken(userID, role)
+	newAccessToken, newRefreshToken, err := AuthRepo.CreateToken(identity)
 	if err != nil {
 		utils.Logger.Error("Failed to generate new tokens", zap.Error(err))
 		http.Error(w, "token generation failed", http.StatusInternalServerError)
 		return
 	}
 
-	// Convert userID from string to uint
-	uid64, err := strconv.ParseUint(userID, 10, 64)
-	if err != nil {
-		utils.Logger.Error("Invalid userID format", zap.Error(err))
-		http.Error(w, "invalid user ID", http.StatusBadRequest)
-		return
-	}
-	uid := uint(uid64)
-
-	// Get business details
-	business, err := BusinessUC.BusinessRepo.GetBusinessByID(strconv.FormatUint(uint64(uid), 10))
-	if err != nil {
-		utils.Logger.Error("Failed to fetch business", zap.Error(err))
-		http.Error(w, "failed to fetch user details", http.StatusInternalServerError)
-		return
-	}
-
-	businessIDUint := uint(0)
-	if parsed, err := strconv.ParseUint(business.ID, 10, 64); err == nil {
-		businessIDUint = uint(parsed)
-	}
 	resp := dto.RefreshTokenResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
-		BusinessID:   businessIDUint,
-		Role:         role,
+		BusinessID:   identity.BusinessID,
+		BranchID:     identity.BranchID,
+		UserType:     identity.UserType,
+		Role:         identity.Role,
 	}
 
-	businessIDUint64, _ := strconv.ParseUint(business.ID, 10, 64)
-	utils.Logger.Info("Tokens refreshed successfully", zap.String("businessID", strconv.FormatUint(businessIDUint64, 10)))
+	utils.Logger.Info("Tokens refreshed successfully", zap.String("businessID", identity.BusinessID))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
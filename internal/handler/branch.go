@@ -27,6 +27,12 @@ func CreateBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Branch-scoped staff cannot open new branches
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: only business-wide users can create branches")
+		return
+	}
+
 	branch := &domain.Branch{
 		ID:            utils.GenerateUUID(),
 		BusinessID:    businessID,
@@ -70,6 +76,9 @@ func GetBranchesHandler(w http.ResponseWriter, r *http.Request) {
 
 	var branchResponses []dto.BranchResponse
 	for _, branch := range branches {
+		if !middleware.CanAccessBranch(r.Context(), branch.ID) {
+			continue
+		}
 		if search == "" || utils.FuzzyMatch(branch.BranchName, search) {
 			branchResponses = append(branchResponses, dto.BranchResponse{
 				BranchID:      branch.ID,
@@ -113,6 +122,11 @@ func UpdateBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.CanAccessBranch(r.Context(), branchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only manage your own branch")
+		return
+	}
+
 	branch := &domain.Branch{
 		ID:            branchID,
 		BusinessID:    businessID, // ✅ From JWT, not query params
@@ -157,6 +171,11 @@ func DeleteBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.CanAccessBranch(r.Context(), branchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only manage your own branch")
+		return
+	}
+
 	err := BranchUC.DeleteBranch(branchID, businessID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
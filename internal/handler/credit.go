@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var CreditUC *usecase.CreditUsecase
+
+// CreateCreditAccountHandler opens a credit account for a customer
+// Route: POST /api/credit-accounts
+func CreateCreditAccountHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := creditAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.CreditAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	account, err := CreditUC.CreateAccount(businessID, userID, &req)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, account)
+}
+
+// GetCreditAccountsHandler lists the business's credit accounts, biggest balance first
+// Route: GET /api/credit-accounts
+func GetCreditAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	accounts, err := CreditUC.GetAccounts(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if accounts == nil {
+		accounts = []*domain.CreditAccount{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"credit_accounts": accounts})
+}
+
+// GetCreditAccountHandler returns a credit account and its ledger, newest first
+// Route: GET /api/credit-accounts/{id}?page=1&per_page=20
+func GetCreditAccountHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	limit, offset := parsePagination(r)
+	statement, err := CreditUC.Statement(chi.URLParam(r, "id"), businessID, limit, offset)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statement)
+}
+
+// UpdateCreditAccountHandler changes a credit account's limit or suspends it
+// Route: PUT /api/credit-accounts/{id}
+func UpdateCreditAccountHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := creditAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.CreditAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	account, err := CreditUC.UpdateAccount(chi.URLParam(r, "id"), businessID, &req)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, account)
+}
+
+// RecordRepaymentHandler records money a debtor paid towards their balance.
+// Branch-scoped staff record repayments against their own branch.
+// Route: POST /api/credit-accounts/{id}/repayments
+func RecordRepaymentHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.RepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if req.BranchID == "" {
+		req.BranchID = middleware.GetBranchScopeFromContext(r.Context())
+	}
+	if req.BranchID != "" && !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only take repayments for your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	entry, account, err := CreditUC.RecordRepayment(chi.URLParam(r, "id"), businessID, userID, &req)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"repayment": entry,
+		"account":   account,
+	})
+}
+
+// GetDebtorsAgeingHandler reports what every debtor owes by age: 0-30, 31-60, 61-90 and over 90 days
+// Route: GET /api/reports/debtors-ageing
+func GetDebtorsAgeingHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	report, err := CreditUC.AgeingReport(businessID, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func writeCreditError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrCreditAccountNotFound) {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSONError(w, http.StatusBadRequest, err.Error())
+}
+
+// creditAdminBusinessID resolves the business for opening and changing credit accounts.
+// A debtor's account covers every branch, so branch-scoped staff may not change it.
+func creditAdminBusinessID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", false
+	}
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: credit accounts can only be managed business-wide")
+		return "", false
+	}
+	return businessID, true
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var CustomerUC *usecase.CustomerUsecase
+
+// CustomerRequest is the body for creating or updating a customer
+type CustomerRequest struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Notes string `json:"notes"`
+}
+
+// CreateCustomerHandler adds a customer to the business
+// Route: POST /api/customers
+func CreateCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	var req CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	c := &domain.Customer{
+		BusinessID: businessID,
+		Name:       req.Name,
+		Phone:      req.Phone,
+		Email:      req.Email,
+		Notes:      req.Notes,
+	}
+	if err := CustomerUC.CreateCustomer(c); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// GetCustomersHandler lists the business's customers by name, optionally filtered by name, phone or email
+// Route: GET /api/customers?q=&page=1&per_page=20
+func GetCustomersHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	limit, offset := parsePagination(r)
+	customers, err := CustomerUC.GetCustomers(businessID, r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if customers == nil {
+		customers = []*domain.Customer{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"customers": customers})
+}
+
+// LookupCustomerHandler finds a customer by phone number at the till
+// Route: GET /api/customers/lookup?phone=
+func LookupCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	c, err := CustomerUC.FindByPhone(businessID, r.URL.Query().Get("phone"))
+	if err != nil {
+		status := http.StatusNotFound
+		if err.Error() != "customer not found" {
+			status = http.StatusBadRequest
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// GetCustomerHandler returns a single customer
+// Route: GET /api/customers/{id}
+func GetCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	c, err := CustomerUC.GetCustomer(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// UpdateCustomerHandler replaces a customer's details
+// Route: PUT /api/customers/{id}
+func UpdateCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	var req CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	c := &domain.Customer{
+		ID:         chi.URLParam(r, "id"),
+		BusinessID: businessID,
+		Name:       req.Name,
+		Phone:      req.Phone,
+		Email:      req.Email,
+		Notes:      req.Notes,
+	}
+	if err := CustomerUC.UpdateCustomer(c); err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "customer not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// DeleteCustomerHandler removes a customer; their past sales are kept
+// Route: DELETE /api/customers/{id}
+func DeleteCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	if err := CustomerUC.DeleteCustomer(chi.URLParam(r, "id"), businessID); err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"message": "Customer deleted successfully"})
+}
+
+// GetCustomerHistoryHandler returns a customer's purchases, newest first, with their lifetime value.
+// Branch-scoped staff only see purchases made in their own branch.
+// Route: GET /api/customers/{id}/history?branch_id=&page=1&per_page=20
+func GetCustomerHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(r)
+	history, err := CustomerUC.GetHistory(chi.URLParam(r, "id"), businessID, branchID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "customer not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var HeldCartUC *usecase.HeldCartUsecase
+
+// HoldCartHandler parks a basket at a branch
+// Route: POST /api/held-carts
+func HoldCartHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.HoldCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only hold carts at your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	cart, err := HeldCartUC.HoldCart(&req, businessID, userID)
+	if err != nil {
+		writeHeldCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, cart)
+}
+
+// GetHeldCartsHandler lists the carts held at a branch, oldest first
+// Route: GET /api/held-carts?branch_id=
+func GetHeldCartsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	carts, err := HeldCartUC.GetHeldCarts(businessID, branchID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if carts == nil {
+		carts = []*domain.HeldCart{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"held_carts": carts})
+}
+
+// ResumeHeldCartHandler returns a held cart with warnings for prices and stock that changed
+// since it was held
+// Route: GET /api/held-carts/{id}
+func ResumeHeldCartHandler(w http.ResponseWriter, r *http.Request) {
+	cart, ok := loadHeldCart(w, r)
+	if !ok {
+		return
+	}
+	resumed, err := HeldCartUC.ResumeHeldCart(cart.ID, cart.BusinessID)
+	if err != nil {
+		writeHeldCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resumed)
+}
+
+// UpdateHeldCartHandler replaces a held cart's basket
+// Route: PUT /api/held-carts/{id}
+func UpdateHeldCartHandler(w http.ResponseWriter, r *http.Request) {
+	cart, ok := loadHeldCart(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.HoldCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	updated, err := HeldCartUC.UpdateHeldCart(cart.ID, cart.BusinessID, &req)
+	if err != nil {
+		writeHeldCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DiscardHeldCartHandler throws a held cart away
+// Route: DELETE /api/held-carts/{id}
+func DiscardHeldCartHandler(w http.ResponseWriter, r *http.Request) {
+	cart, ok := loadHeldCart(w, r)
+	if !ok {
+		return
+	}
+	if err := HeldCartUC.DiscardHeldCart(cart.ID, cart.BusinessID); err != nil {
+		writeHeldCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// CheckoutHeldCartHandler turns a held cart into a sale by the caller, at current prices
+// Route: POST /api/held-carts/{id}/checkout
+func CheckoutHeldCartHandler(w http.ResponseWriter, r *http.Request) {
+	cart, ok := loadHeldCart(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.CheckoutHeldCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	identity := middleware.GetIdentityFromContext(r.Context())
+	resp, err := HeldCartUC.CheckoutHeldCart(cart.ID, cart.BusinessID, identity.UserID, domain.StaffRole(identity.Role), &req)
+	if err != nil {
+		if errors.Is(err, domain.ErrHeldCartNotFound) {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSONError(w, saleErrorStatus(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// loadHeldCart fetches the held cart named in the URL and checks it is within the caller's business and branch
+func loadHeldCart(w http.ResponseWriter, r *http.Request) (*domain.HeldCart, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	cart, err := HeldCartUC.FindHeldCart(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), cart.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: held cart belongs to another branch")
+		return nil, false
+	}
+	return cart, true
+}
+
+func writeHeldCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrHeldCartNotFound), err.Error() == "branch not found":
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case err.Error() == "unauthorized":
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+	case err.Error() == "label is required", err.Error() == "a held cart needs at least one item",
+		err.Error() == "quantity must be greater than 0", err.Error() == "product not found":
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var LoyaltyUC *usecase.LoyaltyUsecase
+
+// GetLoyaltyStatementHandler returns a customer's loyalty points balance and earn/burn history
+// Route: GET /api/customers/{id}/loyalty?page=1&per_page=20
+func GetLoyaltyStatementHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	limit, offset := parsePagination(r)
+	statement, err := LoyaltyUC.Statement(chi.URLParam(r, "id"), businessID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "customer not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, statement)
+}
@@ -32,31 +32,16 @@ func AddProductHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract staff_id from JWT if available (for staff users)
-	// In this codebase, businessID in context is set to claims.UserID, which for staff is their staff ID, for owner is business ID
-	// So we need to determine if this is a staff or owner
-	// Let's try to fetch staff by this ID; if found, use their businessID, else treat as owner
-	staff, _ := StaffUC.StaffRepo.GetStaffByID(businessID)
-	var realBusinessID, createdBy string
-	var updatedBy *string
-	if staff != nil {
-		// Staff user
-		realBusinessID = staff.BusinessID
-		createdBy = staff.ID
-		updatedBy = &staff.ID
-		// Only allow certain roles
-		switch staff.Role {
-		case domain.RoleOwner, domain.RoleManager, domain.RoleCashier, domain.RoleInventory:
-			// allowed
-		default:
-			http.Error(w, "unauthorized staff role", http.StatusForbidden)
-			return
-		}
-	} else {
-		// Owner user
-		realBusinessID = businessID
-		createdBy = businessID
-		updatedBy = &businessID
+	// created_by is the user_id from JWT (staff ID, or business ID for owners)
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		http.Error(w, "missing or invalid user_id in token", http.StatusUnauthorized)
+		return
+	}
+
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only add products to your own branch")
+		return
 	}
 
 	var barcodePtr *string
@@ -67,7 +52,7 @@ func AddProductHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check for unique product name in branch
-	existingProducts, err := ProductUC.GetProductsByBranchID(realBusinessID, req.BranchID)
+	existingProducts, err := ProductUC.GetProductsByBranchID(businessID, req.BranchID)
 	if err == nil {
 		for _, p := range existingProducts {
 			if p.ProductName == req.ProductName {
@@ -91,9 +76,9 @@ func AddProductHandler(w http.ResponseWriter, r *http.Request) {
 		ExpiryDate:        req.ExpiryDate,
 		ProductImageURL:   req.ProductImageURL,
 		BranchID:          req.BranchID,
-		BusinessID:        realBusinessID, // Always from context or staff
-		CreatedBy:         createdBy,      // staff ID or business ID
-		UpdatedBy:         updatedBy,      // staff ID or business ID
+		BusinessID:        businessID, // ✅ From JWT
+		CreatedBy:         userID,     // ✅ From JWT
+		UpdatedBy:         &userID,    // ✅ From JWT
 	}
 
 	err = ProductUC.AddProduct(product)
@@ -111,10 +96,11 @@ func AddProductHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			return ""
 		}(),
-		SellingPrice:    product.SellingPrice,
-		QuantityLeft:    product.QuantityInStock,
-		ProductImageURL: product.ProductImageURL,
-		Message:         "Product added successfully",
+		SellingPrice:      product.SellingPrice,
+		QuantityLeft:      product.QuantityInStock,
+		QuantityAvailable: product.QuantityInStock,
+		ProductImageURL:   product.ProductImageURL,
+		Message:           "Product added successfully",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -144,8 +130,24 @@ func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Updated_by should be the user_id from JWT
-	updatedBy := &businessID // Using businessID as updater for now
+	// Both the product's current branch and any branch it is moved to must be in scope
+	existing, err := ProductUC.GetProductByID(productID)
+	if err != nil {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), existing.BranchID) || !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only manage products in your own branch")
+		return
+	}
+
+	// Updated_by is the user_id from JWT
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		http.Error(w, "missing or invalid user_id in token", http.StatusUnauthorized)
+		return
+	}
+	updatedBy := &userID
 
 	product := &domain.Product{
 		ID:                productID,
@@ -164,11 +166,16 @@ func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 		UpdatedBy:         updatedBy,  // ✅ From JWT
 	}
 
-	err := ProductUC.UpdateProduct(product)
+	err = ProductUC.UpdateProduct(product)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	reserved, err := ProductUC.ReservedQuantities([]*domain.Product{product})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	resp := dto.ProductResponse{
 		ID:          product.ID,
@@ -179,10 +186,12 @@ func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			return ""
 		}(),
-		SellingPrice:    product.SellingPrice,
-		QuantityLeft:    product.QuantityInStock,
-		ProductImageURL: product.ProductImageURL,
-		Message:         "Product updated successfully",
+		SellingPrice:      product.SellingPrice,
+		QuantityLeft:      product.QuantityInStock,
+		QuantityReserved:  reserved[product.ID],
+		QuantityAvailable: max(product.QuantityInStock-reserved[product.ID], 0),
+		ProductImageURL:   product.ProductImageURL,
+		Message:           "Product updated successfully",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -213,8 +222,13 @@ func GetProductHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify product belongs to the authenticated business
-	if product.BusinessID != businessID {
-		http.Error(w, "unauthorized access", http.StatusForbidden)
+	if product.BusinessID != businessID || !middleware.CanAccessBranch(r.Context(), product.BranchID) {
+		middleware.WriteForbidden(w, "unauthorized access")
+		return
+	}
+	reserved, err := ProductUC.ReservedQuantities([]*domain.Product{product})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -227,9 +241,11 @@ func GetProductHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			return ""
 		}(),
-		SellingPrice:    product.SellingPrice,
-		QuantityLeft:    product.QuantityInStock,
-		ProductImageURL: product.ProductImageURL,
+		SellingPrice:      product.SellingPrice,
+		QuantityLeft:      product.QuantityInStock,
+		QuantityReserved:  reserved[product.ID],
+		QuantityAvailable: max(product.QuantityInStock-reserved[product.ID], 0),
+		ProductImageURL:   product.ProductImageURL,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -245,8 +261,15 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Optional: filter by branch_id
+	// Optional: filter by branch_id; branch-scoped users only see their own branch
 	branchID := r.URL.Query().Get("branch_id")
+	if scope := middleware.GetBranchScopeFromContext(r.Context()); scope != "" {
+		if branchID != "" && branchID != scope {
+			middleware.WriteForbidden(w, "forbidden: you can only view your own branch")
+			return
+		}
+		branchID = scope
+	}
 
 	var (
 		products []*domain.Product
@@ -259,6 +282,11 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 		products, err = ProductUC.GetProductsByBusinessID(businessID)
 	}
 
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reserved, err := ProductUC.ReservedQuantities(products)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -275,9 +303,11 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 				}
 				return ""
 			}(),
-			SellingPrice:    p.SellingPrice,
-			QuantityLeft:    p.QuantityInStock,
-			ProductImageURL: p.ProductImageURL,
+			SellingPrice:      p.SellingPrice,
+			QuantityLeft:      p.QuantityInStock,
+			QuantityReserved:  reserved[p.ID],
+			QuantityAvailable: max(p.QuantityInStock-reserved[p.ID], 0),
+			ProductImageURL:   p.ProductImageURL,
 		})
 	}
 
@@ -306,6 +336,11 @@ func DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if existing, err := ProductUC.GetProductByID(productID); err == nil && !middleware.CanAccessBranch(r.Context(), existing.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only manage products in your own branch")
+		return
+	}
+
 	err := ProductUC.DeleteProduct(productID, businessID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -321,7 +356,9 @@ type NotificationProductResponse struct {
 	Barcode      *string `json:"barcode"`
 	SellingPrice float64 `json:"selling_price"`
 	QuantityLeft int     `json:"quantity_left"`
-	ExpiryDate   *int64  `json:"expiring_date"`
+	// On hand less what reservations hold
+	QuantityAvailable int    `json:"quantity_available"`
+	ExpiryDate        *int64 `json:"expiring_date"`
 }
 
 // GetProductNotificationsHandler handles notification queries for products
@@ -374,15 +411,21 @@ func GetProductNotificationsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	reserved, err := ProductUC.ReservedQuantities(products)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	var resp []NotificationProductResponse
 	for _, p := range products {
 		resp = append(resp, NotificationProductResponse{
-			ProductName:  p.ProductName,
-			Barcode:      p.BarcodeValue,
-			SellingPrice: p.SellingPrice,
-			QuantityLeft: p.QuantityInStock,
-			ExpiryDate:   p.ExpiryDate,
+			ProductName:       p.ProductName,
+			Barcode:           p.BarcodeValue,
+			SellingPrice:      p.SellingPrice,
+			QuantityLeft:      p.QuantityInStock,
+			QuantityAvailable: max(p.QuantityInStock-reserved[p.ID], 0),
+			ExpiryDate:        p.ExpiryDate,
 		})
 	}
 
@@ -509,56 +552,38 @@ func GetDashboardStatsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Determine if user is staff or owner
-	var branchID string
-	var realBusinessID string
-	var businessName string
-	staff, _ := StaffUC.StaffRepo.GetStaffByID(businessID)
-	if staff != nil {
-		// Staff user
-		realBusinessID = staff.BusinessID
-		branchID = staff.BranchID
-		biz, err := BusinessUC.BusinessRepo.GetBusinessByID(realBusinessID)
-		if err != nil {
-			http.Error(w, "business not found", http.StatusNotFound)
-			return
-		}
-		businessName = biz.Name
-	} else {
-		// Owner user
-		realBusinessID = businessID
-		branchID = ""
-		biz, err := BusinessUC.BusinessRepo.GetBusinessByID(realBusinessID)
-		if err != nil {
-			http.Error(w, "business not found", http.StatusNotFound)
-			return
-		}
-		businessName = biz.Name
+	// Branch-scoped staff see their own branch; owners see the whole business
+	branchID := middleware.GetBranchScopeFromContext(r.Context())
+	biz, err := BusinessUC.BusinessRepo.GetBusinessByID(businessID)
+	if err != nil {
+		http.Error(w, "business not found", http.StatusNotFound)
+		return
 	}
+	businessName := biz.Name
 
 	// Get total sales today
-	totalSalesToday, err := SaleUC.SaleRepo.GetTotalSalesToday(realBusinessID, branchID)
+	totalSalesToday, err := SaleUC.TotalSalesToday(businessID, branchID)
 	if err != nil {
 		http.Error(w, "failed to get total sales today", http.StatusInternalServerError)
 		return
 	}
 
 	// Get total revenue
-	totalRevenue, err := SaleUC.SaleRepo.GetTotalRevenue(realBusinessID, branchID)
+	totalRevenue, err := SaleUC.SaleRepo.GetTotalRevenue(businessID, branchID)
 	if err != nil {
 		http.Error(w, "failed to get total revenue", http.StatusInternalServerError)
 		return
 	}
 
 	// Get low stock count
-	lowStockCount, err := ProductUC.ProductRepo.GetLowStockCount(realBusinessID, branchID)
+	lowStockCount, err := ProductUC.ProductRepo.GetLowStockCount(businessID, branchID)
 	if err != nil {
 		http.Error(w, "failed to get low stock count", http.StatusInternalServerError)
 		return
 	}
 
 	// Get 5 recent transactions
-	recentSales, err := SaleUC.SaleRepo.GetRecentSales(realBusinessID, branchID, 5)
+	recentSales, err := SaleUC.SaleRepo.GetRecentSales(businessID, branchID, 5)
 	if err != nil {
 		http.Error(w, "failed to get recent transactions", http.StatusInternalServerError)
 		return
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var PromotionUC *usecase.PromotionUsecase
+
+// CreatePromotionHandler adds a promotion that is applied automatically to matching sales
+// Route: POST /api/promotions
+func CreatePromotionHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := promotionAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	promotion, err := PromotionUC.CreatePromotion(&req, businessID, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, promotion)
+}
+
+// GetPromotionsHandler lists the business's promotions, latest start first
+// Route: GET /api/promotions?active=true
+func GetPromotionsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	promotions, err := PromotionUC.ListPromotions(businessID, r.URL.Query().Get("active") == "true")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if promotions == nil {
+		promotions = []*domain.Promotion{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"promotions": promotions})
+}
+
+// GetPromotionHandler returns one promotion
+// Route: GET /api/promotions/{id}
+func GetPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	promotion, err := PromotionUC.GetPromotion(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, promotion)
+}
+
+// UpdatePromotionHandler replaces a promotion's rules
+// Route: PUT /api/promotions/{id}
+func UpdatePromotionHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := promotionAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	promotion, err := PromotionUC.UpdatePromotion(chi.URLParam(r, "id"), businessID, &req)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, promotion)
+}
+
+// EndPromotionHandler stops a promotion straight away
+// Route: POST /api/promotions/{id}/end
+func EndPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := promotionAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	promotion, err := PromotionUC.EndPromotion(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, promotion)
+}
+
+// GetPromotionReportHandler totals the revenue and the amount given away per promotion.
+// Branch-scoped staff only see their own branch.
+// Route: GET /api/reports/promotions?from=&to=&branch_id=
+func GetPromotionReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(w, r, businessID)
+	if !ok {
+		return
+	}
+	report, err := PromotionUC.Report(businessID, branchID, from, to)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func writePromotionError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, domain.ErrPromotionNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSONError(w, status, err.Error())
+}
+
+// promotionAdminBusinessID resolves the business for changing promotions.
+// Promotions may span branches, so branch-scoped staff may not manage them.
+func promotionAdminBusinessID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", false
+	}
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: promotions can only be managed business-wide")
+		return "", false
+	}
+	return businessID, true
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var PurchaseOrderUC *usecase.PurchaseOrderUsecase
+
+// CreatePurchaseOrderHandler drafts a purchase order for a branch
+// Route: POST /api/purchase-orders
+func CreatePurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only order stock for your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	po, err := PurchaseOrderUC.CreatePurchaseOrder(&req, businessID, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, po)
+}
+
+// GetPurchaseOrdersHandler lists purchase orders within the caller's branch scope
+// Route: GET /api/purchase-orders?branch_id=&supplier_id=&status=
+func GetPurchaseOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	orders, err := PurchaseOrderUC.ListPurchaseOrders(businessID, branchID, q.Get("supplier_id"), q.Get("status"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if orders == nil {
+		orders = []*domain.PurchaseOrder{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"purchase_orders": orders})
+}
+
+// GetPurchaseOrderHandler returns a purchase order with its goods-received notes
+// Route: GET /api/purchase-orders/{id}
+func GetPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	po, ok := loadPurchaseOrder(w, r)
+	if !ok {
+		return
+	}
+	notes, err := PurchaseOrderUC.GetGoodsReceivedNotes(po.ID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if notes == nil {
+		notes = []*domain.GoodsReceivedNote{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"purchase_order":       po,
+		"goods_received_notes": notes,
+	})
+}
+
+// SendPurchaseOrderHandler marks a draft order as sent to the supplier
+// Route: POST /api/purchase-orders/{id}/send
+func SendPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	po, ok := loadPurchaseOrder(w, r)
+	if !ok {
+		return
+	}
+	sent, err := PurchaseOrderUC.SendPurchaseOrder(po.ID)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, sent)
+}
+
+// CancelPurchaseOrderHandler cancels an order nothing has been received against
+// Route: POST /api/purchase-orders/{id}/cancel
+func CancelPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	po, ok := loadPurchaseOrder(w, r)
+	if !ok {
+		return
+	}
+	cancelled, err := PurchaseOrderUC.CancelPurchaseOrder(po.ID)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, cancelled)
+}
+
+// ReceiveGoodsHandler records a goods-received note, adding the stock and updating cost prices
+// Route: POST /api/purchase-orders/{id}/receive
+func ReceiveGoodsHandler(w http.ResponseWriter, r *http.Request) {
+	po, ok := loadPurchaseOrder(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.ReceiveGoodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	updated, grn, err := PurchaseOrderUC.ReceiveGoods(po, &req, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"purchase_order":      updated,
+		"goods_received_note": grn,
+	})
+}
+
+// GetReorderReportHandler suggests reorder quantities from low-stock thresholds and recent sales,
+// less what is still due on open purchase orders
+// Route: GET /api/reports/reorder?branch_id=&days=30&cover_days=14
+func GetReorderReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	days, coverDays := 30, 14
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid days")
+			return
+		}
+		days = n
+	}
+	if v := r.URL.Query().Get("cover_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid cover_days")
+			return
+		}
+		coverDays = n
+	}
+	suggestions, err := PurchaseOrderUC.ReorderReport(businessID, branchID, days, coverDays)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"days":        days,
+		"cover_days":  coverDays,
+		"suggestions": suggestions,
+	})
+}
+
+func loadPurchaseOrder(w http.ResponseWriter, r *http.Request) (*domain.PurchaseOrder, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	po, err := PurchaseOrderUC.GetPurchaseOrder(chi.URLParam(r, "id"))
+	if err != nil || po.BusinessID != businessID {
+		writeJSONError(w, http.StatusNotFound, "purchase order not found")
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), po.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: purchase order belongs to another branch")
+		return nil, false
+	}
+	return po, true
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var RecallUC *usecase.RecallUsecase
+
+// CreateRecallHandler starts a recall, blocking sales of the matching stock in every branch
+// Route: POST /api/recalls
+func CreateRecallHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := recallAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.CreateRecallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	recall, err := RecallUC.CreateRecall(&req, businessID, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, recall)
+}
+
+// GetRecallsHandler lists the business's recalls, newest first
+// Route: GET /api/recalls?status=active|closed
+func GetRecallsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	recalls, err := RecallUC.ListRecalls(businessID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if recalls == nil {
+		recalls = []*domain.Recall{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recalls": recalls})
+}
+
+// CloseRecallHandler ends a recall so the remaining matching stock can be sold again
+// Route: POST /api/recalls/{id}/close
+func CloseRecallHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := recallAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	recall, err := RecallUC.CloseRecall(chi.URLParam(r, "id"), businessID, userID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "recall not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, recall)
+}
+
+// GetRecallReportHandler lists the branches holding recalled stock and the sales that dispensed it.
+// Branch-scoped staff only see their own branch. ?format=csv downloads the report as CSV.
+// Route: GET /api/recalls/{id}/report?branch_id=&format=csv
+func GetRecallReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	recall, err := RecallUC.GetRecall(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	report, err := RecallUC.Report(recall, branchID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if r.URL.Query().Get("format") == "csv" {
+		writeRecallCSV(w, report)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// writeRecallCSV writes one row per recalled stock line ("on_hand") and per dispensing sale ("sold")
+func writeRecallCSV(w http.ResponseWriter, report *domain.RecallReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"recall-%s-%s.csv\"",
+		report.Recall.NAFDACRegNumber, time.Unix(report.GeneratedAt, 0).Format("20060102")))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"type", "branch_id", "branch_name", "product_id", "product_name", "batch_number",
+		"expiry_date", "quantity", "sale_id", "cashier_id", "sold_at"})
+	for _, s := range report.Stock {
+		cw.Write([]string{"on_hand", s.BranchID, s.BranchName, s.ProductID, s.ProductName, s.BatchNumber,
+			csvDate(s.ExpiryDate), strconv.Itoa(s.Quantity), "", "", ""})
+	}
+	for _, s := range report.Sales {
+		cw.Write([]string{"sold", s.BranchID, s.BranchName, s.ProductID, s.ProductName, s.BatchNumber,
+			csvDate(s.ExpiryDate), strconv.Itoa(s.Quantity), s.SaleID, s.CashierID,
+			time.Unix(s.SoldAt, 0).UTC().Format(time.RFC3339)})
+	}
+	cw.Flush()
+}
+
+func csvDate(ts *int64) string {
+	if ts == nil {
+		return ""
+	}
+	return time.Unix(*ts, 0).UTC().Format("2006-01-02")
+}
+
+// recallAdminBusinessID resolves the business for starting or closing a recall.
+// Recalls apply to every branch, so branch-scoped staff may not manage them.
+func recallAdminBusinessID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", false
+	}
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: recalls can only be managed business-wide")
+		return "", false
+	}
+	return businessID, true
+}
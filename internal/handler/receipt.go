@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var ReceiptUC *usecase.ReceiptUsecase
+
+// GetReceiptHandler renders a sale's receipt as JSON (the default), plain text or an ESC/POS
+// byte stream for 58mm or 80mm thermal paper (default 80), or a PDF for sharing
+// Route: GET /api/sales/{id}/receipt?format=json|text|escpos|pdf&paper=58|80
+func GetReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = domain.ReceiptFormatJSON
+	}
+	width := domain.ReceiptWidth80mm
+	switch r.URL.Query().Get("paper") {
+	case "", "80":
+	case "58":
+		width = domain.ReceiptWidth58mm
+	default:
+		writeJSONError(w, http.StatusBadRequest, "paper must be 58 or 80")
+		return
+	}
+
+	receipt, err := ReceiptUC.GetReceipt(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		if err.Error() == "sale not found" {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), receipt.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: sale belongs to another branch")
+		return
+	}
+	if format == domain.ReceiptFormatJSON {
+		writeJSON(w, http.StatusOK, receipt)
+		return
+	}
+	body, contentType, err := ReceiptUC.RenderReceipt(receipt, format, width)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if format == domain.ReceiptFormatPDF {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"receipt-%s.pdf\"", receipt.Number()))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
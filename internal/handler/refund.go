@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var RefundUC *usecase.RefundUsecase
+
+// RejectRefundRequest optionally records why a refund was turned down
+type RejectRefundRequest struct {
+	Note string `json:"note"`
+}
+
+// CreateRefundHandler refunds some or all items of a sale
+// Route: POST /api/sales/{id}/refunds
+func CreateRefundHandler(w http.ResponseWriter, r *http.Request) {
+	sale, ok := loadRefundableSale(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.CreateRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	canApprove := middleware.HasPermission(r.Context(), domain.PermRefundApprove)
+
+	refund, err := RefundUC.RequestRefund(&req, sale, userID, canApprove)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "unauthorized" {
+			status = http.StatusUnauthorized
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	status := http.StatusCreated
+	if refund.Status == domain.RefundStatusPendingApproval {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, refund)
+}
+
+// GetSaleRefundsHandler lists every refund raised against a sale
+// Route: GET /api/sales/{id}/refunds
+func GetSaleRefundsHandler(w http.ResponseWriter, r *http.Request) {
+	sale, ok := loadRefundableSale(w, r)
+	if !ok {
+		return
+	}
+	refunds, err := RefundUC.GetRefundsForSale(sale.ID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"refunds": refunds})
+}
+
+// GetPendingRefundsHandler lists refunds waiting for approval within the caller's branch scope
+// Route: GET /api/refunds/pending
+func GetPendingRefundsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	refunds, err := RefundUC.GetPendingRefunds(businessID, branchID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"refunds": refunds})
+}
+
+// ApproveRefundHandler applies a pending refund
+// Route: POST /api/refunds/{id}/approve
+func ApproveRefundHandler(w http.ResponseWriter, r *http.Request) {
+	refund, ok := loadPendingRefund(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	approved, err := RefundUC.ApproveRefund(refund, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, approved)
+}
+
+// RejectRefundHandler turns down a pending refund without touching the sale or stock
+// Route: POST /api/refunds/{id}/reject
+func RejectRefundHandler(w http.ResponseWriter, r *http.Request) {
+	refund, ok := loadPendingRefund(w, r)
+	if !ok {
+		return
+	}
+	var req RejectRefundRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid input")
+			return
+		}
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	rejected, err := RefundUC.RejectRefund(refund.ID, userID, req.Note)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rejected)
+}
+
+// loadRefundableSale fetches the sale named in the URL and checks it is within the caller's business and branch
+func loadRefundableSale(w http.ResponseWriter, r *http.Request) (*domain.Sale, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	sale, err := RefundUC.GetSale(chi.URLParam(r, "id"))
+	if err != nil || sale.BusinessID != businessID {
+		writeJSONError(w, http.StatusNotFound, "sale not found")
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), sale.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: sale belongs to another branch")
+		return nil, false
+	}
+	return sale, true
+}
+
+// loadPendingRefund fetches the refund named in the URL and checks it is within the caller's business and branch
+func loadPendingRefund(w http.ResponseWriter, r *http.Request) (*domain.Refund, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	refund, err := RefundUC.GetRefund(chi.URLParam(r, "id"))
+	if err != nil || refund.BusinessID != businessID {
+		writeJSONError(w, http.StatusNotFound, "refund not found")
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), refund.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: refund belongs to another branch")
+		return nil, false
+	}
+	return refund, true
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var ReservationUC *usecase.ReservationUsecase
+
+// CreateReservationHandler sets stock aside at a branch for a layaway or phone order
+// Route: POST /api/reservations
+func CreateReservationHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only reserve stock at your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	res, err := ReservationUC.CreateReservation(&req, businessID, userID)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// GetReservationsHandler lists reservations, newest first
+// Route: GET /api/reservations?branch_id=&status=&page=1&per_page=20
+func GetReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(r)
+	reservations, err := ReservationUC.GetReservations(businessID, branchID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if reservations == nil {
+		reservations = []*domain.Reservation{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reservations": reservations})
+}
+
+// GetReservationHandler returns a reservation with its items
+// Route: GET /api/reservations/{id}
+func GetReservationHandler(w http.ResponseWriter, r *http.Request) {
+	res, ok := loadReservation(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// ReleaseReservationHandler cancels an active reservation, putting its units back on sale
+// Route: POST /api/reservations/{id}/release
+func ReleaseReservationHandler(w http.ResponseWriter, r *http.Request) {
+	res, ok := loadReservation(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	released, err := ReservationUC.ReleaseReservation(res.ID, res.BusinessID, userID)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, released)
+}
+
+// loadReservation fetches the reservation named in the URL and checks it is within the caller's business and branch
+func loadReservation(w http.ResponseWriter, r *http.Request) (*domain.Reservation, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	res, err := ReservationUC.FindReservation(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), res.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: reservation belongs to another branch")
+		return nil, false
+	}
+	return res, true
+}
+
+func writeReservationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrReservationNotFound), err.Error() == "branch not found":
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock), errors.Is(err, domain.ErrReservationNotActive):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case err.Error() == "unauthorized":
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+	case err.Error() == "reference is required", err.Error() == "expires_at must be in the future",
+		err.Error() == "a reservation cannot last more than 90 days", err.Error() == "a reservation needs at least one item",
+		err.Error() == "quantity must be greater than 0", err.Error() == "product not found":
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+)
+
+// writeJSON encodes v as the JSON response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes the {"error": true, "message": ...} body used by the sales endpoints
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":   true,
+		"message": message,
+	})
+}
+
+// parsePagination reads ?page=&per_page= (default 1 and 20, per_page capped at 100) as limit and offset
+func parsePagination(r *http.Request) (int, int) {
+	page := 1
+	perPage := 20
+	if v := r.URL.Query().Get("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := r.URL.Query().Get("per_page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			perPage = n
+		}
+	}
+	return perPage, (page - 1) * perPage
+}
+
+// scopedBranchFilter reads ?branch_id= for list endpoints, forcing branch-scoped staff onto their own branch
+func scopedBranchFilter(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", "", false
+	}
+	branchID := r.URL.Query().Get("branch_id")
+	if scope := middleware.GetBranchScopeFromContext(r.Context()); scope != "" {
+		if branchID != "" && branchID != scope {
+			middleware.WriteForbidden(w, "forbidden: you can only view your own branch")
+			return "", "", false
+		}
+		branchID = scope
+	}
+	return businessID, branchID, true
+}
+
+// parseTimeRange reads ?from=&to= (unix seconds, to exclusive) for report endpoints, defaulting
+// to today in the business's timezone
+func parseTimeRange(w http.ResponseWriter, r *http.Request, businessID string) (int64, int64, bool) {
+	settings, err := SettingsUC.GetSettings(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return 0, 0, false
+	}
+	today := settings.StartOfDay(time.Now())
+	from, to := today.Unix(), today.AddDate(0, 0, 1).Unix()
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid from")
+			return 0, 0, false
+		}
+		from = n
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid to")
+			return 0, 0, false
+		}
+		to = n
+	}
+	return from, to, true
+}
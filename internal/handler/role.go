@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var RBACUC *usecase.RBACUsecase
+
+// UpdateRolePermissionsRequest replaces the permissions granted by a role
+type UpdateRolePermissionsRequest struct {
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+// GetRolePermissionsHandler lists the effective permissions of every role plus the permission catalogue
+// Route: GET /api/roles/permissions
+func GetRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := roleAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	roles, err := RBACUC.GetRolePermissions(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"roles":       roles,
+		"permissions": domain.AllPermissions,
+	})
+}
+
+// UpdateRolePermissionsHandler customises the permissions a role grants for the business
+// Route: PUT /api/roles/{role}/permissions
+func UpdateRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := roleAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req UpdateRolePermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+
+	view, err := RBACUC.UpdateRolePermissions(businessID, domain.StaffRole(chi.URLParam(r, "role")), req.Permissions, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// ResetRolePermissionsHandler restores the default permissions of a role
+// Route: DELETE /api/roles/{role}/permissions
+func ResetRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := roleAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	view, err := RBACUC.ResetRolePermissions(businessID, domain.StaffRole(chi.URLParam(r, "role")))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// roleAdminBusinessID resolves the business whose roles are being managed.
+// Role policies are business-wide, so branch-scoped staff may not change them.
+func roleAdminBusinessID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", false
+	}
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: role permissions can only be managed business-wide")
+		return "", false
+	}
+	return businessID, true
+}
@@ -2,8 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/internal/middleware"
 	"github.com/joshuaolumoye/pos-backend/internal/usecase"
 	"github.com/joshuaolumoye/pos-backend/pkg/utils"
@@ -61,30 +65,232 @@ func CreateSaleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// All authenticated users can create sales - no role restriction
+	// Role permission is enforced by RBACMiddleware; staff may only sell from their own branch
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only create sales for your own branch")
+		return
+	}
 	utils.Logger.Info("Creating sale",
 		zap.String("businessID", businessID),
 		zap.String("cashierID", cashierID),
 		zap.String("role", role))
 
-	resp, err := SaleUC.CreateSale(&req, businessID, cashierID)
+	resp, err := SaleUC.CreateSale(&req, businessID, cashierID, domain.StaffRole(role))
 	if err != nil {
-		msg := err.Error()
-		status := http.StatusInternalServerError
-		if msg == "unauthorized" {
-			status = http.StatusUnauthorized
-		} else if msg == "invalid input" {
-			status = http.StatusBadRequest
-		} else if msg == "quantity must be greater than 0" || msg == "insufficient stock" || msg == "product does not belong to business" || msg == "stock would become negative" {
-			status = http.StatusBadRequest
-		}
-		w.WriteHeader(status)
+		w.WriteHeader(saleErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"error":   true,
-			"message": msg,
+			"message": err.Error(),
 		})
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+// saleErrorStatus maps an error from recording a sale to its HTTP status
+func saleErrorStatus(err error) int {
+	msg := err.Error()
+	status := http.StatusInternalServerError
+	if msg == "unauthorized" {
+		status = http.StatusUnauthorized
+	} else if msg == "invalid input" {
+		status = http.StatusBadRequest
+	} else if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrProductRecalled) || errors.Is(err, domain.ErrInsufficientPoints) || errors.Is(err, domain.ErrCreditLimitExceeded) || errors.Is(err, domain.ErrNoOpenShift) || errors.Is(err, domain.ErrReservationNotActive) || errors.Is(err, domain.ErrSaleExists) {
+		status = http.StatusConflict
+	} else if errors.Is(err, domain.ErrInvalidPayment) || errors.Is(err, domain.ErrInvalidDiscount) || errors.Is(err, domain.ErrCreditAccountNotFound) || errors.Is(err, domain.ErrReservationNotFound) || errors.Is(err, domain.ErrReservationMismatch) {
+		status = http.StatusBadRequest
+	} else if errors.Is(err, domain.ErrDiscountLimit) {
+		status = http.StatusForbidden
+	} else if msg == "quantity must be greater than 0" || msg == "product does not belong to business" || msg == "stock would become negative" || msg == "customer not found" || msg == "sale id must be a valid UUID" {
+		status = http.StatusBadRequest
+	}
+	return status
+}
+
+// ListSalesHandler lists sales newest first, filtered by creation time (unix seconds, from
+// inclusive, to exclusive), branch, cashier, payment method, status, total and a product the
+// sale contains. Pages are per_page long; pass the response's next_cursor as cursor for the next.
+// Route: GET /api/sales?from=&to=&branch_id=&cashier_id=&payment_method=&status=&min_amount=&max_amount=&product_id=&per_page=20&cursor=
+func ListSalesHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := domain.SaleFilter{
+		BranchID:      branchID,
+		CashierID:     q.Get("cashier_id"),
+		PaymentMethod: q.Get("payment_method"),
+		Status:        q.Get("status"),
+		ProductID:     q.Get("product_id"),
+	}
+	filter.Limit, _ = parsePagination(r)
+	var err error
+	if v := q.Get("from"); v != "" {
+		if filter.From, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.To, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+	}
+	if v := q.Get("min_amount"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid min_amount")
+			return
+		}
+		filter.MinAmount = &n
+	}
+	if v := q.Get("max_amount"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid max_amount")
+			return
+		}
+		filter.MaxAmount = &n
+	}
+	list, err := SaleUC.ListSales(businessID, filter, q.Get("cursor"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GetSaleHandler returns a sale with its items, their product names and the cashier
+// Route: GET /api/sales/{id}
+func GetSaleHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return
+	}
+	sale, err := SaleUC.GetSaleDetails(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		if err.Error() == "sale not found" {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), sale.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: sale belongs to another branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, sale)
+}
+
+// VoidSaleHandler cancels a sale made today and returns its items to stock.
+// Managers and owners may void any sale in their scope; other roles only their own.
+// Route: POST /api/sales/{id}/void
+func VoidSaleHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.VoidSaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	identity := middleware.GetIdentityFromContext(r.Context())
+	role := domain.StaffRole(identity.Role)
+	ownOnly := identity.UserType != domain.UserTypeOwner && role != domain.RoleOwner && role != domain.RoleManager
+
+	sale, err := SaleUC.GetSale(chi.URLParam(r, "id"))
+	if err != nil || sale.BusinessID != identity.BusinessID {
+		writeJSONError(w, http.StatusNotFound, "sale not found")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), sale.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: sale belongs to another branch")
+		return
+	}
+
+	voided, err := SaleUC.VoidSale(sale.ID, identity.BusinessID, identity.UserID, req.Reason, ownOnly)
+	if err != nil {
+		msg := err.Error()
+		switch msg {
+		case "unauthorized":
+			writeJSONError(w, http.StatusUnauthorized, msg)
+		case "you can only void your own sales":
+			middleware.WriteForbidden(w, "forbidden: "+msg)
+		case "sale not found":
+			writeJSONError(w, http.StatusNotFound, msg)
+		case "reason is required":
+			writeJSONError(w, http.StatusBadRequest, msg)
+		default:
+			writeJSONError(w, http.StatusConflict, msg)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, voided)
+}
+
+// GetTenderReportHandler reports revenue net of refunds per payment method for a period (unix seconds,
+// from inclusive, to exclusive), defaulting to today
+// Route: GET /api/reports/tenders?branch_id=&from=&to=
+func GetTenderReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(w, r, businessID)
+	if !ok {
+		return
+	}
+	report, err := SaleUC.RevenueByTender(businessID, branchID, from, to)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetDiscountReportHandler reports the discounts each cashier gave over a period (unix seconds,
+// from inclusive, to exclusive), defaulting to today
+// Route: GET /api/reports/discounts?branch_id=&from=&to=
+func GetDiscountReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(w, r, businessID)
+	if !ok {
+		return
+	}
+	report, err := SaleUC.DiscountsByCashier(businessID, branchID, from, to)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetSalesReportHandler reports sale counts, revenue, cost of goods, gross margin and average
+// basket between two dates (YYYY-MM-DD, inclusive) in the business's timezone, defaulting to
+// today, grouped by day, week or month
+// Route: GET /api/reports/sales?from=&to=&branch_id=&cashier_id=&category=&group_by=day
+func GetSalesReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	report, err := SaleUC.SalesReport(businessID, &usecase.SalesReportRequest{
+		From:      q.Get("from"),
+		To:        q.Get("to"),
+		BranchID:  branchID,
+		CashierID: q.Get("cashier_id"),
+		Category:  q.Get("category"),
+		GroupBy:   q.Get("group_by"),
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var ShiftUC *usecase.ShiftUsecase
+
+// OpenShiftHandler opens a till session for the current user with an opening float
+// Route: POST /api/shifts
+func OpenShiftHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only open shifts at your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	shift, err := ShiftUC.OpenShift(&req, businessID, userID)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, shift)
+}
+
+// GetCurrentShiftHandler returns the current user's open shift and the cash taken so far
+// Route: GET /api/shifts/current
+func GetCurrentShiftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	shift, err := ShiftUC.CurrentShift(userID)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, shift)
+}
+
+// GetShiftsHandler lists shifts, newest first
+// Route: GET /api/shifts?branch_id=&status=&page=1&per_page=20
+func GetShiftsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(r)
+	shifts, err := ShiftUC.GetShifts(businessID, branchID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if shifts == nil {
+		shifts = []*domain.Shift{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"shifts": shifts})
+}
+
+// GetShiftHandler returns a shift with its cash movements and cash summary.
+// Users without shift.manage only see their own shifts.
+// Route: GET /api/shifts/{id}
+func GetShiftHandler(w http.ResponseWriter, r *http.Request) {
+	identity := middleware.GetIdentityFromContext(r.Context())
+	shift, err := ShiftUC.GetShift(chi.URLParam(r, "id"), identity.BusinessID, identity.UserID, !middleware.HasPermission(r.Context(), domain.PermShiftManage))
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), shift.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: shift belongs to another branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, shift)
+}
+
+// RecordCashMovementHandler records cash put into or taken out of an open shift's drawer,
+// e.g. petty cash or a safe drop
+// Route: POST /api/shifts/{id}/cash-movements
+func RecordCashMovementHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	identity := middleware.GetIdentityFromContext(r.Context())
+	if !canAccessShift(w, r, identity.BusinessID) {
+		return
+	}
+	m, err := ShiftUC.RecordCashMovement(chi.URLParam(r, "id"), identity.BusinessID, identity.UserID, !middleware.HasPermission(r.Context(), domain.PermShiftManage), &req)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// CloseShiftHandler closes a shift with the counted cash and reports the variance
+// Route: POST /api/shifts/{id}/close
+func CloseShiftHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	identity := middleware.GetIdentityFromContext(r.Context())
+	if !canAccessShift(w, r, identity.BusinessID) {
+		return
+	}
+	shift, err := ShiftUC.CloseShift(chi.URLParam(r, "id"), identity.BusinessID, identity.UserID, !middleware.HasPermission(r.Context(), domain.PermShiftManage), &req)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, shift)
+}
+
+// canAccessShift rejects changes to a shift at a branch outside the user's scope
+func canAccessShift(w http.ResponseWriter, r *http.Request, businessID string) bool {
+	shift, err := ShiftUC.FindShift(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return false
+	}
+	if !middleware.CanAccessBranch(r.Context(), shift.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: shift belongs to another branch")
+		return false
+	}
+	return true
+}
+
+func writeShiftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrShiftNotFound), errors.Is(err, domain.ErrNoOpenShift):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrShiftAlreadyOpen), errors.Is(err, domain.ErrShiftClosed):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case err.Error() == "you can only manage your own shifts":
+		middleware.WriteForbidden(w, "forbidden: "+err.Error())
+	case err.Error() == "unauthorized":
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+	default:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	}
+}
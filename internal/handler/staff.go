@@ -28,6 +28,16 @@ func CreateStaffHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only add staff to your own branch")
+		return
+	}
+	actorRole, _ := middleware.GetRoleFromContext(r.Context())
+	if !RBACUC.CanAssignRole(actorRole, domain.StaffRole(req.Role)) {
+		middleware.WriteForbidden(w, "forbidden: you cannot assign the "+req.Role+" role")
+		return
+	}
+
 	// Check that branch exists and belongs to this business
 	branch, err := BranchUC.BranchRepo.GetBranchByID(req.BranchID)
 	if err != nil || branch == nil || branch.BusinessID != businessID {
@@ -108,7 +118,13 @@ func StaffLoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate tokens
-	token, refreshToken, err := AuthRepo.CreateToken(staff.ID, string(staff.Role))
+	token, refreshToken, err := AuthRepo.CreateToken(&domain.AuthIdentity{
+		UserID:     staff.ID,
+		BusinessID: staff.BusinessID,
+		BranchID:   staff.BranchID,
+		UserType:   domain.UserTypeStaff,
+		Role:       string(staff.Role),
+	})
 	if err != nil {
 		http.Error(w, "token generation failed", http.StatusInternalServerError)
 		return
@@ -117,6 +133,8 @@ func StaffLoginHandler(w http.ResponseWriter, r *http.Request) {
 	resp := dto.StaffLoginResponse{
 		StaffID:      staffID,
 		StaffName:    staff.FullName,
+		BusinessID:   staff.BusinessID,
+		BranchID:     staff.BranchID,
 		Role:         string(staff.Role),
 		Token:        token,
 		RefreshToken: refreshToken,
@@ -147,8 +165,8 @@ func GetStaffByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify staff belongs to the authenticated business
-	if staff.BusinessID != businessID {
-		http.Error(w, "unauthorized access", http.StatusForbidden)
+	if staff.BusinessID != businessID || !middleware.CanAccessBranch(r.Context(), staff.BranchID) {
+		middleware.WriteForbidden(w, "unauthorized access")
 		return
 	}
 
@@ -178,8 +196,15 @@ func GetStaffListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Optional: filter by branch_id
+	// Optional: filter by branch_id; branch-scoped users only see their own branch
 	branchID := r.URL.Query().Get("branch_id")
+	if scope := middleware.GetBranchScopeFromContext(r.Context()); scope != "" {
+		if branchID != "" && branchID != scope {
+			middleware.WriteForbidden(w, "forbidden: you can only view your own branch")
+			return
+		}
+		branchID = scope
+	}
 
 	var (
 		staff []*domain.Staff
@@ -242,6 +267,24 @@ func UpdateStaffHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	existing, err := StaffUC.StaffRepo.GetStaffByID(staffID)
+	if err != nil {
+		http.Error(w, "staff not found", http.StatusNotFound)
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), existing.BranchID) || !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only manage staff in your own branch")
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r.Context())
+	actorRole, _ := middleware.GetRoleFromContext(r.Context())
+	// Staff may edit their own record without changing their role; anyone else must rank below them
+	editingSelf := actorID == existing.ID && domain.StaffRole(req.Role) == existing.Role
+	if !editingSelf && (!RBACUC.CanAssignRole(actorRole, existing.Role) || !RBACUC.CanAssignRole(actorRole, domain.StaffRole(req.Role))) {
+		middleware.WriteForbidden(w, "forbidden: you cannot manage staff with this role")
+		return
+	}
+
 	staff := &domain.Staff{
 		ID:          staffID,
 		BusinessID:  businessID, // ✅ From JWT, not request
@@ -253,7 +296,7 @@ func UpdateStaffHandler(w http.ResponseWriter, r *http.Request) {
 		PhotoURL:    req.PhotoURL,
 	}
 
-	err := StaffUC.UpdateStaff(staff)
+	err = StaffUC.UpdateStaff(staff)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
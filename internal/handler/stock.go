@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var StockUC *usecase.StockUsecase
+
+// GetProductMovementsHandler returns the stock ledger of a product, newest first
+// Route: GET /api/product/{id}/movements?page=1&per_page=20
+func GetProductMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	product, ok := loadScopedProduct(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(r)
+	movements, err := StockUC.GetProductMovements(product.ID, limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id":        product.ID,
+		"quantity_in_stock": product.QuantityInStock,
+		"movements":         movements,
+	})
+}
+
+// AdjustStockHandler applies a manual stock correction or write-off
+// Route: POST /api/product/{id}/stock-adjustments
+func AdjustStockHandler(w http.ResponseWriter, r *http.Request) {
+	product, ok := loadScopedProduct(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.StockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	newStock, err := StockUC.AdjustStock(product.ID, product.BusinessID, userID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			status = http.StatusConflict
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id":        product.ID,
+		"quantity_in_stock": newStock,
+	})
+}
+
+// ReceiveLotHandler books a batch of stock with its own expiry and cost
+// Route: POST /api/product/{id}/lots
+func ReceiveLotHandler(w http.ResponseWriter, r *http.Request) {
+	product, ok := loadScopedProduct(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.ReceiveLotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	lot, err := StockUC.ReceiveLot(product, userID, &req)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, lot)
+}
+
+// GetProductLotsHandler lists a product's lots in the order they will be sold (earliest expiry first)
+// Route: GET /api/product/{id}/lots?include_empty=true
+func GetProductLotsHandler(w http.ResponseWriter, r *http.Request) {
+	product, ok := loadScopedProduct(w, r)
+	if !ok {
+		return
+	}
+	lots, err := StockUC.GetProductLots(product.ID, r.URL.Query().Get("include_empty") == "true")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if lots == nil {
+		lots = []*domain.ProductLot{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id":        product.ID,
+		"quantity_in_stock": product.QuantityInStock,
+		"lots":              lots,
+	})
+}
+
+// GetStockReconciliationHandler lists products whose stock ledger does not match the quantity in
+// stock or whose stock is below zero
+// Route: GET /api/stock/reconciliation
+func GetStockReconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return
+	}
+	discrepancies, err := StockUC.Reconcile(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	scoped := []*domain.StockDiscrepancy{}
+	for _, d := range discrepancies {
+		if middleware.CanAccessBranch(r.Context(), d.BranchID) {
+			scoped = append(scoped, d)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"balanced":      len(scoped) == 0,
+		"discrepancies": scoped,
+	})
+}
+
+// loadScopedProduct fetches the product named in the URL and checks it is within the caller's business and branch
+func loadScopedProduct(w http.ResponseWriter, r *http.Request) (*domain.Product, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	product, err := ProductUC.GetProductByID(chi.URLParam(r, "id"))
+	if err != nil || product.BusinessID != businessID {
+		writeJSONError(w, http.StatusNotFound, "product not found")
+		return nil, false
+	}
+	if !middleware.CanAccessBranch(r.Context(), product.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: product belongs to another branch")
+		return nil, false
+	}
+	return product, true
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var SupplierUC *usecase.SupplierUsecase
+
+// SupplierRequest is the body for creating or updating a supplier
+type SupplierRequest struct {
+	Name        string `json:"name"`
+	ContactName string `json:"contact_name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+}
+
+// CreateSupplierHandler adds a supplier to the business
+// Route: POST /api/suppliers
+func CreateSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	var req SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	s := &domain.Supplier{
+		BusinessID:  businessID,
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Phone:       req.Phone,
+		Email:       req.Email,
+		Address:     req.Address,
+	}
+	if err := SupplierUC.CreateSupplier(s); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// GetSuppliersHandler lists the business's suppliers
+// Route: GET /api/suppliers
+func GetSuppliersHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	suppliers, err := SupplierUC.GetSuppliers(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if suppliers == nil {
+		suppliers = []*domain.Supplier{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suppliers": suppliers})
+}
+
+// GetSupplierHandler returns a single supplier
+// Route: GET /api/suppliers/{id}
+func GetSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	s, err := SupplierUC.GetSupplier(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// UpdateSupplierHandler replaces a supplier's details
+// Route: PUT /api/suppliers/{id}
+func UpdateSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	var req SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	s := &domain.Supplier{
+		ID:          chi.URLParam(r, "id"),
+		BusinessID:  businessID,
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Phone:       req.Phone,
+		Email:       req.Email,
+		Address:     req.Address,
+	}
+	if err := SupplierUC.UpdateSupplier(s); err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "supplier not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// DeleteSupplierHandler removes a supplier; its purchase orders are kept
+// Route: DELETE /api/suppliers/{id}
+func DeleteSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	if err := SupplierUC.DeleteSupplier(chi.URLParam(r, "id"), businessID); err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"message": "Supplier deleted successfully"})
+}
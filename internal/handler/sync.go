@@ -1,9 +1,56 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
 )
 
+var SyncUC *usecase.SyncUsecase
+
+// SyncDataHandler pushes a till's offline queue and pulls server changes since its last checkpoint
+// Route: POST /api/sync
 func SyncDataHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Parse request, call usecase, return response
+	var req usecase.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized: business ID not found")
+		return
+	}
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized: user ID not found")
+		return
+	}
+
+	scopeBranchID := middleware.GetBranchScopeFromContext(r.Context())
+	role, _ := middleware.GetRoleFromContext(r.Context())
+
+	// Queued product edits and stock adjustments need the same permission as editing a product online
+	canUpdateProducts := middleware.HasPermission(r.Context(), domain.PermProductUpdate)
+
+	resp, err := SyncUC.SyncData(&req, businessID, userID, domain.StaffRole(role), scopeBranchID, canUpdateProducts)
+	if err != nil {
+		utils.Logger.Error("Sync failed", zap.Error(err))
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "unauthorized":
+			status = http.StatusUnauthorized
+		case "invalid checkpoint", "branch_id does not match your branch":
+			status = http.StatusBadRequest
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var TaxUC *usecase.TaxUsecase
+
+// CreateTaxRateHandler adds a tax rate to the business
+// Route: POST /api/tax-rates
+func CreateTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := taxAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	rate, err := TaxUC.CreateTaxRate(businessID, &req)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, rate)
+}
+
+// GetTaxRatesHandler lists the business's tax rates
+// Route: GET /api/tax-rates
+func GetTaxRatesHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	rates, err := TaxUC.GetTaxRates(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rates == nil {
+		rates = []*domain.TaxRate{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tax_rates": rates})
+}
+
+// UpdateTaxRateHandler renames or re-rates a tax rate, or makes it the default
+// Route: PUT /api/tax-rates/{id}
+func UpdateTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := taxAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	rate, err := TaxUC.UpdateTaxRate(chi.URLParam(r, "id"), businessID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "tax rate not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rate)
+}
+
+// DeleteTaxRateHandler removes a tax rate; rules naming it fall back to the default rate
+// Route: DELETE /api/tax-rates/{id}
+func DeleteTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := taxAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	if err := TaxUC.DeleteTaxRate(chi.URLParam(r, "id"), businessID); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "tax rate not found" {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "tax rate deleted"})
+}
+
+// SaveTaxRuleHandler sets a product's or a category's tax treatment, replacing any earlier rule
+// Route: PUT /api/tax-rules
+func SaveTaxRuleHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := taxAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	var req usecase.TaxRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	rule, err := TaxUC.SaveTaxRule(businessID, &req)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// GetTaxRulesHandler lists the business's product and category tax rules
+// Route: GET /api/tax-rules
+func GetTaxRulesHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	rules, err := TaxUC.GetTaxRules(businessID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rules == nil {
+		rules = []*domain.TaxRule{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tax_rules": rules})
+}
+
+// DeleteTaxRuleHandler removes a tax rule so the product or category is taxed at the default rate
+// Route: DELETE /api/tax-rules/{id}
+func DeleteTaxRuleHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, ok := taxAdminBusinessID(w, r)
+	if !ok {
+		return
+	}
+	if err := TaxUC.DeleteTaxRule(chi.URLParam(r, "id"), businessID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrTaxRuleNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "tax rule deleted"})
+}
+
+// GetTaxReportHandler summarises tax collected by treatment and rate, for filing.
+// Branch-scoped staff only see their own branch.
+// Route: GET /api/reports/tax?from=&to=&branch_id=
+func GetTaxReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(w, r, businessID)
+	if !ok {
+		return
+	}
+	summary, err := TaxUC.Summary(businessID, branchID, from, to)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// taxAdminBusinessID resolves the business for changing tax configuration.
+// Tax rates and rules apply to every branch, so branch-scoped staff may not change them.
+func taxAdminBusinessID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return "", false
+	}
+	if middleware.GetBranchScopeFromContext(r.Context()) != "" {
+		middleware.WriteForbidden(w, "forbidden: tax settings can only be managed business-wide")
+		return "", false
+	}
+	return businessID, true
+}
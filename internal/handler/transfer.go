@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var TransferUC *usecase.TransferUsecase
+
+// CreateTransferHandler drafts a transfer out of the caller's branch
+// Route: POST /api/transfers
+func CreateTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	if !middleware.CanAccessBranch(r.Context(), req.FromBranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only transfer stock out of your own branch")
+		return
+	}
+	t, err := TransferUC.CreateTransfer(&req, businessID, userID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// GetTransfersHandler lists transfers into or out of the caller's branch scope
+// Route: GET /api/transfers?status=&branch_id=
+func GetTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	transfers, err := TransferUC.ListTransfers(businessID, branchID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transfers": transfers})
+}
+
+// GetTransferDiscrepanciesHandler lists received transfers where less arrived than was dispatched
+// Route: GET /api/transfers/discrepancies?branch_id=
+func GetTransferDiscrepanciesHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	transfers, err := TransferUC.ListDiscrepancies(businessID, branchID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transfers": transfers})
+}
+
+// GetTransferHandler returns a single transfer
+// Route: GET /api/transfers/{id}
+func GetTransferHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := loadTransfer(w, r)
+	if !ok {
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), t.FromBranchID) && !middleware.CanAccessBranch(r.Context(), t.ToBranchID) {
+		middleware.WriteForbidden(w, "forbidden: transfer does not involve your branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// DispatchTransferHandler sends a draft transfer, taking the stock out of the source branch
+// Route: POST /api/transfers/{id}/dispatch
+func DispatchTransferHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := loadTransfer(w, r)
+	if !ok {
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), t.FromBranchID) {
+		middleware.WriteForbidden(w, "forbidden: only the source branch can dispatch a transfer")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	dispatched, err := TransferUC.DispatchTransfer(t.ID, userID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dispatched)
+}
+
+// ReceiveTransferHandler books goods that arrived into the destination branch
+// Route: POST /api/transfers/{id}/receive
+func ReceiveTransferHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := loadTransfer(w, r)
+	if !ok {
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), t.ToBranchID) {
+		middleware.WriteForbidden(w, "forbidden: only the destination branch can receive a transfer")
+		return
+	}
+	var req usecase.ReceiveTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	received, err := TransferUC.ReceiveTransfer(t.ID, userID, &req)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, received)
+}
+
+// CancelTransferHandler drops a transfer that has not been dispatched
+// Route: POST /api/transfers/{id}/cancel
+func CancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := loadTransfer(w, r)
+	if !ok {
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), t.FromBranchID) {
+		middleware.WriteForbidden(w, "forbidden: only the source branch can cancel a transfer")
+		return
+	}
+	cancelled, err := TransferUC.CancelTransfer(t.ID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cancelled)
+}
+
+func loadTransfer(w http.ResponseWriter, r *http.Request) (*domain.Transfer, bool) {
+	businessID, ok := middleware.GetBusinessIDFromContext(r.Context())
+	if !ok || businessID == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid business_id in token")
+		return nil, false
+	}
+	t, err := TransferUC.GetTransfer(chi.URLParam(r, "id"))
+	if err != nil || t.BusinessID != businessID {
+		writeJSONError(w, http.StatusNotFound, "transfer not found")
+		return nil, false
+	}
+	return t, true
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrProductRecalled) {
+		status = http.StatusConflict
+	}
+	writeJSONError(w, status, err.Error())
+}
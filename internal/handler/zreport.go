@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/middleware"
+	"github.com/joshuaolumoye/pos-backend/internal/usecase"
+)
+
+var ZReportUC *usecase.ZReportUsecase
+
+// CloseZReportRequest names the branch whose trading period is being closed
+type CloseZReportRequest struct {
+	BranchID string `json:"branch_id"`
+}
+
+// PreviewZReportHandler shows the branch's running totals since its last Z-report, without closing
+// Route: GET /api/z-reports/preview?branch_id=
+func PreviewZReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	if branchID == "" {
+		writeJSONError(w, http.StatusBadRequest, "branch_id is required")
+		return
+	}
+	report, err := ZReportUC.Preview(businessID, branchID)
+	if err != nil {
+		writeZReportError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// CloseZReportHandler closes the branch's day with a numbered Z-report
+// Route: POST /api/z-reports
+func CloseZReportHandler(w http.ResponseWriter, r *http.Request) {
+	var req CloseZReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), req.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: you can only close your own branch")
+		return
+	}
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	report, err := ZReportUC.Close(businessID, req.BranchID, userID)
+	if err != nil {
+		writeZReportError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// GetZReportsHandler lists closed Z-reports, newest first, without their breakdowns
+// Route: GET /api/z-reports?branch_id=&page=1&per_page=20
+func GetZReportsHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, branchID, ok := scopedBranchFilter(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(r)
+	reports, err := ZReportUC.GetZReports(businessID, branchID, limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if reports == nil {
+		reports = []*domain.ZReport{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"z_reports": reports})
+}
+
+// GetZReportHandler returns a closed Z-report with its breakdowns
+// Route: GET /api/z-reports/{id}
+func GetZReportHandler(w http.ResponseWriter, r *http.Request) {
+	businessID, _ := middleware.GetBusinessIDFromContext(r.Context())
+	report, err := ZReportUC.GetZReport(chi.URLParam(r, "id"), businessID)
+	if err != nil {
+		writeZReportError(w, err)
+		return
+	}
+	if !middleware.CanAccessBranch(r.Context(), report.BranchID) {
+		middleware.WriteForbidden(w, "forbidden: z-report belongs to another branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func writeZReportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrZReportNotFound), err.Error() == "branch not found":
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrZReportStale), err.Error() == "nothing to report since the last z-report":
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case err.Error() == "unauthorized":
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -68,7 +68,42 @@ func AutoMigrate(db *gorm.DB) error {
 		&Product{},
 		&Sale{},
 		&SaleItem{},
+		&SalePayment{},
 		&Notification{},
+		&SyncOperation{},
+		&RolePermission{},
+		&BusinessSetting{},
+		&Refund{},
+		&RefundItem{},
+		&RefundPayment{},
+		&StockMovement{},
+		&Transfer{},
+		&TransferItem{},
+		&TransferItemLot{},
+		&Supplier{},
+		&PurchaseOrder{},
+		&PurchaseOrderLine{},
+		&GoodsReceivedNote{},
+		&GoodsReceivedNoteItem{},
+		&ProductLot{},
+		&SaleItemLot{},
+		&Recall{},
+		&TaxRate{},
+		&TaxRule{},
+		&Promotion{},
+		&Customer{},
+		&LoyaltyEntry{},
+		&CreditAccount{},
+		&CreditEntry{},
+		&Shift{},
+		&CashMovement{},
+		&ZReport{},
+		&ZReportLine{},
+		&HeldCart{},
+		&HeldCartItem{},
+		&Reservation{},
+		&ReservationItem{},
+		&IdempotencyKey{},
 	)
 
 	if err != nil {
@@ -76,6 +111,15 @@ func AutoMigrate(db *gorm.DB) error {
 		return err
 	}
 
+	// Barcodes used to be unique across all businesses; they are now unique per branch
+	// so the same product can be stocked (and transferred) in several branches
+	if db.Migrator().HasIndex(&Product{}, "idx_products_barcode_value") {
+		if err := db.Migrator().DropIndex(&Product{}, "idx_products_barcode_value"); err != nil {
+			log.Printf("Migration failed: %v", err)
+			return err
+		}
+	}
+
 	log.Println("Auto-migration completed successfully!")
 	return nil
 }
@@ -67,15 +67,48 @@ type Staff struct {
 type Sale struct {
 	ID            string  `gorm:"primaryKey;type:char(36)" json:"id"`
 	BusinessID    string  `gorm:"index;not null;type:char(36)" json:"business_id"`
-	BranchID      string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	BranchID      string  `gorm:"index;uniqueIndex:idx_sales_branch_receipt;not null;type:char(36)" json:"branch_id"`
+	ReceiptNumber *int    `gorm:"uniqueIndex:idx_sales_branch_receipt" json:"receipt_number,omitempty"`
 	CashierID     string  `gorm:"index;not null;type:char(36)" json:"cashier_id"`
+	CustomerID    *string `gorm:"index;type:char(36)" json:"customer_id,omitempty"`
+	ShiftID       *string `gorm:"index;type:char(36)" json:"shift_id,omitempty"`
+	ReservationID *string `gorm:"index;type:char(36)" json:"reservation_id,omitempty"`
+	ZReportID     *string `gorm:"index;type:char(36)" json:"z_report_id,omitempty"`
 	TotalAmount   float64 `gorm:"not null" json:"total_amount"`
 	PaymentMethod string  `gorm:"type:varchar(32);not null" json:"payment_method"`
 	Status        string  `gorm:"type:varchar(32);not null" json:"status"`
 	CreatedAt     int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     int64   `gorm:"autoUpdateTime;index" json:"updated_at"`
+	VoidedBy      *string `gorm:"type:char(36)" json:"voided_by,omitempty"`
+	VoidReason    string  `gorm:"type:varchar(255)" json:"void_reason,omitempty"`
+	VoidedAt      *int64  `json:"voided_at,omitempty"`
+	ChangeDue     float64 `gorm:"not null;default:0" json:"change_due"`
+	// Discounts: GrossAmount is the list value before any promotion or discount
+	GrossAmount     float64  `gorm:"not null;default:0" json:"gross_amount"`
+	PromotionAmount float64  `gorm:"not null;default:0" json:"promotion_amount"`
+	DiscountAmount  float64  `gorm:"not null;default:0" json:"discount_amount"`
+	DiscountType    string   `gorm:"type:varchar(16)" json:"discount_type,omitempty"`
+	DiscountValue   *float64 `json:"discount_value,omitempty"`
+	DiscountReason  string   `gorm:"type:varchar(255)" json:"discount_reason,omitempty"`
+	TaxAmount       float64  `gorm:"not null;default:0" json:"tax_amount"`
+	TaxMode         string   `gorm:"type:varchar(16)" json:"tax_mode,omitempty"`
+
+	PointsEarned   int `gorm:"not null;default:0" json:"points_earned"`
+	PointsRedeemed int `gorm:"not null;default:0" json:"points_redeemed"`
 
 	// Relationships
-	SaleItems []SaleItem `gorm:"foreignKey:SaleID" json:"items,omitempty"`
+	SaleItems []SaleItem    `gorm:"foreignKey:SaleID" json:"items,omitempty"`
+	Payments  []SalePayment `gorm:"foreignKey:SaleID" json:"payments,omitempty"`
+}
+
+type SalePayment struct {
+	ID        string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	SaleID    string  `gorm:"index;not null;type:char(36)" json:"sale_id"`
+	Method    string  `gorm:"index;type:varchar(32);not null" json:"method"`
+	Amount    float64 `gorm:"not null" json:"amount"`
+	Tendered  float64 `gorm:"not null" json:"tendered"`
+	Reference string  `gorm:"type:varchar(128)" json:"reference"`
+	CreatedAt int64   `gorm:"autoCreateTime" json:"created_at"`
 }
 
 type SaleItem struct {
@@ -85,11 +118,29 @@ type SaleItem struct {
 	Quantity  int     `gorm:"not null" json:"quantity"`
 	UnitPrice float64 `gorm:"not null" json:"unit_price"`
 	Subtotal  float64 `gorm:"not null" json:"subtotal"`
-	CreatedAt int64   `gorm:"autoCreateTime" json:"created_at"`
+	// Price before discounts and the line's share of line and basket discounts
+	ListPrice       float64  `gorm:"not null;default:0" json:"list_price"`
+	PromotionID     *string  `gorm:"index;type:char(36)" json:"promotion_id,omitempty"`
+	PromotionAmount float64  `gorm:"not null;default:0" json:"promotion_amount"`
+	DiscountAmount  float64  `gorm:"not null;default:0" json:"discount_amount"`
+	DiscountType    string   `gorm:"type:varchar(16)" json:"discount_type,omitempty"`
+	DiscountValue   *float64 `json:"discount_value,omitempty"`
+	// Tax included in the subtotal
+	TaxTreatment string  `gorm:"type:varchar(16)" json:"tax_treatment,omitempty"`
+	TaxRate      float64 `gorm:"not null;default:0" json:"tax_rate"`
+	TaxAmount    float64 `gorm:"not null;default:0" json:"tax_amount"`
+	// Average cost of the units taken from stock; 0 on sales made before costs were recorded
+	UnitCost float64 `gorm:"not null;default:0" json:"unit_cost"`
+	// Units already returned through completed refunds
+	RefundedQuantity int   `gorm:"not null;default:0" json:"refunded_quantity"`
+	CreatedAt        int64 `gorm:"autoCreateTime" json:"created_at"`
+	// Units an offline sale sold beyond the stock on hand
+	OversoldQuantity int `gorm:"not null;default:0" json:"oversold_quantity"`
 
 	// Relationships
-	Sale    Sale    `gorm:"foreignKey:SaleID" json:"-"`
-	Product Product `gorm:"foreignKey:ProductID" json:"-"`
+	Lots    []SaleItemLot `gorm:"foreignKey:SaleItemID" json:"lots,omitempty"`
+	Sale    Sale          `gorm:"foreignKey:SaleID" json:"-"`
+	Product Product       `gorm:"foreignKey:ProductID" json:"-"`
 }
 
 type Product struct {
@@ -97,8 +148,8 @@ type Product struct {
 	ProductName       string  `gorm:"not null" json:"product_name"`
 	ProductCategory   string  `gorm:"not null" json:"product_category"`
 	BusinessID        string  `gorm:"index;not null;type:char(36)" json:"business_id"`
-	BranchID          string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
-	BarcodeValue      *string `gorm:"uniqueIndex;size:191" json:"barcode_value,omitempty"`
+	BranchID          string  `gorm:"index;uniqueIndex:idx_products_branch_barcode;not null;type:char(36)" json:"branch_id"`
+	BarcodeValue      *string `gorm:"uniqueIndex:idx_products_branch_barcode;size:191" json:"barcode_value,omitempty"`
 	NAFDACRegNumber   *string `json:"nafdac_reg_number,omitempty"`
 	SellingPrice      float64 `gorm:"not null" json:"selling_price"`
 	CostPrice         float64 `gorm:"not null" json:"cost_price"`
@@ -126,3 +177,504 @@ type Notification struct {
 	IsRead           bool   `gorm:"default:false" json:"is_read"`
 	CreatedAt        int64  `gorm:"autoCreateTime" json:"created_at"`
 }
+
+type SyncOperation struct {
+	ID         string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	BusinessID string `gorm:"primaryKey;type:char(36)" json:"business_id"`
+	DeviceID   string `gorm:"type:varchar(64)" json:"device_id"`
+	OpType     string `gorm:"primaryKey;type:varchar(32)" json:"op_type"`
+	Status     string `gorm:"type:varchar(16);not null" json:"status"`
+	Message    string `gorm:"type:text" json:"message"`
+	CreatedAt  int64  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RolePermission struct {
+	BusinessID  string    `gorm:"primaryKey;type:char(36)" json:"business_id"`
+	Role        StaffRole `gorm:"primaryKey;type:varchar(32)" json:"role"`
+	Permissions string    `gorm:"type:text;not null" json:"permissions"` // comma-separated
+	UpdatedBy   string    `gorm:"type:char(36)" json:"updated_by"`
+	UpdatedAt   int64     `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type BusinessSetting struct {
+	BusinessID          string  `gorm:"primaryKey;type:char(36)" json:"business_id"`
+	RefundApprovalLimit float64 `gorm:"not null;default:0" json:"refund_approval_limit"`
+	PaymentMethods      string  `gorm:"type:text" json:"payment_methods"`      // comma-separated; empty means the defaults
+	MaxDiscountPercent  string  `gorm:"type:text" json:"max_discount_percent"` // comma-separated role:percent pairs
+	TaxMode             string  `gorm:"type:varchar(16);not null;default:'inclusive'" json:"tax_mode"`
+	// Loyalty programme; unset values fall back to the defaults
+	LoyaltyEnabled            bool     `gorm:"default:false" json:"loyalty_enabled"`
+	LoyaltyEarnRate           *float64 `json:"loyalty_earn_rate"`
+	LoyaltyPointValue         float64  `gorm:"not null;default:0" json:"loyalty_point_value"`
+	LoyaltyExcludedCategories string   `gorm:"type:text" json:"loyalty_excluded_categories"` // comma-separated
+	LoyaltyExpiryDays         *int     `json:"loyalty_expiry_days"`
+	RequireOpenShift          bool     `gorm:"default:false" json:"require_open_shift"`
+	Timezone                  string   `gorm:"type:varchar(64)" json:"timezone"` // empty means the default
+	UpdatedBy                 string   `gorm:"type:char(36)" json:"updated_by"`
+	UpdatedAt                 int64    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type Refund struct {
+	ID            string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID    string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID      string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	SaleID        string  `gorm:"index;not null;type:char(36)" json:"sale_id"`
+	RequestedBy   string  `gorm:"not null;type:char(36)" json:"requested_by"`
+	ApprovedBy    *string `gorm:"type:char(36)" json:"approved_by,omitempty"`
+	ReasonCode    string  `gorm:"type:varchar(32);not null" json:"reason_code"`
+	Note          string  `gorm:"type:text" json:"note"`
+	PaymentMethod string  `gorm:"type:varchar(32);not null" json:"payment_method"`
+	TotalAmount   float64 `gorm:"not null" json:"total_amount"`
+	Status        string  `gorm:"type:varchar(32);index;not null" json:"status"`
+	CreatedAt     int64   `gorm:"autoCreateTime" json:"created_at"`
+	ResolvedAt    *int64  `json:"resolved_at,omitempty"`
+	ShiftID       *string `gorm:"index;type:char(36)" json:"shift_id,omitempty"`
+	ZReportID     *string `gorm:"index;type:char(36)" json:"z_report_id,omitempty"`
+
+	// Relationships
+	RefundItems    []RefundItem    `gorm:"foreignKey:RefundID" json:"items,omitempty"`
+	RefundPayments []RefundPayment `gorm:"foreignKey:RefundID" json:"payments,omitempty"`
+}
+
+type RefundItem struct {
+	ID          string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	RefundID    string  `gorm:"index;not null;type:char(36)" json:"refund_id"`
+	SaleItemID  string  `gorm:"index;not null;type:char(36)" json:"sale_item_id"`
+	ProductID   string  `gorm:"index;not null;type:char(36)" json:"product_id"`
+	Quantity    int     `gorm:"not null" json:"quantity"`
+	UnitPrice   float64 `gorm:"not null" json:"unit_price"`
+	Amount      float64 `gorm:"not null" json:"amount"`
+	Disposition string  `gorm:"type:varchar(16);not null" json:"disposition"`
+}
+
+type RefundPayment struct {
+	ID       string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	RefundID string  `gorm:"index;not null;type:char(36)" json:"refund_id"`
+	Method   string  `gorm:"index;type:varchar(32);not null" json:"method"`
+	Amount   float64 `gorm:"not null" json:"amount"`
+}
+
+// StockMovement rows are only ever inserted, never updated or deleted
+type StockMovement struct {
+	ID             string `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID     string `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID       string `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	ProductID      string `gorm:"index:idx_stock_movements_product_created;not null;type:char(36)" json:"product_id"`
+	Type           string `gorm:"type:varchar(32);not null" json:"type"`
+	Quantity       int    `gorm:"not null" json:"quantity"`
+	QuantityBefore int    `gorm:"not null" json:"quantity_before"`
+	QuantityAfter  int    `gorm:"not null" json:"quantity_after"`
+	ActorID        string `gorm:"type:char(36);not null" json:"actor_id"`
+	ReferenceType  string `gorm:"type:varchar(32)" json:"reference_type"`
+	ReferenceID    string `gorm:"type:varchar(64);index" json:"reference_id"`
+	Note           string `gorm:"type:text" json:"note"`
+	CreatedAt      int64  `gorm:"index:idx_stock_movements_product_created;not null" json:"created_at"`
+}
+
+type Transfer struct {
+	ID           string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID   string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	FromBranchID string  `gorm:"index;not null;type:char(36)" json:"from_branch_id"`
+	ToBranchID   string  `gorm:"index;not null;type:char(36)" json:"to_branch_id"`
+	Status       string  `gorm:"type:varchar(32);index;not null" json:"status"`
+	Note         string  `gorm:"type:text" json:"note"`
+	CreatedBy    string  `gorm:"type:char(36);not null" json:"created_by"`
+	DispatchedBy *string `gorm:"type:char(36)" json:"dispatched_by,omitempty"`
+	DispatchedAt *int64  `json:"dispatched_at,omitempty"`
+	ReceivedBy   *string `gorm:"type:char(36)" json:"received_by,omitempty"`
+	ReceivedAt   *int64  `json:"received_at,omitempty"`
+	CreatedAt    int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    int64   `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	TransferItems []TransferItem `gorm:"foreignKey:TransferID" json:"items,omitempty"`
+}
+
+type TransferItem struct {
+	ID               string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	TransferID       string  `gorm:"index;not null;type:char(36)" json:"transfer_id"`
+	SourceProductID  string  `gorm:"not null;type:char(36)" json:"source_product_id"`
+	DestProductID    string  `gorm:"not null;type:char(36)" json:"dest_product_id"`
+	BarcodeValue     *string `gorm:"size:191" json:"barcode_value,omitempty"`
+	ProductName      string  `gorm:"not null" json:"product_name"`
+	Quantity         int     `gorm:"not null" json:"quantity"`
+	QuantityReceived int     `gorm:"not null;default:0" json:"quantity_received"`
+
+	Lots []TransferItemLot `gorm:"foreignKey:TransferItemID" json:"lots,omitempty"`
+}
+
+// TransferItemLot is a batch of a transfer item taken out of a lot at the source branch,
+// so it arrives at the destination with its batch number, expiry and cost
+type TransferItemLot struct {
+	ID               string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	TransferID       string  `gorm:"index;not null;type:char(36)" json:"transfer_id"`
+	TransferItemID   string  `gorm:"index;not null;type:char(36)" json:"transfer_item_id"`
+	LotID            string  `gorm:"not null;type:char(36)" json:"lot_id"`
+	BatchNumber      string  `gorm:"type:varchar(64);not null" json:"batch_number"`
+	ExpiryDate       *int64  `json:"expiry_date,omitempty"`
+	CostPrice        float64 `gorm:"not null" json:"cost_price"`
+	SupplierID       *string `gorm:"type:char(36)" json:"supplier_id,omitempty"`
+	Quantity         int     `gorm:"not null" json:"quantity"`
+	QuantityReceived int     `gorm:"not null;default:0" json:"quantity_received"`
+	WrittenOff       int     `gorm:"not null;default:0" json:"written_off"`
+}
+
+type Supplier struct {
+	ID          string `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID  string `gorm:"index;not null;type:char(36)" json:"business_id"`
+	Name        string `gorm:"not null" json:"name"`
+	ContactName string `json:"contact_name"`
+	Phone       string `gorm:"type:varchar(32)" json:"phone"`
+	Email       string `json:"email"`
+	Address     string `gorm:"type:text" json:"address"`
+	CreatedAt   int64  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   *int64 `json:"deleted_at,omitempty"`
+}
+
+type PurchaseOrder struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID   string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	SupplierID string  `gorm:"index;not null;type:char(36)" json:"supplier_id"`
+	Status     string  `gorm:"type:varchar(32);index;not null" json:"status"`
+	Note       string  `gorm:"type:text" json:"note"`
+	ExpectedAt *int64  `json:"expected_at,omitempty"`
+	TotalCost  float64 `gorm:"not null" json:"total_cost"`
+	CreatedBy  string  `gorm:"type:char(36);not null" json:"created_by"`
+	SentAt     *int64  `json:"sent_at,omitempty"`
+	CreatedAt  int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  int64   `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Lines []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID" json:"lines,omitempty"`
+}
+
+type PurchaseOrderLine struct {
+	ID               string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	PurchaseOrderID  string  `gorm:"index;not null;type:char(36)" json:"purchase_order_id"`
+	ProductID        string  `gorm:"index;not null;type:char(36)" json:"product_id"`
+	ProductName      string  `gorm:"not null" json:"product_name"`
+	QuantityOrdered  int     `gorm:"not null" json:"quantity_ordered"`
+	QuantityReceived int     `gorm:"not null;default:0" json:"quantity_received"`
+	UnitCost         float64 `gorm:"not null" json:"unit_cost"`
+}
+
+type GoodsReceivedNote struct {
+	ID              string `gorm:"primaryKey;type:char(36)" json:"id"`
+	PurchaseOrderID string `gorm:"index;not null;type:char(36)" json:"purchase_order_id"`
+	BusinessID      string `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID        string `gorm:"not null;type:char(36)" json:"branch_id"`
+	ReceivedBy      string `gorm:"type:char(36);not null" json:"received_by"`
+	Note            string `gorm:"type:text" json:"note"`
+	CreatedAt       int64  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Items []GoodsReceivedNoteItem `gorm:"foreignKey:GRNID" json:"items,omitempty"`
+}
+
+type GoodsReceivedNoteItem struct {
+	ID        string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	GRNID     string  `gorm:"column:grn_id;index;not null;type:char(36)" json:"grn_id"`
+	LineID    string  `gorm:"not null;type:char(36)" json:"line_id"`
+	ProductID string  `gorm:"not null;type:char(36)" json:"product_id"`
+	Quantity  int     `gorm:"not null" json:"quantity"`
+	UnitCost  float64 `gorm:"not null" json:"unit_cost"`
+	// Lot the units were received into, if any
+	BatchNumber string `gorm:"type:varchar(64)" json:"batch_number"`
+	ExpiryDate  *int64 `json:"expiry_date,omitempty"`
+}
+
+type ProductLot struct {
+	ID               string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID       string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID         string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	ProductID        string  `gorm:"uniqueIndex:idx_product_lots_product_batch;not null;type:char(36)" json:"product_id"`
+	BatchNumber      string  `gorm:"uniqueIndex:idx_product_lots_product_batch;type:varchar(64);not null" json:"batch_number"`
+	ExpiryDate       *int64  `gorm:"index" json:"expiry_date,omitempty"`
+	Quantity         int     `gorm:"not null" json:"quantity"`
+	ReceivedQuantity int     `gorm:"not null" json:"received_quantity"`
+	CostPrice        float64 `gorm:"not null" json:"cost_price"`
+	SupplierID       *string `gorm:"type:char(36)" json:"supplier_id,omitempty"`
+	ReceivedAt       int64   `gorm:"not null" json:"received_at"`
+	CreatedAt        int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        int64   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type SaleItemLot struct {
+	ID               string `gorm:"primaryKey;type:char(36)" json:"id"`
+	SaleID           string `gorm:"index;not null;type:char(36)" json:"sale_id"`
+	SaleItemID       string `gorm:"index;not null;type:char(36)" json:"sale_item_id"`
+	LotID            string `gorm:"index;not null;type:char(36)" json:"lot_id"`
+	ProductID        string `gorm:"not null;type:char(36)" json:"product_id"`
+	BatchNumber      string `gorm:"type:varchar(64);not null" json:"batch_number"`
+	ExpiryDate       *int64 `json:"expiry_date,omitempty"`
+	Quantity         int    `gorm:"not null" json:"quantity"`
+	ReturnedQuantity int    `gorm:"not null;default:0" json:"returned_quantity"`
+}
+
+type Recall struct {
+	ID              string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID      string  `gorm:"index:idx_recalls_business_nafdac;not null;type:char(36)" json:"business_id"`
+	NAFDACRegNumber string  `gorm:"index:idx_recalls_business_nafdac;type:varchar(64);not null" json:"nafdac_reg_number"`
+	BarcodeValue    *string `gorm:"size:191" json:"barcode_value,omitempty"`
+	BatchNumber     *string `gorm:"type:varchar(64)" json:"batch_number,omitempty"`
+	ExpiryFrom      *int64  `json:"expiry_from,omitempty"`
+	ExpiryTo        *int64  `json:"expiry_to,omitempty"`
+	Reason          string  `gorm:"type:text;not null" json:"reason"`
+	Reference       string  `json:"reference"`
+	Status          string  `gorm:"type:varchar(16);not null;default:'active'" json:"status"`
+	CreatedBy       string  `gorm:"type:char(36);not null" json:"created_by"`
+	CreatedAt       int64   `gorm:"autoCreateTime" json:"created_at"`
+	ClosedBy        *string `gorm:"type:char(36)" json:"closed_by,omitempty"`
+	ClosedAt        *int64  `json:"closed_at,omitempty"`
+}
+
+type TaxRate struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	Name       string  `gorm:"not null" json:"name"`
+	Rate       float64 `gorm:"not null" json:"rate"`
+	IsDefault  bool    `gorm:"default:false" json:"is_default"`
+	CreatedAt  int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  int64   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type TaxRule struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	ProductID  *string `gorm:"uniqueIndex:idx_tax_rules_product;type:char(36)" json:"product_id,omitempty"`
+	Category   *string `gorm:"size:191" json:"category,omitempty"`
+	Treatment  string  `gorm:"type:varchar(16);not null" json:"treatment"`
+	TaxRateID  *string `gorm:"type:char(36)" json:"tax_rate_id,omitempty"`
+	CreatedAt  int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  int64   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type Promotion struct {
+	ID             string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID     string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID       *string `gorm:"type:char(36)" json:"branch_id,omitempty"`
+	Name           string  `gorm:"not null" json:"name"`
+	Type           string  `gorm:"type:varchar(32);not null" json:"type"`
+	ProductIDs     string  `gorm:"type:text" json:"product_ids"`
+	Category       *string `gorm:"size:191" json:"category,omitempty"`
+	BuyQuantity    int     `gorm:"not null;default:0" json:"buy_quantity"`
+	GetQuantity    int     `gorm:"not null;default:0" json:"get_quantity"`
+	BundleQuantity int     `gorm:"not null;default:0" json:"bundle_quantity"`
+	BundlePrice    float64 `gorm:"not null;default:0" json:"bundle_price"`
+	Price          float64 `gorm:"not null;default:0" json:"price"`
+	Percent        float64 `gorm:"not null;default:0" json:"percent"`
+	StartsAt       int64   `gorm:"not null" json:"starts_at"`
+	EndsAt         *int64  `json:"ends_at,omitempty"`
+	DailyStart     string  `gorm:"type:varchar(5)" json:"daily_start,omitempty"`
+	DailyEnd       string  `gorm:"type:varchar(5)" json:"daily_end,omitempty"`
+	Active         bool    `gorm:"not null" json:"active"`
+	CreatedBy      string  `gorm:"type:char(36);not null" json:"created_by"`
+	CreatedAt      int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      int64   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type Customer struct {
+	ID         string `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID string `gorm:"index:idx_customers_business_phone;not null;type:char(36)" json:"business_id"`
+	Name       string `gorm:"not null" json:"name"`
+	Phone      string `gorm:"index:idx_customers_business_phone;type:varchar(32)" json:"phone"`
+	Email      string `json:"email"`
+	Notes      string `gorm:"type:text" json:"notes"`
+	CreatedAt  int64  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt  *int64 `json:"deleted_at,omitempty"`
+}
+
+type LoyaltyEntry struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	CustomerID string  `gorm:"index;not null;type:char(36)" json:"customer_id"`
+	SaleID     *string `gorm:"index;type:char(36)" json:"sale_id,omitempty"`
+	Type       string  `gorm:"type:varchar(16);not null" json:"type"`
+	Points     int     `gorm:"not null" json:"points"`
+	// Points of an earn or reversal credit not yet spent or lapsed
+	Remaining int    `gorm:"not null;default:0" json:"remaining"`
+	ExpiresAt *int64 `json:"expires_at,omitempty"`
+	CreatedAt int64  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type CreditAccount struct {
+	ID          string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID  string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	CustomerID  string  `gorm:"uniqueIndex;not null;type:char(36)" json:"customer_id"`
+	CreditLimit float64 `gorm:"not null" json:"credit_limit"`
+	Balance     float64 `gorm:"not null;default:0" json:"balance"`
+	Active      bool    `gorm:"not null" json:"active"`
+	CreatedBy   string  `gorm:"type:char(36);not null" json:"created_by"`
+	CreatedAt   int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   int64   `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Customer Customer `gorm:"foreignKey:CustomerID" json:"-"`
+}
+
+type CreditEntry struct {
+	ID          string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	AccountID   string  `gorm:"index;not null;type:char(36)" json:"account_id"`
+	BusinessID  string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID    string  `gorm:"type:char(36)" json:"branch_id"`
+	Type        string  `gorm:"type:varchar(16);not null" json:"type"`
+	Amount      float64 `gorm:"not null" json:"amount"`
+	Outstanding float64 `gorm:"not null;default:0" json:"outstanding"`
+	SaleID      *string `gorm:"index;type:char(36)" json:"sale_id,omitempty"`
+	Method      string  `gorm:"type:varchar(32)" json:"method"`
+	Reference   string  `gorm:"type:varchar(128)" json:"reference"`
+	Note        string  `gorm:"type:text" json:"note"`
+	RecordedBy  string  `gorm:"type:char(36);not null" json:"recorded_by"`
+	CreatedAt   int64   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type Shift struct {
+	ID           string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID   string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID     string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	CashierID    string  `gorm:"index;not null;type:char(36)" json:"cashier_id"`
+	Status       string  `gorm:"type:varchar(16);not null" json:"status"`
+	OpeningFloat float64 `gorm:"not null" json:"opening_float"`
+	OpenedAt     int64   `gorm:"not null;index" json:"opened_at"`
+	ClosedAt     *int64  `json:"closed_at,omitempty"`
+	ClosedBy     *string `gorm:"type:char(36)" json:"closed_by,omitempty"`
+	ClosingNote  string  `gorm:"type:text" json:"closing_note"`
+	// Cash totals frozen when the shift closes
+	SalesCount     int      `gorm:"not null;default:0" json:"sales_count"`
+	SalesTotal     float64  `gorm:"not null;default:0" json:"sales_total"`
+	CashSales      float64  `gorm:"not null;default:0" json:"cash_sales"`
+	CashRefunds    float64  `gorm:"not null;default:0" json:"cash_refunds"`
+	CashRepayments float64  `gorm:"not null;default:0" json:"cash_repayments"`
+	CashIn         float64  `gorm:"not null;default:0" json:"cash_in"`
+	CashOut        float64  `gorm:"not null;default:0" json:"cash_out"`
+	ExpectedCash   *float64 `json:"expected_cash,omitempty"`
+	CountedCash    *float64 `json:"counted_cash,omitempty"`
+	Variance       *float64 `json:"variance,omitempty"`
+}
+
+type CashMovement struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	ShiftID    string  `gorm:"index;not null;type:char(36)" json:"shift_id"`
+	BusinessID string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID   string  `gorm:"not null;type:char(36)" json:"branch_id"`
+	Type       string  `gorm:"type:varchar(16);not null" json:"type"`
+	Amount     float64 `gorm:"not null" json:"amount"`
+	Reason     string  `gorm:"type:varchar(255)" json:"reason"`
+	RecordedBy string  `gorm:"type:char(36);not null" json:"recorded_by"`
+	CreatedAt  int64   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type ZReport struct {
+	ID           string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID   string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID     string  `gorm:"uniqueIndex:idx_z_reports_branch_number;not null;type:char(36)" json:"branch_id"`
+	Number       int     `gorm:"uniqueIndex:idx_z_reports_branch_number;not null" json:"number"`
+	BusinessDate string  `gorm:"type:varchar(10);not null" json:"business_date"`
+	Timezone     string  `gorm:"type:varchar(64);not null" json:"timezone"`
+	FromTime     int64   `gorm:"not null" json:"from"`
+	ToTime       int64   `gorm:"not null" json:"to"`
+	SalesCount   int     `gorm:"not null" json:"sales_count"`
+	VoidedCount  int     `gorm:"not null" json:"voided_count"`
+	ItemsSold    int     `gorm:"not null" json:"items_sold"`
+	GrossSales   float64 `gorm:"not null" json:"gross_sales"`
+	Promotions   float64 `gorm:"not null" json:"promotions"`
+	Discounts    float64 `gorm:"not null" json:"discounts"`
+	NetSales     float64 `gorm:"not null" json:"net_sales"`
+	TaxAmount    float64 `gorm:"not null" json:"tax_amount"`
+	CostOfGoods  float64 `gorm:"not null" json:"cost_of_goods"`
+	GrossProfit  float64 `gorm:"not null" json:"gross_profit"`
+	RefundCount  int     `gorm:"not null" json:"refund_count"`
+	Refunds      float64 `gorm:"not null" json:"refunds"`
+	ClosedBy     string  `gorm:"type:char(36);not null" json:"closed_by"`
+	ClosedAt     int64   `gorm:"not null" json:"closed_at"`
+
+	// Relationships
+	Lines []ZReportLine `gorm:"foreignKey:ReportID" json:"lines,omitempty"`
+}
+
+type ZReportLine struct {
+	ID         string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	ReportID   string  `gorm:"index;not null;type:char(36)" json:"report_id"`
+	Kind       string  `gorm:"type:varchar(16);not null" json:"kind"`
+	Position   int     `gorm:"not null" json:"position"`
+	Key        string  `gorm:"type:varchar(191);not null" json:"key"`
+	Label      string  `json:"label"`
+	SalesCount int     `gorm:"not null;default:0" json:"sales_count"`
+	Quantity   int     `gorm:"not null;default:0" json:"quantity"`
+	Amount     float64 `gorm:"not null;default:0" json:"amount"`
+	Cost       float64 `gorm:"not null;default:0" json:"cost"`
+}
+
+type HeldCart struct {
+	ID             string   `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID     string   `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID       string   `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	Label          string   `gorm:"type:varchar(100);not null" json:"label"`
+	HeldBy         string   `gorm:"type:char(36);not null" json:"held_by"`
+	CustomerID     *string  `gorm:"type:char(36)" json:"customer_id,omitempty"`
+	CustomerPhone  string   `gorm:"type:varchar(32)" json:"customer_phone,omitempty"`
+	DiscountType   string   `gorm:"type:varchar(16)" json:"discount_type,omitempty"`
+	DiscountValue  *float64 `json:"discount_value,omitempty"`
+	DiscountReason string   `gorm:"type:varchar(255)" json:"discount_reason,omitempty"`
+	CreatedAt      int64    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      int64    `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Items []HeldCartItem `gorm:"foreignKey:CartID" json:"items,omitempty"`
+}
+
+type HeldCartItem struct {
+	ID            string   `gorm:"primaryKey;type:char(36)" json:"id"`
+	CartID        string   `gorm:"index;not null;type:char(36)" json:"cart_id"`
+	Position      int      `gorm:"not null" json:"position"`
+	ProductID     string   `gorm:"type:char(36);not null" json:"product_id"`
+	ProductName   string   `gorm:"type:varchar(255)" json:"product_name"`
+	Quantity      int      `gorm:"not null" json:"quantity"`
+	UnitPrice     float64  `gorm:"not null" json:"unit_price"`
+	DiscountType  string   `gorm:"type:varchar(16)" json:"discount_type,omitempty"`
+	DiscountValue *float64 `json:"discount_value,omitempty"`
+}
+
+type Reservation struct {
+	ID            string  `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID    string  `gorm:"index;not null;type:char(36)" json:"business_id"`
+	BranchID      string  `gorm:"index;not null;type:char(36)" json:"branch_id"`
+	Reference     string  `gorm:"type:varchar(100);not null" json:"reference"`
+	CustomerID    *string `gorm:"type:char(36)" json:"customer_id,omitempty"`
+	CustomerPhone string  `gorm:"type:varchar(32)" json:"customer_phone,omitempty"`
+	Note          string  `gorm:"type:varchar(255)" json:"note,omitempty"`
+	Status        string  `gorm:"index:idx_reservations_status_expiry;type:varchar(32);not null" json:"status"`
+	ExpiresAt     int64   `gorm:"index:idx_reservations_status_expiry;not null" json:"expires_at"`
+	CreatedBy     string  `gorm:"type:char(36);not null" json:"created_by"`
+	SaleID        *string `gorm:"type:char(36)" json:"sale_id,omitempty"`
+	ReleasedBy    *string `gorm:"type:char(36)" json:"released_by,omitempty"`
+	ClosedAt      *int64  `json:"closed_at,omitempty"`
+	CreatedAt     int64   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     int64   `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Items []ReservationItem `gorm:"foreignKey:ReservationID" json:"items,omitempty"`
+}
+
+type ReservationItem struct {
+	ID            string `gorm:"primaryKey;type:char(36)" json:"id"`
+	ReservationID string `gorm:"index;not null;type:char(36)" json:"reservation_id"`
+	ProductID     string `gorm:"index;type:char(36);not null" json:"product_id"`
+	ProductName   string `gorm:"type:varchar(255)" json:"product_name"`
+	Quantity      int    `gorm:"not null" json:"quantity"`
+}
+
+type IdempotencyKey struct {
+	ID             string `gorm:"primaryKey;type:char(36)" json:"id"`
+	BusinessID     string `gorm:"uniqueIndex:idx_idempotency_business_key;not null;type:char(36)" json:"business_id"`
+	IdempotencyKey string `gorm:"uniqueIndex:idx_idempotency_business_key;type:varchar(255);not null" json:"idempotency_key"`
+	UserID         string `gorm:"type:char(36);not null" json:"user_id"`
+	Endpoint       string `gorm:"type:varchar(255);not null" json:"endpoint"`
+	RequestHash    string `gorm:"type:char(64);not null" json:"request_hash"`
+	StatusCode     int    `gorm:"not null;default:0" json:"status_code"`
+	ContentType    string `gorm:"type:varchar(100)" json:"content_type"`
+	Response       string `gorm:"type:text" json:"response"`
+	ClaimedAt      int64  `gorm:"not null;default:0" json:"claimed_at"`
+	CreatedAt      int64  `gorm:"index;not null" json:"created_at"`
+}
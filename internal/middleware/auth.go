@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/pkg/utils"
 	"go.uber.org/zap"
 )
@@ -14,6 +15,8 @@ type contextKey string
 const (
 	businessIDKey contextKey = "business_id"
 	userIDKey     contextKey = "user_id"
+	branchIDKey   contextKey = "branch_id"
+	userTypeKey   contextKey = "user_type"
 	roleKey       contextKey = "role"
 )
 
@@ -46,19 +49,30 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Tokens issued before business_id was added to the claims cannot be scoped safely
+		if claims.BusinessID == "" || claims.UserType == "" {
+			utils.Logger.Warn("Token missing business_id or user_type - rejecting request")
+			writeAuthError(w, "Token is outdated, please log in again", http.StatusUnauthorized)
+			return
+		}
+
 		utils.Logger.Info("Token validated",
 			zap.String("userID", claims.UserID),
+			zap.String("userType", claims.UserType),
 			zap.String("role", claims.Role))
 
-		// Set business_id, user_id, and role for all authenticated users
+		// Set identity values for all authenticated users
 		ctx := r.Context()
-		ctx = contextWithBusinessID(ctx, claims.UserID)
+		ctx = contextWithBusinessID(ctx, claims.BusinessID)
 		ctx = contextWithUserID(ctx, claims.UserID)
+		ctx = contextWithBranchID(ctx, claims.BranchID)
+		ctx = contextWithUserType(ctx, claims.UserType)
 		ctx = contextWithRole(ctx, claims.Role)
 
 		utils.Logger.Info("Context values set",
-			zap.String("businessID", claims.UserID),
+			zap.String("businessID", claims.BusinessID),
 			zap.String("userID", claims.UserID),
+			zap.String("branchID", claims.BranchID),
 			zap.String("role", claims.Role))
 
 		r = r.WithContext(ctx)
@@ -86,6 +100,14 @@ func contextWithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, userIDKey, userID)
 }
 
+func contextWithBranchID(ctx context.Context, branchID string) context.Context {
+	return context.WithValue(ctx, branchIDKey, branchID)
+}
+
+func contextWithUserType(ctx context.Context, userType string) context.Context {
+	return context.WithValue(ctx, userTypeKey, userType)
+}
+
 func contextWithRole(ctx context.Context, role string) context.Context {
 	return context.WithValue(ctx, roleKey, role)
 }
@@ -108,6 +130,35 @@ func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	return "", false
 }
 
+// GetBranchIDFromContext retrieves the staff member's home branch from context (empty for owners)
+func GetBranchIDFromContext(ctx context.Context) (string, bool) {
+	val := ctx.Value(branchIDKey)
+	if id, ok := val.(string); ok {
+		return id, true
+	}
+	return "", false
+}
+
+// GetUserTypeFromContext retrieves user_type ("owner" or "staff") from context
+func GetUserTypeFromContext(ctx context.Context) (string, bool) {
+	val := ctx.Value(userTypeKey)
+	if t, ok := val.(string); ok {
+		return t, true
+	}
+	return "", false
+}
+
+// GetIdentityFromContext assembles the authenticated identity from context
+func GetIdentityFromContext(ctx context.Context) *domain.AuthIdentity {
+	id := &domain.AuthIdentity{}
+	id.UserID, _ = GetUserIDFromContext(ctx)
+	id.BusinessID, _ = GetBusinessIDFromContext(ctx)
+	id.BranchID, _ = GetBranchIDFromContext(ctx)
+	id.UserType, _ = GetUserTypeFromContext(ctx)
+	id.Role, _ = GetRoleFromContext(ctx)
+	return id
+}
+
 // GetRoleFromContext retrieves role from context
 func GetRoleFromContext(ctx context.Context) (string, bool) {
 	val := ctx.Value(roleKey)
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+// IdempotencyKeyHeader names the client-chosen key that makes a retried request safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKeeper remembers requests sent with an idempotency key and their responses
+type IdempotencyKeeper interface {
+	Begin(businessID, userID, key, endpoint string, body []byte) (*domain.IdempotencyRecord, error)
+	Finish(businessID, key string, statusCode int, contentType string, response []byte)
+}
+
+// Idempotency is injected by main.go
+var Idempotency IdempotencyKeeper
+
+// Idempotent makes next safe to retry. A request carrying an Idempotency-Key header runs
+// once; a retry with the same key and body gets the original response back with an
+// Idempotent-Replayed header, and a retry with a different body is refused with 409.
+// Requests without the header are served as usual.
+//
+// The key is kept apart from whatever next writes, so a request that dies after writing
+// but before its response is saved runs again once its lease lapses. Handlers that must
+// never repeat, such as sale creation, also dedupe on a client-generated ID of their own.
+func Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		identity := GetIdentityFromContext(r.Context())
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeAuthError(w, "invalid input", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		replay, err := Idempotency.Begin(identity.BusinessID, identity.UserID, key, r.Method+" "+r.URL.Path, body)
+		switch {
+		case errors.Is(err, domain.ErrInvalidIdempotencyKey):
+			writeAuthError(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrIdempotencyKeyReused), errors.Is(err, domain.ErrIdempotencyKeyInFlight):
+			writeAuthError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			writeAuthError(w, err.Error(), http.StatusInternalServerError)
+			return
+		case replay != nil:
+			if replay.ContentType != "" {
+				w.Header().Set("Content-Type", replay.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(replay.StatusCode)
+			_, _ = io.WriteString(w, replay.Response)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		// Free the key even if the handler panics, so the client can retry
+		defer func() {
+			Idempotency.Finish(identity.BusinessID, key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+		}()
+		next(rec, r)
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body.
+// status stays 0 when the handler writes nothing.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -1,14 +1,85 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
 )
 
-func RBACMiddleware(role string) func(http.Handler) http.Handler {
+const branchScopeKey contextKey = "branch_scope"
+
+// Authorizer decides whether a user may use a permission and which branch they are confined to
+type Authorizer interface {
+	Authorize(identity *domain.AuthIdentity, perm domain.Permission) (branchScope string, err error)
+}
+
+// RBAC is injected by main.go
+var RBAC Authorizer
+
+// RBACMiddleware rejects the request with 403 unless the authenticated user holds perm, or
+// with 500 when their permissions cannot be looked up. An empty perm only requires
+// authentication. On success the user's branch scope is stored in the context for handlers
+// to enforce with CanAccessBranch.
+func RBACMiddleware(perm domain.Permission) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// TODO: Check user role from context
-			next.ServeHTTP(w, r)
+			identity := GetIdentityFromContext(r.Context())
+			if identity.UserID == "" || identity.BusinessID == "" || identity.Role == "" {
+				writeAuthError(w, "forbidden: missing user identity", http.StatusForbidden)
+				return
+			}
+
+			scope, err := RBAC.Authorize(identity, perm)
+			if err != nil && !errors.Is(err, domain.ErrForbidden) {
+				utils.Logger.Error("RBAC permission lookup failed",
+					zap.String("path", r.URL.Path),
+					zap.String("userID", identity.UserID),
+					zap.String("role", identity.Role),
+					zap.Error(err))
+				writeAuthError(w, "failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+			if err != nil {
+				utils.Logger.Warn("RBAC denied request",
+					zap.String("path", r.URL.Path),
+					zap.String("userID", identity.UserID),
+					zap.String("role", identity.Role),
+					zap.String("permission", string(perm)))
+				writeAuthError(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), branchScopeKey, scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// GetBranchScopeFromContext returns the branch the user is confined to ("" = all branches)
+func GetBranchScopeFromContext(ctx context.Context) string {
+	scope, _ := ctx.Value(branchScopeKey).(string)
+	return scope
+}
+
+// CanAccessBranch reports whether the user's branch scope covers branchID
+func CanAccessBranch(ctx context.Context, branchID string) bool {
+	scope := GetBranchScopeFromContext(ctx)
+	return scope == "" || scope == branchID
+}
+
+// HasPermission reports whether the authenticated user also holds perm, for handlers
+// whose behaviour depends on a permission beyond the one guarding the route. A failed
+// permission lookup counts as not holding it.
+func HasPermission(ctx context.Context, perm domain.Permission) bool {
+	_, err := RBAC.Authorize(GetIdentityFromContext(ctx), perm)
+	return err == nil
+}
+
+// WriteForbidden writes the standard 403 JSON error
+func WriteForbidden(w http.ResponseWriter, message string) {
+	writeAuthError(w, message, http.StatusForbidden)
+}
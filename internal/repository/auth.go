@@ -1,22 +1,24 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/pkg/utils"
 )
 
 type AuthRepo struct{}
 
-func (a *AuthRepo) CreateToken(userID string, role string) (string, string, error) {
+func (a *AuthRepo) CreateToken(id *domain.AuthIdentity) (string, string, error) {
 	// Create access token (valid for 24 hours)
-	accessToken, err := utils.GenerateJWT(userID, role, 24*time.Hour)
+	accessToken, err := utils.GenerateJWT(id.UserID, id.BusinessID, id.BranchID, id.UserType, id.Role, 24*time.Hour)
 	if err != nil {
 		return "", "", err
 	}
 
 	// Create refresh token (valid for 7 days)
-	refreshToken, err := utils.GenerateJWT(userID, role, 7*24*time.Hour)
+	refreshToken, err := utils.GenerateJWT(id.UserID, id.BusinessID, id.BranchID, id.UserType, id.Role, 7*24*time.Hour)
 	if err != nil {
 		return "", "", err
 	}
@@ -24,10 +26,20 @@ func (a *AuthRepo) CreateToken(userID string, role string) (string, string, erro
 	return accessToken, refreshToken, nil
 }
 
-func (a *AuthRepo) ValidateToken(token string) (string, string, error) {
+func (a *AuthRepo) ValidateToken(token string) (*domain.AuthIdentity, error) {
 	claims, err := utils.ParseJWT(token)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	// Tokens issued before business_id was added to the claims cannot be trusted for scoping
+	if claims.BusinessID == "" || claims.UserType == "" {
+		return nil, errors.New("token is outdated, please log in again")
 	}
-	return claims.UserID, claims.Role, nil
+	return &domain.AuthIdentity{
+		UserID:     claims.UserID,
+		BusinessID: claims.BusinessID,
+		BranchID:   claims.BranchID,
+		UserType:   claims.UserType,
+		Role:       claims.Role,
+	}, nil
 }
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CreditRepo struct {
+	DB *gorm.DB
+}
+
+func (r *CreditRepo) CreateAccount(a *domain.CreditAccount) error {
+	m := infrastructure.CreditAccount{
+		ID:          a.ID,
+		BusinessID:  a.BusinessID,
+		CustomerID:  a.CustomerID,
+		CreditLimit: a.CreditLimit,
+		Balance:     a.Balance,
+		Active:      a.Active,
+		CreatedBy:   a.CreatedBy,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+	return r.DB.Create(&m).Error
+}
+
+func (r *CreditRepo) GetAccountByID(id string) (*domain.CreditAccount, error) {
+	var m infrastructure.CreditAccount
+	if err := r.DB.Preload("Customer").First(&m, "id = ?", id).Error; err != nil {
+		return nil, domain.ErrCreditAccountNotFound
+	}
+	return toDomainCreditAccount(&m), nil
+}
+
+func (r *CreditRepo) GetAccountByCustomerID(customerID string) (*domain.CreditAccount, error) {
+	var m infrastructure.CreditAccount
+	if err := r.DB.Preload("Customer").First(&m, "customer_id = ?", customerID).Error; err != nil {
+		return nil, domain.ErrCreditAccountNotFound
+	}
+	return toDomainCreditAccount(&m), nil
+}
+
+func (r *CreditRepo) GetAccountsByBusinessID(businessID string) ([]*domain.CreditAccount, error) {
+	var models []*infrastructure.CreditAccount
+	if err := r.DB.Preload("Customer").Where("business_id = ?", businessID).Order("balance DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var accounts []*domain.CreditAccount
+	for _, m := range models {
+		accounts = append(accounts, toDomainCreditAccount(m))
+	}
+	return accounts, nil
+}
+
+func (r *CreditRepo) UpdateAccount(a *domain.CreditAccount) error {
+	return r.DB.Model(&infrastructure.CreditAccount{}).Where("id = ?", a.ID).Updates(map[string]interface{}{
+		"credit_limit": a.CreditLimit,
+		"active":       a.Active,
+		"updated_at":   a.UpdatedAt,
+	}).Error
+}
+
+func (r *CreditRepo) RecordRepayment(entry *domain.CreditEntry) (*domain.CreditAccount, error) {
+	var result *domain.CreditAccount
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var account infrastructure.CreditAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Customer").First(&account, "id = ?", entry.AccountID).Error; err != nil {
+			return domain.ErrCreditAccountNotFound
+		}
+		amount := roundMoney(entry.Amount)
+		if amount > roundMoney(account.Balance) {
+			return fmt.Errorf("repayment of %.2f exceeds the balance of %.2f", amount, account.Balance)
+		}
+		if err := settleCharges(tx, account.ID, amount); err != nil {
+			return err
+		}
+		m := infrastructure.CreditEntry{
+			ID:         entry.ID,
+			AccountID:  account.ID,
+			BusinessID: account.BusinessID,
+			BranchID:   entry.BranchID,
+			Type:       domain.CreditEntryRepayment,
+			Amount:     -amount,
+			Method:     entry.Method,
+			Reference:  entry.Reference,
+			Note:       entry.Note,
+			RecordedBy: entry.RecordedBy,
+			CreatedAt:  entry.CreatedAt,
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		account.Balance = roundMoney(account.Balance - amount)
+		account.UpdatedAt = entry.CreatedAt
+		if err := tx.Model(&account).Updates(map[string]interface{}{
+			"balance":    account.Balance,
+			"updated_at": account.UpdatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		entry.Amount = m.Amount
+		result = toDomainCreditAccount(&account)
+		return nil
+	})
+	return result, err
+}
+
+func (r *CreditRepo) GetEntries(accountID string, limit, offset int) ([]*domain.CreditEntry, error) {
+	var models []*infrastructure.CreditEntry
+	if err := r.DB.Where("account_id = ?", accountID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var entries []*domain.CreditEntry
+	for _, m := range models {
+		entries = append(entries, toDomainCreditEntry(m))
+	}
+	return entries, nil
+}
+
+func (r *CreditRepo) GetOutstandingCharges(businessID string) ([]*domain.CreditEntry, error) {
+	var models []*infrastructure.CreditEntry
+	err := r.DB.Where("business_id = ? AND type = ? AND outstanding > 0", businessID, domain.CreditEntryCharge).
+		Order("created_at ASC").Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	var entries []*domain.CreditEntry
+	for _, m := range models {
+		entries = append(entries, toDomainCreditEntry(m))
+	}
+	return entries, nil
+}
+
+// chargeCredit puts the sale's credit tenders on the customer's account, inside the sale's
+// transaction. The account must be active and stay within its limit.
+func chargeCredit(tx *gorm.DB, sale *domain.Sale) error {
+	var amount float64
+	for _, p := range sale.Payments {
+		if p.Method == domain.PaymentMethodCredit {
+			amount += p.Amount
+		}
+	}
+	amount = roundMoney(amount)
+	if amount == 0 {
+		return nil
+	}
+	if sale.CustomerID == nil {
+		return fmt.Errorf("%w: a credit sale needs a customer", domain.ErrInvalidPayment)
+	}
+	var account infrastructure.CreditAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "customer_id = ?", *sale.CustomerID).Error; err != nil {
+		return domain.ErrCreditAccountNotFound
+	}
+	if account.BusinessID != sale.BusinessID {
+		return domain.ErrCreditAccountNotFound
+	}
+	if !account.Active {
+		return fmt.Errorf("%w: the credit account is suspended", domain.ErrInvalidPayment)
+	}
+	balance := roundMoney(account.Balance + amount)
+	if balance > roundMoney(account.CreditLimit) {
+		return fmt.Errorf("%w: the customer owes %.2f against a limit of %.2f", domain.ErrCreditLimitExceeded, account.Balance, account.CreditLimit)
+	}
+	// Any credit the customer already had in hand pays part of the charge straight away
+	charge := infrastructure.CreditEntry{
+		ID:          uuid.NewString(),
+		AccountID:   account.ID,
+		BusinessID:  account.BusinessID,
+		BranchID:    sale.BranchID,
+		Type:        domain.CreditEntryCharge,
+		Amount:      amount,
+		Outstanding: math.Min(amount, math.Max(0, balance)),
+		SaleID:      &sale.ID,
+		RecordedBy:  sale.CashierID,
+		CreatedAt:   sale.CreatedAt,
+	}
+	if err := tx.Create(&charge).Error; err != nil {
+		return err
+	}
+	return tx.Model(&account).Updates(map[string]interface{}{
+		"balance":    balance,
+		"updated_at": time.Now().Unix(),
+	}).Error
+}
+
+// reverseCredit takes a voided sale's charge off the customer's account. Whatever had already
+// been repaid against it goes to the customer's other charges, oldest first.
+func reverseCredit(tx *gorm.DB, sale *infrastructure.Sale, voidedBy string, voidedAt int64) error {
+	var charges []infrastructure.CreditEntry
+	if err := tx.Where("sale_id = ? AND type = ?", sale.ID, domain.CreditEntryCharge).Find(&charges).Error; err != nil {
+		return err
+	}
+	for _, c := range charges {
+		var account infrastructure.CreditAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "id = ?", c.AccountID).Error; err != nil {
+			return domain.ErrCreditAccountNotFound
+		}
+		if err := tx.Model(&infrastructure.CreditEntry{}).Where("id = ?", c.ID).Update("outstanding", 0).Error; err != nil {
+			return err
+		}
+		if repaid := roundMoney(c.Amount - c.Outstanding); repaid > 0 {
+			if err := settleCharges(tx, account.ID, repaid); err != nil {
+				return err
+			}
+		}
+		reversal := infrastructure.CreditEntry{
+			ID:         uuid.NewString(),
+			AccountID:  account.ID,
+			BusinessID: account.BusinessID,
+			BranchID:   sale.BranchID,
+			Type:       domain.CreditEntryReversal,
+			Amount:     -c.Amount,
+			SaleID:     &sale.ID,
+			Note:       "sale voided",
+			RecordedBy: voidedBy,
+			CreatedAt:  voidedAt,
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&account).Updates(map[string]interface{}{
+			"balance":    roundMoney(account.Balance - c.Amount),
+			"updated_at": voidedAt,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refundCredit takes amount of a refunded credit sale's charge off the customer's account. It
+// comes off what is still owed on the charge first; any part of it the customer had already
+// repaid goes to their other charges, oldest first.
+func refundCredit(tx *gorm.DB, sale *infrastructure.Sale, refundID string, amount float64, refundedBy string, refundedAt int64) error {
+	amount = roundMoney(amount)
+	var charge infrastructure.CreditEntry
+	if err := tx.Where("sale_id = ? AND type = ?", sale.ID, domain.CreditEntryCharge).First(&charge).Error; err != nil {
+		return fmt.Errorf("%w: the sale has no credit charge to refund", domain.ErrInvalidRefundPayment)
+	}
+	var account infrastructure.CreditAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "id = ?", charge.AccountID).Error; err != nil {
+		return domain.ErrCreditAccountNotFound
+	}
+	owed := math.Min(amount, charge.Outstanding)
+	if err := tx.Model(&infrastructure.CreditEntry{}).Where("id = ?", charge.ID).
+		Update("outstanding", roundMoney(charge.Outstanding-owed)).Error; err != nil {
+		return err
+	}
+	if repaid := roundMoney(amount - owed); repaid > 0 {
+		if err := settleCharges(tx, account.ID, repaid); err != nil {
+			return err
+		}
+	}
+	reversal := infrastructure.CreditEntry{
+		ID:         uuid.NewString(),
+		AccountID:  account.ID,
+		BusinessID: account.BusinessID,
+		BranchID:   sale.BranchID,
+		Type:       domain.CreditEntryReversal,
+		Amount:     -amount,
+		SaleID:     &sale.ID,
+		Reference:  refundID,
+		Note:       "sale refunded",
+		RecordedBy: refundedBy,
+		CreatedAt:  refundedAt,
+	}
+	if err := tx.Create(&reversal).Error; err != nil {
+		return err
+	}
+	return tx.Model(&account).Updates(map[string]interface{}{
+		"balance":    roundMoney(account.Balance - amount),
+		"updated_at": refundedAt,
+	}).Error
+}
+
+// settleCharges pays amount off the account's outstanding charges, oldest first
+func settleCharges(tx *gorm.DB, accountID string, amount float64) error {
+	var charges []infrastructure.CreditEntry
+	err := tx.Where("account_id = ? AND type = ? AND outstanding > 0", accountID, domain.CreditEntryCharge).
+		Order("created_at ASC").Find(&charges).Error
+	if err != nil {
+		return err
+	}
+	left := roundMoney(amount)
+	for _, c := range charges {
+		if left <= 0 {
+			break
+		}
+		pay := math.Min(left, c.Outstanding)
+		if err := tx.Model(&infrastructure.CreditEntry{}).Where("id = ?", c.ID).
+			Update("outstanding", roundMoney(c.Outstanding-pay)).Error; err != nil {
+			return err
+		}
+		left = roundMoney(left - pay)
+	}
+	return nil
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func toDomainCreditAccount(m *infrastructure.CreditAccount) *domain.CreditAccount {
+	return &domain.CreditAccount{
+		ID:            m.ID,
+		BusinessID:    m.BusinessID,
+		CustomerID:    m.CustomerID,
+		CreditLimit:   m.CreditLimit,
+		Balance:       m.Balance,
+		Active:        m.Active,
+		CreatedBy:     m.CreatedBy,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		CustomerName:  m.Customer.Name,
+		CustomerPhone: m.Customer.Phone,
+	}
+}
+
+func toDomainCreditEntry(m *infrastructure.CreditEntry) *domain.CreditEntry {
+	return &domain.CreditEntry{
+		ID:          m.ID,
+		AccountID:   m.AccountID,
+		BusinessID:  m.BusinessID,
+		BranchID:    m.BranchID,
+		Type:        m.Type,
+		Amount:      m.Amount,
+		Outstanding: m.Outstanding,
+		SaleID:      m.SaleID,
+		Method:      m.Method,
+		Reference:   m.Reference,
+		Note:        m.Note,
+		RecordedBy:  m.RecordedBy,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type CustomerRepo struct {
+	DB *gorm.DB
+}
+
+func (r *CustomerRepo) CreateCustomer(c *domain.Customer) error {
+	infra := toInfraCustomer(c)
+	return r.DB.Create(&infra).Error
+}
+
+func (r *CustomerRepo) GetCustomerByID(id string) (*domain.Customer, error) {
+	var infra infrastructure.Customer
+	err := r.DB.First(&infra, "id = ? AND (deleted_at IS NULL OR deleted_at = 0)", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return toDomainCustomer(&infra), nil
+}
+
+func (r *CustomerRepo) GetCustomerByPhone(businessID, phone string) (*domain.Customer, error) {
+	var infra infrastructure.Customer
+	err := r.DB.First(&infra, "business_id = ? AND phone = ? AND (deleted_at IS NULL OR deleted_at = 0)", businessID, phone).Error
+	if err != nil {
+		return nil, err
+	}
+	return toDomainCustomer(&infra), nil
+}
+
+func (r *CustomerRepo) GetCustomersByBusinessID(businessID, search string, limit, offset int) ([]*domain.Customer, error) {
+	var infras []*infrastructure.Customer
+	query := r.DB.Where("business_id = ? AND (deleted_at IS NULL OR deleted_at = 0)", businessID)
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("(name LIKE ? OR phone LIKE ? OR email LIKE ?)", like, like, like)
+	}
+	if err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&infras).Error; err != nil {
+		return nil, err
+	}
+	var customers []*domain.Customer
+	for _, infra := range infras {
+		customers = append(customers, toDomainCustomer(infra))
+	}
+	return customers, nil
+}
+
+func (r *CustomerRepo) UpdateCustomer(c *domain.Customer) error {
+	infra := toInfraCustomer(c)
+	return r.DB.Save(&infra).Error
+}
+
+// DeleteCustomer soft-deletes the customer so that past sales keep their reference
+func (r *CustomerRepo) DeleteCustomer(id string) error {
+	now := time.Now().Unix()
+	return r.DB.Model(&infrastructure.Customer{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": now, "updated_at": now}).Error
+}
+
+func (r *CustomerRepo) GetCustomerSales(customerID, branchID string, limit, offset int) ([]*domain.Sale, error) {
+	var sales []*infrastructure.Sale
+	query := r.DB.Preload("SaleItems.Lots").Preload("Payments").Where("customer_id = ?", customerID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&sales).Error; err != nil {
+		return nil, err
+	}
+	var result []*domain.Sale
+	for _, s := range sales {
+		result = append(result, toDomainSale(s))
+	}
+	return result, nil
+}
+
+func (r *CustomerRepo) GetCustomerLifetime(customerID, branchID string) (*domain.CustomerLifetime, error) {
+	var totals struct {
+		SalesCount int
+		TotalSpent float64
+		FirstAt    *int64
+		LastAt     *int64
+	}
+	query := r.DB.Table("sales").
+		Select("COUNT(*) AS sales_count, COALESCE(SUM(total_amount), 0) AS total_spent, MIN(created_at) AS first_at, MAX(created_at) AS last_at").
+		Where("customer_id = ? AND status <> ?", customerID, domain.SaleStatusVoided)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	var refunded float64
+	refunds := r.DB.Table("refunds").
+		Select("COALESCE(SUM(refunds.total_amount), 0)").
+		Joins("JOIN sales ON sales.id = refunds.sale_id").
+		Where("sales.customer_id = ? AND sales.status <> ? AND refunds.status = ?",
+			customerID, domain.SaleStatusVoided, domain.RefundStatusCompleted)
+	if branchID != "" {
+		refunds = refunds.Where("sales.branch_id = ?", branchID)
+	}
+	if err := refunds.Scan(&refunded).Error; err != nil {
+		return nil, err
+	}
+
+	return &domain.CustomerLifetime{
+		SalesCount:      totals.SalesCount,
+		TotalSpent:      totals.TotalSpent,
+		TotalRefunded:   refunded,
+		LifetimeValue:   totals.TotalSpent - refunded,
+		FirstPurchaseAt: totals.FirstAt,
+		LastPurchaseAt:  totals.LastAt,
+	}, nil
+}
+
+func toInfraCustomer(c *domain.Customer) infrastructure.Customer {
+	return infrastructure.Customer{
+		ID:         c.ID,
+		BusinessID: c.BusinessID,
+		Name:       c.Name,
+		Phone:      c.Phone,
+		Email:      c.Email,
+		Notes:      c.Notes,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
+
+func toDomainCustomer(infra *infrastructure.Customer) *domain.Customer {
+	return &domain.Customer{
+		ID:         infra.ID,
+		BusinessID: infra.BusinessID,
+		Name:       infra.Name,
+		Phone:      infra.Phone,
+		Email:      infra.Email,
+		Notes:      infra.Notes,
+		CreatedAt:  infra.CreatedAt,
+		UpdatedAt:  infra.UpdatedAt,
+	}
+}
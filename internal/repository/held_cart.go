@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type HeldCartRepo struct {
+	DB *gorm.DB
+}
+
+func (r *HeldCartRepo) CreateHeldCart(c *domain.HeldCart) error {
+	m := toInfraHeldCart(c)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(&m).Error; err != nil {
+			return err
+		}
+		return createHeldCartItems(tx, c)
+	})
+}
+
+func (r *HeldCartRepo) GetHeldCartByID(id string) (*domain.HeldCart, error) {
+	var m infrastructure.HeldCart
+	err := r.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).First(&m, "id = ?", id).Error
+	if err != nil {
+		return nil, domain.ErrHeldCartNotFound
+	}
+	return toDomainHeldCart(&m), nil
+}
+
+func (r *HeldCartRepo) GetHeldCarts(businessID, branchID string) ([]*domain.HeldCart, error) {
+	query := r.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	var models []*infrastructure.HeldCart
+	if err := query.Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var carts []*domain.HeldCart
+	for _, m := range models {
+		carts = append(carts, toDomainHeldCart(m))
+	}
+	return carts, nil
+}
+
+func (r *HeldCartRepo) UpdateHeldCart(c *domain.HeldCart) error {
+	m := toInfraHeldCart(c)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&infrastructure.HeldCart{}).Where("id = ?", c.ID).Updates(map[string]interface{}{
+			"label":           m.Label,
+			"customer_id":     m.CustomerID,
+			"customer_phone":  m.CustomerPhone,
+			"discount_type":   m.DiscountType,
+			"discount_value":  m.DiscountValue,
+			"discount_reason": m.DiscountReason,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrHeldCartNotFound
+		}
+		if err := tx.Where("cart_id = ?", c.ID).Delete(&infrastructure.HeldCartItem{}).Error; err != nil {
+			return err
+		}
+		return createHeldCartItems(tx, c)
+	})
+}
+
+func (r *HeldCartRepo) DeleteHeldCart(id string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&infrastructure.HeldCart{})
+		if result.Error != nil {
+			return result.Error
+		}
+		// Another till got there first
+		if result.RowsAffected == 0 {
+			return domain.ErrHeldCartNotFound
+		}
+		return tx.Where("cart_id = ?", id).Delete(&infrastructure.HeldCartItem{}).Error
+	})
+}
+
+func createHeldCartItems(tx *gorm.DB, c *domain.HeldCart) error {
+	var items []infrastructure.HeldCartItem
+	for i, it := range c.Items {
+		item := infrastructure.HeldCartItem{
+			ID:          uuid.NewString(),
+			CartID:      c.ID,
+			Position:    i,
+			ProductID:   it.ProductID,
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+			UnitPrice:   it.UnitPrice,
+		}
+		if d := it.Discount; d != nil && d.Value != 0 {
+			item.DiscountType = d.Type
+			item.DiscountValue = &d.Value
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
+
+func toInfraHeldCart(c *domain.HeldCart) infrastructure.HeldCart {
+	m := infrastructure.HeldCart{
+		ID:             c.ID,
+		BusinessID:     c.BusinessID,
+		BranchID:       c.BranchID,
+		Label:          c.Label,
+		HeldBy:         c.HeldBy,
+		CustomerID:     c.CustomerID,
+		CustomerPhone:  c.CustomerPhone,
+		DiscountReason: c.DiscountReason,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+	if d := c.Discount; d != nil && d.Value != 0 {
+		m.DiscountType = d.Type
+		m.DiscountValue = &d.Value
+	}
+	return m
+}
+
+func toDomainHeldCart(m *infrastructure.HeldCart) *domain.HeldCart {
+	c := &domain.HeldCart{
+		ID:             m.ID,
+		BusinessID:     m.BusinessID,
+		BranchID:       m.BranchID,
+		Label:          m.Label,
+		HeldBy:         m.HeldBy,
+		CustomerID:     m.CustomerID,
+		CustomerPhone:  m.CustomerPhone,
+		Discount:       toDomainDiscount(m.DiscountType, m.DiscountValue),
+		DiscountReason: m.DiscountReason,
+		Items:          []domain.HeldCartItem{},
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	for _, it := range m.Items {
+		c.Items = append(c.Items, domain.HeldCartItem{
+			ProductID:   it.ProductID,
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+			UnitPrice:   it.UnitPrice,
+			Discount:    toDomainDiscount(it.DiscountType, it.DiscountValue),
+		})
+	}
+	return c
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepo struct {
+	DB *gorm.DB
+}
+
+func (r *IdempotencyRepo) ClaimIdempotencyKey(rec *domain.IdempotencyRecord, expiredBefore int64) (*domain.IdempotencyRecord, error) {
+	var existing *domain.IdempotencyRecord
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// A key whose replay window has passed can be used again
+		if err := tx.Where("business_id = ? AND idempotency_key = ? AND created_at < ?", rec.BusinessID, rec.Key, expiredBefore).
+			Delete(&infrastructure.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		var m infrastructure.IdempotencyKey
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("business_id = ? AND idempotency_key = ?", rec.BusinessID, rec.Key).First(&m).Error
+		if err == nil {
+			existing = toDomainIdempotencyRecord(&m)
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Create(&infrastructure.IdempotencyKey{
+			ID:             rec.ID,
+			BusinessID:     rec.BusinessID,
+			IdempotencyKey: rec.Key,
+			UserID:         rec.UserID,
+			Endpoint:       rec.Endpoint,
+			RequestHash:    rec.RequestHash,
+			ClaimedAt:      rec.ClaimedAt,
+			CreatedAt:      rec.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		// Another retry claimed the key between our read and insert
+		var m infrastructure.IdempotencyKey
+		if r.DB.Where("business_id = ? AND idempotency_key = ?", rec.BusinessID, rec.Key).First(&m).Error == nil {
+			return toDomainIdempotencyRecord(&m), nil
+		}
+		return nil, err
+	}
+	return existing, nil
+}
+
+// TakeOverIdempotencyKey re-checks the claim in the update itself, so only one retry wins
+func (r *IdempotencyRepo) TakeOverIdempotencyKey(businessID, key string, claimedAt, abandonedBefore int64) (bool, error) {
+	result := r.DB.Model(&infrastructure.IdempotencyKey{}).
+		Where("business_id = ? AND idempotency_key = ? AND status_code = 0 AND claimed_at < ?", businessID, key, abandonedBefore).
+		Update("claimed_at", claimedAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *IdempotencyRepo) SaveIdempotentResponse(businessID, key string, statusCode int, contentType, response string) error {
+	return r.DB.Model(&infrastructure.IdempotencyKey{}).
+		Where("business_id = ? AND idempotency_key = ?", businessID, key).
+		Updates(map[string]interface{}{
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"response":     response,
+		}).Error
+}
+
+func (r *IdempotencyRepo) DeleteIdempotencyKey(businessID, key string) error {
+	return r.DB.Where("business_id = ? AND idempotency_key = ?", businessID, key).Delete(&infrastructure.IdempotencyKey{}).Error
+}
+
+func (r *IdempotencyRepo) DeleteIdempotencyKeysBefore(before int64) (int, error) {
+	result := r.DB.Where("created_at < ?", before).Delete(&infrastructure.IdempotencyKey{})
+	return int(result.RowsAffected), result.Error
+}
+
+func toDomainIdempotencyRecord(m *infrastructure.IdempotencyKey) *domain.IdempotencyRecord {
+	return &domain.IdempotencyRecord{
+		ID:          m.ID,
+		BusinessID:  m.BusinessID,
+		Key:         m.IdempotencyKey,
+		UserID:      m.UserID,
+		Endpoint:    m.Endpoint,
+		RequestHash: m.RequestHash,
+		StatusCode:  m.StatusCode,
+		ContentType: m.ContentType,
+		Response:    m.Response,
+		ClaimedAt:   m.ClaimedAt,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refreshProductExpirySQL keeps products.expiry_date on the earliest expiry still in stock
+// for products tracked by lot, so expiry notifications keep working
+const refreshProductExpirySQL = `UPDATE products SET expiry_date = (
+		SELECT MIN(l.expiry_date) FROM product_lots l WHERE l.product_id = ? AND l.quantity > 0
+	) WHERE id = ? AND EXISTS (SELECT 1 FROM product_lots l2 WHERE l2.product_id = ?)`
+
+type LotRepo struct {
+	DB *gorm.DB
+}
+
+func (r *LotRepo) ReceiveLot(receipt *domain.LotReceipt) (*domain.ProductLot, error) {
+	var result *domain.ProductLot
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var product infrastructure.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ? AND (deleted_at IS NULL OR deleted_at = 0)", receipt.ProductID).Error; err != nil {
+			return errors.New("product not found")
+		}
+		lot, err := receiveIntoLot(tx, &product, receipt)
+		if err != nil {
+			return err
+		}
+		if err := moveStock(tx, &product, receipt.Quantity, receipt.Change); err != nil {
+			return err
+		}
+		if err := tx.Exec(refreshProductExpirySQL, product.ID, product.ID, product.ID).Error; err != nil {
+			return err
+		}
+		result = toDomainLot(lot, time.Now().Unix())
+		return nil
+	})
+	return result, err
+}
+
+func (r *LotRepo) GetLotsByProduct(productID string, includeEmpty bool) ([]*domain.ProductLot, error) {
+	var models []*infrastructure.ProductLot
+	query := r.DB.Where("product_id = ?", productID)
+	if !includeEmpty {
+		query = query.Where("quantity > 0")
+	}
+	if err := query.Order("expiry_date IS NULL").Order("expiry_date ASC").Order("received_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	var lots []*domain.ProductLot
+	for _, m := range models {
+		lots = append(lots, toDomainLot(m, now))
+	}
+	return lots, nil
+}
+
+// receiveIntoLot adds units to the product's lot with the receipt's batch number, creating
+// the lot if needed. The product's stock itself is changed by the caller.
+func receiveIntoLot(tx *gorm.DB, product *infrastructure.Product, receipt *domain.LotReceipt) (*infrastructure.ProductLot, error) {
+	var lot infrastructure.ProductLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&lot, "product_id = ? AND batch_number = ?", product.ID, receipt.BatchNumber).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		lot = infrastructure.ProductLot{
+			ID:               uuid.NewString(),
+			BusinessID:       product.BusinessID,
+			BranchID:         product.BranchID,
+			ProductID:        product.ID,
+			BatchNumber:      receipt.BatchNumber,
+			ExpiryDate:       receipt.ExpiryDate,
+			Quantity:         receipt.Quantity,
+			ReceivedQuantity: receipt.Quantity,
+			CostPrice:        receipt.CostPrice,
+			SupplierID:       receipt.SupplierID,
+			ReceivedAt:       time.Now().Unix(),
+		}
+		return &lot, tx.Create(&lot).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !equalExpiry(lot.ExpiryDate, receipt.ExpiryDate) {
+		return nil, fmt.Errorf("batch %s is already recorded with a different expiry date", receipt.BatchNumber)
+	}
+	lot.Quantity += receipt.Quantity
+	lot.ReceivedQuantity += receipt.Quantity
+	err = tx.Model(&lot).Updates(map[string]interface{}{
+		"quantity":          lot.Quantity,
+		"received_quantity": lot.ReceivedQuantity,
+	}).Error
+	return &lot, err
+}
+
+// lotTake is the number of units taken out of one lot
+type lotTake struct {
+	Lot      *infrastructure.ProductLot
+	Quantity int
+}
+
+// unitCost is the average cost of qty units of product taken from the given lots; units not
+// held in any lot are costed at the product's cost price
+func unitCost(product *infrastructure.Product, qty int, takes []lotTake) float64 {
+	if qty <= 0 {
+		return 0
+	}
+	var cost float64
+	taken := 0
+	for _, t := range takes {
+		cost += float64(t.Quantity) * t.Lot.CostPrice
+		taken += t.Quantity
+	}
+	cost += float64(qty-taken) * product.CostPrice
+	return roundMoney(cost / float64(qty))
+}
+
+// takeFromLots removes qty sellable units of a locked product from its lots, earliest
+// expiry first, falling back to unlotted stock once the lots run out. Expired lots and
+// the lots in skip (e.g. recalled batches) are never used; if only those units would
+// cover qty the request fails. The product's stock itself is changed by the caller.
+func takeFromLots(tx *gorm.DB, product *infrastructure.Product, qty int, skip map[string]bool) ([]lotTake, error) {
+	var lots []infrastructure.ProductLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND quantity > 0", product.ID).
+		Order("expiry_date IS NULL").Order("expiry_date ASC").Order("received_at ASC").
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	lotted, expired, recalled := 0, 0, 0
+	for _, l := range lots {
+		lotted += l.Quantity
+		if skip[l.ID] {
+			recalled += l.Quantity
+		} else if l.ExpiryDate != nil && *l.ExpiryDate <= now {
+			expired += l.Quantity
+		}
+	}
+	unlotted := product.QuantityInStock - lotted
+	if unlotted < 0 {
+		unlotted = 0
+	}
+	if qty > lotted-expired-recalled+unlotted {
+		if recalled > 0 {
+			return nil, fmt.Errorf("%w for product %s: %d units on hand are in recalled lots", domain.ErrInsufficientStock, product.ID, recalled)
+		}
+		if expired > 0 {
+			return nil, fmt.Errorf("%w for product %s: %d units on hand are in expired lots", domain.ErrInsufficientStock, product.ID, expired)
+		}
+		return nil, fmt.Errorf("%w for product %s", domain.ErrInsufficientStock, product.ID)
+	}
+
+	var takes []lotTake
+	need := qty
+	for i := range lots {
+		l := &lots[i]
+		if need == 0 {
+			break
+		}
+		if skip[l.ID] || (l.ExpiryDate != nil && *l.ExpiryDate <= now) {
+			continue
+		}
+		take := l.Quantity
+		if take > need {
+			take = need
+		}
+		l.Quantity -= take
+		if err := tx.Model(l).Update("quantity", l.Quantity).Error; err != nil {
+			return nil, err
+		}
+		takes = append(takes, lotTake{Lot: l, Quantity: take})
+		need -= take
+	}
+	if len(takes) > 0 {
+		if err := tx.Exec(refreshProductExpirySQL, product.ID, product.ID, product.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return takes, nil
+}
+
+// returnToLots puts up to qty units of a sale item back into the lots they were sold from,
+// latest expiry first. Units sold from unlotted stock go back as unlotted stock.
+func returnToLots(tx *gorm.DB, saleItemID, productID string, qty int) error {
+	var used []infrastructure.SaleItemLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_item_id = ? AND returned_quantity < quantity", saleItemID).
+		Order("expiry_date IS NULL DESC").Order("expiry_date DESC").
+		Find(&used).Error
+	if err != nil {
+		return err
+	}
+	if len(used) == 0 {
+		return nil
+	}
+	for i := range used {
+		if qty == 0 {
+			break
+		}
+		u := &used[i]
+		back := u.Quantity - u.ReturnedQuantity
+		if back > qty {
+			back = qty
+		}
+		if err := tx.Model(&infrastructure.ProductLot{}).Where("id = ?", u.LotID).
+			Update("quantity", gorm.Expr("quantity + ?", back)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(u).Update("returned_quantity", u.ReturnedQuantity+back).Error; err != nil {
+			return err
+		}
+		qty -= back
+	}
+	return tx.Exec(refreshProductExpirySQL, productID, productID, productID).Error
+}
+
+// trimLots keeps a product's lots within its stock after a manual decrease made through the
+// sqlx repository. Units are removed from unlotted stock first, then from the lots expiring soonest.
+func trimLots(tx *sqlx.Tx, productID string, newQty int) error {
+	rows, err := tx.Queryx(`SELECT id, quantity FROM product_lots 
+	       WHERE product_id = ? AND quantity > 0 
+	       ORDER BY expiry_date IS NULL, expiry_date ASC, received_at ASC FOR UPDATE`, productID)
+	if err != nil {
+		return err
+	}
+	type lotQty struct {
+		id  string
+		qty int
+	}
+	var lots []lotQty
+	total := 0
+	for rows.Next() {
+		var l lotQty
+		if err := rows.Scan(&l.id, &l.qty); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, l)
+		total += l.qty
+	}
+	rows.Close()
+
+	excess := total - newQty
+	if excess <= 0 {
+		return nil
+	}
+	for _, l := range lots {
+		if excess == 0 {
+			break
+		}
+		take := l.qty
+		if take > excess {
+			take = excess
+		}
+		if _, err := tx.Exec(`UPDATE product_lots SET quantity = ?, updated_at = ? WHERE id = ?`,
+			l.qty-take, time.Now().Unix(), l.id); err != nil {
+			return err
+		}
+		excess -= take
+	}
+	_, err = tx.Exec(refreshProductExpirySQL, productID, productID, productID)
+	return err
+}
+
+func equalExpiry(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func toDomainLot(m *infrastructure.ProductLot, now int64) *domain.ProductLot {
+	return &domain.ProductLot{
+		ID:               m.ID,
+		BusinessID:       m.BusinessID,
+		BranchID:         m.BranchID,
+		ProductID:        m.ProductID,
+		BatchNumber:      m.BatchNumber,
+		ExpiryDate:       m.ExpiryDate,
+		Quantity:         m.Quantity,
+		ReceivedQuantity: m.ReceivedQuantity,
+		CostPrice:        m.CostPrice,
+		SupplierID:       m.SupplierID,
+		ReceivedAt:       m.ReceivedAt,
+		Expired:          m.ExpiryDate != nil && *m.ExpiryDate <= now,
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an empty, migrated in-memory SQLite database
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := infrastructure.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// seedLots stores a product with stock units on hand spread over lots, named by batch number
+func seedLots(t *testing.T, db *gorm.DB, stock int, lots ...infrastructure.ProductLot) *infrastructure.Product {
+	t.Helper()
+	product := &infrastructure.Product{ID: "product-1", ProductName: "Paracetamol", BusinessID: "business-1", BranchID: "branch-1", QuantityInStock: stock, CreatedBy: "user-1"}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range lots {
+		l.ID = "lot-" + strings.ToLower(l.BatchNumber)
+		l.BusinessID, l.BranchID, l.ProductID = product.BusinessID, product.BranchID, product.ID
+		l.ReceivedQuantity = l.Quantity
+		if err := db.Create(&l).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return product
+}
+
+// sell takes qty units the way a sale does, passing over the lots in skip, and returns
+// "BATCH:n" for each lot used
+func sell(t *testing.T, db *gorm.DB, product *infrastructure.Product, qty int, skip map[string]bool) (string, error) {
+	t.Helper()
+	takes, err := takeFromLots(db, product, qty, skip)
+	if err != nil {
+		return "", err
+	}
+	product.QuantityInStock -= qty
+	var used []string
+	for _, take := range takes {
+		var stored infrastructure.ProductLot
+		if err := db.First(&stored, "id = ?", take.Lot.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Quantity != take.Lot.Quantity {
+			t.Errorf("lot %s holds %d, want %d", stored.BatchNumber, stored.Quantity, take.Lot.Quantity)
+		}
+		used = append(used, take.Lot.BatchNumber+":"+strconv.Itoa(take.Quantity))
+	}
+	return strings.Join(used, " "), nil
+}
+
+func TestTakeFromLotsSellsEarliestExpiryFirst(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	in := func(days int) *int64 {
+		at := now.AddDate(0, 0, days).Unix()
+		return &at
+	}
+	// 20 units on hand: 18 in lots, 3 of them expired, and 2 unlotted
+	product := seedLots(t, db, 20,
+		infrastructure.ProductLot{BatchNumber: "LATE", ExpiryDate: in(10), Quantity: 5, ReceivedAt: now.AddDate(0, 0, -3).Unix()},
+		infrastructure.ProductLot{BatchNumber: "SOON", ExpiryDate: in(5), Quantity: 5, ReceivedAt: now.AddDate(0, 0, -2).Unix()},
+		infrastructure.ProductLot{BatchNumber: "NEVER", Quantity: 5, ReceivedAt: now.AddDate(0, 0, -4).Unix()},
+		infrastructure.ProductLot{BatchNumber: "EXPIRED", ExpiryDate: in(-1), Quantity: 3, ReceivedAt: now.AddDate(0, 0, -5).Unix()},
+	)
+
+	if used, err := sell(t, db, product, 7, nil); err != nil || used != "SOON:5 LATE:2" {
+		t.Fatalf("first sale took %q, %v; want SOON:5 LATE:2", used, err)
+	}
+	// Lots without an expiry date go after every dated lot
+	if used, err := sell(t, db, product, 5, nil); err != nil || used != "LATE:3 NEVER:2" {
+		t.Fatalf("second sale took %q, %v; want LATE:3 NEVER:2", used, err)
+	}
+	// 8 units left, but 3 of them have expired
+	_, err := sell(t, db, product, 6, nil)
+	if !errors.Is(err, domain.ErrInsufficientStock) || !strings.Contains(err.Error(), "3 units on hand are in expired lots") {
+		t.Fatalf("selling expired units: error = %v, want %v naming the expired units", err, domain.ErrInsufficientStock)
+	}
+	// Once the lots run out the rest comes from unlotted stock
+	if used, err := sell(t, db, product, 5, nil); err != nil || used != "NEVER:3" {
+		t.Fatalf("last sale took %q, %v; want NEVER:3 and 2 unlotted", used, err)
+	}
+}
+
+func TestTakeFromLotsPassesOverRecalledLots(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	soon, late := now.AddDate(0, 0, 5).Unix(), now.AddDate(0, 0, 10).Unix()
+	product := seedLots(t, db, 10,
+		infrastructure.ProductLot{BatchNumber: "SOON", ExpiryDate: &soon, Quantity: 5, ReceivedAt: now.Unix()},
+		infrastructure.ProductLot{BatchNumber: "LATE", ExpiryDate: &late, Quantity: 5, ReceivedAt: now.Unix()},
+	)
+	recalled := map[string]bool{"lot-soon": true}
+
+	if used, err := sell(t, db, product, 3, recalled); err != nil || used != "LATE:3" {
+		t.Fatalf("sale took %q, %v; want LATE:3", used, err)
+	}
+	_, err := sell(t, db, product, 3, recalled)
+	if !errors.Is(err, domain.ErrInsufficientStock) || !strings.Contains(err.Error(), "5 units on hand are in recalled lots") {
+		t.Fatalf("selling recalled units: error = %v, want %v naming the recalled units", err, domain.ErrInsufficientStock)
+	}
+}
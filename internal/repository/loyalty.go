@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoyaltyRepo struct {
+	DB *gorm.DB
+}
+
+func (r *LoyaltyRepo) GetLoyaltyBalance(customerID string) (int, error) {
+	var balance int
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var customer infrastructure.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", customerID).Error; err != nil {
+			return errors.New("customer not found")
+		}
+		now := time.Now().Unix()
+		if err := expireLoyaltyPoints(tx, &customer, now); err != nil {
+			return err
+		}
+		var err error
+		balance, err = loyaltyBalance(tx, customer.ID, now)
+		return err
+	})
+	return balance, err
+}
+
+func (r *LoyaltyRepo) GetLoyaltyEntries(customerID string, limit, offset int) ([]*domain.LoyaltyEntry, error) {
+	var models []*infrastructure.LoyaltyEntry
+	err := r.DB.Where("customer_id = ?", customerID).
+		Order("created_at DESC").Order("id ASC").
+		Limit(limit).Offset(offset).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	var entries []*domain.LoyaltyEntry
+	for _, m := range models {
+		entries = append(entries, &domain.LoyaltyEntry{
+			ID:         m.ID,
+			BusinessID: m.BusinessID,
+			CustomerID: m.CustomerID,
+			SaleID:     m.SaleID,
+			Type:       m.Type,
+			Points:     m.Points,
+			ExpiresAt:  m.ExpiresAt,
+			CreatedAt:  m.CreatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// settleLoyalty redeems the points the sale was paid with and credits the points it earns,
+// to lapse at expiresAt, inside the sale's transaction
+func settleLoyalty(tx *gorm.DB, sale *domain.Sale, expiresAt *int64) error {
+	if sale.CustomerID == nil || (sale.PointsRedeemed == 0 && sale.PointsEarned == 0) {
+		return nil
+	}
+	var customer infrastructure.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", *sale.CustomerID).Error; err != nil {
+		return errors.New("customer not found")
+	}
+	now := time.Now().Unix()
+	if err := expireLoyaltyPoints(tx, &customer, now); err != nil {
+		return err
+	}
+
+	if sale.PointsRedeemed > 0 {
+		spentExpiry, err := spendLoyaltyPoints(tx, customer.ID, sale.PointsRedeemed, now)
+		if err != nil {
+			return err
+		}
+		redeem := infrastructure.LoyaltyEntry{
+			ID:         uuid.NewString(),
+			BusinessID: customer.BusinessID,
+			CustomerID: customer.ID,
+			SaleID:     &sale.ID,
+			Type:       domain.LoyaltyEntryRedeem,
+			Points:     -sale.PointsRedeemed,
+			ExpiresAt:  spentExpiry,
+			CreatedAt:  now,
+		}
+		if err := tx.Create(&redeem).Error; err != nil {
+			return err
+		}
+	}
+
+	if sale.PointsEarned > 0 {
+		earn := infrastructure.LoyaltyEntry{
+			ID:         uuid.NewString(),
+			BusinessID: customer.BusinessID,
+			CustomerID: customer.ID,
+			SaleID:     &sale.ID,
+			Type:       domain.LoyaltyEntryEarn,
+			Points:     sale.PointsEarned,
+			Remaining:  sale.PointsEarned,
+			ExpiresAt:  expiresAt,
+			CreatedAt:  now,
+		}
+		if err := tx.Create(&earn).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseLoyalty gives back the points a voided sale redeemed and takes back the points it
+// earned, as far as the customer still holds them
+func reverseLoyalty(tx *gorm.DB, sale *infrastructure.Sale) error {
+	return adjustSaleLoyalty(tx, sale, sale.PointsRedeemed, sale.PointsEarned)
+}
+
+// refundLoyalty gives back the points paid for the part of a sale refunded as points and
+// takes back the points earned on the refunded part, in proportion to the value refunded.
+// prior is what earlier refunds of the sale paid back. Both are worked out on the running
+// total, so refunding the whole sale reverses exactly what it earned and redeemed.
+func refundLoyalty(tx *gorm.DB, sale *infrastructure.Sale, tenders []domain.SalePayment, prior, payments []domain.RefundPayment) error {
+	var pointsPaid, pointsRefunded, pointsRefunding, refunded, refunding float64
+	for _, t := range tenders {
+		if t.Method == domain.PaymentMethodLoyalty {
+			pointsPaid += t.Amount
+		}
+	}
+	for _, p := range prior {
+		refunded += p.Amount
+		if p.Method == domain.PaymentMethodLoyalty {
+			pointsRefunded += p.Amount
+		}
+	}
+	for _, p := range payments {
+		refunding += p.Amount
+		if p.Method == domain.PaymentMethodLoyalty {
+			pointsRefunding += p.Amount
+		}
+	}
+	returned := shareOfPoints(sale.PointsRedeemed, pointsRefunded+pointsRefunding, pointsPaid) -
+		shareOfPoints(sale.PointsRedeemed, pointsRefunded, pointsPaid)
+	takenBack := shareOfPoints(sale.PointsEarned, refunded+refunding, sale.TotalAmount) -
+		shareOfPoints(sale.PointsEarned, refunded, sale.TotalAmount)
+	return adjustSaleLoyalty(tx, sale, returned, takenBack)
+}
+
+// shareOfPoints returns the whole points that part out of whole is worth
+func shareOfPoints(points int, part, whole float64) int {
+	if whole <= 0 || part >= whole {
+		return points
+	}
+	return int(math.Round(float64(points) * part / whole))
+}
+
+// adjustSaleLoyalty gives back returned of the points the sale redeemed and takes back
+// takenBack of the points it earned, as far as the customer still holds them
+func adjustSaleLoyalty(tx *gorm.DB, sale *infrastructure.Sale, returned, takenBack int) error {
+	if sale.CustomerID == nil || (returned <= 0 && takenBack <= 0) {
+		return nil
+	}
+	var customer infrastructure.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", *sale.CustomerID).Error; err != nil {
+		return errors.New("customer not found")
+	}
+	now := time.Now().Unix()
+	if err := expireLoyaltyPoints(tx, &customer, now); err != nil {
+		return err
+	}
+
+	if returned > 0 {
+		// The points keep the latest expiry of the points they were spent from
+		var redeem infrastructure.LoyaltyEntry
+		err := tx.Where("sale_id = ? AND type = ?", sale.ID, domain.LoyaltyEntryRedeem).First(&redeem).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		credit := infrastructure.LoyaltyEntry{
+			ID:         uuid.NewString(),
+			BusinessID: customer.BusinessID,
+			CustomerID: customer.ID,
+			SaleID:     &sale.ID,
+			Type:       domain.LoyaltyEntryReversal,
+			Points:     returned,
+			Remaining:  returned,
+			ExpiresAt:  redeem.ExpiresAt,
+			CreatedAt:  now,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return err
+		}
+		// Points whose expiry has already passed lapse straight away
+		if err := expireLoyaltyPoints(tx, &customer, now); err != nil {
+			return err
+		}
+	}
+
+	if takenBack > 0 {
+		// Take the points back from the sale's own credit first, then from the oldest points held
+		taken := 0
+		var earn infrastructure.LoyaltyEntry
+		err := tx.Where("sale_id = ? AND type = ?", sale.ID, domain.LoyaltyEntryEarn).First(&earn).Error
+		if err == nil && earn.Remaining > 0 {
+			taken = min(earn.Remaining, takenBack)
+			if err := tx.Model(&earn).Update("remaining", earn.Remaining-taken).Error; err != nil {
+				return err
+			}
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if rest := takenBack - taken; rest > 0 {
+			balance, err := loyaltyBalance(tx, customer.ID, now)
+			if err != nil {
+				return err
+			}
+			rest = min(rest, balance)
+			if rest > 0 {
+				if _, err := spendLoyaltyPoints(tx, customer.ID, rest, now); err != nil {
+					return err
+				}
+				taken += rest
+			}
+		}
+		if taken > 0 {
+			clawback := infrastructure.LoyaltyEntry{
+				ID:         uuid.NewString(),
+				BusinessID: customer.BusinessID,
+				CustomerID: customer.ID,
+				SaleID:     &sale.ID,
+				Type:       domain.LoyaltyEntryReversal,
+				Points:     -taken,
+				CreatedAt:  now,
+			}
+			if err := tx.Create(&clawback).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loyaltyBalance sums the unspent points that have not lapsed
+func loyaltyBalance(tx *gorm.DB, customerID string, now int64) (int, error) {
+	var balance int
+	err := tx.Model(&infrastructure.LoyaltyEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", customerID, now).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// spendLoyaltyPoints takes points from the credits that lapse soonest and returns the latest
+// expiry among them (nil if any never lapse)
+func spendLoyaltyPoints(tx *gorm.DB, customerID string, points int, now int64) (*int64, error) {
+	var credits []infrastructure.LoyaltyEntry
+	err := tx.Where("customer_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", customerID, now).
+		Order("CASE WHEN expires_at IS NULL THEN 1 ELSE 0 END").Order("expires_at ASC").Order("created_at ASC").
+		Find(&credits).Error
+	if err != nil {
+		return nil, err
+	}
+	var latest *int64
+	left := points
+	for _, c := range credits {
+		if left == 0 {
+			break
+		}
+		take := min(c.Remaining, left)
+		if err := tx.Model(&infrastructure.LoyaltyEntry{}).Where("id = ?", c.ID).Update("remaining", c.Remaining-take).Error; err != nil {
+			return nil, err
+		}
+		left -= take
+		latest = c.ExpiresAt
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("%w: %d needed, %d held", domain.ErrInsufficientPoints, points, points-left)
+	}
+	return latest, nil
+}
+
+// expireLoyaltyPoints records an expiry for every credit that lapsed with points unspent
+func expireLoyaltyPoints(tx *gorm.DB, customer *infrastructure.Customer, now int64) error {
+	var lapsed []infrastructure.LoyaltyEntry
+	err := tx.Where("customer_id = ? AND remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ?", customer.ID, now).
+		Find(&lapsed).Error
+	if err != nil {
+		return err
+	}
+	for _, c := range lapsed {
+		if err := tx.Model(&infrastructure.LoyaltyEntry{}).Where("id = ?", c.ID).Update("remaining", 0).Error; err != nil {
+			return err
+		}
+		expiry := infrastructure.LoyaltyEntry{
+			ID:         uuid.NewString(),
+			BusinessID: customer.BusinessID,
+			CustomerID: customer.ID,
+			SaleID:     c.SaleID,
+			Type:       domain.LoyaltyEntryExpire,
+			Points:     -c.Remaining,
+			CreatedAt:  *c.ExpiresAt,
+		}
+		if err := tx.Create(&expiry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type RolePermissionRepo struct {
+	DB *gorm.DB
+}
+
+func (r *RolePermissionRepo) GetRolePermissions(businessID string, role domain.StaffRole) (*domain.RolePermissions, error) {
+	var infra infrastructure.RolePermission
+	err := r.DB.First(&infra, "business_id = ? AND role = ?", businessID, string(role)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainRolePermissions(&infra), nil
+}
+
+func (r *RolePermissionRepo) GetRolePermissionsByBusinessID(businessID string) ([]*domain.RolePermissions, error) {
+	var infras []*infrastructure.RolePermission
+	if err := r.DB.Where("business_id = ?", businessID).Find(&infras).Error; err != nil {
+		return nil, err
+	}
+	var result []*domain.RolePermissions
+	for _, infra := range infras {
+		result = append(result, toDomainRolePermissions(infra))
+	}
+	return result, nil
+}
+
+func (r *RolePermissionRepo) SaveRolePermissions(rp *domain.RolePermissions) error {
+	perms := make([]string, 0, len(rp.Permissions))
+	for _, p := range rp.Permissions {
+		perms = append(perms, string(p))
+	}
+	infra := infrastructure.RolePermission{
+		BusinessID:  rp.BusinessID,
+		Role:        infrastructure.StaffRole(rp.Role),
+		Permissions: strings.Join(perms, ","),
+		UpdatedBy:   rp.UpdatedBy,
+		UpdatedAt:   rp.UpdatedAt,
+	}
+	return r.DB.Save(&infra).Error
+}
+
+func (r *RolePermissionRepo) DeleteRolePermissions(businessID string, role domain.StaffRole) error {
+	return r.DB.Delete(&infrastructure.RolePermission{}, "business_id = ? AND role = ?", businessID, string(role)).Error
+}
+
+func toDomainRolePermissions(infra *infrastructure.RolePermission) *domain.RolePermissions {
+	rp := &domain.RolePermissions{
+		BusinessID:  infra.BusinessID,
+		Role:        domain.StaffRole(infra.Role),
+		Permissions: []domain.Permission{},
+		UpdatedBy:   infra.UpdatedBy,
+		UpdatedAt:   infra.UpdatedAt,
+	}
+	for _, p := range strings.Split(infra.Permissions, ",") {
+		if p != "" {
+			rp.Permissions = append(rp.Permissions, domain.Permission(p))
+		}
+	}
+	return rp
+}
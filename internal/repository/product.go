@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -24,7 +25,14 @@ func (r *ProductRepo) GetLowStockCount(businessID, branchID string) (int, error)
 	return count, err
 }
 
+// CreateProduct inserts the product together with the opening entry of its stock ledger
 func (r *ProductRepo) CreateProduct(p *domain.Product) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO products (
 		id, product_name, product_category, business_id, branch_id, 
 		barcode_value, nafdac_reg_number, selling_price, cost_price, 
@@ -32,12 +40,18 @@ func (r *ProductRepo) CreateProduct(p *domain.Product) error {
 		created_at, updated_at, created_by
 	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.DB.Exec(query,
+	if _, err := tx.Exec(query,
 		p.ID, p.ProductName, p.ProductCategory, p.BusinessID, p.BranchID,
 		p.BarcodeValue, p.NAFDACRegNumber, p.SellingPrice, p.CostPrice,
 		p.QuantityInStock, p.LowStockThreshold, p.ExpiryDate, p.ProductImageURL,
-		p.CreatedAt, p.UpdatedAt, p.CreatedBy)
-	return err
+		p.CreatedAt, p.UpdatedAt, p.CreatedBy); err != nil {
+		return err
+	}
+	opening := domain.StockChange{Type: domain.StockMovementOpening, ActorID: p.CreatedBy, Note: "opening balance"}
+	if err := insertStockMovement(tx, p.BusinessID, p.BranchID, p.ID, 0, p.QuantityInStock, opening); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *ProductRepo) GetProductByID(productID string) (*domain.Product, error) {
@@ -72,6 +86,29 @@ func (r *ProductRepo) GetProductByID(productID string) (*domain.Product, error)
 	return &p, nil
 }
 
+// GetProductByBarcode finds the product with the given barcode in a branch, used to match products across branches
+func (r *ProductRepo) GetProductByBarcode(businessID, branchID, barcode string) (*domain.Product, error) {
+	var p domain.Product
+	query := `SELECT 
+		       id, product_name, product_category, business_id, branch_id,
+		       barcode_value, nafdac_reg_number, selling_price, cost_price,
+		       quantity_in_stock, low_stock_threshold, expiry_date, product_image_url,
+		       created_at, updated_at, deleted_at, created_by, updated_by
+	       FROM products 
+	       WHERE business_id = ? AND branch_id = ? AND barcode_value = ? AND (deleted_at IS NULL OR deleted_at = 0)`
+
+	err := r.DB.QueryRowx(query, businessID, branchID, barcode).Scan(
+		&p.ID, &p.ProductName, &p.ProductCategory, &p.BusinessID, &p.BranchID,
+		&p.BarcodeValue, &p.NAFDACRegNumber, &p.SellingPrice, &p.CostPrice,
+		&p.QuantityInStock, &p.LowStockThreshold, &p.ExpiryDate, &p.ProductImageURL,
+		&p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.CreatedBy, &p.UpdatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 func (r *ProductRepo) GetProductsByBusinessID(businessID string) ([]*domain.Product, error) {
 	query := `SELECT 
 		       id, product_name, product_category, business_id, branch_id,
@@ -154,7 +191,21 @@ func (r *ProductRepo) GetProductsByBranchID(businessID, branchID string) ([]*dom
 	return products, nil
 }
 
+// UpdateProduct saves the product; a change to quantity_in_stock is recorded in the ledger as an adjustment
 func (r *ProductRepo) UpdateProduct(p *domain.Product) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	lock := `SELECT quantity_in_stock FROM products 
+	       WHERE id = ? AND business_id = ? AND (deleted_at IS NULL OR deleted_at = 0) FOR UPDATE`
+	if err := tx.QueryRowx(lock, p.ID, p.BusinessID).Scan(&current); err != nil {
+		return err
+	}
+
 	query := `UPDATE products SET 
 		product_name = ?, product_category = ?, selling_price = ?, 
 		cost_price = ?, quantity_in_stock = ?, low_stock_threshold = ?, 
@@ -162,17 +213,41 @@ func (r *ProductRepo) UpdateProduct(p *domain.Product) error {
 		product_image_url = ?, branch_id = ?, updated_at = ?, updated_by = ?
 	WHERE id = ? AND business_id = ? AND (deleted_at IS NULL OR deleted_at = 0)`
 
-	_, err := r.DB.Exec(query,
+	if _, err := tx.Exec(query,
 		p.ProductName, p.ProductCategory, p.SellingPrice, p.CostPrice,
 		p.QuantityInStock, p.LowStockThreshold, p.BarcodeValue,
 		p.NAFDACRegNumber, p.ExpiryDate, p.ProductImageURL, p.BranchID,
-		p.UpdatedAt, p.UpdatedBy, p.ID, p.BusinessID)
-	return err
+		p.UpdatedAt, p.UpdatedBy, p.ID, p.BusinessID); err != nil {
+		return err
+	}
+	if p.QuantityInStock < current {
+		if err := checkUnreserved(tx, p.ID, p.QuantityInStock); err != nil {
+			return err
+		}
+		if err := trimLots(tx, p.ID, p.QuantityInStock); err != nil {
+			return err
+		}
+	}
+	if p.QuantityInStock != current {
+		change := domain.StockChange{Type: domain.StockMovementAdjustment, ReferenceType: "product_update", ReferenceID: p.ID}
+		if p.UpdatedBy != nil {
+			change.ActorID = *p.UpdatedBy
+		}
+		if err := insertStockMovement(tx, p.BusinessID, p.BranchID, p.ID, current, p.QuantityInStock, change); err != nil {
+			return err
+		}
+	}
+	// Products tracked by lot take their expiry from the lots, not from the client
+	if _, err := tx.Exec(refreshProductExpirySQL, p.ID, p.ID, p.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *ProductRepo) DeleteProduct(productID string) error {
-	query := `UPDATE products SET deleted_at = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, time.Now().Unix(), productID)
+	query := `UPDATE products SET deleted_at = ?, updated_at = ? WHERE id = ?`
+	now := time.Now().Unix()
+	_, err := r.DB.Exec(query, now, now, productID)
 	return err
 }
 
@@ -268,14 +343,121 @@ func (r *ProductRepo) GetLowStockProducts(businessID string) ([]*domain.Product,
 	return products, nil
 }
 
-func (r *ProductRepo) UpdateProductStock(productID string, quantity int) error {
+// UpdateProductStock sets an absolute stock level (e.g. after a stock count) and records the difference in the ledger
+func (r *ProductRepo) UpdateProductStock(productID string, quantity int, change domain.StockChange) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		current              int
+		businessID, branchID string
+	)
+	lock := `SELECT quantity_in_stock, business_id, branch_id FROM products 
+	       WHERE id = ? AND (deleted_at IS NULL OR deleted_at = 0) FOR UPDATE`
+	if err := tx.QueryRowx(lock, productID).Scan(&current, &businessID, &branchID); err != nil {
+		return err
+	}
 	query := `UPDATE products SET 
 		quantity_in_stock = ?, 
 		updated_at = ? 
-	WHERE id = ? AND (deleted_at IS NULL OR deleted_at = 0)`
+	WHERE id = ?`
+	if _, err := tx.Exec(query, quantity, time.Now().Unix(), productID); err != nil {
+		return err
+	}
+	if quantity < current {
+		if err := trimLots(tx, productID, quantity); err != nil {
+			return err
+		}
+	}
+	if quantity != current {
+		if err := insertStockMovement(tx, businessID, branchID, productID, current, quantity, change); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-	_, err := r.DB.Exec(query, quantity, time.Now().Unix(), productID)
-	return err
+// AdjustProductStock applies a relative stock change under a row lock, records it in the ledger
+// and returns the new quantity. A decrease is refused if it would take stock below zero or under
+// the reserved units; an increase is always accepted, even if stock oversold offline stays below zero.
+func (r *ProductRepo) AdjustProductStock(productID, businessID string, delta int, change domain.StockChange) (int, error) {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		current  int
+		branchID string
+	)
+	query := `SELECT quantity_in_stock, branch_id FROM products 
+	       WHERE id = ? AND business_id = ? AND (deleted_at IS NULL OR deleted_at = 0) FOR UPDATE`
+	if err := tx.QueryRowx(query, productID, businessID).Scan(&current, &branchID); err != nil {
+		return 0, err
+	}
+	newStock := current + delta
+	if delta < 0 && newStock < 0 {
+		return current, fmt.Errorf("%w for product %s: adjustment would take stock below zero (on hand: %d)", domain.ErrInsufficientStock, productID, current)
+	}
+	if _, err := tx.Exec(`UPDATE products SET quantity_in_stock = ?, updated_at = ? WHERE id = ?`,
+		newStock, time.Now().Unix(), productID); err != nil {
+		return 0, err
+	}
+	if delta < 0 {
+		if err := checkUnreserved(tx, productID, newStock); err != nil {
+			return current, err
+		}
+		if err := trimLots(tx, productID, newStock); err != nil {
+			return 0, err
+		}
+	}
+	if err := insertStockMovement(tx, businessID, branchID, productID, current, newStock, change); err != nil {
+		return 0, err
+	}
+	return newStock, tx.Commit()
+}
+
+// GetProductsUpdatedSince returns products changed at or after since, including soft-deleted
+// ones so that offline clients can drop them from their local catalogue
+func (r *ProductRepo) GetProductsUpdatedSince(businessID, branchID string, since int64) ([]*domain.Product, error) {
+	query := `SELECT 
+		       id, product_name, product_category, business_id, branch_id,
+		       barcode_value, nafdac_reg_number, selling_price, cost_price,
+		       quantity_in_stock, low_stock_threshold, expiry_date, product_image_url,
+		       created_at, updated_at, deleted_at, created_by, updated_by
+	       FROM products 
+	       WHERE business_id = ? AND updated_at >= ?`
+	args := []interface{}{businessID, since}
+	if branchID != "" {
+		query += " AND branch_id = ?"
+		args = append(args, branchID)
+	}
+	query += " ORDER BY updated_at ASC"
+
+	rows, err := r.DB.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var products []*domain.Product
+	for rows.Next() {
+		var p domain.Product
+		err := rows.Scan(
+			&p.ID, &p.ProductName, &p.ProductCategory, &p.BusinessID, &p.BranchID,
+			&p.BarcodeValue, &p.NAFDACRegNumber, &p.SellingPrice, &p.CostPrice,
+			&p.QuantityInStock, &p.LowStockThreshold, &p.ExpiryDate, &p.ProductImageURL,
+			&p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.CreatedBy, &p.UpdatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, &p)
+	}
+	return products, nil
 }
 
 // GetProductsByBranch - backward compatibility method
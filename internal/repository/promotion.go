@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type PromotionRepo struct {
+	DB *gorm.DB
+}
+
+func (r *PromotionRepo) CreatePromotion(p *domain.Promotion) error {
+	m := toInfraPromotion(p)
+	return r.DB.Create(&m).Error
+}
+
+func (r *PromotionRepo) GetPromotionByID(id string) (*domain.Promotion, error) {
+	var m infrastructure.Promotion
+	if err := r.DB.First(&m, "id = ?", id).Error; err != nil {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return toDomainPromotion(&m), nil
+}
+
+func (r *PromotionRepo) GetPromotionsByBusinessID(businessID string, activeOnly bool) ([]*domain.Promotion, error) {
+	var models []*infrastructure.Promotion
+	query := r.DB.Where("business_id = ?", businessID)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("starts_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var promotions []*domain.Promotion
+	for _, m := range models {
+		promotions = append(promotions, toDomainPromotion(m))
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepo) GetRunningPromotions(businessID, branchID string, at int64) ([]*domain.Promotion, error) {
+	var models []*infrastructure.Promotion
+	err := r.DB.Where("business_id = ? AND active = ? AND starts_at <= ?", businessID, true, at).
+		Where("(ends_at IS NULL OR ends_at > ?)", at).
+		Where("(branch_id IS NULL OR branch_id = ?)", branchID).
+		Order("created_at ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	var promotions []*domain.Promotion
+	for _, m := range models {
+		promotions = append(promotions, toDomainPromotion(m))
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepo) UpdatePromotion(p *domain.Promotion) error {
+	m := toInfraPromotion(p)
+	return r.DB.Save(&m).Error
+}
+
+func (r *PromotionRepo) GetPromotionTotals(businessID, branchID string, from, to int64) ([]domain.PromotionTotal, error) {
+	var totals []domain.PromotionTotal
+	query := r.DB.Table("sale_items").
+		Select("sale_items.promotion_id, COALESCE(promotions.name, '') AS name, COALESCE(promotions.type, '') AS type, "+
+			"COUNT(DISTINCT sales.id) AS sales_count, SUM(sale_items.quantity) AS quantity, "+
+			"SUM(sale_items.subtotal) AS revenue, SUM(sale_items.promotion_amount) AS promotion_amount").
+		Joins("JOIN sales ON sales.id = sale_items.sale_id").
+		Joins("LEFT JOIN promotions ON promotions.id = sale_items.promotion_id").
+		Where("sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?",
+			businessID, domain.SaleStatusVoided, from, to).
+		Where("sale_items.promotion_id IS NOT NULL")
+	if branchID != "" {
+		query = query.Where("sales.branch_id = ?", branchID)
+	}
+	err := query.Group("sale_items.promotion_id, promotions.name, promotions.type").Order("promotion_amount DESC").Scan(&totals).Error
+	return totals, err
+}
+
+func toInfraPromotion(p *domain.Promotion) infrastructure.Promotion {
+	return infrastructure.Promotion{
+		ID:             p.ID,
+		BusinessID:     p.BusinessID,
+		BranchID:       p.BranchID,
+		Name:           p.Name,
+		Type:           p.Type,
+		ProductIDs:     strings.Join(p.ProductIDs, ","),
+		Category:       p.Category,
+		BuyQuantity:    p.BuyQuantity,
+		GetQuantity:    p.GetQuantity,
+		BundleQuantity: p.BundleQuantity,
+		BundlePrice:    p.BundlePrice,
+		Price:          p.Price,
+		Percent:        p.Percent,
+		StartsAt:       p.StartsAt,
+		EndsAt:         p.EndsAt,
+		DailyStart:     p.DailyStart,
+		DailyEnd:       p.DailyEnd,
+		Active:         p.Active,
+		CreatedBy:      p.CreatedBy,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+func toDomainPromotion(m *infrastructure.Promotion) *domain.Promotion {
+	p := &domain.Promotion{
+		ID:             m.ID,
+		BusinessID:     m.BusinessID,
+		BranchID:       m.BranchID,
+		Name:           m.Name,
+		Type:           m.Type,
+		ProductIDs:     []string{},
+		Category:       m.Category,
+		BuyQuantity:    m.BuyQuantity,
+		GetQuantity:    m.GetQuantity,
+		BundleQuantity: m.BundleQuantity,
+		BundlePrice:    m.BundlePrice,
+		Price:          m.Price,
+		Percent:        m.Percent,
+		StartsAt:       m.StartsAt,
+		EndsAt:         m.EndsAt,
+		DailyStart:     m.DailyStart,
+		DailyEnd:       m.DailyEnd,
+		Active:         m.Active,
+		CreatedBy:      m.CreatedBy,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.ProductIDs != "" {
+		p.ProductIDs = strings.Split(m.ProductIDs, ",")
+	}
+	return p
+}
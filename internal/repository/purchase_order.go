@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderRepo struct {
+	DB *gorm.DB
+}
+
+func (r *PurchaseOrderRepo) CreatePurchaseOrder(po *domain.PurchaseOrder) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		model := infrastructure.PurchaseOrder{
+			ID:         po.ID,
+			BusinessID: po.BusinessID,
+			BranchID:   po.BranchID,
+			SupplierID: po.SupplierID,
+			Status:     po.Status,
+			Note:       po.Note,
+			ExpectedAt: po.ExpectedAt,
+			TotalCost:  po.TotalCost,
+			CreatedBy:  po.CreatedBy,
+			CreatedAt:  po.CreatedAt,
+			UpdatedAt:  po.UpdatedAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		for _, l := range po.Lines {
+			line := infrastructure.PurchaseOrderLine{
+				ID:              l.ID,
+				PurchaseOrderID: po.ID,
+				ProductID:       l.ProductID,
+				ProductName:     l.ProductName,
+				QuantityOrdered: l.QuantityOrdered,
+				UnitCost:        l.UnitCost,
+			}
+			if err := tx.Create(&line).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PurchaseOrderRepo) GetPurchaseOrderByID(id string) (*domain.PurchaseOrder, error) {
+	var model infrastructure.PurchaseOrder
+	if err := r.DB.Preload("Lines").First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainPurchaseOrder(&model), nil
+}
+
+func (r *PurchaseOrderRepo) ListPurchaseOrders(businessID, branchID, supplierID, status string) ([]*domain.PurchaseOrder, error) {
+	var models []*infrastructure.PurchaseOrder
+	query := r.DB.Preload("Lines").Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var orders []*domain.PurchaseOrder
+	for _, m := range models {
+		orders = append(orders, toDomainPurchaseOrder(m))
+	}
+	return orders, nil
+}
+
+func (r *PurchaseOrderRepo) UpdatePurchaseOrderStatus(id, status string, from []string) (*domain.PurchaseOrder, error) {
+	var result *domain.PurchaseOrder
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		model, err := lockPurchaseOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		allowed := false
+		for _, s := range from {
+			if model.Status == s {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("cannot move a purchase order from %s to %s", model.Status, status)
+		}
+		updates := map[string]interface{}{"status": status}
+		if status == domain.PurchaseOrderStatusSent {
+			now := time.Now().Unix()
+			updates["sent_at"] = now
+			model.SentAt = &now
+		}
+		if err := tx.Model(model).Updates(updates).Error; err != nil {
+			return err
+		}
+		model.Status = status
+		result = toDomainPurchaseOrder(model)
+		return nil
+	})
+	return result, err
+}
+
+// ReceiveGoods books a delivery against an order. Each received line increments stock
+// through the ledger and moves the product's cost price to the weighted average of the
+// stock on hand and the units received.
+func (r *PurchaseOrderRepo) ReceiveGoods(grn *domain.GoodsReceivedNote) (*domain.PurchaseOrder, error) {
+	var result *domain.PurchaseOrder
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		model, err := lockPurchaseOrder(tx, grn.PurchaseOrderID)
+		if err != nil {
+			return err
+		}
+		if model.Status != domain.PurchaseOrderStatusSent && model.Status != domain.PurchaseOrderStatusPartiallyReceived {
+			return fmt.Errorf("cannot receive goods against a purchase order with status %s", model.Status)
+		}
+
+		note := infrastructure.GoodsReceivedNote{
+			ID:              grn.ID,
+			PurchaseOrderID: grn.PurchaseOrderID,
+			BusinessID:      grn.BusinessID,
+			BranchID:        model.BranchID,
+			ReceivedBy:      grn.ReceivedBy,
+			Note:            grn.Note,
+			CreatedAt:       grn.CreatedAt,
+		}
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+
+		lines := map[string]*infrastructure.PurchaseOrderLine{}
+		for i := range model.Lines {
+			lines[model.Lines[i].ID] = &model.Lines[i]
+		}
+		for i := range grn.Items {
+			it := &grn.Items[i]
+			line, ok := lines[it.LineID]
+			if !ok {
+				return errors.New("purchase order line not found")
+			}
+			if it.Quantity > line.QuantityOrdered-line.QuantityReceived {
+				return fmt.Errorf("received quantity exceeds quantity outstanding for %s", line.ProductName)
+			}
+			it.ProductID = line.ProductID
+
+			var product infrastructure.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", line.ProductID).Error; err != nil {
+				return errors.New("product not found")
+			}
+			onHand := product.QuantityInStock
+			if onHand < 0 {
+				onHand = 0
+			}
+			newCost := it.UnitCost
+			if onHand+it.Quantity > 0 {
+				newCost = (float64(onHand)*product.CostPrice + float64(it.Quantity)*it.UnitCost) / float64(onHand+it.Quantity)
+				newCost = math.Round(newCost*100) / 100
+			}
+			change := domain.StockChange{
+				Type:          domain.StockMovementReceipt,
+				ActorID:       grn.ReceivedBy,
+				ReferenceType: "grn",
+				ReferenceID:   grn.ID,
+			}
+			if it.BatchNumber != "" {
+				receipt := &domain.LotReceipt{
+					ProductID:   product.ID,
+					BatchNumber: it.BatchNumber,
+					ExpiryDate:  it.ExpiryDate,
+					Quantity:    it.Quantity,
+					CostPrice:   it.UnitCost,
+					SupplierID:  &model.SupplierID,
+				}
+				if _, err := receiveIntoLot(tx, &product, receipt); err != nil {
+					return err
+				}
+			}
+			if err := moveStock(tx, &product, it.Quantity, change); err != nil {
+				return err
+			}
+			if it.BatchNumber != "" {
+				if err := tx.Exec(refreshProductExpirySQL, product.ID, product.ID, product.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&product).Update("cost_price", newCost).Error; err != nil {
+				return err
+			}
+
+			line.QuantityReceived += it.Quantity
+			if err := tx.Model(line).Update("quantity_received", line.QuantityReceived).Error; err != nil {
+				return err
+			}
+			item := infrastructure.GoodsReceivedNoteItem{
+				ID:          it.ID,
+				GRNID:       grn.ID,
+				LineID:      it.LineID,
+				ProductID:   line.ProductID,
+				Quantity:    it.Quantity,
+				UnitCost:    it.UnitCost,
+				BatchNumber: it.BatchNumber,
+				ExpiryDate:  it.ExpiryDate,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		status := domain.PurchaseOrderStatusReceived
+		for _, l := range model.Lines {
+			if l.QuantityReceived < l.QuantityOrdered {
+				status = domain.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		if err := tx.Model(model).Update("status", status).Error; err != nil {
+			return err
+		}
+		model.Status = status
+		result = toDomainPurchaseOrder(model)
+		return nil
+	})
+	return result, err
+}
+
+func (r *PurchaseOrderRepo) GetGoodsReceivedNotes(purchaseOrderID string) ([]*domain.GoodsReceivedNote, error) {
+	var models []*infrastructure.GoodsReceivedNote
+	err := r.DB.Preload("Items").Where("purchase_order_id = ?", purchaseOrderID).
+		Order("created_at ASC").Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	var notes []*domain.GoodsReceivedNote
+	for _, m := range models {
+		note := &domain.GoodsReceivedNote{
+			ID:              m.ID,
+			PurchaseOrderID: m.PurchaseOrderID,
+			BusinessID:      m.BusinessID,
+			BranchID:        m.BranchID,
+			ReceivedBy:      m.ReceivedBy,
+			Note:            m.Note,
+			CreatedAt:       m.CreatedAt,
+		}
+		for _, it := range m.Items {
+			note.Items = append(note.Items, domain.GoodsReceivedNoteItem{
+				ID:          it.ID,
+				GRNID:       it.GRNID,
+				LineID:      it.LineID,
+				ProductID:   it.ProductID,
+				Quantity:    it.Quantity,
+				UnitCost:    it.UnitCost,
+				BatchNumber: it.BatchNumber,
+				ExpiryDate:  it.ExpiryDate,
+			})
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (r *PurchaseOrderRepo) GetQuantitiesOnOrder(businessID, branchID string) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Units     int
+	}
+	query := r.DB.Table("purchase_order_lines").
+		Select("purchase_order_lines.product_id, SUM(purchase_order_lines.quantity_ordered - purchase_order_lines.quantity_received) AS units").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_lines.purchase_order_id").
+		Where("purchase_orders.business_id = ? AND purchase_orders.status NOT IN ?",
+			businessID, []string{domain.PurchaseOrderStatusReceived, domain.PurchaseOrderStatusCancelled}).
+		Where("purchase_order_lines.quantity_ordered > purchase_order_lines.quantity_received")
+	if branchID != "" {
+		query = query.Where("purchase_orders.branch_id = ?", branchID)
+	}
+	if err := query.Group("purchase_order_lines.product_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	units := map[string]int{}
+	for _, row := range rows {
+		units[row.ProductID] = row.Units
+	}
+	return units, nil
+}
+
+func lockPurchaseOrder(tx *gorm.DB, id string) (*infrastructure.PurchaseOrder, error) {
+	var model infrastructure.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&model, "id = ?", id).Error; err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	return &model, nil
+}
+
+func toDomainPurchaseOrder(m *infrastructure.PurchaseOrder) *domain.PurchaseOrder {
+	po := &domain.PurchaseOrder{
+		ID:         m.ID,
+		BusinessID: m.BusinessID,
+		BranchID:   m.BranchID,
+		SupplierID: m.SupplierID,
+		Status:     m.Status,
+		Note:       m.Note,
+		ExpectedAt: m.ExpectedAt,
+		TotalCost:  m.TotalCost,
+		CreatedBy:  m.CreatedBy,
+		SentAt:     m.SentAt,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+	for _, l := range m.Lines {
+		po.Lines = append(po.Lines, domain.PurchaseOrderLine{
+			ID:               l.ID,
+			PurchaseOrderID:  l.PurchaseOrderID,
+			ProductID:        l.ProductID,
+			ProductName:      l.ProductName,
+			QuantityOrdered:  l.QuantityOrdered,
+			QuantityReceived: l.QuantityReceived,
+			UnitCost:         l.UnitCost,
+		})
+	}
+	return po
+}
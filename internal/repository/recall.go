@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type RecallRepo struct {
+	DB *gorm.DB
+}
+
+func (r *RecallRepo) CreateRecall(recall *domain.Recall) error {
+	m := toInfraRecall(recall)
+	return r.DB.Create(&m).Error
+}
+
+func (r *RecallRepo) GetRecallByID(id string) (*domain.Recall, error) {
+	var m infrastructure.Recall
+	if err := r.DB.First(&m, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainRecall(&m), nil
+}
+
+func (r *RecallRepo) GetRecallsByBusinessID(businessID, status string) ([]*domain.Recall, error) {
+	var models []*infrastructure.Recall
+	query := r.DB.Where("business_id = ?", businessID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var recalls []*domain.Recall
+	for _, m := range models {
+		recalls = append(recalls, toDomainRecall(m))
+	}
+	return recalls, nil
+}
+
+func (r *RecallRepo) CloseRecall(id, closedBy string, closedAt int64) error {
+	res := r.DB.Model(&infrastructure.Recall{}).
+		Where("id = ? AND status = ?", id, domain.RecallStatusActive).
+		Updates(map[string]interface{}{
+			"status":    domain.RecallStatusClosed,
+			"closed_by": closedBy,
+			"closed_at": closedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("recall is not active")
+	}
+	return nil
+}
+
+func (r *RecallRepo) GetRecallStock(recall *domain.Recall, branchID string) ([]domain.RecallStockLine, error) {
+	products, err := recallProducts(r.DB, recall, branchID, false)
+	if err != nil || len(products) == 0 {
+		return nil, err
+	}
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	var lots []infrastructure.ProductLot
+	if err := r.DB.Where("product_id IN ?", ids).
+		Order("expiry_date IS NULL").Order("expiry_date ASC").Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	lotsByProduct := map[string][]infrastructure.ProductLot{}
+	for _, l := range lots {
+		lotsByProduct[l.ProductID] = append(lotsByProduct[l.ProductID], l)
+	}
+	branches, err := branchNames(r.DB, products)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []domain.RecallStockLine
+	for _, p := range products {
+		line := domain.RecallStockLine{
+			BranchID:     p.BranchID,
+			BranchName:   branches[p.BranchID],
+			ProductID:    p.ID,
+			ProductName:  p.ProductName,
+			BarcodeValue: p.BarcodeValue,
+			ExpiryDate:   p.ExpiryDate,
+			Quantity:     p.QuantityInStock,
+		}
+		productLots := lotsByProduct[p.ID]
+		if recall.CoversWholeProduct() || (len(productLots) == 0 && recall.BatchNumber == nil && recall.CoversExpiry(p.ExpiryDate)) {
+			if line.Quantity > 0 {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		for _, l := range productLots {
+			if l.Quantity <= 0 || !recall.CoversLot(l.BatchNumber, l.ExpiryDate) {
+				continue
+			}
+			lotLine := line
+			lotLine.LotID = l.ID
+			lotLine.BatchNumber = l.BatchNumber
+			lotLine.ExpiryDate = l.ExpiryDate
+			lotLine.Quantity = l.Quantity
+			lines = append(lines, lotLine)
+		}
+	}
+	return lines, nil
+}
+
+func (r *RecallRepo) GetRecallSales(recall *domain.Recall, branchID string) ([]domain.RecallSaleLine, error) {
+	// Deleted products are included: their past sales still dispensed the recalled stock
+	products, err := recallProducts(r.DB, recall, "", true)
+	if err != nil || len(products) == 0 {
+		return nil, err
+	}
+	productMap := map[string]*infrastructure.Product{}
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		productMap[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+
+	var sales []*infrastructure.Sale
+	query := r.DB.Preload("SaleItems", "product_id IN ?", ids).Preload("SaleItems.Lots").
+		Where("business_id = ? AND status <> ?", recall.BusinessID, domain.SaleStatusVoided).
+		Where("id IN (?)", r.DB.Model(&infrastructure.SaleItem{}).Select("sale_id").Where("product_id IN ?", ids))
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if err := query.Order("created_at ASC").Find(&sales).Error; err != nil {
+		return nil, err
+	}
+	branches, err := branchNames(r.DB, products)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []domain.RecallSaleLine
+	for _, s := range sales {
+		for _, it := range s.SaleItems {
+			p := productMap[it.ProductID]
+			line := domain.RecallSaleLine{
+				SaleID:      s.ID,
+				SaleItemID:  it.ID,
+				BranchID:    s.BranchID,
+				BranchName:  branches[s.BranchID],
+				CashierID:   s.CashierID,
+				ProductID:   it.ProductID,
+				ProductName: p.ProductName,
+				Quantity:    it.Quantity - it.RefundedQuantity,
+				SoldAt:      s.CreatedAt,
+			}
+			if recall.CoversWholeProduct() || (len(it.Lots) == 0 && recall.BatchNumber == nil && recall.CoversExpiry(p.ExpiryDate)) {
+				if line.Quantity > 0 {
+					lines = append(lines, line)
+				}
+				continue
+			}
+			for _, l := range it.Lots {
+				if !recall.CoversLot(l.BatchNumber, l.ExpiryDate) || l.Quantity-l.ReturnedQuantity <= 0 {
+					continue
+				}
+				lotLine := line
+				lotLine.BatchNumber = l.BatchNumber
+				lotLine.ExpiryDate = l.ExpiryDate
+				lotLine.Quantity = l.Quantity - l.ReturnedQuantity
+				lines = append(lines, lotLine)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// recallProducts loads the business's products registered under the recall's NAFDAC
+// number (and barcode, if set), optionally in one branch
+func recallProducts(db *gorm.DB, recall *domain.Recall, branchID string, includeDeleted bool) ([]*infrastructure.Product, error) {
+	var products []*infrastructure.Product
+	query := db.Where("business_id = ? AND nafdac_reg_number = ?", recall.BusinessID, recall.NAFDACRegNumber)
+	if recall.BarcodeValue != nil {
+		query = query.Where("barcode_value = ?", *recall.BarcodeValue)
+	}
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if !includeDeleted {
+		query = query.Where("(deleted_at IS NULL OR deleted_at = 0)")
+	}
+	err := query.Order("branch_id ASC").Order("product_name ASC").Find(&products).Error
+	return products, err
+}
+
+func branchNames(db *gorm.DB, products []*infrastructure.Product) (map[string]string, error) {
+	var ids []string
+	for _, p := range products {
+		ids = append(ids, p.BranchID)
+	}
+	var branches []infrastructure.Branch
+	if err := db.Select("id", "branch_name").Where("id IN ?", ids).Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	names := map[string]string{}
+	for _, b := range branches {
+		names[b.ID] = b.BranchName
+	}
+	return names, nil
+}
+
+// recalledLots checks a locked product against the business's active recalls before it is
+// sold or sent to another branch. It fails with ErrProductRecalled when every unit is
+// recalled, and otherwise returns the lots that must not leave the shelf.
+func recalledLots(tx *gorm.DB, product *infrastructure.Product) (map[string]bool, error) {
+	if product.NAFDACRegNumber == nil || *product.NAFDACRegNumber == "" {
+		return nil, nil
+	}
+	var models []*infrastructure.Recall
+	if err := tx.Where("business_id = ? AND nafdac_reg_number = ? AND status = ?",
+		product.BusinessID, *product.NAFDACRegNumber, domain.RecallStatusActive).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+	var lots []infrastructure.ProductLot
+	if err := tx.Where("product_id = ?", product.ID).Find(&lots).Error; err != nil {
+		return nil, err
+	}
+
+	blocked := map[string]bool{}
+	for _, m := range models {
+		recall := toDomainRecall(m)
+		if recall.BarcodeValue != nil && (product.BarcodeValue == nil || *product.BarcodeValue != *recall.BarcodeValue) {
+			continue
+		}
+		if recall.CoversWholeProduct() || (len(lots) == 0 && recall.BatchNumber == nil && recall.CoversExpiry(product.ExpiryDate)) {
+			return nil, fmt.Errorf("%w: %s (%s)", domain.ErrProductRecalled, product.ProductName, recall.Reason)
+		}
+		for _, l := range lots {
+			if recall.CoversLot(l.BatchNumber, l.ExpiryDate) {
+				blocked[l.ID] = true
+			}
+		}
+	}
+	return blocked, nil
+}
+
+func toInfraRecall(r *domain.Recall) infrastructure.Recall {
+	return infrastructure.Recall{
+		ID:              r.ID,
+		BusinessID:      r.BusinessID,
+		NAFDACRegNumber: r.NAFDACRegNumber,
+		BarcodeValue:    r.BarcodeValue,
+		BatchNumber:     r.BatchNumber,
+		ExpiryFrom:      r.ExpiryFrom,
+		ExpiryTo:        r.ExpiryTo,
+		Reason:          r.Reason,
+		Reference:       r.Reference,
+		Status:          r.Status,
+		CreatedBy:       r.CreatedBy,
+		CreatedAt:       r.CreatedAt,
+		ClosedBy:        r.ClosedBy,
+		ClosedAt:        r.ClosedAt,
+	}
+}
+
+func toDomainRecall(m *infrastructure.Recall) *domain.Recall {
+	return &domain.Recall{
+		ID:              m.ID,
+		BusinessID:      m.BusinessID,
+		NAFDACRegNumber: m.NAFDACRegNumber,
+		BarcodeValue:    m.BarcodeValue,
+		BatchNumber:     m.BatchNumber,
+		ExpiryFrom:      m.ExpiryFrom,
+		ExpiryTo:        m.ExpiryTo,
+		Reason:          m.Reason,
+		Reference:       m.Reference,
+		Status:          m.Status,
+		CreatedBy:       m.CreatedBy,
+		CreatedAt:       m.CreatedAt,
+		ClosedBy:        m.ClosedBy,
+		ClosedAt:        m.ClosedAt,
+	}
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRepo struct {
+	DB *gorm.DB
+}
+
+func (r *RefundRepo) CreateRefund(refund *domain.Refund) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		model := infrastructure.Refund{
+			ID:            refund.ID,
+			BusinessID:    refund.BusinessID,
+			BranchID:      refund.BranchID,
+			SaleID:        refund.SaleID,
+			RequestedBy:   refund.RequestedBy,
+			ApprovedBy:    refund.ApprovedBy,
+			ReasonCode:    refund.ReasonCode,
+			Note:          refund.Note,
+			PaymentMethod: refund.PaymentMethod,
+			TotalAmount:   refund.TotalAmount,
+			Status:        refund.Status,
+			CreatedAt:     refund.CreatedAt,
+			ResolvedAt:    refund.ResolvedAt,
+			ShiftID:       refund.ShiftID,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		for _, it := range refund.Items {
+			item := infrastructure.RefundItem{
+				ID:          it.ID,
+				RefundID:    refund.ID,
+				SaleItemID:  it.SaleItemID,
+				ProductID:   it.ProductID,
+				Quantity:    it.Quantity,
+				UnitPrice:   it.UnitPrice,
+				Amount:      it.Amount,
+				Disposition: it.Disposition,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		for _, p := range refund.Payments {
+			payment := infrastructure.RefundPayment{
+				ID:       p.ID,
+				RefundID: refund.ID,
+				Method:   p.Method,
+				Amount:   p.Amount,
+			}
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+		}
+		if refund.Status == domain.RefundStatusCompleted {
+			return applyRefund(tx, refund, refund.RequestedBy)
+		}
+		return nil
+	})
+}
+
+func (r *RefundRepo) ApproveRefund(refundID, approverID string, shiftID *string) (*domain.Refund, error) {
+	var result *domain.Refund
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var model infrastructure.Refund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("RefundItems").Preload("RefundPayments").First(&model, "id = ?", refundID).Error; err != nil {
+			return errors.New("refund not found")
+		}
+		if model.Status != domain.RefundStatusPendingApproval {
+			return errors.New("refund is not pending approval")
+		}
+		refund := toDomainRefund(&model)
+		if err := applyRefund(tx, refund, approverID); err != nil {
+			return err
+		}
+		now := time.Now().Unix()
+		if err := tx.Model(&model).Updates(map[string]interface{}{
+			"status":      domain.RefundStatusCompleted,
+			"approved_by": approverID,
+			"resolved_at": now,
+			"shift_id":    shiftID,
+		}).Error; err != nil {
+			return err
+		}
+		refund.Status = domain.RefundStatusCompleted
+		refund.ApprovedBy = &approverID
+		refund.ResolvedAt = &now
+		refund.ShiftID = shiftID
+		result = refund
+		return nil
+	})
+	return result, err
+}
+
+func (r *RefundRepo) RejectRefund(refundID, approverID, note string) (*domain.Refund, error) {
+	var result *domain.Refund
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var model infrastructure.Refund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("RefundItems").Preload("RefundPayments").First(&model, "id = ?", refundID).Error; err != nil {
+			return errors.New("refund not found")
+		}
+		if model.Status != domain.RefundStatusPendingApproval {
+			return errors.New("refund is not pending approval")
+		}
+		now := time.Now().Unix()
+		updates := map[string]interface{}{
+			"status":      domain.RefundStatusRejected,
+			"approved_by": approverID,
+			"resolved_at": now,
+		}
+		if note != "" {
+			updates["note"] = note
+		}
+		if err := tx.Model(&model).Updates(updates).Error; err != nil {
+			return err
+		}
+		result = toDomainRefund(&model)
+		return nil
+	})
+	return result, err
+}
+
+func (r *RefundRepo) GetRefundByID(id string) (*domain.Refund, error) {
+	var model infrastructure.Refund
+	if err := r.DB.Preload("RefundItems").Preload("RefundPayments").First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainRefund(&model), nil
+}
+
+func (r *RefundRepo) GetRefundsBySaleID(saleID string) ([]*domain.Refund, error) {
+	var models []*infrastructure.Refund
+	if err := r.DB.Preload("RefundItems").Preload("RefundPayments").Where("sale_id = ?", saleID).Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var refunds []*domain.Refund
+	for _, m := range models {
+		refunds = append(refunds, toDomainRefund(m))
+	}
+	return refunds, nil
+}
+
+func (r *RefundRepo) GetPendingRefunds(businessID, branchID string) ([]*domain.Refund, error) {
+	var models []*infrastructure.Refund
+	query := r.DB.Preload("RefundItems").Preload("RefundPayments").Where("business_id = ? AND status = ?", businessID, domain.RefundStatusPendingApproval)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if err := query.Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var refunds []*domain.Refund
+	for _, m := range models {
+		refunds = append(refunds, toDomainRefund(m))
+	}
+	return refunds, nil
+}
+
+// applyRefund returns the refunded units to the sale items, restocks them where requested,
+// takes any part paid back on credit off the customer's account, settles loyalty points for
+// the refunded share and moves the sale to partially_refunded or refunded. It re-checks refundable quantities
+// under row locks, so two refunds against the same item cannot both succeed.
+func applyRefund(tx *gorm.DB, refund *domain.Refund, actorID string) error {
+	var sale infrastructure.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, "id = ?", refund.SaleID).Error; err != nil {
+		return errors.New("sale not found")
+	}
+	if sale.Status != domain.SaleStatusCompleted && sale.Status != domain.SaleStatusPartiallyRefunded {
+		return fmt.Errorf("cannot refund a sale with status %s", sale.Status)
+	}
+	tenders, prior, err := refundHistory(tx, &sale, refund.ID)
+	if err != nil {
+		return err
+	}
+	if err := domain.CheckRefundPayments(tenders, prior, refund.Payments, refund.TotalAmount); err != nil {
+		return err
+	}
+
+	for _, it := range refund.Items {
+		var saleItem infrastructure.SaleItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&saleItem, "id = ? AND sale_id = ?", it.SaleItemID, sale.ID).Error; err != nil {
+			return errors.New("sale item not found")
+		}
+		if saleItem.Quantity-saleItem.RefundedQuantity < it.Quantity {
+			return fmt.Errorf("refund quantity exceeds quantity remaining on sale item %s", saleItem.ID)
+		}
+		if err := tx.Model(&saleItem).Update("refunded_quantity", saleItem.RefundedQuantity+it.Quantity).Error; err != nil {
+			return err
+		}
+
+		if it.Disposition == domain.RefundDispositionRestock {
+			if err := returnToLots(tx, saleItem.ID, it.ProductID, it.Quantity); err != nil {
+				return err
+			}
+			var product infrastructure.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", it.ProductID).Error; err != nil {
+				return errors.New("product not found")
+			}
+			change := domain.StockChange{
+				Type:          domain.StockMovementRefund,
+				ActorID:       actorID,
+				ReferenceType: "refund",
+				ReferenceID:   refund.ID,
+				Note:          refund.ReasonCode,
+			}
+			if err := moveStock(tx, &product, it.Quantity, change); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Whatever was paid on credit comes off what the customer owes
+	var credited float64
+	for _, p := range refund.Payments {
+		if p.Method == domain.PaymentMethodCredit {
+			credited += p.Amount
+		}
+	}
+	if credited > 0 {
+		if err := refundCredit(tx, &sale, refund.ID, credited, actorID, time.Now().Unix()); err != nil {
+			return err
+		}
+	}
+
+	if err := refundLoyalty(tx, &sale, tenders, prior, refund.Payments); err != nil {
+		return err
+	}
+
+	// Sale is fully refunded once every item has been returned in full
+	var remaining int64
+	if err := tx.Model(&infrastructure.SaleItem{}).
+		Where("sale_id = ? AND refunded_quantity < quantity", sale.ID).
+		Count(&remaining).Error; err != nil {
+		return err
+	}
+	status := domain.SaleStatusPartiallyRefunded
+	if remaining == 0 {
+		status = domain.SaleStatusRefunded
+	}
+	return tx.Model(&sale).Update("status", status).Error
+}
+
+// refundHistory returns how the sale was paid and what the refunds completed on it other than
+// refundID paid back, so a refund can be checked against them once the sale is locked
+func refundHistory(tx *gorm.DB, sale *infrastructure.Sale, refundID string) ([]domain.SalePayment, []domain.RefundPayment, error) {
+	var tenders []infrastructure.SalePayment
+	if err := tx.Where("sale_id = ?", sale.ID).Find(&tenders).Error; err != nil {
+		return nil, nil, err
+	}
+	paid := domain.Sale{ID: sale.ID, PaymentMethod: sale.PaymentMethod, TotalAmount: sale.TotalAmount}
+	for _, t := range tenders {
+		paid.Payments = append(paid.Payments, domain.SalePayment{SaleID: t.SaleID, Method: t.Method, Amount: t.Amount})
+	}
+	var completed []*infrastructure.Refund
+	if err := tx.Preload("RefundPayments").
+		Where("sale_id = ? AND status = ? AND id <> ?", sale.ID, domain.RefundStatusCompleted, refundID).
+		Find(&completed).Error; err != nil {
+		return nil, nil, err
+	}
+	var prior []domain.RefundPayment
+	for _, m := range completed {
+		prior = append(prior, toDomainRefund(m).Payments...)
+	}
+	return paid.Tenders(), prior, nil
+}
+
+func toDomainRefund(m *infrastructure.Refund) *domain.Refund {
+	refund := &domain.Refund{
+		ID:            m.ID,
+		BusinessID:    m.BusinessID,
+		BranchID:      m.BranchID,
+		SaleID:        m.SaleID,
+		RequestedBy:   m.RequestedBy,
+		ApprovedBy:    m.ApprovedBy,
+		ReasonCode:    m.ReasonCode,
+		Note:          m.Note,
+		PaymentMethod: m.PaymentMethod,
+		TotalAmount:   m.TotalAmount,
+		Status:        m.Status,
+		CreatedAt:     m.CreatedAt,
+		ResolvedAt:    m.ResolvedAt,
+		ShiftID:       m.ShiftID,
+	}
+	for _, it := range m.RefundItems {
+		refund.Items = append(refund.Items, domain.RefundItem{
+			ID:          it.ID,
+			RefundID:    it.RefundID,
+			SaleItemID:  it.SaleItemID,
+			ProductID:   it.ProductID,
+			Quantity:    it.Quantity,
+			UnitPrice:   it.UnitPrice,
+			Amount:      it.Amount,
+			Disposition: it.Disposition,
+		})
+	}
+	for _, p := range m.RefundPayments {
+		refund.Payments = append(refund.Payments, domain.RefundPayment{
+			ID:       p.ID,
+			RefundID: p.RefundID,
+			Method:   p.Method,
+			Amount:   p.Amount,
+		})
+	}
+	// Refunds recorded before refund payments were paid back in full through their payment method
+	if len(refund.Payments) == 0 {
+		refund.Payments = []domain.RefundPayment{{RefundID: m.ID, Method: m.PaymentMethod, Amount: m.TotalAmount}}
+	}
+	return refund
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationRepo struct {
+	DB *gorm.DB
+}
+
+// CreateReservation locks each product and checks its unreserved units before saving the
+// reservation, so two reservations cannot both take the last units
+func (r *ReservationRepo) CreateReservation(res *domain.Reservation) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, it := range res.Items {
+			var product infrastructure.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", it.ProductID).Error; err != nil {
+				return errors.New("product not found")
+			}
+			reserved, err := reservedQuantity(tx, product.ID, res.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if product.QuantityInStock-reserved < it.Quantity {
+				return fmt.Errorf("%w for product %s: %d on hand, %d already reserved", domain.ErrInsufficientStock, product.ID, product.QuantityInStock, reserved)
+			}
+		}
+		m := infrastructure.Reservation{
+			ID:            res.ID,
+			BusinessID:    res.BusinessID,
+			BranchID:      res.BranchID,
+			Reference:     res.Reference,
+			CustomerID:    res.CustomerID,
+			CustomerPhone: res.CustomerPhone,
+			Note:          res.Note,
+			Status:        res.Status,
+			ExpiresAt:     res.ExpiresAt,
+			CreatedBy:     res.CreatedBy,
+			CreatedAt:     res.CreatedAt,
+			UpdatedAt:     res.UpdatedAt,
+		}
+		if err := tx.Omit("Items").Create(&m).Error; err != nil {
+			return err
+		}
+		var items []infrastructure.ReservationItem
+		for _, it := range res.Items {
+			items = append(items, infrastructure.ReservationItem{
+				ID:            uuid.NewString(),
+				ReservationID: res.ID,
+				ProductID:     it.ProductID,
+				ProductName:   it.ProductName,
+				Quantity:      it.Quantity,
+			})
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+func (r *ReservationRepo) GetReservationByID(id string) (*domain.Reservation, error) {
+	var m infrastructure.Reservation
+	if err := r.DB.Preload("Items").First(&m, "id = ?", id).Error; err != nil {
+		return nil, domain.ErrReservationNotFound
+	}
+	return toDomainReservation(&m), nil
+}
+
+func (r *ReservationRepo) GetReservations(businessID, branchID, status string, limit, offset int) ([]*domain.Reservation, error) {
+	query := r.DB.Preload("Items").Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var models []*infrastructure.Reservation
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var result []*domain.Reservation
+	for _, m := range models {
+		result = append(result, toDomainReservation(m))
+	}
+	return result, nil
+}
+
+func (r *ReservationRepo) ReleaseReservation(id, releasedBy string, releasedAt int64) (*domain.Reservation, error) {
+	var result *domain.Reservation
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var m infrastructure.Reservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&m, "id = ?", id).Error; err != nil {
+			return domain.ErrReservationNotFound
+		}
+		if m.Status != domain.ReservationStatusActive {
+			return domain.ErrReservationNotActive
+		}
+		if err := tx.Model(&m).Updates(map[string]interface{}{
+			"status":      domain.ReservationStatusReleased,
+			"released_by": releasedBy,
+			"closed_at":   releasedAt,
+		}).Error; err != nil {
+			return err
+		}
+		m.Status = domain.ReservationStatusReleased
+		m.ReleasedBy = &releasedBy
+		m.ClosedAt = &releasedAt
+		result = toDomainReservation(&m)
+		return nil
+	})
+	return result, err
+}
+
+// ExpireReservations closes lapsed reservations as of the moment they lapsed
+func (r *ReservationRepo) ExpireReservations(now int64) (int, error) {
+	result := r.DB.Model(&infrastructure.Reservation{}).
+		Where("status = ? AND expires_at <= ?", domain.ReservationStatusActive, now).
+		Updates(map[string]interface{}{
+			"status":    domain.ReservationStatusExpired,
+			"closed_at": gorm.Expr("expires_at"),
+		})
+	return int(result.RowsAffected), result.Error
+}
+
+func (r *ReservationRepo) GetReservedQuantities(productIDs []string, now int64) (map[string]int, error) {
+	reserved := map[string]int{}
+	if len(productIDs) == 0 {
+		return reserved, nil
+	}
+	var rows []struct {
+		ProductID string
+		Units     int
+	}
+	err := r.DB.Table("reservation_items").
+		Select("reservation_items.product_id, SUM(reservation_items.quantity) AS units").
+		Joins("JOIN reservations ON reservations.id = reservation_items.reservation_id").
+		Where("reservation_items.product_id IN ? AND reservations.status = ? AND reservations.expires_at > ?", productIDs, domain.ReservationStatusActive, now).
+		Group("reservation_items.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		reserved[row.ProductID] = row.Units
+	}
+	return reserved, nil
+}
+
+// reservedQuantitySQL sums the units of a product held by active reservations that have
+// not expired at a given time
+const reservedQuantitySQL = `SELECT COALESCE(SUM(reservation_items.quantity), 0) FROM reservation_items
+	JOIN reservations ON reservations.id = reservation_items.reservation_id
+	WHERE reservation_items.product_id = ? AND reservations.status = ? AND reservations.expires_at > ?`
+
+// reservedQuantity returns the units of a product held by reservations at now. Callers hold
+// the product's row lock so the figure cannot change under them.
+func reservedQuantity(tx *gorm.DB, productID string, now int64) (int, error) {
+	var units int
+	err := tx.Raw(reservedQuantitySQL, productID, domain.ReservationStatusActive, now).Row().Scan(&units)
+	return units, err
+}
+
+// checkUnreserved refuses to take a product below the units its reservations hold, for the
+// sqlx repository. Callers hold the product's row lock.
+func checkUnreserved(tx *sqlx.Tx, productID string, newQty int) error {
+	var reserved int
+	if err := tx.QueryRowx(reservedQuantitySQL, productID, domain.ReservationStatusActive, time.Now().Unix()).Scan(&reserved); err != nil {
+		return err
+	}
+	if newQty < reserved {
+		return fmt.Errorf("%w for product %s: %d units are reserved", domain.ErrInsufficientStock, productID, reserved)
+	}
+	return nil
+}
+
+// fulfilReservation closes the sale's reservation so its units can go into the sale. The
+// sale must carry exactly the reserved products and quantities, so collecting a reservation
+// cannot free units it never held or leave reserved units behind. It must run before the
+// sale takes any stock.
+func fulfilReservation(tx *gorm.DB, sale *domain.Sale, items []domain.SaleItem, now int64) error {
+	var m infrastructure.Reservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&m, "id = ?", *sale.ReservationID).Error; err != nil || m.BusinessID != sale.BusinessID {
+		return domain.ErrReservationNotFound
+	}
+	if m.BranchID != sale.BranchID {
+		return fmt.Errorf("%w: it was made at another branch", domain.ErrReservationNotActive)
+	}
+	if m.Status != domain.ReservationStatusActive || m.ExpiresAt <= now {
+		return domain.ErrReservationNotActive
+	}
+
+	unmatched := map[string]int{}
+	for _, it := range m.Items {
+		unmatched[it.ProductID] += it.Quantity
+	}
+	for _, it := range items {
+		unmatched[it.ProductID] -= it.Quantity
+	}
+	for productID, units := range unmatched {
+		if units > 0 {
+			return fmt.Errorf("%w: %d reserved units of product %s are missing from the sale", domain.ErrReservationMismatch, units, productID)
+		}
+		if units < 0 {
+			return fmt.Errorf("%w: the sale has %d more units of product %s than were reserved", domain.ErrReservationMismatch, -units, productID)
+		}
+	}
+
+	return tx.Model(&m).Updates(map[string]interface{}{
+		"status":    domain.ReservationStatusFulfilled,
+		"sale_id":   sale.ID,
+		"closed_at": now,
+	}).Error
+}
+
+// reopenReservation puts back the reservation a voided sale collected, so its units are held
+// for the customer again. A reservation that lapsed in the meantime is closed as expired.
+func reopenReservation(tx *gorm.DB, sale *infrastructure.Sale, now int64) error {
+	var m infrastructure.Reservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, "id = ?", *sale.ReservationID).Error; err != nil {
+		return domain.ErrReservationNotFound
+	}
+	if m.Status != domain.ReservationStatusFulfilled || m.SaleID == nil || *m.SaleID != sale.ID {
+		return nil
+	}
+	updates := map[string]interface{}{
+		"status":    domain.ReservationStatusActive,
+		"sale_id":   nil,
+		"closed_at": nil,
+	}
+	if m.ExpiresAt <= now {
+		updates["status"] = domain.ReservationStatusExpired
+		updates["closed_at"] = m.ExpiresAt
+	}
+	return tx.Model(&m).Updates(updates).Error
+}
+
+func toDomainReservation(m *infrastructure.Reservation) *domain.Reservation {
+	res := &domain.Reservation{
+		ID:            m.ID,
+		BusinessID:    m.BusinessID,
+		BranchID:      m.BranchID,
+		Reference:     m.Reference,
+		CustomerID:    m.CustomerID,
+		CustomerPhone: m.CustomerPhone,
+		Note:          m.Note,
+		Status:        m.Status,
+		ExpiresAt:     m.ExpiresAt,
+		CreatedBy:     m.CreatedBy,
+		SaleID:        m.SaleID,
+		ReleasedBy:    m.ReleasedBy,
+		ClosedAt:      m.ClosedAt,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		Items:         []domain.ReservationItem{},
+	}
+	for _, it := range m.Items {
+		res.Items = append(res.Items, domain.ReservationItem{
+			ProductID:   it.ProductID,
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+		})
+	}
+	return res
+}
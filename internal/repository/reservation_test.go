@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+)
+
+func TestCollectingReservationThenVoidingTheSale(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().Unix()
+	product := seedLots(t, db, 5)
+	if err := db.Omit("Business").Create(&infrastructure.Branch{ID: product.BranchID, BusinessID: product.BusinessID, BranchName: "Main"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	reservations := &ReservationRepo{DB: db}
+	res := &domain.Reservation{
+		ID: "reservation-1", BusinessID: product.BusinessID, BranchID: product.BranchID, Reference: "R-1",
+		Status: domain.ReservationStatusActive, ExpiresAt: now + 3600, CreatedBy: "user-1", CreatedAt: now,
+		Items: []domain.ReservationItem{{ProductID: product.ID, ProductName: product.ProductName, Quantity: 2}},
+	}
+	if err := reservations.CreateReservation(res); err != nil {
+		t.Fatal(err)
+	}
+
+	sales := &SaleRepo{DB: db}
+	collect := func(id string, qty int) error {
+		sale := &domain.Sale{
+			ID: id, BusinessID: product.BusinessID, BranchID: product.BranchID, CashierID: "user-1",
+			ReservationID: &res.ID, PaymentMethod: domain.PaymentMethodCash, Status: domain.SaleStatusCompleted, CreatedAt: now,
+		}
+		items := []domain.SaleItem{{ID: id + "-item", ProductID: product.ID, Quantity: qty, UnitPrice: 10, Subtotal: float64(qty) * 10}}
+		_, _, err := sales.CreateSale(sale, items, nil)
+		return err
+	}
+
+	// Taking one of the two reserved units would quietly free the other
+	if err := collect("sale-1", 1); !errors.Is(err, domain.ErrReservationMismatch) {
+		t.Fatalf("short sale: error = %v, want %v", err, domain.ErrReservationMismatch)
+	}
+	// Three units would sell one the reservation never held
+	if err := collect("sale-1", 3); !errors.Is(err, domain.ErrReservationMismatch) {
+		t.Fatalf("long sale: error = %v, want %v", err, domain.ErrReservationMismatch)
+	}
+	if err := collect("sale-1", 2); err != nil {
+		t.Fatalf("CreateSale() error = %v", err)
+	}
+	if got, _ := reservations.GetReservationByID(res.ID); got.Status != domain.ReservationStatusFulfilled {
+		t.Fatalf("reservation is %s after the sale, want %s", got.Status, domain.ReservationStatusFulfilled)
+	}
+
+	if _, err := sales.VoidSale("sale-1", "user-1", "wrong customer", now); err != nil {
+		t.Fatalf("VoidSale() error = %v", err)
+	}
+	got, _ := reservations.GetReservationByID(res.ID)
+	if got.Status != domain.ReservationStatusActive || got.SaleID != nil || got.ClosedAt != nil {
+		t.Errorf("reservation after the void = %s, sale %v, closed %v; want it active again", got.Status, got.SaleID, got.ClosedAt)
+	}
+	var stock infrastructure.Product
+	db.First(&stock, "id = ?", product.ID)
+	if reserved, _ := reservedQuantity(db, product.ID, now); stock.QuantityInStock != 5 || reserved != 2 {
+		t.Errorf("%d on hand with %d reserved after the void, want 5 with 2 reserved", stock.QuantityInStock, reserved)
+	}
+}
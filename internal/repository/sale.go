@@ -7,6 +7,7 @@ import (
 
 	"database/sql"
 
+	"github.com/google/uuid"
 	"github.com/joshuaolumoye/pos-backend/internal/domain"
 	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
 	"gorm.io/gorm"
@@ -17,11 +18,10 @@ type SaleRepo struct {
 	DB *gorm.DB
 }
 
-// GetTotalSalesToday returns the number of sales for today (optionally filtered by branch)
-func (r *SaleRepo) GetTotalSalesToday(businessID, branchID string) (int, error) {
+// GetTotalSalesSince returns the number of sales made since the given time (optionally filtered by branch)
+func (r *SaleRepo) GetTotalSalesSince(businessID, branchID string, since int64) (int, error) {
 	var count int64
-	today := time.Now().Truncate(24 * time.Hour).Unix()
-	query := r.DB.Model(&infrastructure.Sale{}).Where("business_id = ? AND created_at >= ?", businessID, today)
+	query := r.DB.Model(&infrastructure.Sale{}).Where("business_id = ? AND created_at >= ? AND status <> ?", businessID, since, domain.SaleStatusVoided)
 	if branchID != "" {
 		query = query.Where("branch_id = ?", branchID)
 	}
@@ -32,7 +32,7 @@ func (r *SaleRepo) GetTotalSalesToday(businessID, branchID string) (int, error)
 // GetTotalRevenue returns the total revenue (optionally filtered by branch)
 func (r *SaleRepo) GetTotalRevenue(businessID, branchID string) (float64, error) {
 	// var total float64
-	query := r.DB.Model(&infrastructure.Sale{}).Select("SUM(total_amount)").Where("business_id = ?", businessID)
+	query := r.DB.Model(&infrastructure.Sale{}).Select("SUM(total_amount)").Where("business_id = ? AND status <> ?", businessID, domain.SaleStatusVoided)
 	if branchID != "" {
 		query = query.Where("branch_id = ?", branchID)
 	}
@@ -46,13 +46,24 @@ func (r *SaleRepo) GetTotalRevenue(businessID, branchID string) (float64, error)
 	if !result.Valid {
 		return 0, nil
 	}
-	return result.Float64, nil
+
+	// Revenue is reported net of completed refunds
+	refundQuery := r.DB.Model(&infrastructure.Refund{}).Select("SUM(total_amount)").
+		Where("business_id = ? AND status = ?", businessID, domain.RefundStatusCompleted)
+	if branchID != "" {
+		refundQuery = refundQuery.Where("branch_id = ?", branchID)
+	}
+	var refunded sql.NullFloat64
+	if err := refundQuery.Row().Scan(&refunded); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return result.Float64 - refunded.Float64, nil
 }
 
 // GetRecentSales returns the 5 most recent sales (optionally filtered by branch)
 func (r *SaleRepo) GetRecentSales(businessID, branchID string, limit int) ([]*domain.Sale, error) {
 	var sales []*infrastructure.Sale
-	query := r.DB.Where("business_id = ?", businessID)
+	query := r.DB.Where("business_id = ? AND status <> ?", businessID, domain.SaleStatusVoided)
 	if branchID != "" {
 		query = query.Where("branch_id = ?", branchID)
 	}
@@ -71,24 +82,462 @@ func (r *SaleRepo) GetRecentSales(businessID, branchID string, limit int) ([]*do
 			PaymentMethod: s.PaymentMethod,
 			Status:        s.Status,
 			CreatedAt:     s.CreatedAt,
+			UpdatedAt:     s.UpdatedAt,
 		})
 	}
 	return result, nil
 }
 
+func (r *SaleRepo) GetUnitsSoldSince(businessID, branchID string, since int64) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Units     int
+	}
+	query := r.DB.Table("sale_items").
+		Select("sale_items.product_id, SUM(sale_items.quantity - sale_items.refunded_quantity) AS units").
+		Joins("JOIN sales ON sales.id = sale_items.sale_id").
+		Where("sales.business_id = ? AND sales.created_at >= ? AND sales.status <> ?", businessID, since, domain.SaleStatusVoided)
+	if branchID != "" {
+		query = query.Where("sales.branch_id = ?", branchID)
+	}
+	if err := query.Group("sale_items.product_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	units := map[string]int{}
+	for _, row := range rows {
+		units[row.ProductID] = row.Units
+	}
+	return units, nil
+}
+
+func (r *SaleRepo) GetRevenueByTender(businessID, branchID string, from, to int64) ([]domain.TenderTotal, error) {
+	var rows []struct {
+		Method     string
+		Amount     float64
+		SalesCount int
+	}
+	query := r.DB.Table("sale_payments").
+		Select("sale_payments.method, SUM(sale_payments.amount) AS amount, COUNT(DISTINCT sale_payments.sale_id) AS sales_count").
+		Joins("JOIN sales ON sales.id = sale_payments.sale_id").
+		Where("sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?", businessID, domain.SaleStatusVoided, from, to)
+	if branchID != "" {
+		query = query.Where("sales.branch_id = ?", branchID)
+	}
+	if err := query.Group("sale_payments.method").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Sales recorded before split payments have no payment rows; count them under their payment method
+	var legacy []struct {
+		Method     string
+		Amount     float64
+		SalesCount int
+	}
+	legacyQuery := r.DB.Model(&infrastructure.Sale{}).
+		Select("payment_method AS method, SUM(total_amount) AS amount, COUNT(*) AS sales_count").
+		Where("business_id = ? AND status <> ? AND created_at >= ? AND created_at < ?", businessID, domain.SaleStatusVoided, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM sale_payments sp WHERE sp.sale_id = sales.id)")
+	if branchID != "" {
+		legacyQuery = legacyQuery.Where("branch_id = ?", branchID)
+	}
+	if err := legacyQuery.Group("payment_method").Scan(&legacy).Error; err != nil {
+		return nil, err
+	}
+
+	// Completed refunds of these sales come off the tenders they were paid back through;
+	// refunds recorded before refund payments have no payment rows and come off their payment method
+	var refunded []struct {
+		Method string
+		Amount float64
+	}
+	refundQuery := r.DB.Table("refund_payments").
+		Select("refund_payments.method, SUM(refund_payments.amount) AS amount").
+		Joins("JOIN refunds ON refunds.id = refund_payments.refund_id").
+		Joins("JOIN sales ON sales.id = refunds.sale_id").
+		Where("refunds.status = ? AND sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?",
+			domain.RefundStatusCompleted, businessID, domain.SaleStatusVoided, from, to)
+	if branchID != "" {
+		refundQuery = refundQuery.Where("sales.branch_id = ?", branchID)
+	}
+	if err := refundQuery.Group("refund_payments.method").Scan(&refunded).Error; err != nil {
+		return nil, err
+	}
+	var legacyRefunded []struct {
+		Method string
+		Amount float64
+	}
+	legacyRefundQuery := r.DB.Table("refunds").
+		Select("refunds.payment_method AS method, SUM(refunds.total_amount) AS amount").
+		Joins("JOIN sales ON sales.id = refunds.sale_id").
+		Where("refunds.status = ? AND sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?",
+			domain.RefundStatusCompleted, businessID, domain.SaleStatusVoided, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM refund_payments rp WHERE rp.refund_id = refunds.id)")
+	if branchID != "" {
+		legacyRefundQuery = legacyRefundQuery.Where("sales.branch_id = ?", branchID)
+	}
+	if err := legacyRefundQuery.Group("refunds.payment_method").Scan(&legacyRefunded).Error; err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	var result []domain.TenderTotal
+	tender := func(method string) *domain.TenderTotal {
+		i, ok := index[method]
+		if !ok {
+			i = len(result)
+			index[method] = i
+			result = append(result, domain.TenderTotal{Method: method})
+		}
+		return &result[i]
+	}
+	for _, row := range append(rows, legacy...) {
+		t := tender(row.Method)
+		t.Amount += row.Amount
+		t.SalesCount += row.SalesCount
+	}
+	for _, row := range append(refunded, legacyRefunded...) {
+		tender(row.Method).Amount -= row.Amount
+	}
+	return result, nil
+}
+
+func (r *SaleRepo) GetDiscountsByCashier(businessID, branchID string, from, to int64) ([]domain.CashierDiscountTotal, error) {
+	var totals []domain.CashierDiscountTotal
+	query := r.DB.Table("sales").
+		Select("sales.cashier_id, COALESCE(staffs.full_name, '') AS cashier_name, COUNT(*) AS sales_count, "+
+			"SUM(sales.gross_amount) AS gross_amount, SUM(sales.discount_amount) AS discount_amount").
+		Joins("LEFT JOIN staffs ON staffs.id = sales.cashier_id").
+		Where("sales.business_id = ? AND sales.status <> ? AND sales.discount_amount > 0 AND sales.created_at >= ? AND sales.created_at < ?",
+			businessID, domain.SaleStatusVoided, from, to)
+	if branchID != "" {
+		query = query.Where("sales.branch_id = ?", branchID)
+	}
+	err := query.Group("sales.cashier_id, staffs.full_name").Order("discount_amount DESC").Scan(&totals).Error
+	return totals, err
+}
+
+// Sale line figures net of completed refunds. saleItemKept is the units the customer kept;
+// a line's subtotal and tax shrink in the same ratio.
+const (
+	saleItemKept     = "(sale_items.quantity - sale_items.refunded_quantity)"
+	saleItemSubtotal = "sale_items.subtotal * " + saleItemKept + " / sale_items.quantity"
+	saleItemTax      = "sale_items.tax_amount * " + saleItemKept + " / sale_items.quantity"
+)
+
+// saleItemCost is the cost of the units a sale line kept. Items sold before unit costs were
+// recorded are costed at the product's current cost price.
+const saleItemCost = saleItemKept + " * CASE WHEN sale_items.unit_cost > 0 THEN sale_items.unit_cost ELSE COALESCE(products.cost_price, 0) END"
+
+func (r *SaleRepo) GetSaleTotals(businessID string, filter domain.SaleReportFilter) ([]domain.SaleTotal, error) {
+	var totals []domain.SaleTotal
+	query := r.DB.Table("sale_items").
+		Select("sales.id AS sale_id, sales.created_at, SUM("+saleItemKept+") AS items_sold, SUM("+saleItemSubtotal+") AS revenue, "+
+			"SUM("+saleItemTax+") AS tax_amount, SUM("+saleItemCost+") AS cost_of_goods").
+		Joins("JOIN sales ON sales.id = sale_items.sale_id").
+		Joins("LEFT JOIN products ON products.id = sale_items.product_id").
+		Where("sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?",
+			businessID, domain.SaleStatusVoided, filter.From, filter.To)
+	if filter.BranchID != "" {
+		query = query.Where("sales.branch_id = ?", filter.BranchID)
+	}
+	if filter.CashierID != "" {
+		query = query.Where("sales.cashier_id = ?", filter.CashierID)
+	}
+	if filter.Category != "" {
+		query = query.Where("products.product_category = ?", filter.Category)
+	}
+	err := query.Group("sales.id, sales.created_at").Order("sales.created_at ASC").Scan(&totals).Error
+	return totals, err
+}
+
+func (r *SaleRepo) AssignReceiptNumber(saleID string) (int, error) {
+	var number int
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var sale infrastructure.Sale
+		if err := tx.First(&sale, "id = ?", saleID).Error; err != nil {
+			return err
+		}
+		if sale.ReceiptNumber != nil {
+			number = *sale.ReceiptNumber
+			return nil
+		}
+		n, err := nextReceiptNumber(tx, sale.BranchID)
+		if err != nil {
+			return err
+		}
+		// Another request may have numbered the sale while we waited for the branch lock
+		result := tx.Model(&infrastructure.Sale{}).Where("id = ? AND receipt_number IS NULL", saleID).Update("receipt_number", n)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Model(&infrastructure.Sale{}).Select("receipt_number").Where("id = ?", saleID).Scan(&number).Error
+		}
+		number = n
+		return nil
+	})
+	return number, err
+}
+
+// nextReceiptNumber locks the branch and returns its next receipt number; the lock is held
+// until tx ends so two sales never get the same number
+func nextReceiptNumber(tx *gorm.DB, branchID string) (int, error) {
+	var branch infrastructure.Branch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&branch, "id = ?", branchID).Error; err != nil {
+		return 0, errors.New("branch not found")
+	}
+	var last int
+	if err := tx.Model(&infrastructure.Sale{}).Select("COALESCE(MAX(receipt_number), 0)").Where("branch_id = ?", branchID).Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+func (r *SaleRepo) ListSales(businessID string, filter domain.SaleFilter) ([]*domain.Sale, error) {
+	query := r.DB.Preload("SaleItems.Lots").Preload("Payments").Where("business_id = ?", businessID)
+	if filter.BranchID != "" {
+		query = query.Where("branch_id = ?", filter.BranchID)
+	}
+	if filter.CashierID != "" {
+		query = query.Where("cashier_id = ?", filter.CashierID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.PaymentMethod != "" {
+		query = query.Where("(payment_method = ? OR EXISTS (SELECT 1 FROM sale_payments sp WHERE sp.sale_id = sales.id AND sp.method = ?))",
+			filter.PaymentMethod, filter.PaymentMethod)
+	}
+	if filter.ProductID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM sale_items si WHERE si.sale_id = sales.id AND si.product_id = ?)", filter.ProductID)
+	}
+	if filter.From > 0 {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if filter.To > 0 {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("total_amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("total_amount <= ?", *filter.MaxAmount)
+	}
+	if filter.AfterID != "" {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", filter.AfterCreatedAt, filter.AfterCreatedAt, filter.AfterID)
+	}
+	var sales []*infrastructure.Sale
+	if err := query.Order("created_at DESC").Order("id DESC").Limit(filter.Limit).Find(&sales).Error; err != nil {
+		return nil, err
+	}
+	var result []*domain.Sale
+	for _, s := range sales {
+		result = append(result, toDomainSale(s))
+	}
+	return result, nil
+}
+
+// GetSaleByID returns a sale together with its items
+func (r *SaleRepo) GetSaleByID(id string) (*domain.Sale, error) {
+	var s infrastructure.Sale
+	if err := r.DB.Preload("SaleItems.Lots").Preload("Payments").First(&s, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainSale(&s), nil
+}
+
+// GetSalesUpdatedSince returns sales (with items) created or changed at or after since,
+// used by the sync pull to bring offline tills up to date
+func (r *SaleRepo) GetSalesUpdatedSince(businessID, branchID string, since int64) ([]*domain.Sale, error) {
+	var sales []*infrastructure.Sale
+	query := r.DB.Preload("SaleItems.Lots").Preload("Payments").Where("business_id = ? AND updated_at >= ?", businessID, since)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if err := query.Order("updated_at ASC").Find(&sales).Error; err != nil {
+		return nil, err
+	}
+	var result []*domain.Sale
+	for _, s := range sales {
+		result = append(result, toDomainSale(s))
+	}
+	return result, nil
+}
+
+func toDomainSale(s *infrastructure.Sale) *domain.Sale {
+	sale := &domain.Sale{
+		ID:              s.ID,
+		BusinessID:      s.BusinessID,
+		BranchID:        s.BranchID,
+		CashierID:       s.CashierID,
+		CustomerID:      s.CustomerID,
+		ShiftID:         s.ShiftID,
+		ReservationID:   s.ReservationID,
+		TotalAmount:     s.TotalAmount,
+		PaymentMethod:   s.PaymentMethod,
+		Status:          s.Status,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+		VoidedBy:        s.VoidedBy,
+		VoidReason:      s.VoidReason,
+		VoidedAt:        s.VoidedAt,
+		ChangeDue:       s.ChangeDue,
+		GrossAmount:     s.GrossAmount,
+		PromotionAmount: s.PromotionAmount,
+		DiscountAmount:  s.DiscountAmount,
+		DiscountReason:  s.DiscountReason,
+		Discount:        toDomainDiscount(s.DiscountType, s.DiscountValue),
+		TaxAmount:       s.TaxAmount,
+		TaxMode:         s.TaxMode,
+		PointsEarned:    s.PointsEarned,
+		PointsRedeemed:  s.PointsRedeemed,
+	}
+	if s.ReceiptNumber != nil {
+		sale.ReceiptNumber = *s.ReceiptNumber
+	}
+	for _, p := range s.Payments {
+		sale.Payments = append(sale.Payments, domain.SalePayment{
+			ID:        p.ID,
+			SaleID:    p.SaleID,
+			Method:    p.Method,
+			Amount:    p.Amount,
+			Tendered:  p.Tendered,
+			Reference: p.Reference,
+			CreatedAt: p.CreatedAt,
+		})
+	}
+	for _, it := range s.SaleItems {
+		sale.Items = append(sale.Items, domain.SaleItem{
+			ID:               it.ID,
+			SaleID:           it.SaleID,
+			ProductID:        it.ProductID,
+			Quantity:         it.Quantity,
+			ListPrice:        it.ListPrice,
+			UnitPrice:        it.UnitPrice,
+			Subtotal:         it.Subtotal,
+			PromotionID:      it.PromotionID,
+			PromotionAmount:  it.PromotionAmount,
+			Discount:         toDomainDiscount(it.DiscountType, it.DiscountValue),
+			DiscountAmount:   it.DiscountAmount,
+			TaxTreatment:     it.TaxTreatment,
+			TaxRate:          it.TaxRate,
+			TaxAmount:        it.TaxAmount,
+			RefundedQuantity: it.RefundedQuantity,
+			OversoldQuantity: it.OversoldQuantity,
+			CreatedAt:        it.CreatedAt,
+			Lots:             toDomainSaleItemLots(it.Lots),
+		})
+	}
+	return sale
+}
+
+// VoidSale reverses every stock deduction made by the sale and marks it voided.
+// Only completed sales can be voided; the status is re-checked under the row lock.
+func (r *SaleRepo) VoidSale(saleID, voidedBy, reason string, voidedAt int64) (*domain.Sale, error) {
+	var result *domain.Sale
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var sale infrastructure.Sale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("SaleItems").First(&sale, "id = ?", saleID).Error; err != nil {
+			return errors.New("sale not found")
+		}
+		if sale.Status != domain.SaleStatusCompleted {
+			return fmt.Errorf("cannot void a sale with status %s", sale.Status)
+		}
+		for _, it := range sale.SaleItems {
+			if err := returnToLots(tx, it.ID, it.ProductID, it.Quantity); err != nil {
+				return err
+			}
+			var product infrastructure.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", it.ProductID).Error; err != nil {
+				return errors.New("product not found")
+			}
+			change := domain.StockChange{
+				Type:          domain.StockMovementVoid,
+				ActorID:       voidedBy,
+				ReferenceType: "sale",
+				ReferenceID:   sale.ID,
+				Note:          reason,
+			}
+			if err := moveStock(tx, &product, it.Quantity, change); err != nil {
+				return err
+			}
+		}
+		if err := reverseLoyalty(tx, &sale); err != nil {
+			return err
+		}
+		if err := reverseCredit(tx, &sale, voidedBy, voidedAt); err != nil {
+			return err
+		}
+		if sale.ReservationID != nil {
+			if err := reopenReservation(tx, &sale, voidedAt); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&sale).Updates(map[string]interface{}{
+			"status":      domain.SaleStatusVoided,
+			"voided_by":   voidedBy,
+			"void_reason": reason,
+			"voided_at":   voidedAt,
+		}).Error; err != nil {
+			return err
+		}
+		sale.Status = domain.SaleStatusVoided
+		sale.VoidedBy = &voidedBy
+		sale.VoidReason = reason
+		sale.VoidedAt = &voidedAt
+		result = toDomainSale(&sale)
+		return nil
+	})
+	return result, err
+}
+
+func toDomainDiscount(discountType string, value *float64) *domain.Discount {
+	if discountType == "" || value == nil {
+		return nil
+	}
+	return &domain.Discount{Type: discountType, Value: *value}
+}
+
+func toDomainSaleItemLots(lots []infrastructure.SaleItemLot) []domain.SaleItemLot {
+	var result []domain.SaleItemLot
+	for _, l := range lots {
+		result = append(result, domain.SaleItemLot{
+			ID:               l.ID,
+			SaleID:           l.SaleID,
+			SaleItemID:       l.SaleItemID,
+			LotID:            l.LotID,
+			ProductID:        l.ProductID,
+			BatchNumber:      l.BatchNumber,
+			ExpiryDate:       l.ExpiryDate,
+			Quantity:         l.Quantity,
+			ReturnedQuantity: l.ReturnedQuantity,
+		})
+	}
+	return result
+}
+
 func NewSaleRepo(db *gorm.DB) *SaleRepo {
 	return &SaleRepo{DB: db}
 }
 
-func (r *SaleRepo) CreateSale(sale *domain.Sale, items []domain.SaleItem) (string, float64, error) {
-	var total float64
+func (r *SaleRepo) CreateSale(sale *domain.Sale, items []domain.SaleItem, pointsExpireAt *int64) (string, float64, error) {
 	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&infrastructure.Sale{}).Where("id = ?", sale.ID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return domain.ErrSaleExists
+		}
 		// Insert sale with total_amount = 0
 		saleModel := infrastructure.Sale{
 			ID:            sale.ID,
 			BusinessID:    sale.BusinessID,
 			BranchID:      sale.BranchID,
 			CashierID:     sale.CashierID,
+			CustomerID:    sale.CustomerID,
+			ShiftID:       sale.ShiftID,
+			ReservationID: sale.ReservationID,
 			TotalAmount:   0,
 			PaymentMethod: sale.PaymentMethod,
 			Status:        sale.Status,
@@ -97,6 +546,13 @@ func (r *SaleRepo) CreateSale(sale *domain.Sale, items []domain.SaleItem) (strin
 		if err := tx.Create(&saleModel).Error; err != nil {
 			return err
 		}
+		// Collecting a reservation frees its units for this sale; other reservations stay off limits
+		now := time.Now().Unix()
+		if sale.ReservationID != nil {
+			if err := fulfilReservation(tx, sale, items, now); err != nil {
+				return err
+			}
+		}
 
 		for i := range items {
 			// Lock product row FOR UPDATE
@@ -107,45 +563,155 @@ func (r *SaleRepo) CreateSale(sale *domain.Sale, items []domain.SaleItem) (strin
 			if product.BusinessID != sale.BusinessID {
 				return errors.New("product does not belong to business")
 			}
-			if product.QuantityInStock < items[i].Quantity {
-				return fmt.Errorf("insufficient stock for product %s", product.ID)
-			}
 			if items[i].Quantity <= 0 {
 				return errors.New("quantity must be greater than 0")
 			}
-			unitPrice := product.SellingPrice
-			subtotal := unitPrice * float64(items[i].Quantity)
+			// An offline sale has already handed its units over: it takes what is on hand and
+			// records the rest as oversold, leaving the stock below zero for reconciliation
+			onHand := items[i].Quantity
+			if sale.AllowOversell {
+				onHand = min(items[i].Quantity, max(product.QuantityInStock, 0))
+				items[i].OversoldQuantity = items[i].Quantity - onHand
+			} else {
+				if product.QuantityInStock < items[i].Quantity {
+					return fmt.Errorf("%w for product %s", domain.ErrInsufficientStock, product.ID)
+				}
+				reserved, err := reservedQuantity(tx, product.ID, now)
+				if err != nil {
+					return err
+				}
+				if product.QuantityInStock-reserved < items[i].Quantity {
+					return fmt.Errorf("%w for product %s: %d of %d on hand are reserved", domain.ErrInsufficientStock, product.ID, reserved, product.QuantityInStock)
+				}
+			}
+			subtotal := items[i].Subtotal
+			// Take the units from the earliest-expiring lots that are not recalled and remember which, for recalls
+			recalled, err := recalledLots(tx, &product)
+			if err != nil {
+				return err
+			}
+			takes, err := takeFromLots(tx, &product, onHand, recalled)
+			if err != nil {
+				return err
+			}
+			items[i].UnitCost = unitCost(&product, items[i].Quantity, takes)
 			// Insert sale item
 			saleItemModel := infrastructure.SaleItem{
-				ID:        items[i].ID,
-				SaleID:    sale.ID,
-				ProductID: items[i].ProductID,
-				Quantity:  items[i].Quantity,
-				UnitPrice: unitPrice,
-				Subtotal:  subtotal,
-				CreatedAt: time.Now().Unix(),
+				ID:               items[i].ID,
+				SaleID:           sale.ID,
+				ProductID:        items[i].ProductID,
+				Quantity:         items[i].Quantity,
+				ListPrice:        items[i].ListPrice,
+				UnitPrice:        items[i].UnitPrice,
+				Subtotal:         subtotal,
+				PromotionID:      items[i].PromotionID,
+				PromotionAmount:  items[i].PromotionAmount,
+				DiscountAmount:   items[i].DiscountAmount,
+				TaxTreatment:     items[i].TaxTreatment,
+				TaxRate:          items[i].TaxRate,
+				TaxAmount:        items[i].TaxAmount,
+				UnitCost:         items[i].UnitCost,
+				OversoldQuantity: items[i].OversoldQuantity,
+				CreatedAt:        time.Now().Unix(),
+			}
+			if d := items[i].Discount; d != nil && d.Value != 0 {
+				saleItemModel.DiscountType = d.Type
+				saleItemModel.DiscountValue = &d.Value
 			}
 			if err := tx.Create(&saleItemModel).Error; err != nil {
 				return err
 			}
+			for _, t := range takes {
+				used := infrastructure.SaleItemLot{
+					ID:          uuid.NewString(),
+					SaleID:      sale.ID,
+					SaleItemID:  saleItemModel.ID,
+					LotID:       t.Lot.ID,
+					ProductID:   product.ID,
+					BatchNumber: t.Lot.BatchNumber,
+					ExpiryDate:  t.Lot.ExpiryDate,
+					Quantity:    t.Quantity,
+				}
+				if err := tx.Create(&used).Error; err != nil {
+					return err
+				}
+			}
 			// Update product stock
 			newStock := product.QuantityInStock - items[i].Quantity
-			if newStock < 0 {
+			if newStock < 0 && !sale.AllowOversell {
 				return errors.New("stock would become negative")
 			}
-			if err := tx.Model(&product).Update("quantity_in_stock", newStock).Error; err != nil {
+			change := domain.StockChange{
+				Type:          domain.StockMovementSale,
+				ActorID:       sale.CashierID,
+				ReferenceType: "sale",
+				ReferenceID:   sale.ID,
+			}
+			if err := moveStock(tx, &product, -items[i].Quantity, change); err != nil {
+				return err
+			}
+		}
+		for i := range sale.Payments {
+			p := &sale.Payments[i]
+			p.ID = uuid.NewString()
+			p.SaleID = sale.ID
+			p.CreatedAt = sale.CreatedAt
+			payment := infrastructure.SalePayment{
+				ID:        p.ID,
+				SaleID:    p.SaleID,
+				Method:    p.Method,
+				Amount:    p.Amount,
+				Tendered:  p.Tendered,
+				Reference: p.Reference,
+				CreatedAt: p.CreatedAt,
+			}
+			if err := tx.Create(&payment).Error; err != nil {
 				return err
 			}
-			total += subtotal
 		}
+		if err := settleLoyalty(tx, sale, pointsExpireAt); err != nil {
+			return err
+		}
+		if err := chargeCredit(tx, sale); err != nil {
+			return err
+		}
+		number, err := nextReceiptNumber(tx, sale.BranchID)
+		if err != nil {
+			return err
+		}
+		sale.ReceiptNumber = number
 		// Update sale total_amount
-		if err := tx.Model(&saleModel).Update("total_amount", total).Error; err != nil {
+		updates := map[string]interface{}{
+			"receipt_number":   number,
+			"total_amount":     sale.TotalAmount,
+			"change_due":       sale.ChangeDue,
+			"gross_amount":     sale.GrossAmount,
+			"promotion_amount": sale.PromotionAmount,
+			"discount_amount":  sale.DiscountAmount,
+			"discount_reason":  sale.DiscountReason,
+			"tax_amount":       sale.TaxAmount,
+			"tax_mode":         sale.TaxMode,
+			"points_earned":    sale.PointsEarned,
+			"points_redeemed":  sale.PointsRedeemed,
+		}
+		if d := sale.Discount; d != nil && d.Value != 0 {
+			updates["discount_type"] = d.Type
+			updates["discount_value"] = d.Value
+		}
+		if err := tx.Model(&saleModel).Updates(updates).Error; err != nil {
 			return err
 		}
 		return nil
 	})
+	if err != nil && !errors.Is(err, domain.ErrSaleExists) {
+		// A retry of the same sale may have been recorded between our check and insert
+		var taken int64
+		if r.DB.Model(&infrastructure.Sale{}).Where("id = ?", sale.ID).Count(&taken).Error == nil && taken > 0 {
+			err = domain.ErrSaleExists
+		}
+	}
 	if err != nil {
 		return "", 0, err
 	}
-	return sale.ID, total, nil
+	return sale.ID, sale.TotalAmount, nil
 }
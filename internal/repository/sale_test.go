@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+)
+
+func TestOfflineSaleOversellsIntoNegativeStock(t *testing.T) {
+	db := newTestDB(t)
+	product := seedLots(t, db, 3, infrastructure.ProductLot{BatchNumber: "B-1", Quantity: 3, CostPrice: 100, ReceivedAt: time.Now().Unix()})
+	if err := db.Omit("Business").Create(&infrastructure.Branch{ID: product.BranchID, BusinessID: product.BusinessID, BranchName: "Main"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	ledger := &StockMovementRepo{DB: db}
+	if _, err := ledger.SeedOpeningBalances(); err != nil {
+		t.Fatal(err)
+	}
+
+	sales := &SaleRepo{DB: db}
+	sellFive := func(id string, offline bool) ([]domain.SaleItem, error) {
+		sale := &domain.Sale{
+			ID: id, BusinessID: product.BusinessID, BranchID: product.BranchID, CashierID: "user-1", AllowOversell: offline,
+			PaymentMethod: domain.PaymentMethodCash, Status: domain.SaleStatusCompleted, CreatedAt: time.Now().Unix(),
+		}
+		items := []domain.SaleItem{{ID: id + "-item", ProductID: product.ID, Quantity: 5, UnitPrice: 10, Subtotal: 50}}
+		_, _, err := sales.CreateSale(sale, items, nil)
+		return items, err
+	}
+
+	if _, err := sellFive("sale-1", false); !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("online oversell: error = %v, want %v", err, domain.ErrInsufficientStock)
+	}
+	items, err := sellFive("sale-2", true)
+	if err != nil {
+		t.Fatalf("offline oversell: CreateSale() error = %v", err)
+	}
+	if items[0].OversoldQuantity != 2 {
+		t.Errorf("OversoldQuantity = %d, want 2", items[0].OversoldQuantity)
+	}
+	stored, err := sales.GetSaleByID("sale-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it := stored.Items[0]; it.OversoldQuantity != 2 || len(it.Lots) != 1 || it.Lots[0].Quantity != 3 {
+		t.Errorf("stored item oversold %d from lots %+v, want 2 oversold and all 3 of B-1", it.OversoldQuantity, it.Lots)
+	}
+
+	// The ledger still balances, but the product is flagged until its stock is counted
+	discrepancies, err := ledger.FindDiscrepancies(product.BusinessID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 || discrepancies[0].QuantityInStock != -2 || discrepancies[0].LedgerQuantity != -2 {
+		t.Errorf("discrepancies = %+v, want product-1 at -2", discrepancies)
+	}
+}
+
+func TestRevenueByTenderNetsRefunds(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().Unix()
+	sale := func(id, method string, total float64, payments ...infrastructure.SalePayment) {
+		t.Helper()
+		s := infrastructure.Sale{ID: id, BusinessID: "business-1", BranchID: "branch-1", CashierID: "user-1", PaymentMethod: method, TotalAmount: total, Status: domain.SaleStatusCompleted, CreatedAt: now}
+		if err := db.Create(&s).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range payments {
+			p.SaleID = id
+			if err := db.Create(&p).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	refund := func(id, saleID, method string, total float64, payments ...infrastructure.RefundPayment) {
+		t.Helper()
+		r := infrastructure.Refund{
+			ID: id, BusinessID: "business-1", BranchID: "branch-1", SaleID: saleID, RequestedBy: "user-1", ReasonCode: domain.RefundReasonChangedMind,
+			PaymentMethod: method, TotalAmount: total, Status: domain.RefundStatusCompleted, CreatedAt: now, RefundPayments: payments,
+		}
+		if err := db.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	sale("sale-1", domain.PaymentMethodSplit, 100,
+		infrastructure.SalePayment{ID: "pay-1", Method: domain.PaymentMethodCash, Amount: 60},
+		infrastructure.SalePayment{ID: "pay-2", Method: domain.PaymentMethodCard, Amount: 40})
+	refund("refund-1", "sale-1", domain.PaymentMethodSplit, 30, infrastructure.RefundPayment{ID: "refund-pay-1", Method: domain.PaymentMethodCard, Amount: 30})
+	// Recorded before sales and refunds had payment rows
+	sale("sale-2", domain.PaymentMethodCash, 20)
+	refund("refund-2", "sale-2", domain.PaymentMethodCash, 5)
+
+	tenders, err := (&SaleRepo{DB: db}).GetRevenueByTender("business-1", "", now-60, now+60)
+	if err != nil {
+		t.Fatalf("GetRevenueByTender() error = %v", err)
+	}
+	got := map[string]float64{}
+	for _, tt := range tenders {
+		got[tt.Method] = tt.Amount
+	}
+	if len(got) != 2 || got[domain.PaymentMethodCash] != 75 || got[domain.PaymentMethodCard] != 10 {
+		t.Errorf("revenue by tender = %v, want cash 75 and card 10", got)
+	}
+}
+
+func TestCreateSaleTwiceUnderOneID(t *testing.T) {
+	db := newTestDB(t)
+	product := seedLots(t, db, 10)
+	if err := db.Omit("Business").Create(&infrastructure.Branch{ID: product.BranchID, BusinessID: product.BusinessID, BranchName: "Main"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	sales := &SaleRepo{DB: db}
+	sell := func() error {
+		sale := &domain.Sale{
+			ID: "sale-1", BusinessID: product.BusinessID, BranchID: product.BranchID, CashierID: "user-1",
+			PaymentMethod: domain.PaymentMethodCash, Status: domain.SaleStatusCompleted, CreatedAt: time.Now().Unix(),
+		}
+		_, _, err := sales.CreateSale(sale, []domain.SaleItem{{ID: uuid.NewString(), ProductID: product.ID, Quantity: 4, UnitPrice: 10, Subtotal: 40}}, nil)
+		return err
+	}
+
+	if err := sell(); err != nil {
+		t.Fatalf("CreateSale() error = %v", err)
+	}
+	if err := sell(); !errors.Is(err, domain.ErrSaleExists) {
+		t.Fatalf("second CreateSale() error = %v, want %v", err, domain.ErrSaleExists)
+	}
+	var stock infrastructure.Product
+	db.First(&stock, "id = ?", product.ID)
+	if stock.QuantityInStock != 6 {
+		t.Errorf("%d on hand, want 6 after one sale of 4", stock.QuantityInStock)
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type SettingsRepo struct {
+	DB *gorm.DB
+}
+
+// GetSettings returns the business's saved settings, or the defaults if it has none
+func (r *SettingsRepo) GetSettings(businessID string) (*domain.BusinessSettings, error) {
+	var infra infrastructure.BusinessSetting
+	err := r.DB.First(&infra, "business_id = ?", businessID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.DefaultBusinessSettings(businessID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings := &domain.BusinessSettings{
+		BusinessID:          infra.BusinessID,
+		RefundApprovalLimit: infra.RefundApprovalLimit,
+		PaymentMethods:      domain.DefaultPaymentMethods,
+		TaxMode:             infra.TaxMode,
+		LoyaltyEnabled:      infra.LoyaltyEnabled,
+		LoyaltyEarnRate:     domain.DefaultLoyaltyEarnRate,
+		LoyaltyPointValue:   infra.LoyaltyPointValue,
+		LoyaltyExpiryDays:   domain.DefaultLoyaltyExpiryDays,
+		RequireOpenShift:    infra.RequireOpenShift,
+		Timezone:            infra.Timezone,
+		UpdatedBy:           infra.UpdatedBy,
+		UpdatedAt:           infra.UpdatedAt,
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = domain.DefaultTimezone
+	}
+	if settings.TaxMode == "" {
+		settings.TaxMode = domain.TaxModeInclusive
+	}
+	if infra.LoyaltyEarnRate != nil {
+		settings.LoyaltyEarnRate = *infra.LoyaltyEarnRate
+	}
+	if settings.LoyaltyPointValue <= 0 {
+		settings.LoyaltyPointValue = domain.DefaultLoyaltyPointValue
+	}
+	if infra.LoyaltyExpiryDays != nil {
+		settings.LoyaltyExpiryDays = *infra.LoyaltyExpiryDays
+	}
+	if infra.LoyaltyExcludedCategories != "" {
+		settings.LoyaltyExcludedCategories = strings.Split(infra.LoyaltyExcludedCategories, ",")
+	}
+	if infra.PaymentMethods != "" {
+		settings.PaymentMethods = strings.Split(infra.PaymentMethods, ",")
+	}
+	if infra.MaxDiscountPercent != "" {
+		settings.MaxDiscountPercent = map[domain.StaffRole]float64{}
+		for _, pair := range strings.Split(infra.MaxDiscountPercent, ",") {
+			role, limit, ok := strings.Cut(pair, ":")
+			if !ok {
+				continue
+			}
+			if v, err := strconv.ParseFloat(limit, 64); err == nil {
+				settings.MaxDiscountPercent[domain.StaffRole(role)] = v
+			}
+		}
+	}
+	return settings, nil
+}
+
+func (r *SettingsRepo) SaveSettings(s *domain.BusinessSettings) error {
+	infra := infrastructure.BusinessSetting{
+		BusinessID:                s.BusinessID,
+		RefundApprovalLimit:       s.RefundApprovalLimit,
+		PaymentMethods:            strings.Join(s.PaymentMethods, ","),
+		MaxDiscountPercent:        formatDiscountLimits(s.MaxDiscountPercent),
+		TaxMode:                   s.TaxMode,
+		LoyaltyEnabled:            s.LoyaltyEnabled,
+		LoyaltyEarnRate:           &s.LoyaltyEarnRate,
+		LoyaltyPointValue:         s.LoyaltyPointValue,
+		LoyaltyExcludedCategories: strings.Join(s.LoyaltyExcludedCategories, ","),
+		LoyaltyExpiryDays:         &s.LoyaltyExpiryDays,
+		RequireOpenShift:          s.RequireOpenShift,
+		Timezone:                  s.Timezone,
+		UpdatedBy:                 s.UpdatedBy,
+		UpdatedAt:                 s.UpdatedAt,
+	}
+	return r.DB.Save(&infra).Error
+}
+
+func formatDiscountLimits(limits map[domain.StaffRole]float64) string {
+	var pairs []string
+	for role, limit := range limits {
+		pairs = append(pairs, string(role)+":"+strconv.FormatFloat(limit, 'f', -1, 64))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
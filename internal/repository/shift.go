@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftRepo struct {
+	DB *gorm.DB
+}
+
+func (r *ShiftRepo) OpenShift(s *domain.Shift) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the cashier's open shifts so two tills cannot open one at the same time
+		var open []infrastructure.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("cashier_id = ? AND status = ?", s.CashierID, domain.ShiftStatusOpen).
+			Find(&open).Error; err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("%w: close shift %s first", domain.ErrShiftAlreadyOpen, open[0].ID)
+		}
+		m := infrastructure.Shift{
+			ID:           s.ID,
+			BusinessID:   s.BusinessID,
+			BranchID:     s.BranchID,
+			CashierID:    s.CashierID,
+			Status:       s.Status,
+			OpeningFloat: s.OpeningFloat,
+			OpenedAt:     s.OpenedAt,
+		}
+		return tx.Create(&m).Error
+	})
+}
+
+func (r *ShiftRepo) GetShiftByID(id string) (*domain.Shift, error) {
+	var m infrastructure.Shift
+	if err := r.DB.First(&m, "id = ?", id).Error; err != nil {
+		return nil, domain.ErrShiftNotFound
+	}
+	return toDomainShift(&m), nil
+}
+
+func (r *ShiftRepo) GetOpenShift(cashierID string) (*domain.Shift, error) {
+	var m infrastructure.Shift
+	err := r.DB.Where("cashier_id = ? AND status = ?", cashierID, domain.ShiftStatusOpen).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNoOpenShift
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainShift(&m), nil
+}
+
+func (r *ShiftRepo) GetShifts(businessID, branchID, status string, limit, offset int) ([]*domain.Shift, error) {
+	query := r.DB.Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var models []*infrastructure.Shift
+	if err := query.Order("opened_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var shifts []*domain.Shift
+	for _, m := range models {
+		shifts = append(shifts, toDomainShift(m))
+	}
+	return shifts, nil
+}
+
+func (r *ShiftRepo) AddCashMovement(m *domain.CashMovement) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var shift infrastructure.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, "id = ?", m.ShiftID).Error; err != nil {
+			return domain.ErrShiftNotFound
+		}
+		if shift.Status != domain.ShiftStatusOpen {
+			return domain.ErrShiftClosed
+		}
+		return tx.Create(&infrastructure.CashMovement{
+			ID:         m.ID,
+			ShiftID:    shift.ID,
+			BusinessID: shift.BusinessID,
+			BranchID:   shift.BranchID,
+			Type:       m.Type,
+			Amount:     m.Amount,
+			Reason:     m.Reason,
+			RecordedBy: m.RecordedBy,
+			CreatedAt:  m.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *ShiftRepo) GetCashMovements(shiftID string) ([]*domain.CashMovement, error) {
+	var models []*infrastructure.CashMovement
+	if err := r.DB.Where("shift_id = ?", shiftID).Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var movements []*domain.CashMovement
+	for _, m := range models {
+		movements = append(movements, &domain.CashMovement{
+			ID:         m.ID,
+			ShiftID:    m.ShiftID,
+			BusinessID: m.BusinessID,
+			BranchID:   m.BranchID,
+			Type:       m.Type,
+			Amount:     m.Amount,
+			Reason:     m.Reason,
+			RecordedBy: m.RecordedBy,
+			CreatedAt:  m.CreatedAt,
+		})
+	}
+	return movements, nil
+}
+
+func (r *ShiftRepo) GetCashSummary(s *domain.Shift) (*domain.ShiftCashSummary, error) {
+	var m infrastructure.Shift
+	if err := r.DB.First(&m, "id = ?", s.ID).Error; err != nil {
+		return nil, domain.ErrShiftNotFound
+	}
+	if m.Status == domain.ShiftStatusClosed {
+		summary := &domain.ShiftCashSummary{
+			SalesCount:     m.SalesCount,
+			SalesTotal:     m.SalesTotal,
+			OpeningFloat:   m.OpeningFloat,
+			CashSales:      m.CashSales,
+			CashRefunds:    m.CashRefunds,
+			CashRepayments: m.CashRepayments,
+			CashIn:         m.CashIn,
+			CashOut:        m.CashOut,
+		}
+		summary.Expected()
+		return summary, nil
+	}
+	return shiftCashSummary(r.DB, &m, time.Now().Unix())
+}
+
+func (r *ShiftRepo) CloseShift(id, closedBy string, counted float64, note string, closedAt int64) (*domain.Shift, error) {
+	var result *domain.Shift
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var shift infrastructure.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, "id = ?", id).Error; err != nil {
+			return domain.ErrShiftNotFound
+		}
+		if shift.Status != domain.ShiftStatusOpen {
+			return domain.ErrShiftClosed
+		}
+		summary, err := shiftCashSummary(tx, &shift, closedAt)
+		if err != nil {
+			return err
+		}
+		expected := summary.ExpectedCash
+		counted = roundMoney(counted)
+		variance := roundMoney(counted - expected)
+		shift.Status = domain.ShiftStatusClosed
+		shift.ClosedAt = &closedAt
+		shift.ClosedBy = &closedBy
+		shift.ClosingNote = note
+		shift.SalesCount = summary.SalesCount
+		shift.SalesTotal = summary.SalesTotal
+		shift.CashSales = summary.CashSales
+		shift.CashRefunds = summary.CashRefunds
+		shift.CashRepayments = summary.CashRepayments
+		shift.CashIn = summary.CashIn
+		shift.CashOut = summary.CashOut
+		shift.ExpectedCash = &expected
+		shift.CountedCash = &counted
+		shift.Variance = &variance
+		if err := tx.Save(&shift).Error; err != nil {
+			return err
+		}
+		result = toDomainShift(&shift)
+		result.Summary = summary
+		return nil
+	})
+	return result, err
+}
+
+// shiftCashSummary totals the cash that went through a shift's drawer up to until
+func shiftCashSummary(tx *gorm.DB, shift *infrastructure.Shift, until int64) (*domain.ShiftCashSummary, error) {
+	summary := &domain.ShiftCashSummary{OpeningFloat: shift.OpeningFloat}
+
+	var sales struct {
+		SalesCount int
+		SalesTotal float64
+	}
+	err := tx.Model(&infrastructure.Sale{}).
+		Select("COUNT(*) AS sales_count, COALESCE(SUM(total_amount), 0) AS sales_total").
+		Where("shift_id = ? AND status <> ?", shift.ID, domain.SaleStatusVoided).
+		Scan(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.SalesCount = sales.SalesCount
+	summary.SalesTotal = roundMoney(sales.SalesTotal)
+
+	// Payment amounts are already net of the change handed back
+	err = tx.Table("sale_payments").
+		Select("COALESCE(SUM(sale_payments.amount), 0)").
+		Joins("JOIN sales ON sales.id = sale_payments.sale_id").
+		Where("sales.shift_id = ? AND sales.status <> ? AND sale_payments.method = ?", shift.ID, domain.SaleStatusVoided, domain.PaymentMethodCash).
+		Scan(&summary.CashSales).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Refunds are paid out of the drawer of the shift they were completed on. Those recorded
+	// without a shift belong to whoever completed them, at the branch, while the shift was open.
+	paidOut := func(q *gorm.DB) *gorm.DB {
+		return q.Where("refunds.status = ?", domain.RefundStatusCompleted).
+			Where(tx.Where("refunds.shift_id = ?", shift.ID).
+				Or("refunds.shift_id IS NULL AND refunds.branch_id = ? AND COALESCE(refunds.approved_by, refunds.requested_by) = ? AND refunds.resolved_at >= ? AND refunds.resolved_at <= ?",
+					shift.BranchID, shift.CashierID, shift.OpenedAt, until))
+	}
+	err = paidOut(tx.Table("refund_payments").
+		Select("COALESCE(SUM(refund_payments.amount), 0)").
+		Joins("JOIN refunds ON refunds.id = refund_payments.refund_id").
+		Where("refund_payments.method = ?", domain.PaymentMethodCash)).
+		Scan(&summary.CashRefunds).Error
+	if err != nil {
+		return nil, err
+	}
+	// Refunds recorded before refund payments have no payment rows; count them under their payment method
+	var legacyRefunds float64
+	err = paidOut(tx.Model(&infrastructure.Refund{}).
+		Select("COALESCE(SUM(refunds.total_amount), 0)").
+		Where("refunds.payment_method = ?", domain.PaymentMethodCash).
+		Where("NOT EXISTS (SELECT 1 FROM refund_payments rp WHERE rp.refund_id = refunds.id)")).
+		Scan(&legacyRefunds).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.CashRefunds += legacyRefunds
+
+	// Repayments are stored as negative amounts
+	err = tx.Model(&infrastructure.CreditEntry{}).
+		Select("COALESCE(SUM(-amount), 0)").
+		Where("branch_id = ? AND recorded_by = ? AND type = ? AND method = ? AND created_at >= ? AND created_at <= ?",
+			shift.BranchID, shift.CashierID, domain.CreditEntryRepayment, domain.PaymentMethodCash, shift.OpenedAt, until).
+		Scan(&summary.CashRepayments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var movements []struct {
+		Type   string
+		Amount float64
+	}
+	err = tx.Model(&infrastructure.CashMovement{}).
+		Select("type, SUM(amount) AS amount").
+		Where("shift_id = ?", shift.ID).
+		Group("type").Scan(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movements {
+		switch m.Type {
+		case domain.CashMovementIn:
+			summary.CashIn = roundMoney(m.Amount)
+		case domain.CashMovementOut:
+			summary.CashOut = roundMoney(m.Amount)
+		}
+	}
+
+	summary.CashSales = roundMoney(summary.CashSales)
+	summary.CashRefunds = roundMoney(summary.CashRefunds)
+	summary.CashRepayments = roundMoney(summary.CashRepayments)
+	summary.Expected()
+	return summary, nil
+}
+
+func toDomainShift(m *infrastructure.Shift) *domain.Shift {
+	return &domain.Shift{
+		ID:           m.ID,
+		BusinessID:   m.BusinessID,
+		BranchID:     m.BranchID,
+		CashierID:    m.CashierID,
+		Status:       m.Status,
+		OpeningFloat: m.OpeningFloat,
+		OpenedAt:     m.OpenedAt,
+		ClosedAt:     m.ClosedAt,
+		ClosedBy:     m.ClosedBy,
+		ExpectedCash: m.ExpectedCash,
+		CountedCash:  m.CountedCash,
+		Variance:     m.Variance,
+		ClosingNote:  m.ClosingNote,
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+)
+
+func TestShiftCashRefundsFollowWhoPaidThemOut(t *testing.T) {
+	db := newTestDB(t)
+	opened := time.Now().Add(-time.Hour).Unix()
+	shift := &infrastructure.Shift{ID: "shift-1", BusinessID: "business-1", BranchID: "branch-1", CashierID: "user-1", Status: domain.ShiftStatusOpen, OpenedAt: opened}
+	if err := db.Create(shift).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	shiftID, cashier, manager := shift.ID, "user-1", "user-2"
+	during, before := opened+60, opened-3600
+	refund := func(id string, amount float64, requestedBy string, approvedBy *string, resolvedAt int64, shiftID *string) {
+		t.Helper()
+		r := infrastructure.Refund{
+			ID: id, BusinessID: "business-1", BranchID: "branch-1", SaleID: "sale-" + id, RequestedBy: requestedBy, ApprovedBy: approvedBy,
+			ReasonCode: domain.RefundReasonChangedMind, PaymentMethod: domain.PaymentMethodCash, TotalAmount: amount,
+			Status: domain.RefundStatusCompleted, CreatedAt: before, ResolvedAt: &resolvedAt, ShiftID: shiftID,
+			RefundPayments: []infrastructure.RefundPayment{{ID: id + "-cash", Method: domain.PaymentMethodCash, Amount: amount}},
+		}
+		if err := db.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Asked for by the cashier but paid out by a manager: not from this drawer
+	refund("a", 10, cashier, &manager, during, nil)
+	// Asked for before the shift opened, approved and paid out on it
+	refund("b", 20, manager, &cashier, during, &shiftID)
+	// Recorded without a shift: attributed to whoever completed it while the shift was open
+	refund("c", 40, manager, &cashier, during, nil)
+	refund("d", 80, manager, &cashier, before, nil)
+
+	summary, err := (&ShiftRepo{DB: db}).GetCashSummary(&domain.Shift{ID: shift.ID})
+	if err != nil {
+		t.Fatalf("GetCashSummary() error = %v", err)
+	}
+	if summary.CashRefunds != 60 {
+		t.Errorf("CashRefunds = %.2f, want 60.00 from refunds b and c", summary.CashRefunds)
+	}
+}
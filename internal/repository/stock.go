@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type StockMovementRepo struct {
+	DB *gorm.DB
+}
+
+func (r *StockMovementRepo) GetMovementsByProduct(productID string, limit, offset int) ([]*domain.StockMovement, error) {
+	var models []*infrastructure.StockMovement
+	err := r.DB.Where("product_id = ?", productID).
+		Order("created_at DESC").Order("id").
+		Limit(limit).Offset(offset).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	var movements []*domain.StockMovement
+	for _, m := range models {
+		movements = append(movements, &domain.StockMovement{
+			ID:             m.ID,
+			BusinessID:     m.BusinessID,
+			BranchID:       m.BranchID,
+			ProductID:      m.ProductID,
+			Type:           m.Type,
+			Quantity:       m.Quantity,
+			QuantityBefore: m.QuantityBefore,
+			QuantityAfter:  m.QuantityAfter,
+			ActorID:        m.ActorID,
+			ReferenceType:  m.ReferenceType,
+			ReferenceID:    m.ReferenceID,
+			Note:           m.Note,
+			CreatedAt:      m.CreatedAt,
+		})
+	}
+	return movements, nil
+}
+
+// SeedOpeningBalances gives products created before the ledger existed an opening entry
+// for their current stock, so that the ledger of every product sums to its quantity in stock
+func (r *StockMovementRepo) SeedOpeningBalances() (int, error) {
+	var products []infrastructure.Product
+	err := r.DB.Model(&infrastructure.Product{}).
+		Where("NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = products.id)").
+		Find(&products).Error
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().Unix()
+	for _, p := range products {
+		m := infrastructure.StockMovement{
+			ID:             uuid.NewString(),
+			BusinessID:     p.BusinessID,
+			BranchID:       p.BranchID,
+			ProductID:      p.ID,
+			Type:           domain.StockMovementOpening,
+			Quantity:       p.QuantityInStock,
+			QuantityBefore: 0,
+			QuantityAfter:  p.QuantityInStock,
+			ActorID:        p.CreatedBy,
+			Note:           "opening balance",
+			CreatedAt:      now,
+		}
+		if err := r.DB.Create(&m).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(products), nil
+}
+
+func (r *StockMovementRepo) FindDiscrepancies(businessID string) ([]*domain.StockDiscrepancy, error) {
+	query := r.DB.Table("products p").
+		Select("p.id AS product_id, p.business_id, p.branch_id, p.product_name, p.quantity_in_stock, COALESCE(SUM(m.quantity), 0) AS ledger_quantity").
+		Joins("LEFT JOIN stock_movements m ON m.product_id = p.id").
+		Where("p.deleted_at IS NULL OR p.deleted_at = 0")
+	if businessID != "" {
+		query = query.Where("p.business_id = ?", businessID)
+	}
+	var discrepancies []*domain.StockDiscrepancy
+	err := query.
+		Group("p.id, p.business_id, p.branch_id, p.product_name, p.quantity_in_stock").
+		Having("p.quantity_in_stock <> COALESCE(SUM(m.quantity), 0) OR p.quantity_in_stock < 0").
+		Scan(&discrepancies).Error
+	return discrepancies, err
+}
+
+// moveStock changes a locked product's stock by delta and writes the ledger entry in the same GORM transaction
+func moveStock(tx *gorm.DB, product *infrastructure.Product, delta int, change domain.StockChange) error {
+	before := product.QuantityInStock
+	after := before + delta
+	if err := tx.Model(product).Update("quantity_in_stock", after).Error; err != nil {
+		return err
+	}
+	product.QuantityInStock = after
+	m := infrastructure.StockMovement{
+		ID:             uuid.NewString(),
+		BusinessID:     product.BusinessID,
+		BranchID:       product.BranchID,
+		ProductID:      product.ID,
+		Type:           change.Type,
+		Quantity:       delta,
+		QuantityBefore: before,
+		QuantityAfter:  after,
+		ActorID:        change.ActorID,
+		ReferenceType:  change.ReferenceType,
+		ReferenceID:    change.ReferenceID,
+		Note:           change.Note,
+		CreatedAt:      time.Now().Unix(),
+	}
+	return tx.Create(&m).Error
+}
+
+// insertStockMovement writes a ledger entry inside an sqlx transaction
+func insertStockMovement(tx *sqlx.Tx, businessID, branchID, productID string, before, after int, change domain.StockChange) error {
+	query := `INSERT INTO stock_movements (
+		id, business_id, branch_id, product_id, type, quantity, quantity_before, quantity_after,
+		actor_id, reference_type, reference_id, note, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.Exec(query,
+		uuid.NewString(), businessID, branchID, productID, change.Type, after-before, before, after,
+		change.ActorID, change.ReferenceType, change.ReferenceID, change.Note, time.Now().Unix())
+	return err
+}
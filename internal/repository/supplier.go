@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type SupplierRepo struct {
+	DB *gorm.DB
+}
+
+func (r *SupplierRepo) CreateSupplier(s *domain.Supplier) error {
+	infra := toInfraSupplier(s)
+	return r.DB.Create(&infra).Error
+}
+
+func (r *SupplierRepo) GetSupplierByID(id string) (*domain.Supplier, error) {
+	var infra infrastructure.Supplier
+	err := r.DB.First(&infra, "id = ? AND (deleted_at IS NULL OR deleted_at = 0)", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return toDomainSupplier(&infra), nil
+}
+
+func (r *SupplierRepo) GetSuppliersByBusinessID(businessID string) ([]*domain.Supplier, error) {
+	var infras []*infrastructure.Supplier
+	err := r.DB.Where("business_id = ? AND (deleted_at IS NULL OR deleted_at = 0)", businessID).
+		Order("name ASC").Find(&infras).Error
+	if err != nil {
+		return nil, err
+	}
+	var suppliers []*domain.Supplier
+	for _, infra := range infras {
+		suppliers = append(suppliers, toDomainSupplier(infra))
+	}
+	return suppliers, nil
+}
+
+func (r *SupplierRepo) UpdateSupplier(s *domain.Supplier) error {
+	infra := toInfraSupplier(s)
+	return r.DB.Save(&infra).Error
+}
+
+// DeleteSupplier soft-deletes the supplier so that past purchase orders keep their reference
+func (r *SupplierRepo) DeleteSupplier(id string) error {
+	now := time.Now().Unix()
+	return r.DB.Model(&infrastructure.Supplier{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": now, "updated_at": now}).Error
+}
+
+func toInfraSupplier(s *domain.Supplier) infrastructure.Supplier {
+	return infrastructure.Supplier{
+		ID:          s.ID,
+		BusinessID:  s.BusinessID,
+		Name:        s.Name,
+		ContactName: s.ContactName,
+		Phone:       s.Phone,
+		Email:       s.Email,
+		Address:     s.Address,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+func toDomainSupplier(infra *infrastructure.Supplier) *domain.Supplier {
+	return &domain.Supplier{
+		ID:          infra.ID,
+		BusinessID:  infra.BusinessID,
+		Name:        infra.Name,
+		ContactName: infra.ContactName,
+		Phone:       infra.Phone,
+		Email:       infra.Email,
+		Address:     infra.Address,
+		CreatedAt:   infra.CreatedAt,
+		UpdatedAt:   infra.UpdatedAt,
+	}
+}
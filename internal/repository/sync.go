@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type SyncRepo struct {
+	DB *gorm.DB
+}
+
+func (r *SyncRepo) GetOperation(businessID, opType, opID string) (*domain.SyncOperation, error) {
+	var infra infrastructure.SyncOperation
+	err := r.DB.First(&infra, "business_id = ? AND op_type = ? AND id = ?", businessID, opType, opID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SyncOperation{
+		ID:         infra.ID,
+		BusinessID: infra.BusinessID,
+		DeviceID:   infra.DeviceID,
+		OpType:     infra.OpType,
+		Status:     infra.Status,
+		Message:    infra.Message,
+		CreatedAt:  infra.CreatedAt,
+	}, nil
+}
+
+func (r *SyncRepo) RecordOperation(op *domain.SyncOperation) error {
+	infra := infrastructure.SyncOperation{
+		ID:         op.ID,
+		BusinessID: op.BusinessID,
+		DeviceID:   op.DeviceID,
+		OpType:     op.OpType,
+		Status:     op.Status,
+		Message:    op.Message,
+		CreatedAt:  op.CreatedAt,
+	}
+	return r.DB.Create(&infra).Error
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+)
+
+type TaxRepo struct {
+	DB *gorm.DB
+}
+
+func (r *TaxRepo) CreateTaxRate(rate *domain.TaxRate) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultTaxRate(tx, rate); err != nil {
+			return err
+		}
+		m := toInfraTaxRate(rate)
+		return tx.Create(&m).Error
+	})
+}
+
+func (r *TaxRepo) GetTaxRateByID(id string) (*domain.TaxRate, error) {
+	var m infrastructure.TaxRate
+	if err := r.DB.First(&m, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainTaxRate(&m), nil
+}
+
+func (r *TaxRepo) GetTaxRatesByBusinessID(businessID string) ([]*domain.TaxRate, error) {
+	var models []*infrastructure.TaxRate
+	if err := r.DB.Where("business_id = ?", businessID).Order("is_default DESC").Order("name ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var rates []*domain.TaxRate
+	for _, m := range models {
+		rates = append(rates, toDomainTaxRate(m))
+	}
+	return rates, nil
+}
+
+func (r *TaxRepo) UpdateTaxRate(rate *domain.TaxRate) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultTaxRate(tx, rate); err != nil {
+			return err
+		}
+		m := toInfraTaxRate(rate)
+		return tx.Save(&m).Error
+	})
+}
+
+// DeleteTaxRate removes the rate; rules that named it fall back to the default rate
+func (r *TaxRepo) DeleteTaxRate(id string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&infrastructure.TaxRule{}).Where("tax_rate_id = ?", id).Update("tax_rate_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&infrastructure.TaxRate{}, "id = ?", id).Error
+	})
+}
+
+func (r *TaxRepo) SaveTaxRule(rule *domain.TaxRule) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var existing infrastructure.TaxRule
+		query := tx.Where("business_id = ?", rule.BusinessID)
+		if rule.ProductID != nil {
+			query = query.Where("product_id = ?", *rule.ProductID)
+		} else {
+			query = query.Where("product_id IS NULL AND category = ?", *rule.Category)
+		}
+		err := query.First(&existing).Error
+		if err == nil {
+			rule.ID = existing.ID
+			rule.CreatedAt = existing.CreatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		m := toInfraTaxRule(rule)
+		return tx.Save(&m).Error
+	})
+}
+
+func (r *TaxRepo) GetTaxRuleByID(id string) (*domain.TaxRule, error) {
+	var m infrastructure.TaxRule
+	if err := r.DB.First(&m, "id = ?", id).Error; err != nil {
+		return nil, domain.ErrTaxRuleNotFound
+	}
+	return toDomainTaxRule(&m), nil
+}
+
+func (r *TaxRepo) GetTaxRulesByBusinessID(businessID string) ([]*domain.TaxRule, error) {
+	var models []*infrastructure.TaxRule
+	if err := r.DB.Where("business_id = ?", businessID).Order("category ASC").Order("product_id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var rules []*domain.TaxRule
+	for _, m := range models {
+		rules = append(rules, toDomainTaxRule(m))
+	}
+	return rules, nil
+}
+
+func (r *TaxRepo) DeleteTaxRule(id string) error {
+	return r.DB.Delete(&infrastructure.TaxRule{}, "id = ?", id).Error
+}
+
+func (r *TaxRepo) GetTaxSummary(businessID, branchID string, from, to int64) ([]domain.TaxSummaryLine, error) {
+	var lines []domain.TaxSummaryLine
+	// Refunded units are taken out pro rata, as refunds return the line's tax-inclusive unit price
+	query := r.DB.Table("sale_items").
+		Select("sale_items.tax_treatment AS treatment, sale_items.tax_rate AS rate, "+
+			"SUM((sale_items.subtotal - sale_items.tax_amount) * (sale_items.quantity - sale_items.refunded_quantity) / sale_items.quantity) AS net_amount, "+
+			"SUM(sale_items.tax_amount * (sale_items.quantity - sale_items.refunded_quantity) / sale_items.quantity) AS tax_amount").
+		Joins("JOIN sales ON sales.id = sale_items.sale_id").
+		Where("sales.business_id = ? AND sales.status <> ? AND sales.created_at >= ? AND sales.created_at < ?",
+			businessID, domain.SaleStatusVoided, from, to).
+		Where("sale_items.tax_treatment <> ''")
+	if branchID != "" {
+		query = query.Where("sales.branch_id = ?", branchID)
+	}
+	err := query.Group("sale_items.tax_treatment, sale_items.tax_rate").Order("treatment ASC").Order("rate DESC").Scan(&lines).Error
+	return lines, err
+}
+
+// clearDefaultTaxRate keeps a single default rate per business
+func clearDefaultTaxRate(tx *gorm.DB, rate *domain.TaxRate) error {
+	if !rate.IsDefault {
+		return nil
+	}
+	return tx.Model(&infrastructure.TaxRate{}).
+		Where("business_id = ? AND id <> ? AND is_default = ?", rate.BusinessID, rate.ID, true).
+		Update("is_default", false).Error
+}
+
+func toInfraTaxRate(r *domain.TaxRate) infrastructure.TaxRate {
+	return infrastructure.TaxRate{
+		ID:         r.ID,
+		BusinessID: r.BusinessID,
+		Name:       r.Name,
+		Rate:       r.Rate,
+		IsDefault:  r.IsDefault,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
+
+func toDomainTaxRate(m *infrastructure.TaxRate) *domain.TaxRate {
+	return &domain.TaxRate{
+		ID:         m.ID,
+		BusinessID: m.BusinessID,
+		Name:       m.Name,
+		Rate:       m.Rate,
+		IsDefault:  m.IsDefault,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
+
+func toInfraTaxRule(r *domain.TaxRule) infrastructure.TaxRule {
+	return infrastructure.TaxRule{
+		ID:         r.ID,
+		BusinessID: r.BusinessID,
+		ProductID:  r.ProductID,
+		Category:   r.Category,
+		Treatment:  r.Treatment,
+		TaxRateID:  r.TaxRateID,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
+
+func toDomainTaxRule(m *infrastructure.TaxRule) *domain.TaxRule {
+	return &domain.TaxRule{
+		ID:         m.ID,
+		BusinessID: m.BusinessID,
+		ProductID:  m.ProductID,
+		Category:   m.Category,
+		Treatment:  m.Treatment,
+		TaxRateID:  m.TaxRateID,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
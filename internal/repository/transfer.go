@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransferRepo struct {
+	DB *gorm.DB
+}
+
+func (r *TransferRepo) CreateTransfer(t *domain.Transfer) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		model := infrastructure.Transfer{
+			ID:           t.ID,
+			BusinessID:   t.BusinessID,
+			FromBranchID: t.FromBranchID,
+			ToBranchID:   t.ToBranchID,
+			Status:       t.Status,
+			Note:         t.Note,
+			CreatedBy:    t.CreatedBy,
+			CreatedAt:    t.CreatedAt,
+			UpdatedAt:    t.UpdatedAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		for _, it := range t.Items {
+			item := infrastructure.TransferItem{
+				ID:              it.ID,
+				TransferID:      t.ID,
+				SourceProductID: it.SourceProductID,
+				DestProductID:   it.DestProductID,
+				BarcodeValue:    it.BarcodeValue,
+				ProductName:     it.ProductName,
+				Quantity:        it.Quantity,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *TransferRepo) GetTransferByID(id string) (*domain.Transfer, error) {
+	var model infrastructure.Transfer
+	if err := r.DB.Preload("TransferItems").Preload("TransferItems.Lots", orderTransferLots).First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toDomainTransfer(&model), nil
+}
+
+func (r *TransferRepo) ListTransfers(businessID, branchID, status string) ([]*domain.Transfer, error) {
+	var models []*infrastructure.Transfer
+	query := r.DB.Preload("TransferItems").Preload("TransferItems.Lots", orderTransferLots).Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("from_branch_id = ? OR to_branch_id = ?", branchID, branchID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var transfers []*domain.Transfer
+	for _, m := range models {
+		transfers = append(transfers, toDomainTransfer(m))
+	}
+	return transfers, nil
+}
+
+// DispatchTransfer takes the goods out of the source branch's stock; they stay in transit until received
+func (r *TransferRepo) DispatchTransfer(id, userID string) (*domain.Transfer, error) {
+	var result *domain.Transfer
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		model, err := lockTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		if model.Status != domain.TransferStatusDraft {
+			return fmt.Errorf("cannot dispatch a transfer with status %s", model.Status)
+		}
+		for i := range model.TransferItems {
+			it := &model.TransferItems[i]
+			var product infrastructure.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", it.SourceProductID).Error; err != nil {
+				return errors.New("product not found")
+			}
+			if product.QuantityInStock < it.Quantity {
+				return fmt.Errorf("%w for product %s", domain.ErrInsufficientStock, product.ID)
+			}
+			// Units set aside for a customer stay at the branch that reserved them
+			reserved, err := reservedQuantity(tx, product.ID, time.Now().Unix())
+			if err != nil {
+				return err
+			}
+			if product.QuantityInStock-reserved < it.Quantity {
+				return fmt.Errorf("%w for product %s: %d of %d on hand are reserved", domain.ErrInsufficientStock, product.ID, reserved, product.QuantityInStock)
+			}
+			// Expired and recalled lots cannot be sent to another branch
+			recalled, err := recalledLots(tx, &product)
+			if err != nil {
+				return err
+			}
+			takes, err := takeFromLots(tx, &product, it.Quantity, recalled)
+			if err != nil {
+				return err
+			}
+			for _, t := range takes {
+				sent := infrastructure.TransferItemLot{
+					ID:             uuid.NewString(),
+					TransferID:     model.ID,
+					TransferItemID: it.ID,
+					LotID:          t.Lot.ID,
+					BatchNumber:    t.Lot.BatchNumber,
+					ExpiryDate:     t.Lot.ExpiryDate,
+					CostPrice:      t.Lot.CostPrice,
+					SupplierID:     t.Lot.SupplierID,
+					Quantity:       t.Quantity,
+				}
+				if err := tx.Create(&sent).Error; err != nil {
+					return err
+				}
+				it.Lots = append(it.Lots, sent)
+			}
+			change := domain.StockChange{
+				Type:          domain.StockMovementTransferOut,
+				ActorID:       userID,
+				ReferenceType: "transfer",
+				ReferenceID:   model.ID,
+			}
+			if err := moveStock(tx, &product, -it.Quantity, change); err != nil {
+				return err
+			}
+		}
+		now := time.Now().Unix()
+		if err := tx.Model(model).Updates(map[string]interface{}{
+			"status":        domain.TransferStatusDispatched,
+			"dispatched_by": userID,
+			"dispatched_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		model.Status = domain.TransferStatusDispatched
+		model.DispatchedBy = &userID
+		model.DispatchedAt = &now
+		result = toDomainTransfer(model)
+		return nil
+	})
+	return result, err
+}
+
+func (r *TransferRepo) ReceiveTransfer(id, userID string, received map[string]int, close bool) (*domain.Transfer, error) {
+	var result *domain.Transfer
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		model, err := lockTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		if model.Status != domain.TransferStatusDispatched && model.Status != domain.TransferStatusPartiallyReceived {
+			return fmt.Errorf("cannot receive a transfer with status %s", model.Status)
+		}
+		for itemID := range received {
+			found := false
+			for _, it := range model.TransferItems {
+				if it.ID == itemID {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.New("transfer item not found")
+			}
+		}
+
+		complete := true
+		for i := range model.TransferItems {
+			it := &model.TransferItems[i]
+			qty := received[it.ID]
+			if qty > it.Quantity-it.QuantityReceived {
+				return fmt.Errorf("received quantity exceeds quantity in transit for %s", it.ProductName)
+			}
+			if qty > 0 {
+				var product infrastructure.Product
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", it.DestProductID).Error; err != nil {
+					return errors.New("product not found")
+				}
+				if err := receiveTransferLots(tx, &product, it, qty); err != nil {
+					return err
+				}
+				change := domain.StockChange{
+					Type:          domain.StockMovementTransferIn,
+					ActorID:       userID,
+					ReferenceType: "transfer",
+					ReferenceID:   model.ID,
+				}
+				if err := moveStock(tx, &product, qty, change); err != nil {
+					return err
+				}
+				it.QuantityReceived += qty
+				if err := tx.Model(it).Update("quantity_received", it.QuantityReceived).Error; err != nil {
+					return err
+				}
+			}
+			if it.QuantityReceived < it.Quantity {
+				complete = false
+			}
+		}
+
+		status := domain.TransferStatusPartiallyReceived
+		if complete || close {
+			status = domain.TransferStatusReceived
+			for i := range model.TransferItems {
+				if err := writeOffTransferLots(tx, &model.TransferItems[i]); err != nil {
+					return err
+				}
+			}
+		}
+		now := time.Now().Unix()
+		if err := tx.Model(model).Updates(map[string]interface{}{
+			"status":      status,
+			"received_by": userID,
+			"received_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		model.Status = status
+		model.ReceivedBy = &userID
+		model.ReceivedAt = &now
+		result = toDomainTransfer(model)
+		return nil
+	})
+	return result, err
+}
+
+// CancelTransfer drops a transfer that has not been dispatched yet
+func (r *TransferRepo) CancelTransfer(id string) (*domain.Transfer, error) {
+	var result *domain.Transfer
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		model, err := lockTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		if model.Status != domain.TransferStatusDraft {
+			return errors.New("only draft transfers can be cancelled")
+		}
+		if err := tx.Model(model).Update("status", domain.TransferStatusCancelled).Error; err != nil {
+			return err
+		}
+		model.Status = domain.TransferStatusCancelled
+		result = toDomainTransfer(model)
+		return nil
+	})
+	return result, err
+}
+
+// receiveTransferLots puts qty arriving units of a transfer item into the destination
+// product's lots, batch by batch in the order they were dispatched. Units that left the source
+// as unlotted stock arrive last, as unlotted stock. The product's stock itself is changed by
+// the caller.
+func receiveTransferLots(tx *gorm.DB, product *infrastructure.Product, it *infrastructure.TransferItem, qty int) error {
+	received := false
+	for i := range it.Lots {
+		l := &it.Lots[i]
+		n := min(qty, l.Quantity-l.QuantityReceived)
+		if n <= 0 {
+			continue
+		}
+		receipt := &domain.LotReceipt{
+			ProductID:   product.ID,
+			BatchNumber: l.BatchNumber,
+			ExpiryDate:  l.ExpiryDate,
+			Quantity:    n,
+			CostPrice:   l.CostPrice,
+			SupplierID:  l.SupplierID,
+		}
+		if _, err := receiveIntoLot(tx, product, receipt); err != nil {
+			return err
+		}
+		l.QuantityReceived += n
+		if err := tx.Model(l).Update("quantity_received", l.QuantityReceived).Error; err != nil {
+			return err
+		}
+		qty -= n
+		received = true
+	}
+	if !received {
+		return nil
+	}
+	return tx.Exec(refreshProductExpirySQL, product.ID, product.ID, product.ID).Error
+}
+
+// writeOffTransferLots records, for each batch of a closed transfer item, the units that never arrived
+func writeOffTransferLots(tx *gorm.DB, it *infrastructure.TransferItem) error {
+	for i := range it.Lots {
+		l := &it.Lots[i]
+		l.WrittenOff = l.Quantity - l.QuantityReceived
+		if l.WrittenOff == 0 {
+			continue
+		}
+		if err := tx.Model(l).Update("written_off", l.WrittenOff).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lockTransfer(tx *gorm.DB, id string) (*infrastructure.Transfer, error) {
+	var model infrastructure.Transfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("TransferItems").Preload("TransferItems.Lots", orderTransferLots).
+		First(&model, "id = ?", id).Error
+	if err != nil {
+		return nil, errors.New("transfer not found")
+	}
+	return &model, nil
+}
+
+// orderTransferLots lists a transfer item's batches in the order they were taken, earliest expiry first
+func orderTransferLots(db *gorm.DB) *gorm.DB {
+	return db.Order("expiry_date IS NULL").Order("expiry_date ASC")
+}
+
+func toDomainTransfer(m *infrastructure.Transfer) *domain.Transfer {
+	t := &domain.Transfer{
+		ID:           m.ID,
+		BusinessID:   m.BusinessID,
+		FromBranchID: m.FromBranchID,
+		ToBranchID:   m.ToBranchID,
+		Status:       m.Status,
+		Note:         m.Note,
+		CreatedBy:    m.CreatedBy,
+		DispatchedBy: m.DispatchedBy,
+		DispatchedAt: m.DispatchedAt,
+		ReceivedBy:   m.ReceivedBy,
+		ReceivedAt:   m.ReceivedAt,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+	for _, it := range m.TransferItems {
+		item := domain.TransferItem{
+			ID:               it.ID,
+			TransferID:       it.TransferID,
+			SourceProductID:  it.SourceProductID,
+			DestProductID:    it.DestProductID,
+			BarcodeValue:     it.BarcodeValue,
+			ProductName:      it.ProductName,
+			Quantity:         it.Quantity,
+			QuantityReceived: it.QuantityReceived,
+		}
+		for _, l := range it.Lots {
+			item.Lots = append(item.Lots, domain.TransferItemLot{
+				ID:               l.ID,
+				LotID:            l.LotID,
+				BatchNumber:      l.BatchNumber,
+				ExpiryDate:       l.ExpiryDate,
+				CostPrice:        l.CostPrice,
+				Quantity:         l.Quantity,
+				QuantityReceived: l.QuantityReceived,
+				WrittenOff:       l.WrittenOff,
+			})
+		}
+		switch m.Status {
+		case domain.TransferStatusDispatched, domain.TransferStatusPartiallyReceived:
+			item.InTransit = it.Quantity - it.QuantityReceived
+		case domain.TransferStatusReceived:
+			item.Discrepancy = it.Quantity - it.QuantityReceived
+		}
+		t.Items = append(t.Items, item)
+	}
+	return t
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+)
+
+func TestTransferKeepsLotsInTransit(t *testing.T) {
+	db := newTestDB(t)
+	expiry := time.Now().AddDate(0, 3, 0).Unix()
+	// 10 units at the source: 6 in batch B-1 and 4 unlotted
+	source := seedLots(t, db, 10, infrastructure.ProductLot{BatchNumber: "B-1", ExpiryDate: &expiry, Quantity: 6, CostPrice: 250, ReceivedAt: time.Now().Unix()})
+	dest := infrastructure.Product{ID: "product-2", ProductName: "Paracetamol", BusinessID: "business-1", BranchID: "branch-2", CreatedBy: "user-1"}
+	if err := db.Create(&dest).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &TransferRepo{DB: db}
+	transfer := &domain.Transfer{
+		ID: "transfer-1", BusinessID: "business-1", FromBranchID: "branch-1", ToBranchID: "branch-2",
+		Status: domain.TransferStatusDraft, CreatedBy: "user-1",
+		Items: []domain.TransferItem{{ID: "item-1", SourceProductID: source.ID, DestProductID: dest.ID, ProductName: "Paracetamol", Quantity: 8}},
+	}
+	if err := repo.CreateTransfer(transfer); err != nil {
+		t.Fatal(err)
+	}
+	dispatched, err := repo.DispatchTransfer(transfer.ID, "user-1")
+	if err != nil {
+		t.Fatalf("DispatchTransfer() error = %v", err)
+	}
+	if lots := dispatched.Items[0].Lots; len(lots) != 1 || lots[0].BatchNumber != "B-1" || lots[0].Quantity != 6 {
+		t.Fatalf("dispatched lots = %+v, want all 6 units of B-1", lots)
+	}
+
+	// 5 arrive, all from the batch, then the transfer is closed short
+	if _, err := repo.ReceiveTransfer(transfer.ID, "user-2", map[string]int{"item-1": 5}, false); err != nil {
+		t.Fatalf("ReceiveTransfer() error = %v", err)
+	}
+	closed, err := repo.ReceiveTransfer(transfer.ID, "user-2", nil, true)
+	if err != nil {
+		t.Fatalf("closing ReceiveTransfer() error = %v", err)
+	}
+
+	var lot infrastructure.ProductLot
+	if err := db.First(&lot, "product_id = ? AND batch_number = ?", dest.ID, "B-1").Error; err != nil {
+		t.Fatalf("no B-1 lot at the destination: %v", err)
+	}
+	if lot.Quantity != 5 || lot.CostPrice != 250 || lot.ExpiryDate == nil || *lot.ExpiryDate != expiry {
+		t.Errorf("destination lot holds %d at %.2f expiring %v, want 5 at 250.00 expiring %d", lot.Quantity, lot.CostPrice, lot.ExpiryDate, expiry)
+	}
+	item := closed.Items[0]
+	if item.Discrepancy != 3 || item.Lots[0].WrittenOff != 1 {
+		t.Errorf("closed with %d missing and %d of B-1 written off, want 3 and 1", item.Discrepancy, item.Lots[0].WrittenOff)
+	}
+}
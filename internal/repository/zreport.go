@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/internal/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ZReportRepo struct {
+	DB *gorm.DB
+}
+
+func (r *ZReportRepo) GetUnreportedSales(branchID string, until int64) ([]*domain.ZReportSale, error) {
+	var sales []*domain.ZReportSale
+	err := r.DB.Table("sales").
+		Select("sales.id, sales.cashier_id, COALESCE(staffs.full_name, '') AS cashier_name, sales.status, "+
+			"sales.gross_amount, sales.promotion_amount, sales.discount_amount, sales.tax_amount, sales.total_amount, sales.created_at").
+		Joins("LEFT JOIN staffs ON staffs.id = sales.cashier_id").
+		Where("sales.branch_id = ? AND sales.z_report_id IS NULL AND sales.created_at < ?", branchID, until).
+		Order("sales.created_at ASC").
+		Scan(&sales).Error
+	return sales, err
+}
+
+func (r *ZReportRepo) GetUnreportedItems(branchID string, until int64) ([]*domain.ZReportItem, error) {
+	var items []*domain.ZReportItem
+	err := r.DB.Table("sale_items").
+		Select("sale_items.product_id, COALESCE(products.product_name, '') AS product_name, COALESCE(products.product_category, '') AS category, "+
+			"SUM("+saleItemKept+") AS quantity, SUM("+saleItemSubtotal+") AS amount, SUM("+saleItemTax+") AS tax_amount, "+
+			"SUM("+saleItemCost+") AS cost").
+		Joins("JOIN sales ON sales.id = sale_items.sale_id").
+		Joins("LEFT JOIN products ON products.id = sale_items.product_id").
+		Where("sales.branch_id = ? AND sales.z_report_id IS NULL AND sales.created_at < ? AND sales.status <> ?", branchID, until, domain.SaleStatusVoided).
+		Group("sale_items.product_id, products.product_name, products.product_category").
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *ZReportRepo) GetUnreportedTenders(branchID string, until int64) ([]domain.TenderTotal, error) {
+	var tenders []domain.TenderTotal
+	err := r.DB.Table("sale_payments").
+		Select("sale_payments.method, SUM(sale_payments.amount) AS amount, COUNT(DISTINCT sale_payments.sale_id) AS sales_count").
+		Joins("JOIN sales ON sales.id = sale_payments.sale_id").
+		Where("sales.branch_id = ? AND sales.z_report_id IS NULL AND sales.created_at < ? AND sales.status <> ?", branchID, until, domain.SaleStatusVoided).
+		Group("sale_payments.method").
+		Scan(&tenders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Sales recorded before split payments have no payment rows; count them under their payment method
+	var legacy []domain.TenderTotal
+	err = r.DB.Model(&infrastructure.Sale{}).
+		Select("payment_method AS method, SUM(total_amount) AS amount, COUNT(*) AS sales_count").
+		Where("branch_id = ? AND z_report_id IS NULL AND created_at < ? AND status <> ?", branchID, until, domain.SaleStatusVoided).
+		Where("NOT EXISTS (SELECT 1 FROM sale_payments WHERE sale_payments.sale_id = sales.id)").
+		Group("payment_method").
+		Scan(&legacy).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range legacy {
+		merged := false
+		for i := range tenders {
+			if tenders[i].Method == l.Method {
+				tenders[i].Amount += l.Amount
+				tenders[i].SalesCount += l.SalesCount
+				merged = true
+			}
+		}
+		if !merged {
+			tenders = append(tenders, l)
+		}
+	}
+	return tenders, nil
+}
+
+func (r *ZReportRepo) GetUnreportedRefunds(branchID string, until int64) (int, float64, error) {
+	var row struct {
+		RefundCount int
+		Refunds     float64
+	}
+	err := r.DB.Model(&infrastructure.Refund{}).
+		Select("COUNT(*) AS refund_count, COALESCE(SUM(total_amount), 0) AS refunds").
+		Where(unreportedRefunds, branchID, domain.RefundStatusCompleted, until).
+		Scan(&row).Error
+	return row.RefundCount, row.Refunds, err
+}
+
+// unreportedRefunds selects a branch's completed refunds not yet on a Z-report; a refund
+// counts from when it was approved, or from when it was made if it needed no approval
+const unreportedRefunds = "branch_id = ? AND status = ? AND z_report_id IS NULL AND COALESCE(resolved_at, created_at) < ?"
+
+func (r *ZReportRepo) GetLastZReport(branchID string) (*domain.ZReport, error) {
+	var m infrastructure.ZReport
+	err := r.DB.Where("branch_id = ?", branchID).Order("number DESC").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrZReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainZReport(&m), nil
+}
+
+func (r *ZReportRepo) CreateZReport(report *domain.ZReport) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the branch so reports are numbered one at a time
+		var branch infrastructure.Branch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&branch, "id = ?", report.BranchID).Error; err != nil {
+			return errors.New("branch not found")
+		}
+		var last int
+		if err := tx.Model(&infrastructure.ZReport{}).Select("COALESCE(MAX(number), 0)").Where("branch_id = ?", report.BranchID).Scan(&last).Error; err != nil {
+			return err
+		}
+		report.Number = last + 1
+
+		marked := tx.Model(&infrastructure.Sale{}).
+			Where("branch_id = ? AND z_report_id IS NULL AND created_at < ?", report.BranchID, report.To).
+			Update("z_report_id", report.ID)
+		if marked.Error != nil {
+			return marked.Error
+		}
+		if int(marked.RowsAffected) != report.SalesCount+report.VoidedCount {
+			return domain.ErrZReportStale
+		}
+		marked = tx.Model(&infrastructure.Refund{}).
+			Where(unreportedRefunds, report.BranchID, domain.RefundStatusCompleted, report.To).
+			Update("z_report_id", report.ID)
+		if marked.Error != nil {
+			return marked.Error
+		}
+		if int(marked.RowsAffected) != report.RefundCount {
+			return domain.ErrZReportStale
+		}
+
+		m := infrastructure.ZReport{
+			ID:           report.ID,
+			BusinessID:   report.BusinessID,
+			BranchID:     report.BranchID,
+			Number:       report.Number,
+			BusinessDate: report.BusinessDate,
+			Timezone:     report.Timezone,
+			FromTime:     report.From,
+			ToTime:       report.To,
+			SalesCount:   report.SalesCount,
+			VoidedCount:  report.VoidedCount,
+			ItemsSold:    report.ItemsSold,
+			GrossSales:   report.GrossSales,
+			Promotions:   report.Promotions,
+			Discounts:    report.Discounts,
+			NetSales:     report.NetSales,
+			TaxAmount:    report.TaxAmount,
+			CostOfGoods:  report.CostOfGoods,
+			GrossProfit:  report.GrossProfit,
+			RefundCount:  report.RefundCount,
+			Refunds:      report.Refunds,
+			ClosedBy:     report.ClosedBy,
+			ClosedAt:     report.ClosedAt,
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		var lines []infrastructure.ZReportLine
+		for _, group := range [][]*domain.ZReportLine{report.Tenders, report.Categories, report.Cashiers, report.Hours, report.Items} {
+			for i, l := range group {
+				lines = append(lines, infrastructure.ZReportLine{
+					ID:         uuid.NewString(),
+					ReportID:   report.ID,
+					Kind:       l.Kind,
+					Position:   i,
+					Key:        l.Key,
+					Label:      l.Label,
+					SalesCount: l.SalesCount,
+					Quantity:   l.Quantity,
+					Amount:     l.Amount,
+					Cost:       l.Cost,
+				})
+			}
+		}
+		if len(lines) > 0 {
+			return tx.Create(&lines).Error
+		}
+		return nil
+	})
+}
+
+func (r *ZReportRepo) GetZReportByID(id string) (*domain.ZReport, error) {
+	var m infrastructure.ZReport
+	err := r.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("kind ASC").Order("position ASC")
+	}).First(&m, "id = ?", id).Error
+	if err != nil {
+		return nil, domain.ErrZReportNotFound
+	}
+	report := toDomainZReport(&m)
+	for _, l := range m.Lines {
+		line := &domain.ZReportLine{
+			Kind:       l.Kind,
+			Key:        l.Key,
+			Label:      l.Label,
+			SalesCount: l.SalesCount,
+			Quantity:   l.Quantity,
+			Amount:     l.Amount,
+			Cost:       l.Cost,
+		}
+		switch l.Kind {
+		case domain.ZReportLineTender:
+			report.Tenders = append(report.Tenders, line)
+		case domain.ZReportLineCategory:
+			report.Categories = append(report.Categories, line)
+		case domain.ZReportLineCashier:
+			report.Cashiers = append(report.Cashiers, line)
+		case domain.ZReportLineHour:
+			report.Hours = append(report.Hours, line)
+		case domain.ZReportLineItem:
+			report.Items = append(report.Items, line)
+		}
+	}
+	return report, nil
+}
+
+func (r *ZReportRepo) GetZReports(businessID, branchID string, limit, offset int) ([]*domain.ZReport, error) {
+	query := r.DB.Where("business_id = ?", businessID)
+	if branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	var models []*infrastructure.ZReport
+	if err := query.Order("closed_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var reports []*domain.ZReport
+	for _, m := range models {
+		reports = append(reports, toDomainZReport(m))
+	}
+	return reports, nil
+}
+
+func toDomainZReport(m *infrastructure.ZReport) *domain.ZReport {
+	return &domain.ZReport{
+		ID:           m.ID,
+		BusinessID:   m.BusinessID,
+		BranchID:     m.BranchID,
+		Number:       m.Number,
+		BusinessDate: m.BusinessDate,
+		Timezone:     m.Timezone,
+		From:         m.FromTime,
+		To:           m.ToTime,
+		SalesCount:   m.SalesCount,
+		VoidedCount:  m.VoidedCount,
+		ItemsSold:    m.ItemsSold,
+		GrossSales:   m.GrossSales,
+		Promotions:   m.Promotions,
+		Discounts:    m.Discounts,
+		NetSales:     m.NetSales,
+		TaxAmount:    m.TaxAmount,
+		CostOfGoods:  m.CostOfGoods,
+		GrossProfit:  m.GrossProfit,
+		RefundCount:  m.RefundCount,
+		Refunds:      m.Refunds,
+		ClosedBy:     m.ClosedBy,
+		ClosedAt:     m.ClosedAt,
+	}
+}
@@ -119,11 +119,13 @@ func (u *BusinessUsecase) Login(email, password string, authRepo domain.AuthRepo
 
 	utils.Logger.Info("Password verified, generating tokens", zap.String("email", email))
 
-	role := "owner"
-	access, refresh, err := authRepo.CreateToken(
-		business.ID,
-		role,
-	)
+	role := string(domain.RoleOwner)
+	access, refresh, err := authRepo.CreateToken(&domain.AuthIdentity{
+		UserID:     business.ID,
+		BusinessID: business.ID,
+		UserType:   domain.UserTypeOwner,
+		Role:       role,
+	})
 	if err != nil {
 		utils.Logger.Error("Token generation failed", zap.Error(err))
 		return "", "", "", "", errors.New("token generation failed")
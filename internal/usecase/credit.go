@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type CreditUsecase struct {
+	CreditRepo   domain.CreditRepository
+	CustomerRepo domain.CustomerRepository
+}
+
+// CreditAccountRequest is the body for opening or updating a credit account
+type CreditAccountRequest struct {
+	CustomerID  string  `json:"customer_id"`
+	CreditLimit float64 `json:"credit_limit"`
+	Active      *bool   `json:"active"`
+}
+
+// RepaymentRequest records money a debtor paid towards their balance
+type RepaymentRequest struct {
+	Amount    float64 `json:"amount"`
+	Method    string  `json:"method"`
+	Reference string  `json:"reference"`
+	Note      string  `json:"note"`
+	// Branch where the money was received, for cash-up
+	BranchID string `json:"branch_id"`
+}
+
+// CreditStatement is a credit account with a page of its ledger, newest first
+type CreditStatement struct {
+	Account *domain.CreditAccount `json:"account"`
+	Entries []*domain.CreditEntry `json:"entries"`
+}
+
+// DebtorsAgeingReport is what every debtor owes, split by how long it has been outstanding
+type DebtorsAgeingReport struct {
+	AsOf       int64                  `json:"as_of"`
+	Current    float64                `json:"current"`
+	Days31To60 float64                `json:"days_31_60"`
+	Days61To90 float64                `json:"days_61_90"`
+	Over90Days float64                `json:"over_90_days"`
+	Total      float64                `json:"total"`
+	Debtors    []*domain.DebtorAgeing `json:"debtors"`
+}
+
+// CreateAccount opens a credit account for one of the business's customers
+func (u *CreditUsecase) CreateAccount(businessID, createdBy string, req *CreditAccountRequest) (*domain.CreditAccount, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	customer, err := u.CustomerRepo.GetCustomerByID(req.CustomerID)
+	if err != nil || customer.BusinessID != businessID {
+		return nil, errors.New("customer not found")
+	}
+	if req.CreditLimit < 0 {
+		return nil, errors.New("credit_limit cannot be negative")
+	}
+	if _, err := u.CreditRepo.GetAccountByCustomerID(customer.ID); err == nil {
+		return nil, errors.New("the customer already has a credit account")
+	}
+	now := time.Now().Unix()
+	account := &domain.CreditAccount{
+		ID:            utils.GenerateUUID(),
+		BusinessID:    businessID,
+		CustomerID:    customer.ID,
+		CreditLimit:   roundMoney(req.CreditLimit),
+		Active:        req.Active == nil || *req.Active,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		CustomerName:  customer.Name,
+		CustomerPhone: customer.Phone,
+	}
+	if err := u.CreditRepo.CreateAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (u *CreditUsecase) GetAccounts(businessID string) ([]*domain.CreditAccount, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.CreditRepo.GetAccountsByBusinessID(businessID)
+}
+
+func (u *CreditUsecase) GetAccount(id, businessID string) (*domain.CreditAccount, error) {
+	account, err := u.CreditRepo.GetAccountByID(id)
+	if err != nil || account.BusinessID != businessID {
+		return nil, domain.ErrCreditAccountNotFound
+	}
+	return account, nil
+}
+
+// Statement returns a credit account and a page of its charges, repayments and reversals
+func (u *CreditUsecase) Statement(id, businessID string, limit, offset int) (*CreditStatement, error) {
+	account, err := u.GetAccount(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := u.CreditRepo.GetEntries(account.ID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*domain.CreditEntry{}
+	}
+	return &CreditStatement{Account: account, Entries: entries}, nil
+}
+
+// UpdateAccount changes an account's limit and whether it may take new credit sales. Lowering
+// the limit below the balance blocks further credit until the debtor pays down.
+func (u *CreditUsecase) UpdateAccount(id, businessID string, req *CreditAccountRequest) (*domain.CreditAccount, error) {
+	account, err := u.GetAccount(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if req.CreditLimit < 0 {
+		return nil, errors.New("credit_limit cannot be negative")
+	}
+	account.CreditLimit = roundMoney(req.CreditLimit)
+	if req.Active != nil {
+		account.Active = *req.Active
+	}
+	account.UpdatedAt = time.Now().Unix()
+	if err := u.CreditRepo.UpdateAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// RecordRepayment takes a payment off a debtor's balance, settling their oldest charges first
+func (u *CreditUsecase) RecordRepayment(id, businessID, recordedBy string, req *RepaymentRequest) (*domain.CreditEntry, *domain.CreditAccount, error) {
+	account, err := u.GetAccount(id, businessID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, nil, errors.New("amount must be greater than zero")
+	}
+	method := utils.Sanitize(req.Method)
+	if method == "" {
+		method = domain.PaymentMethodCash
+	}
+	entry := &domain.CreditEntry{
+		ID:         utils.GenerateUUID(),
+		AccountID:  account.ID,
+		BusinessID: businessID,
+		BranchID:   req.BranchID,
+		Type:       domain.CreditEntryRepayment,
+		Amount:     roundMoney(req.Amount),
+		Method:     method,
+		Reference:  utils.Sanitize(req.Reference),
+		Note:       utils.Sanitize(req.Note),
+		RecordedBy: recordedBy,
+		CreatedAt:  time.Now().Unix(),
+	}
+	account, err = u.CreditRepo.RecordRepayment(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, account, nil
+}
+
+// AgeingReport buckets every debtor's unpaid charges by age: 0-30, 31-60, 61-90 and over 90 days.
+// Debtors who owe the most come first.
+func (u *CreditUsecase) AgeingReport(businessID string, asOf time.Time) (*DebtorsAgeingReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	accounts, err := u.CreditRepo.GetAccountsByBusinessID(businessID)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := u.CreditRepo.GetOutstandingCharges(businessID)
+	if err != nil {
+		return nil, err
+	}
+	byAccount := make(map[string]*domain.DebtorAgeing)
+	for _, a := range accounts {
+		byAccount[a.ID] = &domain.DebtorAgeing{
+			AccountID:     a.ID,
+			CustomerID:    a.CustomerID,
+			CustomerName:  a.CustomerName,
+			CustomerPhone: a.CustomerPhone,
+			CreditLimit:   a.CreditLimit,
+		}
+	}
+	report := &DebtorsAgeingReport{AsOf: asOf.Unix(), Debtors: []*domain.DebtorAgeing{}}
+	var total domain.DebtorAgeing
+	for _, c := range charges {
+		debtor, ok := byAccount[c.AccountID]
+		if !ok {
+			continue
+		}
+		age := int((asOf.Unix() - c.CreatedAt) / (24 * 60 * 60))
+		debtor.Add(c.Outstanding, age)
+		total.Add(c.Outstanding, age)
+	}
+	for _, d := range byAccount {
+		if d.Total <= 0 {
+			continue
+		}
+		d.Current = roundMoney(d.Current)
+		d.Days31To60 = roundMoney(d.Days31To60)
+		d.Days61To90 = roundMoney(d.Days61To90)
+		d.Over90Days = roundMoney(d.Over90Days)
+		d.Total = roundMoney(d.Total)
+		report.Debtors = append(report.Debtors, d)
+	}
+	sort.Slice(report.Debtors, func(i, j int) bool {
+		return report.Debtors[i].Total > report.Debtors[j].Total
+	})
+	report.Current = roundMoney(total.Current)
+	report.Days31To60 = roundMoney(total.Days31To60)
+	report.Days61To90 = roundMoney(total.Days61To90)
+	report.Over90Days = roundMoney(total.Over90Days)
+	report.Total = roundMoney(total.Total)
+	return report, nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type CustomerUsecase struct {
+	CustomerRepo domain.CustomerRepository
+}
+
+// CustomerHistory is a customer's record together with their purchases and lifetime value
+type CustomerHistory struct {
+	Customer *domain.Customer         `json:"customer"`
+	Lifetime *domain.CustomerLifetime `json:"lifetime"`
+	Sales    []*domain.Sale           `json:"sales"`
+}
+
+func (u *CustomerUsecase) CreateCustomer(c *domain.Customer) error {
+	if c.BusinessID == "" {
+		return errors.New("missing business_id")
+	}
+	if err := u.prepareCustomer(c); err != nil {
+		return err
+	}
+	c.ID = utils.GenerateUUID()
+	now := time.Now().Unix()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return u.CustomerRepo.CreateCustomer(c)
+}
+
+func (u *CustomerUsecase) GetCustomer(id, businessID string) (*domain.Customer, error) {
+	c, err := u.CustomerRepo.GetCustomerByID(id)
+	if err != nil || c.BusinessID != businessID {
+		return nil, errors.New("customer not found")
+	}
+	return c, nil
+}
+
+// FindByPhone looks a customer up by phone number at the till; any common way of writing
+// a Nigerian number matches
+func (u *CustomerUsecase) FindByPhone(businessID, phone string) (*domain.Customer, error) {
+	phone = normalizePhone(phone)
+	if phone == "" {
+		return nil, errors.New("a valid phone number is required")
+	}
+	c, err := u.CustomerRepo.GetCustomerByPhone(businessID, phone)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	return c, nil
+}
+
+func (u *CustomerUsecase) GetCustomers(businessID, search string, limit, offset int) ([]*domain.Customer, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.CustomerRepo.GetCustomersByBusinessID(businessID, utils.Sanitize(search), limit, offset)
+}
+
+func (u *CustomerUsecase) UpdateCustomer(c *domain.Customer) error {
+	existing, err := u.GetCustomer(c.ID, c.BusinessID)
+	if err != nil {
+		return err
+	}
+	if err := u.prepareCustomer(c); err != nil {
+		return err
+	}
+	c.CreatedAt = existing.CreatedAt
+	c.UpdatedAt = time.Now().Unix()
+	return u.CustomerRepo.UpdateCustomer(c)
+}
+
+func (u *CustomerUsecase) DeleteCustomer(id, businessID string) error {
+	if _, err := u.GetCustomer(id, businessID); err != nil {
+		return err
+	}
+	return u.CustomerRepo.DeleteCustomer(id)
+}
+
+// GetHistory returns a customer's sales, newest first, and their lifetime value.
+// With a branch, only purchases made in that branch are counted.
+func (u *CustomerUsecase) GetHistory(id, businessID, branchID string, limit, offset int) (*CustomerHistory, error) {
+	c, err := u.GetCustomer(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	lifetime, err := u.CustomerRepo.GetCustomerLifetime(c.ID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	lifetime.TotalSpent = roundMoney(lifetime.TotalSpent)
+	lifetime.TotalRefunded = roundMoney(lifetime.TotalRefunded)
+	lifetime.LifetimeValue = roundMoney(lifetime.LifetimeValue)
+	if lifetime.SalesCount > 0 {
+		lifetime.AverageSale = roundMoney(lifetime.TotalSpent / float64(lifetime.SalesCount))
+	}
+	sales, err := u.CustomerRepo.GetCustomerSales(c.ID, branchID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if sales == nil {
+		sales = []*domain.Sale{}
+	}
+	return &CustomerHistory{Customer: c, Lifetime: lifetime, Sales: sales}, nil
+}
+
+// prepareCustomer sanitises and validates c; phone numbers must be unique within the business
+func (u *CustomerUsecase) prepareCustomer(c *domain.Customer) error {
+	c.Name = utils.Sanitize(c.Name)
+	c.Email = utils.Sanitize(c.Email)
+	c.Notes = utils.Sanitize(c.Notes)
+	if c.Name == "" {
+		return errors.New("customer name is required")
+	}
+	if c.Phone != "" {
+		c.Phone = normalizePhone(c.Phone)
+		if c.Phone == "" {
+			return errors.New("invalid phone number")
+		}
+		if existing, err := u.CustomerRepo.GetCustomerByPhone(c.BusinessID, c.Phone); err == nil && existing.ID != c.ID {
+			return errors.New("a customer with this phone number already exists")
+		}
+	}
+	return nil
+}
+
+// normalizePhone writes a phone number in international form so the same number always
+// matches: 0803 123 4567, 2348031234567 and +234 803 123 4567 all become +2348031234567.
+// Returns "" when the input is not a plausible phone number.
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	switch {
+	case len(d) == 11 && strings.HasPrefix(d, "0"):
+		d = "234" + d[1:]
+	case len(d) == 10 && !strings.HasPrefix(d, "0") && !strings.HasPrefix(strings.TrimSpace(phone), "+"):
+		d = "234" + d
+	}
+	if len(d) < 8 || len(d) > 15 {
+		return ""
+	}
+	return "+" + d
+}
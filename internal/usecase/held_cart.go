@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+type HeldCartUsecase struct {
+	HeldCartRepo domain.HeldCartRepository
+	ProductRepo  domain.ProductRepository
+	BranchRepo   domain.BranchRepository
+	SaleUC       *SaleUsecase
+}
+
+// HoldCartRequest parks a basket at a branch under a label the till can find it by
+type HoldCartRequest struct {
+	BranchID       string            `json:"branch_id"`
+	Label          string            `json:"label"`
+	CustomerID     *string           `json:"customer_id,omitempty"`
+	CustomerPhone  string            `json:"customer_phone,omitempty"`
+	Items          []SaleItemRequest `json:"items"`
+	Discount       *domain.Discount  `json:"discount,omitempty"`
+	DiscountReason string            `json:"discount_reason"`
+}
+
+// CheckoutHeldCartRequest pays for a held cart, as payments or a single payment_method
+// like CreateSaleRequest
+type CheckoutHeldCartRequest struct {
+	PaymentMethod string               `json:"payment_method"`
+	Payments      []SalePaymentRequest `json:"payments"`
+}
+
+// ResumedCart is a held cart with what has changed since it was held
+type ResumedCart struct {
+	*domain.HeldCart
+	Warnings []domain.HeldCartWarning `json:"warnings"`
+}
+
+// HoldCart parks a basket at the branch; the stock stays available to other sales
+func (u *HeldCartUsecase) HoldCart(req *HoldCartRequest, businessID, userID string) (*domain.HeldCart, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	branch, err := u.BranchRepo.GetBranchByID(req.BranchID)
+	if err != nil || branch.BusinessID != businessID {
+		return nil, errors.New("branch not found")
+	}
+	now := time.Now().Unix()
+	cart := &domain.HeldCart{
+		ID:         utils.GenerateUUID(),
+		BusinessID: businessID,
+		BranchID:   branch.ID,
+		HeldBy:     userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := u.fill(cart, req); err != nil {
+		return nil, err
+	}
+	if err := u.HeldCartRepo.CreateHeldCart(cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (u *HeldCartUsecase) GetHeldCarts(businessID, branchID string) ([]*domain.HeldCart, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.HeldCartRepo.GetHeldCarts(businessID, branchID)
+}
+
+// FindHeldCart returns a held cart of the business
+func (u *HeldCartUsecase) FindHeldCart(id, businessID string) (*domain.HeldCart, error) {
+	cart, err := u.HeldCartRepo.GetHeldCartByID(id)
+	if err != nil || cart.BusinessID != businessID {
+		return nil, domain.ErrHeldCartNotFound
+	}
+	return cart, nil
+}
+
+// ResumeHeldCart returns a held cart with a warning for every product whose price has
+// changed, that no longer has enough stock, or that has gone since the cart was held
+func (u *HeldCartUsecase) ResumeHeldCart(id, businessID string) (*ResumedCart, error) {
+	cart, err := u.FindHeldCart(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	resumed := &ResumedCart{HeldCart: cart, Warnings: []domain.HeldCartWarning{}}
+	wanted := map[string]int{}
+	for _, it := range cart.Items {
+		wanted[it.ProductID] += it.Quantity
+	}
+	checked := map[string]bool{}
+	for _, it := range cart.Items {
+		if checked[it.ProductID] {
+			continue
+		}
+		checked[it.ProductID] = true
+		product, err := u.ProductRepo.GetProductByID(it.ProductID)
+		if err != nil || product.BusinessID != businessID || product.DeletedAt != nil {
+			resumed.Warnings = append(resumed.Warnings, domain.HeldCartWarning{
+				Type:        domain.HeldCartWarningUnavailable,
+				ProductID:   it.ProductID,
+				ProductName: it.ProductName,
+			})
+			continue
+		}
+		if product.SellingPrice != it.UnitPrice {
+			resumed.Warnings = append(resumed.Warnings, domain.HeldCartWarning{
+				Type:         domain.HeldCartWarningPriceChanged,
+				ProductID:    product.ID,
+				ProductName:  product.ProductName,
+				HeldPrice:    it.UnitPrice,
+				CurrentPrice: product.SellingPrice,
+			})
+		}
+		if product.QuantityInStock < wanted[product.ID] {
+			resumed.Warnings = append(resumed.Warnings, domain.HeldCartWarning{
+				Type:        domain.HeldCartWarningInsufficientStock,
+				ProductID:   product.ID,
+				ProductName: product.ProductName,
+				Quantity:    wanted[product.ID],
+				InStock:     product.QuantityInStock,
+			})
+		}
+	}
+	return resumed, nil
+}
+
+// UpdateHeldCart replaces a held cart's basket, e.g. after the customer adds an item
+func (u *HeldCartUsecase) UpdateHeldCart(id, businessID string, req *HoldCartRequest) (*domain.HeldCart, error) {
+	cart, err := u.FindHeldCart(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.fill(cart, req); err != nil {
+		return nil, err
+	}
+	cart.UpdatedAt = time.Now().Unix()
+	if err := u.HeldCartRepo.UpdateHeldCart(cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// DiscardHeldCart throws a held cart away
+func (u *HeldCartUsecase) DiscardHeldCart(id, businessID string) error {
+	cart, err := u.FindHeldCart(id, businessID)
+	if err != nil {
+		return err
+	}
+	return u.HeldCartRepo.DeleteHeldCart(cart.ID)
+}
+
+// CheckoutHeldCart turns a held cart into a sale rung up by cashierID at today's prices. The
+// cart is taken off hold first so two tills cannot sell it twice, and put back if the sale fails.
+func (u *HeldCartUsecase) CheckoutHeldCart(id, businessID, cashierID string, role domain.StaffRole, req *CheckoutHeldCartRequest) (*CreateSaleResponse, error) {
+	cart, err := u.FindHeldCart(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.HeldCartRepo.DeleteHeldCart(cart.ID); err != nil {
+		return nil, err
+	}
+	sale := &CreateSaleRequest{
+		BranchID:       cart.BranchID,
+		CustomerID:     cart.CustomerID,
+		CustomerPhone:  cart.CustomerPhone,
+		PaymentMethod:  req.PaymentMethod,
+		Payments:       req.Payments,
+		Discount:       cart.Discount,
+		DiscountReason: cart.DiscountReason,
+	}
+	for _, it := range cart.Items {
+		sale.Items = append(sale.Items, SaleItemRequest{
+			ProductID: it.ProductID,
+			Quantity:  it.Quantity,
+			Discount:  it.Discount,
+		})
+	}
+	resp, err := u.SaleUC.CreateSale(sale, businessID, cashierID, role)
+	if err != nil {
+		if restoreErr := u.HeldCartRepo.CreateHeldCart(cart); restoreErr != nil {
+			utils.Logger.Error("Failed to put held cart back after checkout failed", zap.String("cart_id", cart.ID), zap.Error(restoreErr))
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// fill copies the request's basket onto the cart, noting each product's name and price
+func (u *HeldCartUsecase) fill(cart *domain.HeldCart, req *HoldCartRequest) error {
+	label := strings.TrimSpace(utils.Sanitize(req.Label))
+	if label == "" {
+		return errors.New("label is required")
+	}
+	if len(req.Items) == 0 {
+		return errors.New("a held cart needs at least one item")
+	}
+	var items []domain.HeldCartItem
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			return errors.New("quantity must be greater than 0")
+		}
+		product, err := u.ProductRepo.GetProductByID(it.ProductID)
+		if err != nil || product.BusinessID != cart.BusinessID {
+			return errors.New("product not found")
+		}
+		items = append(items, domain.HeldCartItem{
+			ProductID:   product.ID,
+			ProductName: product.ProductName,
+			Quantity:    it.Quantity,
+			UnitPrice:   product.SellingPrice,
+			Discount:    it.Discount,
+		})
+	}
+	cart.Label = label
+	cart.CustomerID = req.CustomerID
+	cart.CustomerPhone = strings.TrimSpace(req.CustomerPhone)
+	cart.Items = items
+	cart.Discount = req.Discount
+	cart.DiscountReason = utils.Sanitize(req.DiscountReason)
+	return nil
+}
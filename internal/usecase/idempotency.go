@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyUsecase struct {
+	IdempotencyRepo domain.IdempotencyRepository
+}
+
+// Begin claims key for a request to endpoint with the given body. It returns nil when the
+// request should go ahead, or the stored response when the same request already succeeded.
+// A key sent again by another user, to another endpoint or with another body is refused, as
+// is a retry while the first request is still within its lease.
+func (u *IdempotencyUsecase) Begin(businessID, userID, key, endpoint string, body []byte) (*domain.IdempotencyRecord, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, domain.ErrInvalidIdempotencyKey
+	}
+	sum := sha256.Sum256(body)
+	now := time.Now()
+	rec := &domain.IdempotencyRecord{
+		ID:          utils.GenerateUUID(),
+		BusinessID:  businessID,
+		Key:         key,
+		UserID:      userID,
+		Endpoint:    endpoint,
+		RequestHash: hex.EncodeToString(sum[:]),
+		ClaimedAt:   now.Unix(),
+		CreatedAt:   now.Unix(),
+	}
+	existing, err := u.IdempotencyRepo.ClaimIdempotencyKey(rec, now.Add(-domain.IdempotencyKeyTTL).Unix())
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.UserID != rec.UserID || existing.Endpoint != rec.Endpoint || existing.RequestHash != rec.RequestHash {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		// The first attempt died without answering; this retry runs the request instead
+		took, err := u.IdempotencyRepo.TakeOverIdempotencyKey(businessID, key, now.Unix(), now.Add(-domain.IdempotencyLease).Unix())
+		if err != nil {
+			return nil, err
+		}
+		if !took {
+			return nil, domain.ErrIdempotencyKeyInFlight
+		}
+		return nil, nil
+	}
+	return existing, nil
+}
+
+// Finish stores a successful response for replay. Any other outcome frees the key, since
+// nothing was recorded and the client may retry, possibly with a corrected request.
+func (u *IdempotencyUsecase) Finish(businessID, key string, statusCode int, contentType string, response []byte) {
+	var err error
+	if statusCode >= 200 && statusCode < 300 {
+		err = u.IdempotencyRepo.SaveIdempotentResponse(businessID, key, statusCode, contentType, string(response))
+	} else {
+		err = u.IdempotencyRepo.DeleteIdempotencyKey(businessID, key)
+	}
+	if err != nil {
+		utils.Logger.Error("Failed to finish idempotent request", zap.String("businessID", businessID), zap.String("key", key), zap.Error(err))
+	}
+}
+
+// RunCleanupJob deletes records older than domain.IdempotencyKeyTTL each interval. It never returns.
+func (u *IdempotencyUsecase) RunCleanupJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		deleted, err := u.IdempotencyRepo.DeleteIdempotencyKeysBefore(time.Now().Add(-domain.IdempotencyKeyTTL).Unix())
+		if err != nil {
+			utils.Logger.Error("Idempotency key cleanup failed", zap.Error(err))
+			continue
+		}
+		if deleted > 0 {
+			utils.Logger.Info("Deleted expired idempotency keys", zap.Int("keys", deleted))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+// memoryIdempotencyRepo keeps idempotency records in a map, by business and key
+type memoryIdempotencyRepo map[string]*domain.IdempotencyRecord
+
+func (r memoryIdempotencyRepo) ClaimIdempotencyKey(rec *domain.IdempotencyRecord, expiredBefore int64) (*domain.IdempotencyRecord, error) {
+	if existing, ok := r[rec.BusinessID+"/"+rec.Key]; ok && existing.CreatedAt >= expiredBefore {
+		copied := *existing
+		return &copied, nil
+	}
+	copied := *rec
+	r[rec.BusinessID+"/"+rec.Key] = &copied
+	return nil, nil
+}
+
+func (r memoryIdempotencyRepo) TakeOverIdempotencyKey(businessID, key string, claimedAt, abandonedBefore int64) (bool, error) {
+	rec, ok := r[businessID+"/"+key]
+	if !ok || rec.StatusCode != 0 || rec.ClaimedAt >= abandonedBefore {
+		return false, nil
+	}
+	rec.ClaimedAt = claimedAt
+	return true, nil
+}
+
+func (r memoryIdempotencyRepo) SaveIdempotentResponse(businessID, key string, statusCode int, contentType, response string) error {
+	rec := r[businessID+"/"+key]
+	rec.StatusCode, rec.ContentType, rec.Response = statusCode, contentType, response
+	return nil
+}
+
+func (r memoryIdempotencyRepo) DeleteIdempotencyKey(businessID, key string) error {
+	delete(r, businessID+"/"+key)
+	return nil
+}
+
+func (r memoryIdempotencyRepo) DeleteIdempotencyKeysBefore(before int64) (int, error) {
+	return 0, nil
+}
+
+const saleEndpoint = "POST /api/sales/create"
+
+func TestIdempotentSaleIsReplayed(t *testing.T) {
+	uc := &IdempotencyUsecase{IdempotencyRepo: memoryIdempotencyRepo{}}
+	body := []byte(`{"branch_id":"branch-1"}`)
+
+	if replay, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, body); replay != nil || err != nil {
+		t.Fatalf("first Begin() = %v, %v; want the request to go ahead", replay, err)
+	}
+	if _, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, body); !errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		t.Fatalf("retry while running: error = %v, want %v", err, domain.ErrIdempotencyKeyInFlight)
+	}
+
+	uc.Finish("business-1", "key-1", 201, "application/json", []byte(`{"success":true}`))
+	replay, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, body)
+	if err != nil || replay == nil {
+		t.Fatalf("retry after success = %v, %v; want the stored response", replay, err)
+	}
+	if replay.StatusCode != 201 || replay.Response != `{"success":true}` {
+		t.Errorf("replayed %d %s, want 201 {\"success\":true}", replay.StatusCode, replay.Response)
+	}
+
+	// The key stays bound to the request that first used it
+	if _, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, []byte(`{"branch_id":"branch-2"}`)); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("another body: error = %v, want %v", err, domain.ErrIdempotencyKeyReused)
+	}
+	if _, err := uc.Begin("business-1", "user-2", "key-1", saleEndpoint, body); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("another user: error = %v, want %v", err, domain.ErrIdempotencyKeyReused)
+	}
+	if _, err := uc.Begin("business-1", "user-1", "key-1", "POST /api/product/add", body); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("another endpoint: error = %v, want %v", err, domain.ErrIdempotencyKeyReused)
+	}
+	// Keys are per business
+	if replay, err := uc.Begin("business-2", "user-1", "key-1", saleEndpoint, body); replay != nil || err != nil {
+		t.Errorf("another business = %v, %v; want the request to go ahead", replay, err)
+	}
+}
+
+func TestFailedIdempotentRequestCanBeRetried(t *testing.T) {
+	for _, status := range []int{0, 400, 409, 500} {
+		uc := &IdempotencyUsecase{IdempotencyRepo: memoryIdempotencyRepo{}}
+		if _, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, nil); err != nil {
+			t.Fatalf("Begin() error = %v", err)
+		}
+		uc.Finish("business-1", "key-1", status, "application/json", []byte(`{"success":false}`))
+		if replay, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, []byte(`{"fixed":true}`)); replay != nil || err != nil {
+			t.Errorf("retry after %d = %v, %v; want the request to run again", status, replay, err)
+		}
+	}
+}
+
+func TestAbandonedIdempotentRequestIsTakenOver(t *testing.T) {
+	repo := memoryIdempotencyRepo{}
+	uc := &IdempotencyUsecase{IdempotencyRepo: repo}
+	if _, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, nil); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	// The first attempt died without answering and its lease ran out
+	repo["business-1/key-1"].ClaimedAt -= int64((domain.IdempotencyLease + time.Second) / time.Second)
+
+	if replay, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, nil); replay != nil || err != nil {
+		t.Fatalf("retry after the lease = %v, %v; want it to take the request over", replay, err)
+	}
+	// The retry now holds a fresh lease
+	if _, err := uc.Begin("business-1", "user-1", "key-1", saleEndpoint, nil); !errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		t.Errorf("second retry: error = %v, want %v", err, domain.ErrIdempotencyKeyInFlight)
+	}
+}
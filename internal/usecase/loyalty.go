@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+type LoyaltyUsecase struct {
+	LoyaltyRepo  domain.LoyaltyRepository
+	CustomerRepo domain.CustomerRepository
+	SettingsRepo domain.SettingsRepository
+}
+
+// Statement returns a customer's points balance, what it is worth at the till, and a page of
+// their earn/burn history, newest first
+func (u *LoyaltyUsecase) Statement(customerID, businessID string, limit, offset int) (*domain.LoyaltyStatement, error) {
+	customer, err := u.CustomerRepo.GetCustomerByID(customerID)
+	if err != nil || customer.BusinessID != businessID {
+		return nil, errors.New("customer not found")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := u.LoyaltyRepo.GetLoyaltyBalance(customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := u.LoyaltyRepo.GetLoyaltyEntries(customer.ID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*domain.LoyaltyEntry{}
+	}
+	return &domain.LoyaltyStatement{
+		CustomerID:   customer.ID,
+		Balance:      balance,
+		BalanceValue: roundMoney(float64(balance) * settings.LoyaltyPointValue),
+		Entries:      entries,
+	}, nil
+}
@@ -9,7 +9,8 @@ import (
 )
 
 type ProductUsecase struct {
-	ProductRepo domain.ProductRepository
+	ProductRepo     domain.ProductRepository
+	ReservationRepo domain.ReservationRepository
 }
 
 func (u *ProductUsecase) AddProduct(p *domain.Product) error {
@@ -169,3 +170,12 @@ func (u *ProductUsecase) GetLowStockProducts(businessID string) ([]*domain.Produ
 	}
 	return u.ProductRepo.GetLowStockProducts(businessID)
 }
+
+// ReservedQuantities returns how many units of each product are held by reservations
+func (u *ProductUsecase) ReservedQuantities(products []*domain.Product) (map[string]int, error) {
+	var ids []string
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return u.ReservationRepo.GetReservedQuantities(ids, time.Now().Unix())
+}
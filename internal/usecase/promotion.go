@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type PromotionUsecase struct {
+	PromotionRepo domain.PromotionRepository
+	ProductRepo   domain.ProductRepository
+	BranchRepo    domain.BranchRepository
+}
+
+// PromotionRequest creates or replaces a promotion. Only the fields used by the promotion's
+// type are kept: buy_quantity and get_quantity for buy_x_get_y, bundle_quantity and
+// bundle_price for bundle, price for price_override and percent for category_percent.
+type PromotionRequest struct {
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	BranchID       *string  `json:"branch_id"`
+	ProductIDs     []string `json:"product_ids"`
+	Category       *string  `json:"category"`
+	BuyQuantity    int      `json:"buy_quantity"`
+	GetQuantity    int      `json:"get_quantity"`
+	BundleQuantity int      `json:"bundle_quantity"`
+	BundlePrice    float64  `json:"bundle_price"`
+	Price          float64  `json:"price"`
+	Percent        float64  `json:"percent"`
+	StartsAt       int64    `json:"starts_at"`
+	EndsAt         *int64   `json:"ends_at"`
+	DailyStart     string   `json:"daily_start"`
+	DailyEnd       string   `json:"daily_end"`
+	Active         *bool    `json:"active"`
+}
+
+// PromotionReport is what promotions gave away over a period
+type PromotionReport struct {
+	From            int64                   `json:"from"`
+	To              int64                   `json:"to"`
+	Revenue         float64                 `json:"revenue"`
+	PromotionAmount float64                 `json:"promotion_amount"`
+	Promotions      []domain.PromotionTotal `json:"promotions"`
+}
+
+func (u *PromotionUsecase) CreatePromotion(req *PromotionRequest, businessID, userID string) (*domain.Promotion, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	now := time.Now().Unix()
+	p := &domain.Promotion{
+		ID:         utils.GenerateUUID(),
+		BusinessID: businessID,
+		Active:     true,
+		CreatedBy:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := u.applyRequest(p, req); err != nil {
+		return nil, err
+	}
+	if err := u.PromotionRepo.CreatePromotion(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (u *PromotionUsecase) ListPromotions(businessID string, activeOnly bool) ([]*domain.Promotion, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.PromotionRepo.GetPromotionsByBusinessID(businessID, activeOnly)
+}
+
+func (u *PromotionUsecase) GetPromotion(id, businessID string) (*domain.Promotion, error) {
+	p, err := u.PromotionRepo.GetPromotionByID(id)
+	if err != nil || p.BusinessID != businessID {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return p, nil
+}
+
+// UpdatePromotion replaces a promotion's rules. Sales already made keep the prices they were given.
+func (u *PromotionUsecase) UpdatePromotion(id, businessID string, req *PromotionRequest) (*domain.Promotion, error) {
+	p, err := u.GetPromotion(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.applyRequest(p, req); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = time.Now().Unix()
+	if err := u.PromotionRepo.UpdatePromotion(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// EndPromotion stops a promotion straight away; it stays listed for reporting
+func (u *PromotionUsecase) EndPromotion(id, businessID string) (*domain.Promotion, error) {
+	p, err := u.GetPromotion(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	p.Active = false
+	if p.EndsAt == nil || *p.EndsAt > now {
+		p.EndsAt = &now
+	}
+	p.UpdatedAt = now
+	if err := u.PromotionRepo.UpdatePromotion(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Report totals, per promotion, the discounted lines on non-voided sales made in [from, to)
+func (u *PromotionUsecase) Report(businessID, branchID string, from, to int64) (*PromotionReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if from >= to {
+		return nil, errors.New("from must be before to")
+	}
+	totals, err := u.PromotionRepo.GetPromotionTotals(businessID, branchID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &PromotionReport{From: from, To: to, Promotions: []domain.PromotionTotal{}}
+	for _, t := range totals {
+		t.Revenue = roundMoney(t.Revenue)
+		t.PromotionAmount = roundMoney(t.PromotionAmount)
+		report.Revenue += t.Revenue
+		report.PromotionAmount += t.PromotionAmount
+		report.Promotions = append(report.Promotions, t)
+	}
+	report.Revenue = roundMoney(report.Revenue)
+	report.PromotionAmount = roundMoney(report.PromotionAmount)
+	return report, nil
+}
+
+// applyRequest validates req and copies it onto p
+func (u *PromotionUsecase) applyRequest(p *domain.Promotion, req *PromotionRequest) error {
+	p.Name = utils.Sanitize(req.Name)
+	p.Type = req.Type
+	p.BranchID = sanitizeOptional(req.BranchID)
+	p.Category = sanitizeOptional(req.Category)
+	p.ProductIDs = []string{}
+	for _, id := range req.ProductIDs {
+		if id = utils.Sanitize(id); id != "" {
+			p.ProductIDs = append(p.ProductIDs, id)
+		}
+	}
+	p.BuyQuantity, p.GetQuantity, p.BundleQuantity = 0, 0, 0
+	p.BundlePrice, p.Price, p.Percent = 0, 0, 0
+	p.StartsAt = req.StartsAt
+	if p.StartsAt == 0 {
+		p.StartsAt = time.Now().Unix()
+	}
+	p.EndsAt = req.EndsAt
+	p.DailyStart = req.DailyStart
+	p.DailyEnd = req.DailyEnd
+	if req.Active != nil {
+		p.Active = *req.Active
+	}
+
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	switch p.Type {
+	case domain.PromotionBuyXGetY:
+		if req.BuyQuantity <= 0 || req.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be greater than 0")
+		}
+		p.BuyQuantity, p.GetQuantity = req.BuyQuantity, req.GetQuantity
+	case domain.PromotionBundle:
+		if req.BundleQuantity < 2 {
+			return errors.New("bundle_quantity must be at least 2")
+		}
+		if req.BundlePrice <= 0 {
+			return errors.New("bundle_price must be greater than 0")
+		}
+		p.BundleQuantity, p.BundlePrice = req.BundleQuantity, roundMoney(req.BundlePrice)
+	case domain.PromotionPriceOverride:
+		if req.Price <= 0 {
+			return errors.New("price must be greater than 0")
+		}
+		p.Price = roundMoney(req.Price)
+	case domain.PromotionCategoryPercent:
+		if req.Percent <= 0 || req.Percent > 100 {
+			return errors.New("percent must be between 0 and 100")
+		}
+		if p.Category == nil || len(p.ProductIDs) > 0 {
+			return errors.New("category_percent promotions take a category and no product_ids")
+		}
+		p.Percent = req.Percent
+	default:
+		return errors.New("type must be buy_x_get_y, bundle, price_override or category_percent")
+	}
+	if len(p.ProductIDs) == 0 && p.Category == nil {
+		return errors.New("give product_ids or a category")
+	}
+	if p.EndsAt != nil && *p.EndsAt <= p.StartsAt {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if (p.DailyStart == "") != (p.DailyEnd == "") {
+		return errors.New("give both daily_start and daily_end, or neither")
+	}
+	if p.DailyStart != "" {
+		start, err1 := time.Parse("15:04", p.DailyStart)
+		end, err2 := time.Parse("15:04", p.DailyEnd)
+		if err1 != nil || err2 != nil {
+			return errors.New("daily_start and daily_end must be HH:MM")
+		}
+		if start.Equal(end) {
+			return errors.New("daily_start and daily_end must differ")
+		}
+	}
+
+	if p.BranchID != nil {
+		branch, err := u.BranchRepo.GetBranchByID(*p.BranchID)
+		if err != nil || branch.BusinessID != p.BusinessID {
+			return errors.New("branch not found")
+		}
+	}
+	for _, id := range p.ProductIDs {
+		product, err := u.ProductRepo.GetProductByID(id)
+		if err != nil || product.BusinessID != p.BusinessID {
+			return errors.New("product not found: " + id)
+		}
+		if p.BranchID != nil && product.BranchID != *p.BranchID {
+			return errors.New("product " + id + " is not stocked in the promotion's branch")
+		}
+	}
+	return nil
+}
+
+// loadRunningPromotions returns the promotions that price a sale made in a branch at the given time
+func loadRunningPromotions(repo domain.PromotionRepository, businessID, branchID string, at int64) ([]*domain.Promotion, error) {
+	promotions, err := repo.GetRunningPromotions(businessID, branchID, at)
+	if err != nil {
+		return nil, err
+	}
+	var running []*domain.Promotion
+	for _, p := range promotions {
+		if p.RunsAt(time.Unix(at, 0)) {
+			running = append(running, p)
+		}
+	}
+	return running, nil
+}
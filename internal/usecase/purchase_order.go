@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type PurchaseOrderUsecase struct {
+	PurchaseOrderRepo domain.PurchaseOrderRepository
+	SupplierRepo      domain.SupplierRepository
+	ProductRepo       domain.ProductRepository
+	SaleRepo          domain.SaleRepository
+}
+
+type PurchaseOrderLineRequest struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitCost  float64 `json:"unit_cost"`
+}
+
+type CreatePurchaseOrderRequest struct {
+	BranchID   string                     `json:"branch_id"`
+	SupplierID string                     `json:"supplier_id"`
+	Note       string                     `json:"note"`
+	ExpectedAt *int64                     `json:"expected_at"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+type ReceiveGoodsItemRequest struct {
+	LineID   string `json:"line_id"`
+	Quantity int    `json:"quantity"`
+	// Defaults to the unit cost on the order line
+	UnitCost *float64 `json:"unit_cost"`
+	// Optional batch the units belong to, for lot-tracked products
+	BatchNumber string `json:"batch_number"`
+	ExpiryDate  *int64 `json:"expiry_date"`
+}
+
+type ReceiveGoodsRequest struct {
+	Note  string                    `json:"note"`
+	Items []ReceiveGoodsItemRequest `json:"items"`
+}
+
+func (u *PurchaseOrderUsecase) CreatePurchaseOrder(req *CreatePurchaseOrderRequest, businessID, userID string) (*domain.PurchaseOrder, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	if req.BranchID == "" || req.SupplierID == "" || len(req.Lines) == 0 {
+		return nil, errors.New("invalid input")
+	}
+	supplier, err := u.SupplierRepo.GetSupplierByID(req.SupplierID)
+	if err != nil || supplier.BusinessID != businessID {
+		return nil, errors.New("supplier not found")
+	}
+
+	now := time.Now().Unix()
+	po := &domain.PurchaseOrder{
+		ID:         uuid.NewString(),
+		BusinessID: businessID,
+		BranchID:   req.BranchID,
+		SupplierID: supplier.ID,
+		Status:     domain.PurchaseOrderStatusDraft,
+		Note:       utils.Sanitize(req.Note),
+		ExpectedAt: req.ExpectedAt,
+		CreatedBy:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	seen := map[string]bool{}
+	for _, l := range req.Lines {
+		if l.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		if l.UnitCost < 0 {
+			return nil, errors.New("unit cost cannot be negative")
+		}
+		if seen[l.ProductID] {
+			return nil, errors.New("duplicate product in purchase order")
+		}
+		seen[l.ProductID] = true
+		product, err := u.ProductRepo.GetProductByID(l.ProductID)
+		if err != nil || product.BusinessID != businessID || product.BranchID != req.BranchID {
+			return nil, errors.New("product not found in branch")
+		}
+		po.Lines = append(po.Lines, domain.PurchaseOrderLine{
+			ID:              uuid.NewString(),
+			PurchaseOrderID: po.ID,
+			ProductID:       product.ID,
+			ProductName:     product.ProductName,
+			QuantityOrdered: l.Quantity,
+			UnitCost:        l.UnitCost,
+		})
+		po.TotalCost += float64(l.Quantity) * l.UnitCost
+	}
+	po.TotalCost = roundMoney(po.TotalCost)
+	if err := u.PurchaseOrderRepo.CreatePurchaseOrder(po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+func (u *PurchaseOrderUsecase) GetPurchaseOrder(id string) (*domain.PurchaseOrder, error) {
+	po, err := u.PurchaseOrderRepo.GetPurchaseOrderByID(id)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	return po, nil
+}
+
+func (u *PurchaseOrderUsecase) ListPurchaseOrders(businessID, branchID, supplierID, status string) ([]*domain.PurchaseOrder, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.PurchaseOrderRepo.ListPurchaseOrders(businessID, branchID, supplierID, status)
+}
+
+func (u *PurchaseOrderUsecase) SendPurchaseOrder(id string) (*domain.PurchaseOrder, error) {
+	return u.PurchaseOrderRepo.UpdatePurchaseOrderStatus(id, domain.PurchaseOrderStatusSent,
+		[]string{domain.PurchaseOrderStatusDraft})
+}
+
+// CancelPurchaseOrder cancels an order that has not had any goods received against it
+func (u *PurchaseOrderUsecase) CancelPurchaseOrder(id string) (*domain.PurchaseOrder, error) {
+	return u.PurchaseOrderRepo.UpdatePurchaseOrderStatus(id, domain.PurchaseOrderStatusCancelled,
+		[]string{domain.PurchaseOrderStatusDraft, domain.PurchaseOrderStatusSent})
+}
+
+// ReceiveGoods records a goods-received note against a sent order
+func (u *PurchaseOrderUsecase) ReceiveGoods(po *domain.PurchaseOrder, req *ReceiveGoodsRequest, userID string) (*domain.PurchaseOrder, *domain.GoodsReceivedNote, error) {
+	if userID == "" {
+		return nil, nil, errors.New("unauthorized")
+	}
+	if len(req.Items) == 0 {
+		return nil, nil, errors.New("invalid input")
+	}
+	lines := map[string]domain.PurchaseOrderLine{}
+	for _, l := range po.Lines {
+		lines[l.ID] = l
+	}
+	grn := &domain.GoodsReceivedNote{
+		ID:              uuid.NewString(),
+		PurchaseOrderID: po.ID,
+		BusinessID:      po.BusinessID,
+		BranchID:        po.BranchID,
+		ReceivedBy:      userID,
+		Note:            utils.Sanitize(req.Note),
+		CreatedAt:       time.Now().Unix(),
+	}
+	seen := map[string]bool{}
+	for _, it := range req.Items {
+		line, ok := lines[it.LineID]
+		if !ok {
+			return nil, nil, errors.New("purchase order line not found")
+		}
+		if seen[it.LineID] {
+			return nil, nil, errors.New("duplicate line in goods received note")
+		}
+		seen[it.LineID] = true
+		if it.Quantity <= 0 {
+			return nil, nil, errors.New("quantity must be greater than 0")
+		}
+		unitCost := line.UnitCost
+		if it.UnitCost != nil {
+			if *it.UnitCost < 0 {
+				return nil, nil, errors.New("unit cost cannot be negative")
+			}
+			unitCost = *it.UnitCost
+		}
+		batch := utils.Sanitize(it.BatchNumber)
+		if batch == "" && it.ExpiryDate != nil {
+			return nil, nil, errors.New("batch_number is required when expiry_date is given")
+		}
+		grn.Items = append(grn.Items, domain.GoodsReceivedNoteItem{
+			ID:          uuid.NewString(),
+			GRNID:       grn.ID,
+			LineID:      line.ID,
+			ProductID:   line.ProductID,
+			Quantity:    it.Quantity,
+			UnitCost:    unitCost,
+			BatchNumber: batch,
+			ExpiryDate:  it.ExpiryDate,
+		})
+	}
+	updated, err := u.PurchaseOrderRepo.ReceiveGoods(grn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, grn, nil
+}
+
+func (u *PurchaseOrderUsecase) GetGoodsReceivedNotes(purchaseOrderID string) ([]*domain.GoodsReceivedNote, error) {
+	return u.PurchaseOrderRepo.GetGoodsReceivedNotes(purchaseOrderID)
+}
+
+// ReorderReport suggests what to order for each product that will not last coverDays at the
+// sales velocity of the last velocityDays. The suggestion tops stock up to the low-stock
+// threshold plus coverDays of sales, counting the units still due on open purchase orders.
+func (u *PurchaseOrderUsecase) ReorderReport(businessID, branchID string, velocityDays, coverDays int) ([]*domain.ReorderSuggestion, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if velocityDays <= 0 || coverDays <= 0 {
+		return nil, errors.New("days must be greater than 0")
+	}
+	var (
+		products []*domain.Product
+		err      error
+	)
+	if branchID != "" {
+		products, err = u.ProductRepo.GetProductsByBranchID(businessID, branchID)
+	} else {
+		products, err = u.ProductRepo.GetProductsByBusinessID(businessID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().AddDate(0, 0, -velocityDays).Unix()
+	sold, err := u.SaleRepo.GetUnitsSoldSince(businessID, branchID, since)
+	if err != nil {
+		return nil, err
+	}
+	onOrder, err := u.PurchaseOrderRepo.GetQuantitiesOnOrder(businessID, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []*domain.ReorderSuggestion{}
+	for _, p := range products {
+		units := sold[p.ID]
+		velocity := float64(units) / float64(velocityDays)
+		target := p.LowStockThreshold + int(math.Ceil(velocity*float64(coverDays)))
+		suggested := target - p.QuantityInStock - onOrder[p.ID]
+		if suggested <= 0 {
+			continue
+		}
+		daysOfCover := -1.0
+		if velocity > 0 {
+			daysOfCover = math.Round(float64(p.QuantityInStock)/velocity*10) / 10
+		}
+		suggestions = append(suggestions, &domain.ReorderSuggestion{
+			ProductID:         p.ID,
+			ProductName:       p.ProductName,
+			BranchID:          p.BranchID,
+			QuantityInStock:   p.QuantityInStock,
+			LowStockThreshold: p.LowStockThreshold,
+			UnitsSold:         units,
+			DailyVelocity:     math.Round(velocity*100) / 100,
+			DaysOfCover:       daysOfCover,
+			OnOrder:           onOrder[p.ID],
+			SuggestedQuantity: suggested,
+			CostPrice:         p.CostPrice,
+		})
+	}
+	// Most urgent first: lowest cover, then products that are not selling at all
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i].DaysOfCover, suggestions[j].DaysOfCover
+		if (a < 0) != (b < 0) {
+			return a >= 0
+		}
+		return a < b
+	})
+	return suggestions, nil
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+type reorderProductRepo struct {
+	domain.ProductRepository
+	products []*domain.Product
+}
+
+func (r reorderProductRepo) GetProductsByBusinessID(businessID string) ([]*domain.Product, error) {
+	return r.products, nil
+}
+
+type reorderSaleRepo struct {
+	domain.SaleRepository
+	sold map[string]int
+}
+
+func (r reorderSaleRepo) GetUnitsSoldSince(businessID, branchID string, since int64) (map[string]int, error) {
+	return r.sold, nil
+}
+
+type reorderPurchaseOrderRepo struct {
+	domain.PurchaseOrderRepository
+	onOrder map[string]int
+}
+
+func (r reorderPurchaseOrderRepo) GetQuantitiesOnOrder(businessID, branchID string) (map[string]int, error) {
+	return r.onOrder, nil
+}
+
+func TestReorderReportCountsOpenPurchaseOrders(t *testing.T) {
+	uc := &PurchaseOrderUsecase{
+		ProductRepo: reorderProductRepo{products: []*domain.Product{
+			{ID: "product-1", ProductName: "Paracetamol", QuantityInStock: 5, LowStockThreshold: 10},
+			{ID: "product-2", ProductName: "Amoxicillin", QuantityInStock: 5, LowStockThreshold: 10},
+		}},
+		// 30 units in 30 days: 14 more are needed to cover two weeks
+		SaleRepo:          reorderSaleRepo{sold: map[string]int{"product-1": 30, "product-2": 30}},
+		PurchaseOrderRepo: reorderPurchaseOrderRepo{onOrder: map[string]int{"product-1": 12, "product-2": 40}},
+	}
+
+	suggestions, err := uc.ReorderReport("business-1", "", 30, 14)
+	if err != nil {
+		t.Fatalf("ReorderReport() error = %v", err)
+	}
+	// product-2 is covered by what it already has on order
+	if len(suggestions) != 1 {
+		t.Fatalf("got %d suggestions, want only product-1", len(suggestions))
+	}
+	if s := suggestions[0]; s.ProductID != "product-1" || s.OnOrder != 12 || s.SuggestedQuantity != 7 {
+		t.Errorf("suggestion = %s with %d on order, suggesting %d; want product-1 with 12 on order, suggesting 7", s.ProductID, s.OnOrder, s.SuggestedQuantity)
+	}
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+type RBACUsecase struct {
+	RolePermissionRepo domain.RolePermissionRepository
+}
+
+// RolePermissionsView is the effective permission set of a role for a business
+type RolePermissionsView struct {
+	Role        domain.StaffRole    `json:"role"`
+	Permissions []domain.Permission `json:"permissions"`
+	Customised  bool                `json:"customised"`
+}
+
+// roleRank orders roles for assignment checks; staff may only hand out roles below their own
+var roleRank = map[domain.StaffRole]int{
+	domain.RoleOwner:     3,
+	domain.RoleManager:   2,
+	domain.RoleCashier:   1,
+	domain.RoleInventory: 1,
+}
+
+// Authorize checks that the user holds perm and returns the branch they are confined to
+// ("" means every branch of the business). Business owners and owner-role staff are never
+// restricted; every other staff member is scoped to their own branch. Refusals wrap
+// domain.ErrForbidden; any other error means the permissions could not be looked up.
+func (u *RBACUsecase) Authorize(id *domain.AuthIdentity, perm domain.Permission) (string, error) {
+	role := domain.StaffRole(id.Role)
+	if id.UserType == domain.UserTypeOwner || role == domain.RoleOwner {
+		return "", nil
+	}
+	if id.BranchID == "" {
+		return "", fmt.Errorf("%w: staff token has no branch", domain.ErrForbidden)
+	}
+	if perm == "" {
+		return id.BranchID, nil
+	}
+
+	perms, _, err := u.EffectivePermissions(id.BusinessID, role)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range perms {
+		if p == perm {
+			return id.BranchID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: your role does not allow %s", domain.ErrForbidden, perm)
+}
+
+// EffectivePermissions returns the business's customised permissions for role,
+// falling back to the defaults; the bool reports whether they were customised
+func (u *RBACUsecase) EffectivePermissions(businessID string, role domain.StaffRole) ([]domain.Permission, bool, error) {
+	if role == domain.RoleOwner {
+		return domain.AllPermissions, false, nil
+	}
+	custom, err := u.RolePermissionRepo.GetRolePermissions(businessID, role)
+	if err != nil {
+		return nil, false, err
+	}
+	if custom != nil {
+		return custom.Permissions, true, nil
+	}
+	return domain.DefaultRolePermissions[role], false, nil
+}
+
+// GetRolePermissions lists the effective permissions of every role for a business
+func (u *RBACUsecase) GetRolePermissions(businessID string) ([]RolePermissionsView, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	var views []RolePermissionsView
+	for _, role := range []domain.StaffRole{domain.RoleOwner, domain.RoleManager, domain.RoleCashier, domain.RoleInventory} {
+		perms, customised, err := u.EffectivePermissions(businessID, role)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, RolePermissionsView{Role: role, Permissions: perms, Customised: customised})
+	}
+	return views, nil
+}
+
+// UpdateRolePermissions replaces the permissions a role grants within a business
+func (u *RBACUsecase) UpdateRolePermissions(businessID string, role domain.StaffRole, perms []domain.Permission, updatedBy string) (*RolePermissionsView, error) {
+	if businessID == "" || updatedBy == "" {
+		return nil, errors.New("missing business_id or updated_by")
+	}
+	if err := validateCustomisableRole(role); err != nil {
+		return nil, err
+	}
+	seen := map[domain.Permission]bool{}
+	unique := []domain.Permission{}
+	for _, p := range perms {
+		if !domain.IsValidPermission(p) {
+			return nil, errors.New("invalid permission: " + string(p))
+		}
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	rp := &domain.RolePermissions{
+		BusinessID:  businessID,
+		Role:        role,
+		Permissions: unique,
+		UpdatedBy:   updatedBy,
+		UpdatedAt:   time.Now().Unix(),
+	}
+	if err := u.RolePermissionRepo.SaveRolePermissions(rp); err != nil {
+		return nil, err
+	}
+	return &RolePermissionsView{Role: role, Permissions: unique, Customised: true}, nil
+}
+
+// ResetRolePermissions drops a business's customisation so the role falls back to the defaults
+func (u *RBACUsecase) ResetRolePermissions(businessID string, role domain.StaffRole) (*RolePermissionsView, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if err := validateCustomisableRole(role); err != nil {
+		return nil, err
+	}
+	if err := u.RolePermissionRepo.DeleteRolePermissions(businessID, role); err != nil {
+		return nil, err
+	}
+	return &RolePermissionsView{Role: role, Permissions: domain.DefaultRolePermissions[role]}, nil
+}
+
+// CanAssignRole reports whether a user with actorRole may create or update staff with targetRole
+func (u *RBACUsecase) CanAssignRole(actorRole string, targetRole domain.StaffRole) bool {
+	actor := domain.StaffRole(actorRole)
+	if actor == domain.RoleOwner {
+		return true
+	}
+	return roleRank[targetRole] < roleRank[actor]
+}
+
+func validateCustomisableRole(role domain.StaffRole) error {
+	switch role {
+	case domain.RoleManager, domain.RoleCashier, domain.RoleInventory:
+		return nil
+	case domain.RoleOwner:
+		return errors.New("owner permissions cannot be changed")
+	default:
+		return errors.New("invalid staff role")
+	}
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+// rolePermissionRepoStub returns one customised role, or err for every lookup when set
+type rolePermissionRepoStub struct {
+	domain.RolePermissionRepository
+	custom *domain.RolePermissions
+	err    error
+}
+
+func (r rolePermissionRepoStub) GetRolePermissions(businessID string, role domain.StaffRole) (*domain.RolePermissions, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.custom != nil && r.custom.Role == role {
+		return r.custom, nil
+	}
+	return nil, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	cashier := &domain.AuthIdentity{UserID: "user-1", BusinessID: "business-1", BranchID: "branch-1", UserType: domain.UserTypeStaff, Role: string(domain.RoleCashier)}
+
+	defaults := &RBACUsecase{RolePermissionRepo: rolePermissionRepoStub{}}
+	if scope, err := defaults.Authorize(cashier, domain.PermSaleCreate); err != nil || scope != "branch-1" {
+		t.Errorf("default cashier selling = %q, %v; want scoped to branch-1", scope, err)
+	}
+	if _, err := defaults.Authorize(cashier, domain.PermStaffManage); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("default cashier managing staff: error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	// A business can take a default permission away
+	custom := &RBACUsecase{RolePermissionRepo: rolePermissionRepoStub{custom: &domain.RolePermissions{Role: domain.RoleCashier, Permissions: []domain.Permission{domain.PermProductView}}}}
+	if _, err := custom.Authorize(cashier, domain.PermSaleCreate); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("customised cashier selling: error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	// An unreadable customisation must not fall back to the defaults
+	broken := &RBACUsecase{RolePermissionRepo: rolePermissionRepoStub{err: errors.New("connection refused")}}
+	_, err := broken.Authorize(cashier, domain.PermSaleCreate)
+	if err == nil || errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("lookup failure: error = %v, want the lookup error", err)
+	}
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type RecallUsecase struct {
+	RecallRepo domain.RecallRepository
+}
+
+// CreateRecallRequest identifies the recalled stock by NAFDAC number, optionally narrowed
+// by barcode, batch and an expiry window (unix timestamps, inclusive)
+type CreateRecallRequest struct {
+	NAFDACRegNumber string  `json:"nafdac_reg_number"`
+	BarcodeValue    *string `json:"barcode_value"`
+	BatchNumber     *string `json:"batch_number"`
+	ExpiryFrom      *int64  `json:"expiry_from"`
+	ExpiryTo        *int64  `json:"expiry_to"`
+	Reason          string  `json:"reason"`
+	Reference       string  `json:"reference"`
+}
+
+// CreateRecall starts a recall; matching stock cannot be sold until it is closed
+func (u *RecallUsecase) CreateRecall(req *CreateRecallRequest, businessID, userID string) (*domain.Recall, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	recall := &domain.Recall{
+		ID:              utils.GenerateUUID(),
+		BusinessID:      businessID,
+		NAFDACRegNumber: utils.Sanitize(req.NAFDACRegNumber),
+		BarcodeValue:    sanitizeOptional(req.BarcodeValue),
+		BatchNumber:     sanitizeOptional(req.BatchNumber),
+		ExpiryFrom:      req.ExpiryFrom,
+		ExpiryTo:        req.ExpiryTo,
+		Reason:          utils.Sanitize(req.Reason),
+		Reference:       utils.Sanitize(req.Reference),
+		Status:          domain.RecallStatusActive,
+		CreatedBy:       userID,
+		CreatedAt:       time.Now().Unix(),
+	}
+	if recall.NAFDACRegNumber == "" {
+		return nil, errors.New("nafdac_reg_number is required")
+	}
+	if recall.Reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if recall.ExpiryFrom != nil && recall.ExpiryTo != nil && *recall.ExpiryFrom > *recall.ExpiryTo {
+		return nil, errors.New("expiry_from must not be after expiry_to")
+	}
+	if err := u.RecallRepo.CreateRecall(recall); err != nil {
+		return nil, err
+	}
+	return recall, nil
+}
+
+func (u *RecallUsecase) GetRecall(id, businessID string) (*domain.Recall, error) {
+	recall, err := u.RecallRepo.GetRecallByID(id)
+	if err != nil || recall.BusinessID != businessID {
+		return nil, errors.New("recall not found")
+	}
+	return recall, nil
+}
+
+func (u *RecallUsecase) ListRecalls(businessID, status string) ([]*domain.Recall, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if status != "" && status != domain.RecallStatusActive && status != domain.RecallStatusClosed {
+		return nil, errors.New("invalid status")
+	}
+	return u.RecallRepo.GetRecallsByBusinessID(businessID, status)
+}
+
+// CloseRecall ends a recall so that any remaining matching stock can be sold again
+func (u *RecallUsecase) CloseRecall(id, businessID, userID string) (*domain.Recall, error) {
+	recall, err := u.GetRecall(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if err := u.RecallRepo.CloseRecall(recall.ID, userID, now); err != nil {
+		return nil, err
+	}
+	recall.Status = domain.RecallStatusClosed
+	recall.ClosedBy = &userID
+	recall.ClosedAt = &now
+	return recall, nil
+}
+
+// Report lists the recalled stock each branch still holds and every sale that dispensed it,
+// limited to one branch when branchID is set
+func (u *RecallUsecase) Report(recall *domain.Recall, branchID string) (*domain.RecallReport, error) {
+	stock, err := u.RecallRepo.GetRecallStock(recall, branchID)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := u.RecallRepo.GetRecallSales(recall, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := map[string]*domain.RecallBranchSummary{}
+	summary := func(id, name string) *domain.RecallBranchSummary {
+		s, ok := summaries[id]
+		if !ok {
+			s = &domain.RecallBranchSummary{BranchID: id, BranchName: name}
+			summaries[id] = s
+		}
+		return s
+	}
+	for _, line := range stock {
+		summary(line.BranchID, line.BranchName).UnitsOnHand += line.Quantity
+	}
+	counted := map[string]bool{}
+	for _, line := range sales {
+		s := summary(line.BranchID, line.BranchName)
+		s.UnitsSold += line.Quantity
+		if !counted[line.SaleID] {
+			counted[line.SaleID] = true
+			s.SalesCount++
+		}
+	}
+
+	report := &domain.RecallReport{
+		Recall:      recall,
+		GeneratedAt: time.Now().Unix(),
+		Branches:    []domain.RecallBranchSummary{},
+		Stock:       stock,
+		Sales:       sales,
+	}
+	for _, s := range summaries {
+		report.Branches = append(report.Branches, *s)
+	}
+	sort.Slice(report.Branches, func(i, j int) bool {
+		return report.Branches[i].BranchName < report.Branches[j].BranchName
+	})
+	if report.Stock == nil {
+		report.Stock = []domain.RecallStockLine{}
+	}
+	if report.Sales == nil {
+		report.Sales = []domain.RecallSaleLine{}
+	}
+	return report, nil
+}
+
+// sanitizeOptional sanitizes an optional string input, treating blank as unset
+func sanitizeOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := utils.Sanitize(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
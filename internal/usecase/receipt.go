@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type ReceiptUsecase struct {
+	SaleRepo     domain.SaleRepository
+	BusinessRepo domain.BusinessRepository
+	BranchRepo   domain.BranchRepository
+	StaffRepo    domain.StaffRepository
+	ProductRepo  domain.ProductRepository
+	CustomerRepo domain.CustomerRepository
+	SettingsRepo domain.SettingsRepository
+}
+
+// GetReceipt builds the receipt for a sale of the business. Sales recorded before receipts
+// were numbered get their branch's next number the first time their receipt is printed.
+func (u *ReceiptUsecase) GetReceipt(saleID, businessID string) (*domain.Receipt, error) {
+	sale, err := u.SaleRepo.GetSaleByID(saleID)
+	if err != nil || sale.BusinessID != businessID {
+		return nil, errors.New("sale not found")
+	}
+	if sale.ReceiptNumber == 0 {
+		if sale.ReceiptNumber, err = u.SaleRepo.AssignReceiptNumber(sale.ID); err != nil {
+			return nil, err
+		}
+	}
+	business, err := u.BusinessRepo.GetBusinessByID(businessID)
+	if err != nil {
+		return nil, errors.New("business not found")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location()
+
+	receipt := &domain.Receipt{
+		ReceiptNumber:   sale.ReceiptNumber,
+		SaleID:          sale.ID,
+		Status:          sale.Status,
+		BusinessName:    business.Name,
+		BusinessPhone:   business.PhoneNumber,
+		BranchID:        sale.BranchID,
+		BranchAddress:   business.StoreAddress,
+		Currency:        business.Currency,
+		Date:            time.Unix(sale.CreatedAt, 0).In(loc).Format("2006-01-02 15:04"),
+		Timezone:        loc.String(),
+		Items:           []domain.ReceiptItem{},
+		GrossAmount:     sale.GrossAmount,
+		PromotionAmount: sale.PromotionAmount,
+		DiscountAmount:  sale.DiscountAmount,
+		TaxAmount:       sale.TaxAmount,
+		TotalAmount:     sale.TotalAmount,
+		Payments:        []domain.ReceiptPayment{},
+		ChangeDue:       sale.ChangeDue,
+		PointsEarned:    sale.PointsEarned,
+		PointsRedeemed:  sale.PointsRedeemed,
+	}
+	if branch, err := u.BranchRepo.GetBranchByID(sale.BranchID); err == nil {
+		receipt.BranchName = branch.BranchName
+		if branch.BranchAddress != "" {
+			receipt.BranchAddress = branch.BranchAddress
+		}
+	}
+	receipt.CashierName = findCashier(business, u.StaffRepo, sale.CashierID).FullName
+	if sale.CustomerID != nil {
+		if customer, err := u.CustomerRepo.GetCustomerByID(*sale.CustomerID); err == nil {
+			receipt.CustomerName = customer.Name
+		}
+	}
+
+	var gross float64
+	for _, it := range sale.Items {
+		name := "Item"
+		if product, err := u.ProductRepo.GetProductByID(it.ProductID); err == nil {
+			name = product.ProductName
+		}
+		// Sales made before list prices were kept show what was charged
+		price := it.ListPrice
+		if price == 0 {
+			price = it.UnitPrice
+		}
+		amount := roundMoney(price * float64(it.Quantity))
+		gross += amount
+		receipt.Items = append(receipt.Items, domain.ReceiptItem{
+			Name:      name,
+			Quantity:  it.Quantity,
+			UnitPrice: price,
+			Amount:    amount,
+		})
+	}
+	if receipt.GrossAmount == 0 {
+		receipt.GrossAmount = roundMoney(gross)
+	}
+	for _, p := range sale.Payments {
+		receipt.Payments = append(receipt.Payments, domain.ReceiptPayment{
+			Method:    p.Method,
+			Amount:    p.Amount,
+			Tendered:  p.Tendered,
+			Reference: p.Reference,
+		})
+	}
+	// Sales recorded before split payments were paid in full with their payment method
+	if len(sale.Payments) == 0 {
+		receipt.Payments = append(receipt.Payments, domain.ReceiptPayment{
+			Method:   sale.PaymentMethod,
+			Amount:   sale.TotalAmount,
+			Tendered: sale.TotalAmount,
+		})
+	}
+	return receipt, nil
+}
+
+// RenderReceipt renders a receipt as plain text, ESC/POS or PDF for paper width characters
+// wide, returning the content and its content type
+func (u *ReceiptUsecase) RenderReceipt(receipt *domain.Receipt, format string, width int) ([]byte, string, error) {
+	if width != domain.ReceiptWidth58mm && width != domain.ReceiptWidth80mm {
+		return nil, "", errors.New("unsupported paper width")
+	}
+	switch format {
+	case domain.ReceiptFormatText:
+		return []byte(receipt.Text(width)), "text/plain; charset=utf-8", nil
+	case domain.ReceiptFormatESCPOS:
+		return receipt.ESCPOS(width), "application/octet-stream", nil
+	case domain.ReceiptFormatPDF:
+		var lines []utils.PDFLine
+		for _, l := range receipt.Lines(width) {
+			lines = append(lines, utils.PDFLine{Text: l.Text, Bold: l.Bold})
+		}
+		return utils.TextPDF(lines, width), "application/pdf", nil
+	}
+	return nil, "", errors.New("format must be json, text, escpos or pdf")
+}
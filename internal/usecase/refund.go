@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type RefundUsecase struct {
+	RefundRepo   domain.RefundRepository
+	SaleRepo     domain.SaleRepository
+	SettingsRepo domain.SettingsRepository
+	ShiftRepo    domain.ShiftRepository
+}
+
+type RefundItemRequest struct {
+	SaleItemID string `json:"sale_item_id"`
+	Quantity   int    `json:"quantity"`
+	// restock (default) or write_off for damaged goods
+	Disposition string `json:"disposition"`
+}
+
+// RefundPaymentRequest pays back part of a refund through one payment method
+type RefundPaymentRequest struct {
+	Method string  `json:"method"`
+	Amount float64 `json:"amount"`
+}
+
+type CreateRefundRequest struct {
+	ReasonCode string `json:"reason_code"`
+	Note       string `json:"note"`
+	// Method to pay the whole refund back through. Defaults to the sale's payment method
+	// when it was paid with one; a split sale needs payments instead.
+	PaymentMethod string                 `json:"payment_method"`
+	Payments      []RefundPaymentRequest `json:"payments"`
+	Items         []RefundItemRequest    `json:"items"`
+}
+
+// RequestRefund refunds part or all of a sale. Refunds above the business's approval
+// limit stay pending until approved, unless the requester may approve refunds themselves.
+// The refund is paid back through accepted payment methods; see domain.CheckRefundPayments
+// for how they must match the way the sale was paid.
+func (u *RefundUsecase) RequestRefund(req *CreateRefundRequest, sale *domain.Sale, requestedBy string, canApprove bool) (*domain.Refund, error) {
+	if requestedBy == "" {
+		return nil, errors.New("unauthorized")
+	}
+	if sale.Status != domain.SaleStatusCompleted && sale.Status != domain.SaleStatusPartiallyRefunded {
+		return nil, errors.New("sale cannot be refunded")
+	}
+	if !domain.IsValidRefundReason(req.ReasonCode) {
+		return nil, errors.New("invalid reason_code")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("invalid input")
+	}
+
+	saleItems := map[string]domain.SaleItem{}
+	for _, it := range sale.Items {
+		saleItems[it.ID] = it
+	}
+	requested := map[string]int{}
+	refund := &domain.Refund{
+		ID:          uuid.NewString(),
+		BusinessID:  sale.BusinessID,
+		BranchID:    sale.BranchID,
+		SaleID:      sale.ID,
+		RequestedBy: requestedBy,
+		ReasonCode:  req.ReasonCode,
+		Note:        utils.Sanitize(req.Note),
+		CreatedAt:   time.Now().Unix(),
+	}
+	for _, it := range req.Items {
+		saleItem, ok := saleItems[it.SaleItemID]
+		if !ok {
+			return nil, errors.New("sale item not found")
+		}
+		if it.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		requested[it.SaleItemID] += it.Quantity
+		if requested[it.SaleItemID] > saleItem.Quantity-saleItem.RefundedQuantity {
+			return nil, errors.New("refund quantity exceeds quantity remaining on sale item")
+		}
+		disposition := it.Disposition
+		if disposition == "" {
+			disposition = domain.RefundDispositionRestock
+		}
+		if disposition != domain.RefundDispositionRestock && disposition != domain.RefundDispositionWriteOff {
+			return nil, errors.New("invalid disposition")
+		}
+		amount := saleItem.RefundAmount(it.Quantity)
+		refund.Items = append(refund.Items, domain.RefundItem{
+			ID:          uuid.NewString(),
+			RefundID:    refund.ID,
+			SaleItemID:  saleItem.ID,
+			ProductID:   saleItem.ProductID,
+			Quantity:    it.Quantity,
+			UnitPrice:   saleItem.UnitPrice,
+			Amount:      amount,
+			Disposition: disposition,
+		})
+		refund.TotalAmount += amount
+	}
+	refund.TotalAmount = roundMoney(refund.TotalAmount)
+
+	settings, err := u.SettingsRepo.GetSettings(sale.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.payRefund(refund, req, sale, settings); err != nil {
+		return nil, err
+	}
+	refund.Status = domain.RefundStatusCompleted
+	if refund.TotalAmount > settings.RefundApprovalLimit && !canApprove {
+		refund.Status = domain.RefundStatusPendingApproval
+	} else {
+		now := refund.CreatedAt
+		refund.ApprovedBy = &requestedBy
+		refund.ResolvedAt = &now
+		if refund.ShiftID, err = u.drawerShift(requestedBy, refund.BranchID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.RefundRepo.CreateRefund(refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// payRefund sets how the refund is paid back. Without explicit payments the whole refund goes
+// back through req.PaymentMethod, or through the sale's own method if it was paid with one.
+func (u *RefundUsecase) payRefund(refund *domain.Refund, req *CreateRefundRequest, sale *domain.Sale, settings *domain.BusinessSettings) error {
+	requested := req.Payments
+	if len(requested) == 0 {
+		method := req.PaymentMethod
+		if method == "" {
+			tenders := map[string]bool{}
+			for _, t := range sale.Tenders() {
+				tenders[t.Method] = true
+			}
+			if len(tenders) != 1 {
+				return errors.New("payments are required to refund a sale paid with several methods")
+			}
+			for m := range tenders {
+				method = m
+			}
+		}
+		requested = []RefundPaymentRequest{{Method: method, Amount: refund.TotalAmount}}
+	}
+
+	methods := map[string]bool{}
+	for _, p := range requested {
+		method := utils.Sanitize(p.Method)
+		if !settings.AcceptsPaymentMethod(method) {
+			return fmt.Errorf("payment method %q is not accepted", method)
+		}
+		methods[method] = true
+		refund.Payments = append(refund.Payments, domain.RefundPayment{
+			ID:       uuid.NewString(),
+			RefundID: refund.ID,
+			Method:   method,
+			Amount:   roundMoney(p.Amount),
+		})
+	}
+	refund.PaymentMethod = refund.Payments[0].Method
+	if len(methods) > 1 {
+		refund.PaymentMethod = domain.PaymentMethodSplit
+	}
+
+	previous, err := u.RefundRepo.GetRefundsBySaleID(sale.ID)
+	if err != nil {
+		return err
+	}
+	var prior []domain.RefundPayment
+	for _, r := range previous {
+		if r.Status == domain.RefundStatusCompleted {
+			prior = append(prior, r.Payments...)
+		}
+	}
+	return domain.CheckRefundPayments(sale.Tenders(), prior, refund.Payments, refund.TotalAmount)
+}
+
+// GetSale loads the sale being refunded, with its items
+func (u *RefundUsecase) GetSale(saleID string) (*domain.Sale, error) {
+	sale, err := u.SaleRepo.GetSaleByID(saleID)
+	if err != nil {
+		return nil, errors.New("sale not found")
+	}
+	return sale, nil
+}
+
+// ApproveRefund completes a pending refund; the approver pays it out of their own drawer
+func (u *RefundUsecase) ApproveRefund(refund *domain.Refund, approverID string) (*domain.Refund, error) {
+	if approverID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	shiftID, err := u.drawerShift(approverID, refund.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	return u.RefundRepo.ApproveRefund(refund.ID, approverID, shiftID)
+}
+
+// drawerShift returns the shift whose drawer userID pays a refund at branchID out of: their
+// open shift there, or nil when they have none
+func (u *RefundUsecase) drawerShift(userID, branchID string) (*string, error) {
+	shift, err := u.ShiftRepo.GetOpenShift(userID)
+	if errors.Is(err, domain.ErrNoOpenShift) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if shift.BranchID != branchID {
+		return nil, nil
+	}
+	return &shift.ID, nil
+}
+
+func (u *RefundUsecase) RejectRefund(refundID, approverID, note string) (*domain.Refund, error) {
+	if approverID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	return u.RefundRepo.RejectRefund(refundID, approverID, utils.Sanitize(note))
+}
+
+func (u *RefundUsecase) GetRefund(id string) (*domain.Refund, error) {
+	return u.RefundRepo.GetRefundByID(id)
+}
+
+func (u *RefundUsecase) GetRefundsForSale(saleID string) ([]*domain.Refund, error) {
+	return u.RefundRepo.GetRefundsBySaleID(saleID)
+}
+
+func (u *RefundUsecase) GetPendingRefunds(businessID, branchID string) ([]*domain.Refund, error) {
+	return u.RefundRepo.GetPendingRefunds(businessID, branchID)
+}
+
+// roundMoney rounds an amount to 2 decimal places
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
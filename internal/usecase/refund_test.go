@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+// refundRepoStub records created refunds; the other RefundRepository methods are not used
+type refundRepoStub struct {
+	domain.RefundRepository
+	refunds []*domain.Refund
+}
+
+func (r *refundRepoStub) CreateRefund(refund *domain.Refund) error {
+	r.refunds = append(r.refunds, refund)
+	return nil
+}
+
+func (r *refundRepoStub) GetRefundsBySaleID(saleID string) ([]*domain.Refund, error) {
+	return r.refunds, nil
+}
+
+type settingsRepoStub struct {
+	domain.SettingsRepository
+}
+
+func (settingsRepoStub) GetSettings(businessID string) (*domain.BusinessSettings, error) {
+	return domain.DefaultBusinessSettings(businessID), nil
+}
+
+// shiftRepoStub has cashier "user-1" on shift-1 at branch-1 and nobody else on shift
+type shiftRepoStub struct {
+	domain.ShiftRepository
+}
+
+func (shiftRepoStub) GetOpenShift(userID string) (*domain.Shift, error) {
+	if userID != "user-1" {
+		return nil, domain.ErrNoOpenShift
+	}
+	return &domain.Shift{ID: "shift-1", BranchID: "branch-1", CashierID: userID, Status: domain.ShiftStatusOpen}, nil
+}
+
+// refundableSale is a cash sale of 3 units of one product, one already returned, and 1 of another
+func refundableSale() *domain.Sale {
+	return &domain.Sale{
+		ID:            "sale-1",
+		BusinessID:    "business-1",
+		BranchID:      "branch-1",
+		TotalAmount:   100,
+		PaymentMethod: domain.PaymentMethodCash,
+		Status:        domain.SaleStatusPartiallyRefunded,
+		Payments:      []domain.SalePayment{{Method: domain.PaymentMethodCash, Amount: 100}},
+		Items: []domain.SaleItem{
+			{ID: "item-1", ProductID: "product-1", Quantity: 3, Subtotal: 60, UnitPrice: 20, RefundedQuantity: 1},
+			{ID: "item-2", ProductID: "product-2", Quantity: 1, Subtotal: 40, UnitPrice: 40},
+		},
+	}
+}
+
+func TestRequestRefundPaysBackWhatIsLeft(t *testing.T) {
+	repo := &refundRepoStub{}
+	uc := &RefundUsecase{RefundRepo: repo, SettingsRepo: settingsRepoStub{}, ShiftRepo: shiftRepoStub{}}
+	req := &CreateRefundRequest{
+		ReasonCode: domain.RefundReasonChangedMind,
+		Items:      []RefundItemRequest{{SaleItemID: "item-1", Quantity: 2}, {SaleItemID: "item-2", Quantity: 1}},
+	}
+
+	refund, err := uc.RequestRefund(req, refundableSale(), "user-1", true)
+	if err != nil {
+		t.Fatalf("RequestRefund() error = %v", err)
+	}
+	if refund.TotalAmount != 80 {
+		t.Errorf("TotalAmount = %.2f, want 80.00", refund.TotalAmount)
+	}
+	if len(refund.Payments) != 1 || refund.Payments[0].Method != domain.PaymentMethodCash || refund.Payments[0].Amount != 80 {
+		t.Errorf("Payments = %+v, want 80.00 back in cash", refund.Payments)
+	}
+	if refund.Status != domain.RefundStatusCompleted || len(repo.refunds) != 1 {
+		t.Errorf("refund is %s with %d stored, want it completed and stored", refund.Status, len(repo.refunds))
+	}
+	if refund.ShiftID == nil || *refund.ShiftID != "shift-1" {
+		t.Errorf("ShiftID = %v, want the cashier's open shift-1", refund.ShiftID)
+	}
+}
+
+func TestRequestRefundRejectsInvalidItems(t *testing.T) {
+	uc := &RefundUsecase{RefundRepo: &refundRepoStub{}, SettingsRepo: settingsRepoStub{}, ShiftRepo: shiftRepoStub{}}
+	for want, items := range map[string][]RefundItemRequest{
+		"refund quantity exceeds quantity remaining on sale item": {{SaleItemID: "item-1", Quantity: 1}, {SaleItemID: "item-1", Quantity: 2}},
+		"quantity must be greater than 0":                         {{SaleItemID: "item-2", Quantity: 0}},
+		"sale item not found":                                     {{SaleItemID: "item-9", Quantity: 1}},
+	} {
+		req := &CreateRefundRequest{ReasonCode: domain.RefundReasonChangedMind, Items: items}
+		if _, err := uc.RequestRefund(req, refundableSale(), "user-1", true); err == nil || err.Error() != want {
+			t.Errorf("RequestRefund(%+v) error = %v, want %q", items, err, want)
+		}
+	}
+}
+
+func TestRequestRefundOfSplitSaleNeedsPayments(t *testing.T) {
+	uc := &RefundUsecase{RefundRepo: &refundRepoStub{}, SettingsRepo: settingsRepoStub{}, ShiftRepo: shiftRepoStub{}}
+	sale := refundableSale()
+	sale.PaymentMethod = domain.PaymentMethodSplit
+	sale.Payments = []domain.SalePayment{{Method: domain.PaymentMethodCash, Amount: 60}, {Method: domain.PaymentMethodCard, Amount: 40}}
+	req := &CreateRefundRequest{ReasonCode: domain.RefundReasonChangedMind, Items: []RefundItemRequest{{SaleItemID: "item-2", Quantity: 1}}}
+
+	if _, err := uc.RequestRefund(req, sale, "user-1", true); err == nil {
+		t.Fatal("RequestRefund() without payments succeeded for a split sale")
+	}
+	req.Payments = []RefundPaymentRequest{{Method: domain.PaymentMethodCash, Amount: 10}, {Method: domain.PaymentMethodCard, Amount: 30}}
+	refund, err := uc.RequestRefund(req, sale, "user-1", true)
+	if err != nil {
+		t.Fatalf("RequestRefund() error = %v", err)
+	}
+	if refund.PaymentMethod != domain.PaymentMethodSplit || len(refund.Payments) != 2 {
+		t.Errorf("refund paid with %s in %d payments, want split over 2", refund.PaymentMethod, len(refund.Payments))
+	}
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Reservations can hold stock for at most this long
+const maxReservationDays = 90
+
+type ReservationUsecase struct {
+	ReservationRepo domain.ReservationRepository
+	ProductRepo     domain.ProductRepository
+	BranchRepo      domain.BranchRepository
+}
+
+// CreateReservationRequest sets stock aside at a branch until expires_at (unix seconds)
+type CreateReservationRequest struct {
+	BranchID      string                   `json:"branch_id"`
+	Reference     string                   `json:"reference"`
+	CustomerID    *string                  `json:"customer_id,omitempty"`
+	CustomerPhone string                   `json:"customer_phone,omitempty"`
+	Note          string                   `json:"note"`
+	ExpiresAt     int64                    `json:"expires_at"`
+	Items         []ReservationItemRequest `json:"items"`
+}
+
+type ReservationItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// CreateReservation holds the requested units back from other sales until the reservation
+// expires. Lines for the same product are merged.
+func (u *ReservationUsecase) CreateReservation(req *CreateReservationRequest, businessID, userID string) (*domain.Reservation, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	branch, err := u.BranchRepo.GetBranchByID(req.BranchID)
+	if err != nil || branch.BusinessID != businessID {
+		return nil, errors.New("branch not found")
+	}
+	reference := strings.TrimSpace(utils.Sanitize(req.Reference))
+	if reference == "" {
+		return nil, errors.New("reference is required")
+	}
+	now := time.Now()
+	if req.ExpiresAt <= now.Unix() {
+		return nil, errors.New("expires_at must be in the future")
+	}
+	if req.ExpiresAt > now.AddDate(0, 0, maxReservationDays).Unix() {
+		return nil, errors.New("a reservation cannot last more than 90 days")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("a reservation needs at least one item")
+	}
+	res := &domain.Reservation{
+		ID:            utils.GenerateUUID(),
+		BusinessID:    businessID,
+		BranchID:      branch.ID,
+		Reference:     reference,
+		CustomerID:    req.CustomerID,
+		CustomerPhone: strings.TrimSpace(req.CustomerPhone),
+		Note:          utils.Sanitize(req.Note),
+		Status:        domain.ReservationStatusActive,
+		ExpiresAt:     req.ExpiresAt,
+		CreatedBy:     userID,
+		CreatedAt:     now.Unix(),
+		UpdatedAt:     now.Unix(),
+	}
+	lines := map[string]int{}
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		if i, ok := lines[it.ProductID]; ok {
+			res.Items[i].Quantity += it.Quantity
+			continue
+		}
+		product, err := u.ProductRepo.GetProductByID(it.ProductID)
+		if err != nil || product.BusinessID != businessID || product.DeletedAt != nil {
+			return nil, errors.New("product not found")
+		}
+		lines[it.ProductID] = len(res.Items)
+		res.Items = append(res.Items, domain.ReservationItem{
+			ProductID:   product.ID,
+			ProductName: product.ProductName,
+			Quantity:    it.Quantity,
+		})
+	}
+	if err := u.ReservationRepo.CreateReservation(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (u *ReservationUsecase) GetReservations(businessID, branchID, status string, limit, offset int) ([]*domain.Reservation, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	switch status {
+	case "", domain.ReservationStatusActive, domain.ReservationStatusFulfilled, domain.ReservationStatusReleased, domain.ReservationStatusExpired:
+	default:
+		return nil, errors.New("status must be active, fulfilled, released or expired")
+	}
+	return u.ReservationRepo.GetReservations(businessID, branchID, status, limit, offset)
+}
+
+// FindReservation returns a reservation of the business
+func (u *ReservationUsecase) FindReservation(id, businessID string) (*domain.Reservation, error) {
+	res, err := u.ReservationRepo.GetReservationByID(id)
+	if err != nil || res.BusinessID != businessID {
+		return nil, domain.ErrReservationNotFound
+	}
+	return res, nil
+}
+
+// ReleaseReservation cancels an active reservation and puts its units back on sale
+func (u *ReservationUsecase) ReleaseReservation(id, businessID, userID string) (*domain.Reservation, error) {
+	res, err := u.FindReservation(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	return u.ReservationRepo.ReleaseReservation(res.ID, userID, time.Now().Unix())
+}
+
+// RunExpiryJob marks reservations that have lapsed as expired each interval. Lapsed
+// reservations stop holding stock the moment they expire; the job only records it.
+// It never returns.
+func (u *ReservationUsecase) RunExpiryJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired, err := u.ReservationRepo.ExpireReservations(time.Now().Unix())
+		if err != nil {
+			utils.Logger.Error("Expiring reservations failed", zap.Error(err))
+			continue
+		}
+		if expired > 0 {
+			utils.Logger.Info("Expired lapsed reservations", zap.Int("reservations", expired))
+		}
+	}
+}
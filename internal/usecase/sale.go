@@ -1,43 +1,224 @@
 package usecase
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
 )
 
 type SaleUsecase struct {
 	SaleRepo       domain.SaleRepository
 	ProductRepo    domain.ProductRepository
+	SettingsRepo   domain.SettingsRepository
+	TaxRepo        domain.TaxRepository
+	PromotionRepo  domain.PromotionRepository
+	CustomerRepo   domain.CustomerRepository
+	ShiftRepo      domain.ShiftRepository
+	StaffRepo      domain.StaffRepository
+	BusinessRepo   domain.BusinessRepository
 	NotificationUC *NotificationUsecase
 }
 
 type SaleItemRequest struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string           `json:"product_id"`
+	Quantity  int              `json:"quantity"`
+	Discount  *domain.Discount `json:"discount,omitempty"`
 }
 
+// SalePaymentRequest is one tender; for cash, amount is what the customer handed over
+type SalePaymentRequest struct {
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// CreateSaleRequest takes either payments (one or more tenders) or, for older clients,
+// a single payment_method that pays the total exactly. Running promotions are applied
+// first, then line discounts, then the basket discount; any discount needs a discount_reason.
+// The buyer is identified by customer_id or, for loyalty members, customer_phone; with the
+// loyalty programme enabled they earn points and may pay with a "loyalty" tender, and
+// debtors may put some or all of the sale on their account with a "credit" tender.
 type CreateSaleRequest struct {
-	BranchID      string            `json:"branch_id"`
-	PaymentMethod string            `json:"payment_method"`
-	Items         []SaleItemRequest `json:"items"`
+	BranchID       string               `json:"branch_id"`
+	CustomerID     *string              `json:"customer_id,omitempty"`
+	CustomerPhone  string               `json:"customer_phone,omitempty"`
+	PaymentMethod  string               `json:"payment_method"`
+	Payments       []SalePaymentRequest `json:"payments"`
+	Items          []SaleItemRequest    `json:"items"`
+	Discount       *domain.Discount     `json:"discount,omitempty"`
+	DiscountReason string               `json:"discount_reason"`
+	// Reservation being collected; its units are released into this sale, which must carry
+	// exactly the reserved products and quantities
+	ReservationID *string `json:"reservation_id,omitempty"`
+	// Optional client-generated UUID for the sale. Sending the sale again with the same ID
+	// returns the sale already recorded instead of selling twice.
+	SaleID string `json:"sale_id,omitempty"`
 }
 
 type CreateSaleResponse struct {
-	Success     bool    `json:"success"`
-	SaleID      string  `json:"sale_id"`
-	TotalAmount float64 `json:"total_amount"`
+	Success         bool                 `json:"success"`
+	SaleID          string               `json:"sale_id"`
+	ReceiptNumber   int                  `json:"receipt_number"`
+	ShiftID         *string              `json:"shift_id,omitempty"`
+	GrossAmount     float64              `json:"gross_amount"`
+	PromotionAmount float64              `json:"promotion_amount"`
+	DiscountAmount  float64              `json:"discount_amount"`
+	TaxAmount       float64              `json:"tax_amount"`
+	TotalAmount     float64              `json:"total_amount"`
+	ChangeDue       float64              `json:"change_due"`
+	PointsEarned    int                  `json:"points_earned"`
+	PointsRedeemed  int                  `json:"points_redeemed"`
+	Payments        []domain.SalePayment `json:"payments"`
+	// Units of each product an offline sale sold beyond the stock on hand, by product ID
+	Oversold map[string]int `json:"oversold,omitempty"`
+	// Set when the sale had already been recorded under the requested sale ID
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// CreateSale records a sale rung up by cashierID, whose role sets how much discount they may give.
+// The sale joins the cashier's open shift at the branch; businesses that require a shift
+// reject sales from cashiers without one.
+func (u *SaleUsecase) CreateSale(req *CreateSaleRequest, businessID, cashierID string, role domain.StaffRole) (*CreateSaleResponse, error) {
+	saleID := uuid.NewString()
+	if req.SaleID != "" {
+		if _, err := uuid.Parse(req.SaleID); err != nil {
+			return nil, errors.New("sale id must be a valid UUID")
+		}
+		saleID = req.SaleID
+		// A retry is answered before anything, such as a closed shift, could refuse it
+		if resp, err := u.recordedSale(saleID, businessID, cashierID); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	var shiftID *string
+	shift, err := u.ShiftRepo.GetOpenShift(cashierID)
+	switch {
+	case err == nil && shift.BranchID == req.BranchID:
+		shiftID = &shift.ID
+	case err != nil && !errors.Is(err, domain.ErrNoOpenShift):
+		return nil, err
+	case settings.RequireOpenShift:
+		return nil, fmt.Errorf("%w: open a shift at this branch before selling", domain.ErrNoOpenShift)
+	}
+	return u.createSale(req, businessID, cashierID, role, saleID, time.Now().Unix(), shiftID, false)
 }
 
-func (u *SaleUsecase) CreateSale(req *CreateSaleRequest, businessID, cashierID string) (*CreateSaleResponse, error) {
+// ImportSale records a sale that was rung up offline, keeping the client-generated
+// sale ID and the time the sale actually happened at the till. The till has already
+// handed the goods over, so units that have sold out since are recorded as oversold.
+func (u *SaleUsecase) ImportSale(req *CreateSaleRequest, businessID, cashierID string, role domain.StaffRole, saleID string, createdAt int64) (*CreateSaleResponse, error) {
+	if _, err := uuid.Parse(saleID); err != nil {
+		return nil, errors.New("sale id must be a valid UUID")
+	}
+	if createdAt <= 0 {
+		createdAt = time.Now().Unix()
+	}
+	// An offline sale joins the cashier's shift only if it is still open and was open when the sale was made
+	var shiftID *string
+	if shift, err := u.ShiftRepo.GetOpenShift(cashierID); err == nil && shift.BranchID == req.BranchID && shift.OpenedAt <= createdAt {
+		shiftID = &shift.ID
+	}
+	return u.createSale(req, businessID, cashierID, role, saleID, createdAt, shiftID, true)
+}
+
+// GetSale returns a sale with its items
+func (u *SaleUsecase) GetSale(saleID string) (*domain.Sale, error) {
+	sale, err := u.SaleRepo.GetSaleByID(saleID)
+	if err != nil {
+		return nil, errors.New("sale not found")
+	}
+	return sale, nil
+}
+
+// VoidSaleRequest gives the reason a sale is being voided
+type VoidSaleRequest struct {
+	Reason string `json:"reason"`
+}
+
+// VoidSale cancels a completed sale rung up today and puts its stock back.
+// When ownOnly is set the user may only void sales they rang up themselves.
+func (u *SaleUsecase) VoidSale(saleID, businessID, userID, reason string, ownOnly bool) (*domain.Sale, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	reason = utils.Sanitize(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	sale, err := u.SaleRepo.GetSaleByID(saleID)
+	if err != nil || sale.BusinessID != businessID {
+		return nil, errors.New("sale not found")
+	}
+	if ownOnly && sale.CashierID != userID {
+		return nil, errors.New("you can only void your own sales")
+	}
+	if sale.Status != domain.SaleStatusCompleted {
+		return nil, errors.New("only completed sales can be voided")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if sale.CreatedAt < settings.StartOfDay(now).Unix() {
+		return nil, errors.New("sales can only be voided on the day they were made")
+	}
+	return u.SaleRepo.VoidSale(sale.ID, userID, reason, now.Unix())
+}
+
+func (u *SaleUsecase) createSale(req *CreateSaleRequest, businessID, cashierID string, role domain.StaffRole, saleID string, createdAt int64, shiftID *string, offline bool) (*CreateSaleResponse, error) {
 	if businessID == "" || cashierID == "" {
 		return nil, errors.New("unauthorized")
 	}
-	if req.BranchID == "" || req.PaymentMethod == "" || len(req.Items) == 0 {
+	if req.BranchID == "" || (req.PaymentMethod == "" && len(req.Payments) == 0) || len(req.Items) == 0 {
 		return nil, errors.New("invalid input")
 	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := buildPayments(req, settings)
+	if err != nil {
+		return nil, err
+	}
+	tax, err := loadTaxPolicy(u.TaxRepo, settings)
+	if err != nil {
+		return nil, err
+	}
+	promotions, err := loadRunningPromotions(u.PromotionRepo, businessID, req.BranchID, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := u.findBuyer(req, businessID)
+	if err != nil {
+		return nil, err
+	}
+	var customerID *string
+	var loyalty *domain.LoyaltyPolicy
+	if customer != nil {
+		customerID = &customer.ID
+		loyalty = settings.LoyaltyPolicy()
+	}
+	for _, p := range payments {
+		if p.Method == domain.PaymentMethodLoyalty && customer == nil {
+			return nil, fmt.Errorf("%w: paying with points needs a loyalty customer", domain.ErrInvalidPayment)
+		}
+		if p.Method == domain.PaymentMethodCredit && customer == nil {
+			return nil, fmt.Errorf("%w: a credit sale needs a customer", domain.ErrInvalidPayment)
+		}
+	}
 	var items []domain.SaleItem
 	for _, item := range req.Items {
 		if item.Quantity <= 0 {
@@ -47,23 +228,74 @@ func (u *SaleUsecase) CreateSale(req *CreateSaleRequest, businessID, cashierID s
 			ID:        uuid.NewString(),
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
+			Discount:  item.Discount,
 		})
 	}
 	sale := &domain.Sale{
-		ID:            uuid.NewString(),
-		BusinessID:    businessID,
-		BranchID:      req.BranchID,
-		CashierID:     cashierID,
-		TotalAmount:   0,
-		PaymentMethod: req.PaymentMethod,
-		Status:        "completed",
-		CreatedAt:     time.Now().Unix(),
+		ID:             saleID,
+		BusinessID:     businessID,
+		BranchID:       req.BranchID,
+		CashierID:      cashierID,
+		CustomerID:     customerID,
+		ShiftID:        shiftID,
+		ReservationID:  req.ReservationID,
+		AllowOversell:  offline,
+		TotalAmount:    0,
+		PaymentMethod:  payments[0].Method,
+		Status:         domain.SaleStatusCompleted,
+		CreatedAt:      createdAt,
+		Payments:       payments,
+		Discount:       req.Discount,
+		DiscountReason: utils.Sanitize(req.DiscountReason),
+	}
+	if len(payments) > 1 {
+		sale.PaymentMethod = domain.PaymentMethodSplit
+	}
+	pricing := &domain.SalePricing{
+		DiscountLimit: settings.DiscountLimit(role),
+		Tax:           tax,
+		Promotions:    promotions,
+	}
+	if err := u.priceSale(sale, items, pricing); err != nil {
+		return nil, err
+	}
+	// Settle the tenders against the priced total
+	if sale.ChangeDue, err = domain.SettlePayments(sale.TotalAmount, sale.Payments); err != nil {
+		return nil, err
+	}
+	var pointsExpireAt *int64
+	if loyalty != nil {
+		var loyaltyPaid float64
+		for _, p := range sale.Payments {
+			if p.Method == domain.PaymentMethodLoyalty {
+				loyaltyPaid += p.Amount
+			}
+		}
+		sale.PointsRedeemed = loyalty.PointsFor(loyaltyPaid)
+		sale.PointsEarned = loyalty.PointsEarned(sale, items)
+		pointsExpireAt = loyalty.ExpiresAt(time.Now().Unix())
+	}
+	_, total, err := u.SaleRepo.CreateSale(sale, items, pointsExpireAt)
+	if errors.Is(err, domain.ErrSaleExists) {
+		// Recorded by a retry that got there first; the sale transaction checked the ID
+		if resp, rerr := u.recordedSale(saleID, businessID, cashierID); resp != nil || rerr != nil {
+			return resp, rerr
+		}
 	}
-	saleID, total, err := u.SaleRepo.CreateSale(sale, items)
 	if err != nil {
 		return nil, err
 	}
 
+	var oversold map[string]int
+	for _, item := range items {
+		if item.OversoldQuantity > 0 {
+			if oversold == nil {
+				oversold = map[string]int{}
+			}
+			oversold[item.ProductID] += item.OversoldQuantity
+		}
+	}
+
 	// After sale, check for low stock and create notification if needed
 	for _, item := range items {
 		product, err := u.ProductRepo.GetProductByID(item.ProductID)
@@ -75,8 +307,512 @@ func (u *SaleUsecase) CreateSale(req *CreateSaleRequest, businessID, cashierID s
 		}
 	}
 	return &CreateSaleResponse{
-		Success:     true,
-		SaleID:      saleID,
-		TotalAmount: total,
+		Success:         true,
+		SaleID:          sale.ID,
+		ReceiptNumber:   sale.ReceiptNumber,
+		ShiftID:         sale.ShiftID,
+		GrossAmount:     sale.GrossAmount,
+		PromotionAmount: sale.PromotionAmount,
+		DiscountAmount:  sale.DiscountAmount,
+		TaxAmount:       sale.TaxAmount,
+		TotalAmount:     total,
+		ChangeDue:       sale.ChangeDue,
+		PointsEarned:    sale.PointsEarned,
+		PointsRedeemed:  sale.PointsRedeemed,
+		Payments:        sale.Payments,
+		Oversold:        oversold,
 	}, nil
 }
+
+// recordedSale answers a retried sale: it returns the sale already stored under saleID, or
+// nil when there is none yet. An ID used by another business or cashier is refused.
+func (u *SaleUsecase) recordedSale(saleID, businessID, cashierID string) (*CreateSaleResponse, error) {
+	sale, err := u.SaleRepo.GetSaleByID(saleID)
+	if err != nil {
+		// Not recorded, as far as we can tell; the sale transaction checks the ID again
+		return nil, nil
+	}
+	if sale.BusinessID != businessID || sale.CashierID != cashierID {
+		return nil, domain.ErrSaleExists
+	}
+	resp := &CreateSaleResponse{
+		Success:         true,
+		SaleID:          sale.ID,
+		ReceiptNumber:   sale.ReceiptNumber,
+		ShiftID:         sale.ShiftID,
+		GrossAmount:     sale.GrossAmount,
+		PromotionAmount: sale.PromotionAmount,
+		DiscountAmount:  sale.DiscountAmount,
+		TaxAmount:       sale.TaxAmount,
+		TotalAmount:     sale.TotalAmount,
+		ChangeDue:       sale.ChangeDue,
+		PointsEarned:    sale.PointsEarned,
+		PointsRedeemed:  sale.PointsRedeemed,
+		Payments:        sale.Payments,
+		Replayed:        true,
+	}
+	for _, item := range sale.Items {
+		if item.OversoldQuantity > 0 {
+			if resp.Oversold == nil {
+				resp.Oversold = map[string]int{}
+			}
+			resp.Oversold[item.ProductID] += item.OversoldQuantity
+		}
+	}
+	return resp, nil
+}
+
+// priceSale prices items at their products' selling prices under the business's pricing policy
+func (u *SaleUsecase) priceSale(sale *domain.Sale, items []domain.SaleItem, pricing *domain.SalePricing) error {
+	for i := range items {
+		product, err := u.ProductRepo.GetProductByID(items[i].ProductID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		if product.BusinessID != sale.BusinessID {
+			return errors.New("product does not belong to business")
+		}
+		items[i].ListPrice = product.SellingPrice
+		items[i].Category = product.ProductCategory
+		if pricing.Tax != nil {
+			items[i].TaxTreatment, items[i].TaxRate = pricing.Tax.Resolve(product.ID, product.ProductCategory)
+		}
+	}
+	return domain.PriceSale(sale, items, pricing)
+}
+
+// SaleList is one page of sales; NextCursor fetches the next page and is empty on the last
+type SaleList struct {
+	Sales      []*domain.Sale `json:"sales"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Sale list page sizes, used when the filter's limit is unset or too large
+const (
+	defaultSalePageSize = 20
+	maxSalePageSize     = 100
+)
+
+// ListSales returns the business's sales matching the filter, newest first, a page at a time.
+// cursor is the previous page's NextCursor, empty for the first page.
+func (u *SaleUsecase) ListSales(businessID string, filter domain.SaleFilter, cursor string) (*SaleList, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	switch filter.Status {
+	case "", domain.SaleStatusCompleted, domain.SaleStatusPartiallyRefunded, domain.SaleStatusRefunded, domain.SaleStatusVoided:
+	default:
+		return nil, errors.New("status must be completed, partially_refunded, refunded or voided")
+	}
+	if filter.From > 0 && filter.To > 0 && filter.From >= filter.To {
+		return nil, errors.New("from must be before to")
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, errors.New("min_amount cannot be more than max_amount")
+	}
+	if cursor != "" {
+		var err error
+		if filter.AfterCreatedAt, filter.AfterID, err = decodeSaleCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSalePageSize
+	}
+	limit = min(limit, maxSalePageSize)
+	// Fetch one extra sale to tell whether there is another page
+	filter.Limit = limit + 1
+	sales, err := u.SaleRepo.ListSales(businessID, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := &SaleList{Sales: []*domain.Sale{}}
+	if len(sales) > limit {
+		sales = sales[:limit]
+		last := sales[limit-1]
+		list.NextCursor = encodeSaleCursor(last.CreatedAt, last.ID)
+	}
+	list.Sales = append(list.Sales, sales...)
+	return list, nil
+}
+
+// SaleCashier is who rang up a sale
+type SaleCashier struct {
+	ID       string `json:"id"`
+	StaffID  string `json:"staff_id,omitempty"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`
+}
+
+// SaleItemDetails is a sale item with the name of its product
+type SaleItemDetails struct {
+	domain.SaleItem
+	ProductName string `json:"product_name"`
+}
+
+// SaleDetails is a sale with its product names and cashier
+type SaleDetails struct {
+	*domain.Sale
+	Cashier *SaleCashier      `json:"cashier"`
+	Items   []SaleItemDetails `json:"items"`
+}
+
+// GetSaleDetails returns a sale of the business with its items, product names and cashier
+func (u *SaleUsecase) GetSaleDetails(id, businessID string) (*SaleDetails, error) {
+	sale, err := u.SaleRepo.GetSaleByID(id)
+	if err != nil || sale.BusinessID != businessID {
+		return nil, errors.New("sale not found")
+	}
+	business, err := u.BusinessRepo.GetBusinessByID(businessID)
+	if err != nil {
+		return nil, errors.New("business not found")
+	}
+	details := &SaleDetails{
+		Sale:    sale,
+		Cashier: findCashier(business, u.StaffRepo, sale.CashierID),
+		Items:   []SaleItemDetails{},
+	}
+	names := map[string]string{}
+	for _, it := range sale.Items {
+		name, ok := names[it.ProductID]
+		if !ok {
+			if product, err := u.ProductRepo.GetProductByID(it.ProductID); err == nil {
+				name = product.ProductName
+			}
+			names[it.ProductID] = name
+		}
+		details.Items = append(details.Items, SaleItemDetails{SaleItem: it, ProductName: name})
+	}
+	return details, nil
+}
+
+// findCashier looks up who rang up a sale; the owner sells under the business's ID. A
+// cashier who has since been removed keeps their ID with an empty name.
+func findCashier(business *domain.Business, staffRepo domain.StaffRepository, cashierID string) *SaleCashier {
+	if cashierID == business.ID {
+		return &SaleCashier{ID: business.ID, FullName: business.OwnerFullName, Role: string(domain.RoleOwner)}
+	}
+	cashier := &SaleCashier{ID: cashierID}
+	if staff, err := staffRepo.GetStaffByID(cashierID); err == nil {
+		cashier.StaffID = staff.StaffID
+		cashier.FullName = staff.FullName
+		cashier.Role = string(staff.Role)
+	}
+	return cashier
+}
+
+// encodeSaleCursor marks the position after a sale in a newest-first listing
+func encodeSaleCursor(createdAt int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt, 10) + ":" + id))
+}
+
+func decodeSaleCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", errors.New("invalid cursor")
+	}
+	at, id, ok := strings.Cut(string(raw), ":")
+	createdAt, err := strconv.ParseInt(at, 10, 64)
+	if !ok || err != nil || id == "" {
+		return 0, "", errors.New("invalid cursor")
+	}
+	return createdAt, id, nil
+}
+
+// TenderReport is revenue for a period broken down by payment method
+type TenderReport struct {
+	From    int64                `json:"from"`
+	To      int64                `json:"to"`
+	Total   float64              `json:"total"`
+	Tenders []domain.TenderTotal `json:"tenders"`
+}
+
+// RevenueByTender totals the non-voided sales made in [from, to) per payment method, less what
+// was refunded through each method
+func (u *SaleUsecase) RevenueByTender(businessID, branchID string, from, to int64) (*TenderReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if from >= to {
+		return nil, errors.New("from must be before to")
+	}
+	tenders, err := u.SaleRepo.GetRevenueByTender(businessID, branchID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &TenderReport{From: from, To: to, Tenders: []domain.TenderTotal{}}
+	for _, t := range tenders {
+		t.Amount = roundMoney(t.Amount)
+		report.Total += t.Amount
+		report.Tenders = append(report.Tenders, t)
+	}
+	report.Total = roundMoney(report.Total)
+	return report, nil
+}
+
+// DiscountReport lists the discounts each cashier gave over a period
+type DiscountReport struct {
+	From          int64                         `json:"from"`
+	To            int64                         `json:"to"`
+	TotalDiscount float64                       `json:"total_discount"`
+	Cashiers      []domain.CashierDiscountTotal `json:"cashiers"`
+}
+
+// DiscountsByCashier totals the discounts given on non-voided sales made in [from, to) per cashier
+func (u *SaleUsecase) DiscountsByCashier(businessID, branchID string, from, to int64) (*DiscountReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if from >= to {
+		return nil, errors.New("from must be before to")
+	}
+	totals, err := u.SaleRepo.GetDiscountsByCashier(businessID, branchID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &DiscountReport{From: from, To: to, Cashiers: []domain.CashierDiscountTotal{}}
+	for _, t := range totals {
+		t.GrossAmount = roundMoney(t.GrossAmount)
+		t.DiscountAmount = roundMoney(t.DiscountAmount)
+		report.TotalDiscount += t.DiscountAmount
+		report.Cashiers = append(report.Cashiers, t)
+	}
+	report.TotalDiscount = roundMoney(report.TotalDiscount)
+	return report, nil
+}
+
+// TotalSalesToday counts the non-voided sales made since midnight in the business's timezone
+func (u *SaleUsecase) TotalSalesToday(businessID, branchID string) (int, error) {
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return 0, err
+	}
+	return u.SaleRepo.GetTotalSalesSince(businessID, branchID, settings.StartOfDay(time.Now()).Unix())
+}
+
+// Sales report groupings
+const (
+	SalesReportByDay   = "day"
+	SalesReportByWeek  = "week"
+	SalesReportByMonth = "month"
+)
+
+// maxSalesReportDays bounds the range of a sales report
+const maxSalesReportDays = 3 * 366
+
+// SalesReportRequest asks for sales between two local dates (YYYY-MM-DD, both inclusive)
+// in the business's timezone, defaulting to today, grouped by day, week or month
+type SalesReportRequest struct {
+	From      string
+	To        string
+	BranchID  string
+	CashierID string
+	Category  string
+	GroupBy   string
+}
+
+// SalesReportTotals are the takings of a set of sales. Revenue includes tax; gross profit is
+// revenue less tax and the cost of goods sold, and gross margin is that as a percentage of
+// revenue less tax.
+type SalesReportTotals struct {
+	SalesCount    int     `json:"sales_count"`
+	ItemsSold     int     `json:"items_sold"`
+	Revenue       float64 `json:"revenue"`
+	TaxAmount     float64 `json:"tax_amount"`
+	CostOfGoods   float64 `json:"cost_of_goods"`
+	GrossProfit   float64 `json:"gross_profit"`
+	GrossMargin   float64 `json:"gross_margin"`
+	AverageBasket float64 `json:"average_basket"`
+}
+
+func (t *SalesReportTotals) add(s domain.SaleTotal) {
+	t.SalesCount++
+	t.ItemsSold += s.ItemsSold
+	t.Revenue += s.Revenue
+	t.TaxAmount += s.TaxAmount
+	t.CostOfGoods += s.CostOfGoods
+}
+
+func (t *SalesReportTotals) finish() {
+	t.Revenue = roundMoney(t.Revenue)
+	t.TaxAmount = roundMoney(t.TaxAmount)
+	t.CostOfGoods = roundMoney(t.CostOfGoods)
+	t.GrossProfit = roundMoney(t.Revenue - t.TaxAmount - t.CostOfGoods)
+	if net := t.Revenue - t.TaxAmount; net > 0 {
+		t.GrossMargin = roundMoney(t.GrossProfit / net * 100)
+	}
+	if t.SalesCount > 0 {
+		t.AverageBasket = roundMoney(t.Revenue / float64(t.SalesCount))
+	}
+}
+
+// SalesReportPeriod is one day, week (from Monday) or month of a sales report. Period is its
+// first date, or YYYY-MM for months; Start and End (exclusive) are clipped to the report's range.
+type SalesReportPeriod struct {
+	Period string `json:"period"`
+	Start  int64  `json:"start"`
+	End    int64  `json:"end"`
+	SalesReportTotals
+}
+
+// SalesReport is sales over a range of local dates, in total and per period. With a category
+// set, only that category's items count and a sale's basket is what it took in the category.
+type SalesReport struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Timezone  string `json:"timezone"`
+	GroupBy   string `json:"group_by"`
+	BranchID  string `json:"branch_id,omitempty"`
+	CashierID string `json:"cashier_id,omitempty"`
+	Category  string `json:"category,omitempty"`
+	SalesReportTotals
+	Periods []*SalesReportPeriod `json:"periods"`
+}
+
+// SalesReport totals the non-voided sales made between the requested local dates, with a
+// row for every period in the range, including those without sales
+func (u *SaleUsecase) SalesReport(businessID string, req *SalesReportRequest) (*SalesReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if req.GroupBy == "" {
+		req.GroupBy = SalesReportByDay
+	}
+	if req.GroupBy != SalesReportByDay && req.GroupBy != SalesReportByWeek && req.GroupBy != SalesReportByMonth {
+		return nil, errors.New("group_by must be day, week or month")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location()
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from, to := today, today
+	if req.From != "" {
+		if from, err = time.ParseInLocation("2006-01-02", req.From, loc); err != nil {
+			return nil, errors.New("from must be a date (YYYY-MM-DD)")
+		}
+	}
+	if req.To != "" {
+		if to, err = time.ParseInLocation("2006-01-02", req.To, loc); err != nil {
+			return nil, errors.New("to must be a date (YYYY-MM-DD)")
+		}
+	}
+	if to.Before(from) {
+		return nil, errors.New("from must be on or before to")
+	}
+	end := to.AddDate(0, 0, 1)
+	if end.After(from.AddDate(0, 0, maxSalesReportDays)) {
+		return nil, fmt.Errorf("date range cannot be longer than %d days", maxSalesReportDays)
+	}
+
+	report := &SalesReport{
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		Timezone:  loc.String(),
+		GroupBy:   req.GroupBy,
+		BranchID:  req.BranchID,
+		CashierID: req.CashierID,
+		Category:  strings.TrimSpace(req.Category),
+		Periods:   []*SalesReportPeriod{},
+	}
+	start := from
+	switch req.GroupBy {
+	case SalesReportByWeek:
+		start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	case SalesReportByMonth:
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	for start.Before(end) {
+		next := start.AddDate(0, 0, 1)
+		label := start.Format("2006-01-02")
+		switch req.GroupBy {
+		case SalesReportByWeek:
+			next = start.AddDate(0, 0, 7)
+		case SalesReportByMonth:
+			next = start.AddDate(0, 1, 0)
+			label = start.Format("2006-01")
+		}
+		p := &SalesReportPeriod{Period: label, Start: start.Unix(), End: next.Unix()}
+		if start.Before(from) {
+			p.Start = from.Unix()
+		}
+		if next.After(end) {
+			p.End = end.Unix()
+		}
+		report.Periods = append(report.Periods, p)
+		start = next
+	}
+
+	totals, err := u.SaleRepo.GetSaleTotals(businessID, domain.SaleReportFilter{
+		From:      from.Unix(),
+		To:        end.Unix(),
+		BranchID:  report.BranchID,
+		CashierID: report.CashierID,
+		Category:  report.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range totals {
+		report.add(s)
+		i := sort.Search(len(report.Periods), func(i int) bool { return report.Periods[i].End > s.CreatedAt })
+		if i < len(report.Periods) {
+			report.Periods[i].add(s)
+		}
+	}
+	report.finish()
+	for _, p := range report.Periods {
+		p.finish()
+	}
+	return report, nil
+}
+
+// findBuyer resolves the customer a sale is for, by ID or by phone number; nil when none is given
+func (u *SaleUsecase) findBuyer(req *CreateSaleRequest, businessID string) (*domain.Customer, error) {
+	var customer *domain.Customer
+	var err error
+	switch {
+	case req.CustomerID != nil && *req.CustomerID != "":
+		customer, err = u.CustomerRepo.GetCustomerByID(*req.CustomerID)
+	case req.CustomerPhone != "":
+		phone := normalizePhone(req.CustomerPhone)
+		if phone == "" {
+			return nil, errors.New("customer not found")
+		}
+		customer, err = u.CustomerRepo.GetCustomerByPhone(businessID, phone)
+	default:
+		return nil, nil
+	}
+	if err != nil || customer.BusinessID != businessID {
+		return nil, errors.New("customer not found")
+	}
+	return customer, nil
+}
+
+// buildPayments turns the request's tenders into sale payments, checking each method
+// against the business's accepted payment methods
+func buildPayments(req *CreateSaleRequest, settings *domain.BusinessSettings) ([]domain.SalePayment, error) {
+	requested := req.Payments
+	if len(requested) == 0 {
+		requested = []SalePaymentRequest{{Method: req.PaymentMethod}}
+	}
+	var payments []domain.SalePayment
+	for _, p := range requested {
+		method := strings.ToLower(utils.Sanitize(p.Method))
+		if !settings.AcceptsPaymentMethod(method) {
+			return nil, fmt.Errorf("%w: payment method %q is not accepted", domain.ErrInvalidPayment, p.Method)
+		}
+		if len(req.Payments) > 0 && p.Amount <= 0 {
+			return nil, fmt.Errorf("%w: payment amounts must be greater than 0", domain.ErrInvalidPayment)
+		}
+		payments = append(payments, domain.SalePayment{
+			Method:    method,
+			Tendered:  p.Amount,
+			Reference: utils.Sanitize(p.Reference),
+		})
+	}
+	return payments, nil
+}
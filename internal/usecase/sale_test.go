@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+)
+
+// pagedSaleRepo holds n sales and returns up to the filter's limit of them
+type pagedSaleRepo struct {
+	domain.SaleRepository
+	n     int
+	limit int
+}
+
+func (r *pagedSaleRepo) ListSales(businessID string, filter domain.SaleFilter) ([]*domain.Sale, error) {
+	r.limit = filter.Limit
+	var sales []*domain.Sale
+	for i := 0; i < r.n && i < filter.Limit; i++ {
+		sales = append(sales, &domain.Sale{ID: fmt.Sprintf("sale-%d", i), CreatedAt: int64(1000 - i)})
+	}
+	return sales, nil
+}
+
+func TestListSalesPageSize(t *testing.T) {
+	for _, tt := range []struct {
+		limit, want int
+	}{
+		{0, defaultSalePageSize},
+		{-5, defaultSalePageSize},
+		{10, 10},
+		{1000, maxSalePageSize},
+	} {
+		repo := &pagedSaleRepo{n: 500}
+		list, err := (&SaleUsecase{SaleRepo: repo}).ListSales("business-1", domain.SaleFilter{Limit: tt.limit}, "")
+		if err != nil {
+			t.Fatalf("ListSales(limit %d) error = %v", tt.limit, err)
+		}
+		if len(list.Sales) != tt.want || list.NextCursor == "" {
+			t.Errorf("ListSales(limit %d) = %d sales, cursor %q; want %d and a next page", tt.limit, len(list.Sales), list.NextCursor, tt.want)
+		}
+		if repo.limit != tt.want+1 {
+			t.Errorf("ListSales(limit %d) asked the repository for %d, want %d", tt.limit, repo.limit, tt.want+1)
+		}
+	}
+}
+
+// storedSaleRepo holds one recorded sale
+type storedSaleRepo struct {
+	domain.SaleRepository
+	sale *domain.Sale
+}
+
+func (r storedSaleRepo) GetSaleByID(id string) (*domain.Sale, error) {
+	if r.sale == nil || r.sale.ID != id {
+		return nil, errors.New("record not found")
+	}
+	return r.sale, nil
+}
+
+func TestCreateSaleRetriedWithSaleID(t *testing.T) {
+	const saleID = "6f1c2b8e-3d4a-4f5b-9c6d-7e8f9a0b1c2d"
+	stored := &domain.Sale{ID: saleID, BusinessID: "business-1", CashierID: "user-1", ReceiptNumber: 7, TotalAmount: 50}
+	// Nothing but the sale lookup is wired up: a retry must be answered before pricing or stock
+	uc := &SaleUsecase{SaleRepo: storedSaleRepo{sale: stored}}
+	req := &CreateSaleRequest{SaleID: saleID, BranchID: "branch-1", PaymentMethod: domain.PaymentMethodCash, Items: []SaleItemRequest{{ProductID: "product-1", Quantity: 1}}}
+
+	resp, err := uc.CreateSale(req, "business-1", "user-1", domain.RoleCashier)
+	if err != nil {
+		t.Fatalf("CreateSale() error = %v", err)
+	}
+	if !resp.Replayed || resp.SaleID != saleID || resp.ReceiptNumber != 7 || resp.TotalAmount != 50 {
+		t.Errorf("CreateSale() = %+v, want the recorded sale replayed", resp)
+	}
+	if _, err := uc.CreateSale(req, "business-1", "user-2", domain.RoleCashier); !errors.Is(err, domain.ErrSaleExists) {
+		t.Errorf("another cashier reusing the ID: error = %v, want %v", err, domain.ErrSaleExists)
+	}
+	req.SaleID = "not-a-uuid"
+	if _, err := uc.CreateSale(req, "business-1", "user-1", domain.RoleCashier); err == nil {
+		t.Error("CreateSale() accepted a sale ID that is not a UUID")
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type SettingsUsecase struct {
+	SettingsRepo domain.SettingsRepository
+}
+
+// UpdateSettingsRequest changes only the fields that are present
+type UpdateSettingsRequest struct {
+	RefundApprovalLimit *float64 `json:"refund_approval_limit"`
+	PaymentMethods      []string `json:"payment_methods"`
+	// Replaces the per-role discount limits; roles left out fall back to the defaults
+	MaxDiscountPercent map[domain.StaffRole]float64 `json:"max_discount_percent"`
+	TaxMode            *string                      `json:"tax_mode"`
+	LoyaltyEnabled     *bool                        `json:"loyalty_enabled"`
+	LoyaltyEarnRate    *float64                     `json:"loyalty_earn_rate"`
+	LoyaltyPointValue  *float64                     `json:"loyalty_point_value"`
+	// Replaces the list of categories that earn no points
+	LoyaltyExcludedCategories []string `json:"loyalty_excluded_categories"`
+	LoyaltyExpiryDays         *int     `json:"loyalty_expiry_days"`
+	RequireOpenShift          *bool    `json:"require_open_shift"`
+	Timezone                  *string  `json:"timezone"`
+}
+
+func (u *SettingsUsecase) GetSettings(businessID string) (*domain.BusinessSettings, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.SettingsRepo.GetSettings(businessID)
+}
+
+func (u *SettingsUsecase) UpdateSettings(businessID string, req *UpdateSettingsRequest, updatedBy string) (*domain.BusinessSettings, error) {
+	if businessID == "" || updatedBy == "" {
+		return nil, errors.New("missing business_id or updated_by")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	if req.RefundApprovalLimit != nil {
+		if *req.RefundApprovalLimit < 0 {
+			return nil, errors.New("refund_approval_limit cannot be negative")
+		}
+		settings.RefundApprovalLimit = *req.RefundApprovalLimit
+	}
+	if req.PaymentMethods != nil {
+		methods, err := normalizePaymentMethods(req.PaymentMethods)
+		if err != nil {
+			return nil, err
+		}
+		settings.PaymentMethods = methods
+	}
+	if req.MaxDiscountPercent != nil {
+		for role, limit := range req.MaxDiscountPercent {
+			if err := validateCustomisableRole(role); err != nil {
+				return nil, err
+			}
+			if limit < 0 || limit > 100 {
+				return nil, errors.New("max_discount_percent must be between 0 and 100")
+			}
+		}
+		settings.MaxDiscountPercent = req.MaxDiscountPercent
+	}
+	if req.TaxMode != nil {
+		if *req.TaxMode != domain.TaxModeInclusive && *req.TaxMode != domain.TaxModeExclusive {
+			return nil, errors.New("tax_mode must be inclusive or exclusive")
+		}
+		settings.TaxMode = *req.TaxMode
+	}
+	if req.LoyaltyEnabled != nil {
+		settings.LoyaltyEnabled = *req.LoyaltyEnabled
+	}
+	if req.LoyaltyEarnRate != nil {
+		if *req.LoyaltyEarnRate < 0 {
+			return nil, errors.New("loyalty_earn_rate cannot be negative")
+		}
+		settings.LoyaltyEarnRate = *req.LoyaltyEarnRate
+	}
+	if req.LoyaltyPointValue != nil {
+		if *req.LoyaltyPointValue <= 0 {
+			return nil, errors.New("loyalty_point_value must be greater than 0")
+		}
+		settings.LoyaltyPointValue = roundMoney(*req.LoyaltyPointValue)
+	}
+	if req.LoyaltyExcludedCategories != nil {
+		var categories []string
+		for _, c := range req.LoyaltyExcludedCategories {
+			c = utils.Sanitize(c)
+			if strings.Contains(c, ",") {
+				return nil, errors.New("invalid category: " + c)
+			}
+			if c != "" {
+				categories = append(categories, c)
+			}
+		}
+		settings.LoyaltyExcludedCategories = categories
+	}
+	if req.LoyaltyExpiryDays != nil {
+		if *req.LoyaltyExpiryDays < 0 {
+			return nil, errors.New("loyalty_expiry_days cannot be negative")
+		}
+		settings.LoyaltyExpiryDays = *req.LoyaltyExpiryDays
+	}
+	if req.RequireOpenShift != nil {
+		settings.RequireOpenShift = *req.RequireOpenShift
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return nil, errors.New("invalid timezone: " + *req.Timezone)
+		}
+		settings.Timezone = *req.Timezone
+	}
+	settings.UpdatedBy = updatedBy
+	settings.UpdatedAt = time.Now().Unix()
+	if err := u.SettingsRepo.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// normalizePaymentMethods lower-cases and de-duplicates a payment method list
+func normalizePaymentMethods(methods []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, m := range methods {
+		m = strings.ToLower(utils.Sanitize(m))
+		if m == "" || strings.Contains(m, ",") || len(m) > 32 {
+			return nil, errors.New("invalid payment method: " + m)
+		}
+		if m == domain.PaymentMethodSplit {
+			return nil, errors.New("split is reserved for sales paid with several tenders")
+		}
+		if m == domain.PaymentMethodLoyalty {
+			return nil, errors.New("loyalty is accepted automatically while the loyalty programme is enabled")
+		}
+		if m == domain.PaymentMethodCredit {
+			return nil, errors.New("credit is accepted automatically for customers with a credit account")
+		}
+		if !seen[m] {
+			seen[m] = true
+			result = append(result, m)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("at least one payment method is required")
+	}
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type ShiftUsecase struct {
+	ShiftRepo  domain.ShiftRepository
+	BranchRepo domain.BranchRepository
+}
+
+// OpenShiftRequest starts a till session with the cash already in the drawer
+type OpenShiftRequest struct {
+	BranchID     string  `json:"branch_id"`
+	OpeningFloat float64 `json:"opening_float"`
+}
+
+// CashMovementRequest records cash put into (cash_in) or taken out of (cash_out) the drawer
+type CashMovementRequest struct {
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// CloseShiftRequest ends a till session with the cash counted in the drawer
+type CloseShiftRequest struct {
+	CountedCash *float64 `json:"counted_cash"`
+	Note        string   `json:"note"`
+}
+
+// OpenShift starts a shift for the cashier at a branch; a cashier has at most one open shift
+func (u *ShiftUsecase) OpenShift(req *OpenShiftRequest, businessID, cashierID string) (*domain.Shift, error) {
+	if businessID == "" || cashierID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	branch, err := u.BranchRepo.GetBranchByID(req.BranchID)
+	if err != nil || branch.BusinessID != businessID {
+		return nil, errors.New("branch not found")
+	}
+	if req.OpeningFloat < 0 {
+		return nil, errors.New("opening_float cannot be negative")
+	}
+	shift := &domain.Shift{
+		ID:           utils.GenerateUUID(),
+		BusinessID:   businessID,
+		BranchID:     branch.ID,
+		CashierID:    cashierID,
+		Status:       domain.ShiftStatusOpen,
+		OpeningFloat: roundMoney(req.OpeningFloat),
+		OpenedAt:     time.Now().Unix(),
+	}
+	if err := u.ShiftRepo.OpenShift(shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// CurrentShift returns the cashier's open shift with the cash taken so far
+func (u *ShiftUsecase) CurrentShift(cashierID string) (*domain.Shift, error) {
+	shift, err := u.ShiftRepo.GetOpenShift(cashierID)
+	if err != nil {
+		return nil, err
+	}
+	return u.withDetails(shift)
+}
+
+// GetShift returns a shift with its cash movements and cash summary.
+// When ownOnly is set the user may only see their own shifts.
+func (u *ShiftUsecase) GetShift(id, businessID, userID string, ownOnly bool) (*domain.Shift, error) {
+	shift, err := u.getShift(id, businessID, userID, ownOnly)
+	if err != nil {
+		return nil, err
+	}
+	return u.withDetails(shift)
+}
+
+func (u *ShiftUsecase) GetShifts(businessID, branchID, status string, limit, offset int) ([]*domain.Shift, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if status != "" && status != domain.ShiftStatusOpen && status != domain.ShiftStatusClosed {
+		return nil, errors.New("status must be open or closed")
+	}
+	return u.ShiftRepo.GetShifts(businessID, branchID, status, limit, offset)
+}
+
+// RecordCashMovement records cash added to or removed from an open shift's drawer
+func (u *ShiftUsecase) RecordCashMovement(id, businessID, userID string, ownOnly bool, req *CashMovementRequest) (*domain.CashMovement, error) {
+	shift, err := u.getShift(id, businessID, userID, ownOnly)
+	if err != nil {
+		return nil, err
+	}
+	if req.Type != domain.CashMovementIn && req.Type != domain.CashMovementOut {
+		return nil, errors.New("type must be cash_in or cash_out")
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	reason := utils.Sanitize(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	m := &domain.CashMovement{
+		ID:         utils.GenerateUUID(),
+		ShiftID:    shift.ID,
+		BusinessID: shift.BusinessID,
+		BranchID:   shift.BranchID,
+		Type:       req.Type,
+		Amount:     roundMoney(req.Amount),
+		Reason:     reason,
+		RecordedBy: userID,
+		CreatedAt:  time.Now().Unix(),
+	}
+	if err := u.ShiftRepo.AddCashMovement(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CloseShift ends a shift with the counted cash and reports the variance against what the
+// drawer should hold
+func (u *ShiftUsecase) CloseShift(id, businessID, userID string, ownOnly bool, req *CloseShiftRequest) (*domain.Shift, error) {
+	shift, err := u.getShift(id, businessID, userID, ownOnly)
+	if err != nil {
+		return nil, err
+	}
+	if req.CountedCash == nil {
+		return nil, errors.New("counted_cash is required")
+	}
+	if *req.CountedCash < 0 {
+		return nil, errors.New("counted_cash cannot be negative")
+	}
+	return u.ShiftRepo.CloseShift(shift.ID, userID, *req.CountedCash, utils.Sanitize(req.Note), time.Now().Unix())
+}
+
+// FindShift returns a shift of the business without its details
+func (u *ShiftUsecase) FindShift(id, businessID string) (*domain.Shift, error) {
+	shift, err := u.ShiftRepo.GetShiftByID(id)
+	if err != nil || shift.BusinessID != businessID {
+		return nil, domain.ErrShiftNotFound
+	}
+	return shift, nil
+}
+
+func (u *ShiftUsecase) getShift(id, businessID, userID string, ownOnly bool) (*domain.Shift, error) {
+	shift, err := u.FindShift(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if ownOnly && shift.CashierID != userID {
+		return nil, errors.New("you can only manage your own shifts")
+	}
+	return shift, nil
+}
+
+func (u *ShiftUsecase) withDetails(shift *domain.Shift) (*domain.Shift, error) {
+	summary, err := u.ShiftRepo.GetCashSummary(shift)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := u.ShiftRepo.GetCashMovements(shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	if movements == nil {
+		movements = []*domain.CashMovement{}
+	}
+	shift.Summary = summary
+	shift.Movements = movements
+	return shift, nil
+}
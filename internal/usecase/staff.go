@@ -70,7 +70,13 @@ func (u *StaffUsecase) Login(staffID, password string, authRepo domain.AuthRepos
 		return "", "", "", "", errors.New("invalid credentials")
 	}
 
-	access, refresh, err := authRepo.CreateToken(staff.ID, string(staff.Role))
+	access, refresh, err := authRepo.CreateToken(&domain.AuthIdentity{
+		UserID:     staff.ID,
+		BusinessID: staff.BusinessID,
+		BranchID:   staff.BranchID,
+		UserType:   domain.UserTypeStaff,
+		Role:       string(staff.Role),
+	})
 	if err != nil {
 		return "", "", "", "", errors.New("token generation failed")
 	}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+type StockUsecase struct {
+	StockMovementRepo domain.StockMovementRepository
+	ProductRepo       domain.ProductRepository
+	LotRepo           domain.LotRepository
+}
+
+// ReceiveLotRequest books a batch of stock received outside a purchase order
+type ReceiveLotRequest struct {
+	BatchNumber string   `json:"batch_number"`
+	ExpiryDate  *int64   `json:"expiry_date"`
+	Quantity    int      `json:"quantity"`
+	CostPrice   *float64 `json:"cost_price"`
+	Note        string   `json:"note"`
+}
+
+// StockAdjustmentRequest is a manual stock correction; write-offs must reduce stock
+type StockAdjustmentRequest struct {
+	Delta int    `json:"delta"`
+	Type  string `json:"type"` // adjustment (default) or write_off
+	Note  string `json:"note"`
+}
+
+func (u *StockUsecase) GetProductMovements(productID string, limit, offset int) ([]*domain.StockMovement, error) {
+	if productID == "" {
+		return nil, errors.New("missing product_id")
+	}
+	return u.StockMovementRepo.GetMovementsByProduct(productID, limit, offset)
+}
+
+// AdjustStock applies a manual correction to a product's stock and returns the new quantity
+func (u *StockUsecase) AdjustStock(productID, businessID, userID string, req *StockAdjustmentRequest) (int, error) {
+	if businessID == "" || userID == "" {
+		return 0, errors.New("unauthorized")
+	}
+	if req.Delta == 0 {
+		return 0, errors.New("delta must not be zero")
+	}
+	movementType := req.Type
+	if movementType == "" {
+		movementType = domain.StockMovementAdjustment
+	}
+	switch movementType {
+	case domain.StockMovementAdjustment:
+	case domain.StockMovementWriteOff:
+		if req.Delta > 0 {
+			return 0, errors.New("a write-off must reduce stock")
+		}
+	default:
+		return 0, errors.New("invalid adjustment type")
+	}
+	change := domain.StockChange{
+		Type:          movementType,
+		ActorID:       userID,
+		ReferenceType: "manual",
+		Note:          utils.Sanitize(req.Note),
+	}
+	return u.ProductRepo.AdjustProductStock(productID, businessID, req.Delta, change)
+}
+
+// ReceiveLot adds a batch of stock to a product; the cost defaults to the product's cost price
+func (u *StockUsecase) ReceiveLot(product *domain.Product, userID string, req *ReceiveLotRequest) (*domain.ProductLot, error) {
+	if userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	batch := utils.Sanitize(req.BatchNumber)
+	if batch == "" {
+		return nil, errors.New("batch_number is required")
+	}
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	cost := product.CostPrice
+	if req.CostPrice != nil {
+		if *req.CostPrice < 0 {
+			return nil, errors.New("cost price cannot be negative")
+		}
+		cost = *req.CostPrice
+	}
+	return u.LotRepo.ReceiveLot(&domain.LotReceipt{
+		ProductID:   product.ID,
+		BatchNumber: batch,
+		ExpiryDate:  req.ExpiryDate,
+		Quantity:    req.Quantity,
+		CostPrice:   cost,
+		Change: domain.StockChange{
+			Type:          domain.StockMovementReceipt,
+			ActorID:       userID,
+			ReferenceType: "lot",
+			ReferenceID:   batch,
+			Note:          utils.Sanitize(req.Note),
+		},
+	})
+}
+
+func (u *StockUsecase) GetProductLots(productID string, includeEmpty bool) ([]*domain.ProductLot, error) {
+	return u.LotRepo.GetLotsByProduct(productID, includeEmpty)
+}
+
+// Reconcile lists the products of a business whose ledger does not sum to the quantity in stock
+// or whose stock has gone below zero
+func (u *StockUsecase) Reconcile(businessID string) ([]*domain.StockDiscrepancy, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.StockMovementRepo.FindDiscrepancies(businessID)
+}
+
+// RunReconciliationJob checks the ledger of every business each interval and logs any
+// product whose on-hand quantity has drifted from it. It never returns.
+func (u *StockUsecase) RunReconciliationJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		discrepancies, err := u.StockMovementRepo.FindDiscrepancies("")
+		if err != nil {
+			utils.Logger.Error("Stock reconciliation failed", zap.Error(err))
+			continue
+		}
+		for _, d := range discrepancies {
+			utils.Logger.Warn("Stock ledger does not match quantity in stock",
+				zap.String("businessID", d.BusinessID),
+				zap.String("branchID", d.BranchID),
+				zap.String("productID", d.ProductID),
+				zap.Int("quantityInStock", d.QuantityInStock),
+				zap.Int("ledgerQuantity", d.LedgerQuantity))
+		}
+		utils.Logger.Info("Stock reconciliation finished", zap.Int("discrepancies", len(discrepancies)))
+	}
+}
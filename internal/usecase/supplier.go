@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type SupplierUsecase struct {
+	SupplierRepo domain.SupplierRepository
+}
+
+func (u *SupplierUsecase) CreateSupplier(s *domain.Supplier) error {
+	if s.BusinessID == "" {
+		return errors.New("missing business_id")
+	}
+	sanitizeSupplier(s)
+	if s.Name == "" {
+		return errors.New("supplier name is required")
+	}
+	s.ID = utils.GenerateUUID()
+	now := time.Now().Unix()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return u.SupplierRepo.CreateSupplier(s)
+}
+
+func (u *SupplierUsecase) GetSupplier(id, businessID string) (*domain.Supplier, error) {
+	s, err := u.SupplierRepo.GetSupplierByID(id)
+	if err != nil || s.BusinessID != businessID {
+		return nil, errors.New("supplier not found")
+	}
+	return s, nil
+}
+
+func (u *SupplierUsecase) GetSuppliers(businessID string) ([]*domain.Supplier, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.SupplierRepo.GetSuppliersByBusinessID(businessID)
+}
+
+func (u *SupplierUsecase) UpdateSupplier(s *domain.Supplier) error {
+	existing, err := u.GetSupplier(s.ID, s.BusinessID)
+	if err != nil {
+		return err
+	}
+	sanitizeSupplier(s)
+	if s.Name == "" {
+		return errors.New("supplier name is required")
+	}
+	s.CreatedAt = existing.CreatedAt
+	s.UpdatedAt = time.Now().Unix()
+	return u.SupplierRepo.UpdateSupplier(s)
+}
+
+func (u *SupplierUsecase) DeleteSupplier(id, businessID string) error {
+	if _, err := u.GetSupplier(id, businessID); err != nil {
+		return err
+	}
+	return u.SupplierRepo.DeleteSupplier(id)
+}
+
+func sanitizeSupplier(s *domain.Supplier) {
+	s.Name = utils.Sanitize(s.Name)
+	s.ContactName = utils.Sanitize(s.ContactName)
+	s.Phone = utils.Sanitize(s.Phone)
+	s.Email = utils.Sanitize(s.Email)
+	s.Address = utils.Sanitize(s.Address)
+}
@@ -1,8 +1,446 @@
 package usecase
 
-type SyncUsecase struct{}
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
 
-func (u *SyncUsecase) SyncData() error {
-	// TODO: Implement offline-first sync logic
-	return nil
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type SyncUsecase struct {
+	SyncRepo    domain.SyncRepository
+	SaleRepo    domain.SaleRepository
+	ProductRepo domain.ProductRepository
+	SaleUC      *SaleUsecase
+	ProductUC   *ProductUsecase
+}
+
+// SyncRequest is a batch of operations queued on a till while offline,
+// plus the checkpoint returned by the previous successful sync
+type SyncRequest struct {
+	DeviceID         string                `json:"device_id"`
+	BranchID         string                `json:"branch_id"`
+	LastCheckpoint   int64                 `json:"last_checkpoint"`
+	Sales            []SyncSale            `json:"sales"`
+	ProductEdits     []SyncProductEdit     `json:"product_edits"`
+	StockAdjustments []SyncStockAdjustment `json:"stock_adjustments"`
+}
+
+// SyncSale is a sale rung up offline; ID is the client-generated sale UUID
+type SyncSale struct {
+	ID             string               `json:"id"`
+	BranchID       string               `json:"branch_id"`
+	CustomerID     *string              `json:"customer_id,omitempty"`
+	CustomerPhone  string               `json:"customer_phone,omitempty"`
+	PaymentMethod  string               `json:"payment_method"`
+	Payments       []SalePaymentRequest `json:"payments"`
+	Items          []SaleItemRequest    `json:"items"`
+	Discount       *domain.Discount     `json:"discount,omitempty"`
+	DiscountReason string               `json:"discount_reason"`
+	CreatedAt      int64                `json:"created_at"`
+}
+
+// SyncProductFields holds the editable product fields; nil means "not changed"
+type SyncProductFields struct {
+	ProductName       *string  `json:"product_name,omitempty"`
+	ProductCategory   *string  `json:"product_category,omitempty"`
+	SellingPrice      *float64 `json:"selling_price,omitempty"`
+	CostPrice         *float64 `json:"cost_price,omitempty"`
+	LowStockThreshold *int     `json:"low_stock_threshold,omitempty"`
+	BarcodeValue      *string  `json:"barcode_value,omitempty"`
+	NAFDACRegNumber   *string  `json:"nafdac_reg_number,omitempty"`
+	ExpiryDate        *int64   `json:"expiry_date,omitempty"`
+}
+
+// SyncProductEdit carries the new values of the edited fields together with the
+// values the client saw before editing, so concurrent server edits can be detected per field.
+// Stock is never edited this way; use stock adjustments instead.
+type SyncProductEdit struct {
+	ID        string            `json:"id"`
+	ProductID string            `json:"product_id"`
+	Changes   SyncProductFields `json:"changes"`
+	Base      SyncProductFields `json:"base"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+// SyncStockAdjustment is a relative stock change (count correction, damage, etc.)
+type SyncStockAdjustment struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	Delta     int    `json:"delta"`
+	Reason    string `json:"reason"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// SyncResult reports the outcome of a single uploaded record
+type SyncResult struct {
+	ClientID     string      `json:"client_id"`
+	Type         string      `json:"type"`
+	Status       string      `json:"status"`
+	Message      string      `json:"message,omitempty"`
+	Replayed     bool        `json:"replayed,omitempty"`
+	ServerRecord interface{} `json:"server_record,omitempty"`
+}
+
+// SyncChanges holds everything changed on the server since the client's checkpoint
+type SyncChanges struct {
+	Products []*domain.Product `json:"products"`
+	Sales    []*domain.Sale    `json:"sales"`
+}
+
+type SyncResponse struct {
+	Checkpoint int64        `json:"checkpoint"`
+	Results    []SyncResult `json:"results"`
+	Changes    SyncChanges  `json:"changes"`
+}
+
+// syncOp is one uploaded record, ordered on a single timeline before being applied
+type syncOp struct {
+	at     int64
+	opType string
+	id     string
+	apply  func() SyncResult
+}
+
+// syncOpOrder fixes the processing order of records that share a timestamp
+var syncOpOrder = map[string]int{
+	domain.SyncOpStockAdjustment: 0,
+	domain.SyncOpProductEdit:     1,
+	domain.SyncOpSale:            2,
+}
+
+// SyncData applies the client's queued operations and returns the server-side changes
+// since its last checkpoint.
+//
+// Operations from all record types are applied in client timestamp order (ties broken
+// by type, then by client ID) so the same batch always resolves the same way. A record
+// that cannot be applied is reported back individually and never fails the batch:
+//   - sales are recorded even if they oversell, since the till has already handed the goods over;
+//     the units beyond the stock on hand take the product below zero, where stock reconciliation
+//     flags it until the stock is counted, and are reported back in the result
+//   - product edits keep the server value for any field changed on the server since the client read it
+//   - stock adjustments that would take stock below zero or under the reserved units are
+//     rejected as conflicts
+//
+// Applied and conflicting outcomes are recorded by record type and client ID, so retrying
+// an upload replays the original result instead of applying it twice.
+//
+// Product edits and stock adjustments are only applied when canUpdateProducts is set, i.e.
+// the caller holds product.update, and only to products in the caller's branch scope.
+func (u *SyncUsecase) SyncData(req *SyncRequest, businessID, userID string, role domain.StaffRole, scopeBranchID string, canUpdateProducts bool) (*SyncResponse, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	if req.LastCheckpoint < 0 {
+		return nil, errors.New("invalid checkpoint")
+	}
+	branchID := req.BranchID
+	if scopeBranchID != "" {
+		if branchID != "" && branchID != scopeBranchID {
+			return nil, errors.New("branch_id does not match your branch")
+		}
+		branchID = scopeBranchID
+	}
+	// Checkpoint is taken before anything is applied so nothing written during
+	// this sync can fall between this pull and the next one
+	checkpoint := time.Now().Unix()
+
+	var ops []syncOp
+	for i := range req.StockAdjustments {
+		adj := req.StockAdjustments[i]
+		ops = append(ops, syncOp{adj.CreatedAt, domain.SyncOpStockAdjustment, adj.ID, func() SyncResult {
+			return u.applyStockAdjustment(&adj, businessID, userID, scopeBranchID, canUpdateProducts)
+		}})
+	}
+	for i := range req.ProductEdits {
+		edit := req.ProductEdits[i]
+		ops = append(ops, syncOp{edit.CreatedAt, domain.SyncOpProductEdit, edit.ID, func() SyncResult {
+			return u.applyProductEdit(&edit, businessID, userID, scopeBranchID, canUpdateProducts)
+		}})
+	}
+	for i := range req.Sales {
+		sale := req.Sales[i]
+		if sale.BranchID == "" {
+			sale.BranchID = branchID
+		}
+		ops = append(ops, syncOp{sale.CreatedAt, domain.SyncOpSale, sale.ID, func() SyncResult {
+			return u.applySale(&sale, businessID, userID, role, scopeBranchID)
+		}})
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].at != ops[j].at {
+			return ops[i].at < ops[j].at
+		}
+		if ops[i].opType != ops[j].opType {
+			return syncOpOrder[ops[i].opType] < syncOpOrder[ops[j].opType]
+		}
+		return ops[i].id < ops[j].id
+	})
+
+	results := make([]SyncResult, 0, len(ops))
+	for _, op := range ops {
+		result := u.replayOrApply(businessID, req.DeviceID, op)
+		results = append(results, result)
+	}
+
+	products, err := u.ProductRepo.GetProductsUpdatedSince(businessID, branchID, req.LastCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := u.SaleRepo.GetSalesUpdatedSince(businessID, branchID, req.LastCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncResponse{
+		Checkpoint: checkpoint,
+		Results:    results,
+		Changes: SyncChanges{
+			Products: products,
+			Sales:    sales,
+		},
+	}, nil
+}
+
+func (u *SyncUsecase) replayOrApply(businessID, deviceID string, op syncOp) SyncResult {
+	if op.id == "" || len(op.id) > 64 {
+		return SyncResult{ClientID: op.id, Type: op.opType, Status: domain.SyncStatusRejected, Message: "client id is required (max 64 characters)"}
+	}
+	if existing, _ := u.SyncRepo.GetOperation(businessID, op.opType, op.id); existing != nil {
+		return SyncResult{
+			ClientID: op.id,
+			Type:     existing.OpType,
+			Status:   existing.Status,
+			Message:  existing.Message,
+			Replayed: true,
+		}
+	}
+
+	result := op.apply()
+
+	// Rejected records changed nothing, so the client may fix and resend them under the same ID
+	if result.Status != domain.SyncStatusRejected {
+		err := u.SyncRepo.RecordOperation(&domain.SyncOperation{
+			ID:         op.id,
+			BusinessID: businessID,
+			DeviceID:   utils.Sanitize(deviceID),
+			OpType:     op.opType,
+			Status:     result.Status,
+			Message:    result.Message,
+			CreatedAt:  time.Now().Unix(),
+		})
+		if err != nil {
+			utils.Logger.Warn("Failed to record sync operation", utils.ZapError(err))
+		}
+	}
+	return result
+}
+
+func (u *SyncUsecase) applySale(s *SyncSale, businessID, userID string, role domain.StaffRole, scopeBranchID string) SyncResult {
+	result := SyncResult{ClientID: s.ID, Type: domain.SyncOpSale}
+
+	// The sale may have been written by an earlier upload whose response never reached the till
+	if existing, _ := u.SaleRepo.GetSaleByID(s.ID); existing != nil {
+		if existing.BusinessID != businessID {
+			result.Status = domain.SyncStatusRejected
+			result.Message = "sale id already in use"
+			return result
+		}
+		result.Status = domain.SyncStatusApplied
+		result.Replayed = true
+		result.ServerRecord = existing
+		return result
+	}
+	if scopeBranchID != "" && s.BranchID != scopeBranchID {
+		result.Status = domain.SyncStatusRejected
+		result.Message = "branch_id does not match your branch"
+		return result
+	}
+
+	saleReq := &CreateSaleRequest{
+		BranchID:       s.BranchID,
+		CustomerID:     s.CustomerID,
+		CustomerPhone:  s.CustomerPhone,
+		PaymentMethod:  s.PaymentMethod,
+		Payments:       s.Payments,
+		Items:          s.Items,
+		Discount:       s.Discount,
+		DiscountReason: s.DiscountReason,
+	}
+	resp, err := u.SaleUC.ImportSale(saleReq, businessID, userID, role, s.ID, s.CreatedAt)
+	if err != nil {
+		result.Message = err.Error()
+		if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrProductRecalled) || errors.Is(err, domain.ErrInsufficientPoints) || errors.Is(err, domain.ErrCreditLimitExceeded) || errors.Is(err, domain.ErrReservationNotActive) || errors.Is(err, domain.ErrReservationMismatch) {
+			result.Status = domain.SyncStatusConflict
+		} else {
+			result.Status = domain.SyncStatusRejected
+		}
+		return result
+	}
+	result.Status = domain.SyncStatusApplied
+	result.Replayed = resp.Replayed
+	result.ServerRecord = resp
+	if len(resp.Oversold) > 0 {
+		result.Message = "sale oversold stock; the products are below zero until their stock is reconciled"
+	}
+	return result
+}
+
+func (u *SyncUsecase) applyProductEdit(e *SyncProductEdit, businessID, userID, scopeBranchID string, canUpdateProducts bool) SyncResult {
+	result := SyncResult{ClientID: e.ID, Type: domain.SyncOpProductEdit}
+
+	existing, err := u.ProductRepo.GetProductByID(e.ProductID)
+	if err != nil || existing.BusinessID != businessID {
+		result.Status = domain.SyncStatusRejected
+		result.Message = "product not found"
+		return result
+	}
+	if msg := syncProductForbidden(existing, scopeBranchID, canUpdateProducts); msg != "" {
+		result.Status = domain.SyncStatusRejected
+		result.Message = msg
+		return result
+	}
+
+	updated := *existing
+	var conflicts []string
+
+	// mergeField applies a client change unless the server value moved away from the
+	// value the client based its edit on; in that case the server value is kept
+	mergeField := func(name string, hasBase, serverEqualsBase, serverEqualsNew bool, apply func()) {
+		if hasBase && !serverEqualsBase && !serverEqualsNew {
+			conflicts = append(conflicts, name)
+			return
+		}
+		apply()
+	}
+
+	c, b := e.Changes, e.Base
+	if c.ProductName != nil {
+		mergeField("product_name", b.ProductName != nil,
+			b.ProductName != nil && existing.ProductName == *b.ProductName, existing.ProductName == *c.ProductName,
+			func() { updated.ProductName = *c.ProductName })
+	}
+	if c.ProductCategory != nil {
+		mergeField("product_category", b.ProductCategory != nil,
+			b.ProductCategory != nil && existing.ProductCategory == *b.ProductCategory, existing.ProductCategory == *c.ProductCategory,
+			func() { updated.ProductCategory = *c.ProductCategory })
+	}
+	if c.SellingPrice != nil {
+		mergeField("selling_price", b.SellingPrice != nil,
+			b.SellingPrice != nil && existing.SellingPrice == *b.SellingPrice, existing.SellingPrice == *c.SellingPrice,
+			func() { updated.SellingPrice = *c.SellingPrice })
+	}
+	if c.CostPrice != nil {
+		mergeField("cost_price", b.CostPrice != nil,
+			b.CostPrice != nil && existing.CostPrice == *b.CostPrice, existing.CostPrice == *c.CostPrice,
+			func() { updated.CostPrice = *c.CostPrice })
+	}
+	if c.LowStockThreshold != nil {
+		mergeField("low_stock_threshold", b.LowStockThreshold != nil,
+			b.LowStockThreshold != nil && existing.LowStockThreshold == *b.LowStockThreshold, existing.LowStockThreshold == *c.LowStockThreshold,
+			func() { updated.LowStockThreshold = *c.LowStockThreshold })
+	}
+	if c.BarcodeValue != nil {
+		mergeField("barcode_value", b.BarcodeValue != nil,
+			b.BarcodeValue != nil && equalStringPtr(existing.BarcodeValue, *b.BarcodeValue), equalStringPtr(existing.BarcodeValue, *c.BarcodeValue),
+			func() { v := *c.BarcodeValue; updated.BarcodeValue = &v })
+	}
+	if c.NAFDACRegNumber != nil {
+		mergeField("nafdac_reg_number", b.NAFDACRegNumber != nil,
+			b.NAFDACRegNumber != nil && equalStringPtr(existing.NAFDACRegNumber, *b.NAFDACRegNumber), equalStringPtr(existing.NAFDACRegNumber, *c.NAFDACRegNumber),
+			func() { v := *c.NAFDACRegNumber; updated.NAFDACRegNumber = &v })
+	}
+	if c.ExpiryDate != nil {
+		mergeField("expiry_date", b.ExpiryDate != nil,
+			b.ExpiryDate != nil && equalInt64Ptr(existing.ExpiryDate, *b.ExpiryDate), equalInt64Ptr(existing.ExpiryDate, *c.ExpiryDate),
+			func() { v := *c.ExpiryDate; updated.ExpiryDate = &v })
+	}
+
+	updated.UpdatedBy = &userID
+	if err := u.ProductUC.UpdateProduct(&updated); err != nil {
+		result.Status = domain.SyncStatusRejected
+		result.Message = err.Error()
+		return result
+	}
+
+	if len(conflicts) > 0 {
+		result.Status = domain.SyncStatusConflict
+		result.Message = "server value kept for: " + strings.Join(conflicts, ", ")
+	} else {
+		result.Status = domain.SyncStatusApplied
+	}
+	result.ServerRecord = &updated
+	return result
+}
+
+func (u *SyncUsecase) applyStockAdjustment(a *SyncStockAdjustment, businessID, userID, scopeBranchID string, canUpdateProducts bool) SyncResult {
+	result := SyncResult{ClientID: a.ID, Type: domain.SyncOpStockAdjustment}
+	if a.Delta == 0 {
+		result.Status = domain.SyncStatusRejected
+		result.Message = "delta must not be zero"
+		return result
+	}
+	product, err := u.ProductRepo.GetProductByID(a.ProductID)
+	if err != nil || product.BusinessID != businessID {
+		result.Status = domain.SyncStatusRejected
+		result.Message = "product not found"
+		return result
+	}
+	if msg := syncProductForbidden(product, scopeBranchID, canUpdateProducts); msg != "" {
+		result.Status = domain.SyncStatusRejected
+		result.Message = msg
+		return result
+	}
+
+	change := domain.StockChange{
+		Type:          domain.StockMovementAdjustment,
+		ActorID:       userID,
+		ReferenceType: "sync",
+		ReferenceID:   a.ID,
+		Note:          utils.Sanitize(a.Reason),
+	}
+	newStock, err := u.ProductRepo.AdjustProductStock(a.ProductID, businessID, a.Delta, change)
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			result.Status = domain.SyncStatusConflict
+			result.Message = err.Error()
+			result.ServerRecord = map[string]interface{}{"product_id": a.ProductID, "quantity_in_stock": newStock}
+			return result
+		}
+		result.Status = domain.SyncStatusRejected
+		result.Message = err.Error()
+		return result
+	}
+	result.Status = domain.SyncStatusApplied
+	result.ServerRecord = map[string]interface{}{"product_id": a.ProductID, "quantity_in_stock": newStock}
+	return result
+}
+
+func equalStringPtr(p *string, v string) bool {
+	if p == nil {
+		return v == ""
+	}
+	return *p == v
+}
+
+func equalInt64Ptr(p *int64, v int64) bool {
+	if p == nil {
+		return v == 0
+	}
+	return *p == v
+}
+
+// syncProductForbidden applies the checks UpdateProductHandler makes to a queued product
+// edit or stock adjustment, returning why the caller may not change product, or "" if they may
+func syncProductForbidden(product *domain.Product, scopeBranchID string, canUpdateProducts bool) string {
+	if !canUpdateProducts {
+		return "forbidden: you do not have permission to update products"
+	}
+	if scopeBranchID != "" && product.BranchID != scopeBranchID {
+		return "forbidden: you can only manage products in your own branch"
+	}
+	return ""
 }
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type TaxUsecase struct {
+	TaxRepo      domain.TaxRepository
+	SettingsRepo domain.SettingsRepository
+	ProductRepo  domain.ProductRepository
+}
+
+// TaxRateRequest is the body for creating or updating a tax rate
+type TaxRateRequest struct {
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	IsDefault bool    `json:"is_default"`
+}
+
+// TaxRuleRequest sets the tax treatment of a product or a category; exactly one of them is given
+type TaxRuleRequest struct {
+	ProductID *string `json:"product_id"`
+	Category  *string `json:"category"`
+	Treatment string  `json:"treatment"`
+	TaxRateID *string `json:"tax_rate_id"`
+}
+
+// TaxSummary is the tax collected over a period, for filing
+type TaxSummary struct {
+	From      int64                   `json:"from"`
+	To        int64                   `json:"to"`
+	TaxMode   string                  `json:"tax_mode"`
+	NetAmount float64                 `json:"net_amount"`
+	TaxAmount float64                 `json:"tax_amount"`
+	Lines     []domain.TaxSummaryLine `json:"lines"`
+}
+
+func (u *TaxUsecase) CreateTaxRate(businessID string, req *TaxRateRequest) (*domain.TaxRate, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	rate := &domain.TaxRate{
+		ID:         utils.GenerateUUID(),
+		BusinessID: businessID,
+		Name:       utils.Sanitize(req.Name),
+		Rate:       req.Rate,
+		IsDefault:  req.IsDefault,
+	}
+	if err := validateTaxRate(rate); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	rate.CreatedAt = now
+	rate.UpdatedAt = now
+	if err := u.TaxRepo.CreateTaxRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (u *TaxUsecase) GetTaxRates(businessID string) ([]*domain.TaxRate, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.TaxRepo.GetTaxRatesByBusinessID(businessID)
+}
+
+func (u *TaxUsecase) UpdateTaxRate(id, businessID string, req *TaxRateRequest) (*domain.TaxRate, error) {
+	rate, err := u.getTaxRate(id, businessID)
+	if err != nil {
+		return nil, err
+	}
+	rate.Name = utils.Sanitize(req.Name)
+	rate.Rate = req.Rate
+	rate.IsDefault = req.IsDefault
+	if err := validateTaxRate(rate); err != nil {
+		return nil, err
+	}
+	rate.UpdatedAt = time.Now().Unix()
+	if err := u.TaxRepo.UpdateTaxRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (u *TaxUsecase) DeleteTaxRate(id, businessID string) error {
+	if _, err := u.getTaxRate(id, businessID); err != nil {
+		return err
+	}
+	return u.TaxRepo.DeleteTaxRate(id)
+}
+
+// SaveTaxRule creates or replaces the tax rule for a product or a category
+func (u *TaxUsecase) SaveTaxRule(businessID string, req *TaxRuleRequest) (*domain.TaxRule, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	rule := &domain.TaxRule{
+		ID:         utils.GenerateUUID(),
+		BusinessID: businessID,
+		ProductID:  sanitizeOptional(req.ProductID),
+		Category:   sanitizeOptional(req.Category),
+		Treatment:  req.Treatment,
+	}
+	if (rule.ProductID == nil) == (rule.Category == nil) {
+		return nil, errors.New("give either product_id or category")
+	}
+	if rule.ProductID != nil {
+		product, err := u.ProductRepo.GetProductByID(*rule.ProductID)
+		if err != nil || product.BusinessID != businessID {
+			return nil, errors.New("product not found")
+		}
+	}
+	switch rule.Treatment {
+	case domain.TaxTreatmentStandard:
+		if req.TaxRateID != nil && *req.TaxRateID != "" {
+			if _, err := u.getTaxRate(*req.TaxRateID, businessID); err != nil {
+				return nil, err
+			}
+			rule.TaxRateID = req.TaxRateID
+		}
+	case domain.TaxTreatmentZeroRated, domain.TaxTreatmentExempt:
+	default:
+		return nil, errors.New("treatment must be standard, zero_rated or exempt")
+	}
+	now := time.Now().Unix()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := u.TaxRepo.SaveTaxRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (u *TaxUsecase) GetTaxRules(businessID string) ([]*domain.TaxRule, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.TaxRepo.GetTaxRulesByBusinessID(businessID)
+}
+
+func (u *TaxUsecase) DeleteTaxRule(id, businessID string) error {
+	rule, err := u.TaxRepo.GetTaxRuleByID(id)
+	if err != nil || rule.BusinessID != businessID {
+		return domain.ErrTaxRuleNotFound
+	}
+	return u.TaxRepo.DeleteTaxRule(id)
+}
+
+// Summary totals the tax on sales made in [from, to), net of refunds, by treatment and rate
+func (u *TaxUsecase) Summary(businessID, branchID string, from, to int64) (*TaxSummary, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	if from >= to {
+		return nil, errors.New("from must be before to")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := u.TaxRepo.GetTaxSummary(businessID, branchID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	summary := &TaxSummary{From: from, To: to, TaxMode: settings.TaxMode, Lines: []domain.TaxSummaryLine{}}
+	for _, l := range lines {
+		l.NetAmount = roundMoney(l.NetAmount)
+		l.TaxAmount = roundMoney(l.TaxAmount)
+		summary.NetAmount += l.NetAmount
+		summary.TaxAmount += l.TaxAmount
+		summary.Lines = append(summary.Lines, l)
+	}
+	summary.NetAmount = roundMoney(summary.NetAmount)
+	summary.TaxAmount = roundMoney(summary.TaxAmount)
+	return summary, nil
+}
+
+func (u *TaxUsecase) getTaxRate(id, businessID string) (*domain.TaxRate, error) {
+	rate, err := u.TaxRepo.GetTaxRateByID(id)
+	if err != nil || rate.BusinessID != businessID {
+		return nil, errors.New("tax rate not found")
+	}
+	return rate, nil
+}
+
+// loadTaxPolicy builds the tax policy a business's sales are priced under
+func loadTaxPolicy(taxRepo domain.TaxRepository, settings *domain.BusinessSettings) (*domain.TaxPolicy, error) {
+	rates, err := taxRepo.GetTaxRatesByBusinessID(settings.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := taxRepo.GetTaxRulesByBusinessID(settings.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewTaxPolicy(settings.TaxMode, rates, rules), nil
+}
+
+func validateTaxRate(rate *domain.TaxRate) error {
+	if rate.Name == "" {
+		return errors.New("name is required")
+	}
+	if rate.Rate < 0 || rate.Rate > 100 {
+		return errors.New("rate must be between 0 and 100")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type TransferUsecase struct {
+	TransferRepo domain.TransferRepository
+	ProductRepo  domain.ProductRepository
+	BranchRepo   domain.BranchRepository
+}
+
+type TransferItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	// Optional; by default the destination product is the one with the same barcode
+	DestProductID string `json:"dest_product_id,omitempty"`
+}
+
+type CreateTransferRequest struct {
+	FromBranchID string                `json:"from_branch_id"`
+	ToBranchID   string                `json:"to_branch_id"`
+	Note         string                `json:"note"`
+	Items        []TransferItemRequest `json:"items"`
+}
+
+type ReceiveTransferItemRequest struct {
+	ItemID   string `json:"item_id"`
+	Quantity int    `json:"quantity"`
+}
+
+type ReceiveTransferRequest struct {
+	Items []ReceiveTransferItemRequest `json:"items"`
+	// Close the transfer even if not everything arrived; the shortfall is reported as a discrepancy
+	Close bool `json:"close"`
+}
+
+// CreateTransfer drafts a transfer, matching each source product to the product with the
+// same barcode in the destination branch. No stock moves until the transfer is dispatched.
+func (u *TransferUsecase) CreateTransfer(req *CreateTransferRequest, businessID, userID string) (*domain.Transfer, error) {
+	if businessID == "" || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	if req.FromBranchID == "" || req.ToBranchID == "" || len(req.Items) == 0 {
+		return nil, errors.New("invalid input")
+	}
+	if req.FromBranchID == req.ToBranchID {
+		return nil, errors.New("source and destination branch must differ")
+	}
+	for _, id := range []string{req.FromBranchID, req.ToBranchID} {
+		branch, err := u.BranchRepo.GetBranchByID(id)
+		if err != nil || branch.BusinessID != businessID {
+			return nil, errors.New("branch not found")
+		}
+	}
+
+	now := time.Now().Unix()
+	t := &domain.Transfer{
+		ID:           uuid.NewString(),
+		BusinessID:   businessID,
+		FromBranchID: req.FromBranchID,
+		ToBranchID:   req.ToBranchID,
+		Status:       domain.TransferStatusDraft,
+		Note:         utils.Sanitize(req.Note),
+		CreatedBy:    userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	seen := map[string]bool{}
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		if seen[it.ProductID] {
+			return nil, errors.New("duplicate product in transfer")
+		}
+		seen[it.ProductID] = true
+
+		source, err := u.ProductRepo.GetProductByID(it.ProductID)
+		if err != nil || source.BusinessID != businessID || source.BranchID != req.FromBranchID {
+			return nil, errors.New("product not found in source branch")
+		}
+		dest, err := u.matchDestProduct(source, req.ToBranchID, it.DestProductID)
+		if err != nil {
+			return nil, err
+		}
+		t.Items = append(t.Items, domain.TransferItem{
+			ID:              uuid.NewString(),
+			TransferID:      t.ID,
+			SourceProductID: source.ID,
+			DestProductID:   dest.ID,
+			BarcodeValue:    source.BarcodeValue,
+			ProductName:     source.ProductName,
+			Quantity:        it.Quantity,
+		})
+	}
+	if err := u.TransferRepo.CreateTransfer(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (u *TransferUsecase) matchDestProduct(source *domain.Product, toBranchID, destProductID string) (*domain.Product, error) {
+	if destProductID != "" {
+		dest, err := u.ProductRepo.GetProductByID(destProductID)
+		if err != nil || dest.BusinessID != source.BusinessID || dest.BranchID != toBranchID {
+			return nil, errors.New("destination product not found in destination branch")
+		}
+		return dest, nil
+	}
+	if source.BarcodeValue == nil || *source.BarcodeValue == "" {
+		return nil, errors.New("product " + source.ProductName + " has no barcode; specify dest_product_id")
+	}
+	dest, err := u.ProductRepo.GetProductByBarcode(source.BusinessID, toBranchID, *source.BarcodeValue)
+	if err != nil {
+		return nil, errors.New("no product with barcode " + *source.BarcodeValue + " in destination branch")
+	}
+	return dest, nil
+}
+
+func (u *TransferUsecase) GetTransfer(id string) (*domain.Transfer, error) {
+	t, err := u.TransferRepo.GetTransferByID(id)
+	if err != nil {
+		return nil, errors.New("transfer not found")
+	}
+	return t, nil
+}
+
+func (u *TransferUsecase) ListTransfers(businessID, branchID, status string) ([]*domain.Transfer, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.TransferRepo.ListTransfers(businessID, branchID, status)
+}
+
+// ListDiscrepancies returns closed transfers where less arrived than was dispatched
+func (u *TransferUsecase) ListDiscrepancies(businessID, branchID string) ([]*domain.Transfer, error) {
+	transfers, err := u.ListTransfers(businessID, branchID, domain.TransferStatusReceived)
+	if err != nil {
+		return nil, err
+	}
+	result := []*domain.Transfer{}
+	for _, t := range transfers {
+		if t.HasDiscrepancy() {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (u *TransferUsecase) DispatchTransfer(id, userID string) (*domain.Transfer, error) {
+	if userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	return u.TransferRepo.DispatchTransfer(id, userID)
+}
+
+func (u *TransferUsecase) ReceiveTransfer(id, userID string, req *ReceiveTransferRequest) (*domain.Transfer, error) {
+	if userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+	if len(req.Items) == 0 && !req.Close {
+		return nil, errors.New("invalid input")
+	}
+	received := map[string]int{}
+	for _, it := range req.Items {
+		if it.Quantity < 0 {
+			return nil, errors.New("quantity cannot be negative")
+		}
+		received[it.ItemID] += it.Quantity
+	}
+	return u.TransferRepo.ReceiveTransfer(id, userID, received, req.Close)
+}
+
+func (u *TransferUsecase) CancelTransfer(id string) (*domain.Transfer, error) {
+	return u.TransferRepo.CancelTransfer(id)
+}
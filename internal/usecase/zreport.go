@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joshuaolumoye/pos-backend/internal/domain"
+	"github.com/joshuaolumoye/pos-backend/pkg/utils"
+)
+
+type ZReportUsecase struct {
+	ZReportRepo  domain.ZReportRepository
+	SettingsRepo domain.SettingsRepository
+	BranchRepo   domain.BranchRepository
+}
+
+// Preview shows what the branch's next Z-report would hold if it were closed now (an X-report)
+func (u *ZReportUsecase) Preview(businessID, branchID string) (*domain.ZReport, error) {
+	report, err := u.build(businessID, branchID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	report.ID = ""
+	report.ClosedAt = 0
+	return report, nil
+}
+
+// Close ends the branch's trading period: the report is numbered, saved and never changes again
+func (u *ZReportUsecase) Close(businessID, branchID, closedBy string) (*domain.ZReport, error) {
+	if closedBy == "" {
+		return nil, errors.New("unauthorized")
+	}
+	report, err := u.build(businessID, branchID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if report.SalesCount+report.VoidedCount+report.RefundCount == 0 {
+		return nil, errors.New("nothing to report since the last z-report")
+	}
+	report.ClosedBy = closedBy
+	if err := u.ZReportRepo.CreateZReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (u *ZReportUsecase) GetZReport(id, businessID string) (*domain.ZReport, error) {
+	report, err := u.ZReportRepo.GetZReportByID(id)
+	if err != nil || report.BusinessID != businessID {
+		return nil, domain.ErrZReportNotFound
+	}
+	return report, nil
+}
+
+func (u *ZReportUsecase) GetZReports(businessID, branchID string, limit, offset int) ([]*domain.ZReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	return u.ZReportRepo.GetZReports(businessID, branchID, limit, offset)
+}
+
+// build totals the branch's sales and refunds not yet on a Z-report, up to now
+func (u *ZReportUsecase) build(businessID, branchID string, now time.Time) (*domain.ZReport, error) {
+	if businessID == "" {
+		return nil, errors.New("missing business_id")
+	}
+	branch, err := u.BranchRepo.GetBranchByID(branchID)
+	if err != nil || branch.BusinessID != businessID {
+		return nil, errors.New("branch not found")
+	}
+	settings, err := u.SettingsRepo.GetSettings(businessID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location()
+	report := &domain.ZReport{
+		ID:           utils.GenerateUUID(),
+		BusinessID:   businessID,
+		BranchID:     branch.ID,
+		Number:       1,
+		BusinessDate: now.In(loc).Format("2006-01-02"),
+		Timezone:     loc.String(),
+		To:           now.Unix(),
+		ClosedAt:     now.Unix(),
+	}
+	last, err := u.ZReportRepo.GetLastZReport(branch.ID)
+	if err == nil {
+		report.Number = last.Number + 1
+		report.From = last.To
+	} else if !errors.Is(err, domain.ErrZReportNotFound) {
+		return nil, err
+	}
+
+	sales, err := u.ZReportRepo.GetUnreportedSales(branch.ID, report.To)
+	if err != nil {
+		return nil, err
+	}
+	cashiers := map[string]*domain.ZReportLine{}
+	var hours [24]*domain.ZReportLine
+	for _, s := range sales {
+		if s.Status == domain.SaleStatusVoided {
+			report.VoidedCount++
+			continue
+		}
+		report.SalesCount++
+		report.GrossSales += s.GrossAmount
+		report.Promotions += s.PromotionAmount
+		report.Discounts += s.DiscountAmount
+		report.NetSales += s.TotalAmount
+		report.TaxAmount += s.TaxAmount
+
+		c, ok := cashiers[s.CashierID]
+		if !ok {
+			c = &domain.ZReportLine{Kind: domain.ZReportLineCashier, Key: s.CashierID, Label: s.CashierName}
+			cashiers[s.CashierID] = c
+		}
+		c.SalesCount++
+		c.Amount += s.TotalAmount
+
+		hour := time.Unix(s.CreatedAt, 0).In(loc).Hour()
+		if hours[hour] == nil {
+			hours[hour] = &domain.ZReportLine{Kind: domain.ZReportLineHour, Key: fmt.Sprintf("%02d", hour)}
+		}
+		hours[hour].SalesCount++
+		hours[hour].Amount += s.TotalAmount
+	}
+	for _, c := range cashiers {
+		c.Amount = roundMoney(c.Amount)
+		report.Cashiers = append(report.Cashiers, c)
+	}
+	sortZReportLines(report.Cashiers)
+	for _, h := range hours {
+		if h != nil {
+			h.Amount = roundMoney(h.Amount)
+			report.Hours = append(report.Hours, h)
+		}
+	}
+
+	items, err := u.ZReportRepo.GetUnreportedItems(branch.ID, report.To)
+	if err != nil {
+		return nil, err
+	}
+	categories := map[string]*domain.ZReportLine{}
+	var kept float64
+	for _, it := range items {
+		report.ItemsSold += it.Quantity
+		report.CostOfGoods += it.Cost
+		kept += it.Amount - it.TaxAmount
+		report.Items = append(report.Items, &domain.ZReportLine{
+			Kind:     domain.ZReportLineItem,
+			Key:      it.ProductID,
+			Label:    it.ProductName,
+			Quantity: it.Quantity,
+			Amount:   roundMoney(it.Amount),
+			Cost:     roundMoney(it.Cost),
+		})
+		c, ok := categories[it.Category]
+		if !ok {
+			c = &domain.ZReportLine{Kind: domain.ZReportLineCategory, Key: it.Category}
+			categories[it.Category] = c
+		}
+		c.Quantity += it.Quantity
+		c.Amount += it.Amount
+		c.Cost += it.Cost
+	}
+	sortZReportLines(report.Items)
+	for _, c := range categories {
+		c.Amount = roundMoney(c.Amount)
+		c.Cost = roundMoney(c.Cost)
+		report.Categories = append(report.Categories, c)
+	}
+	sortZReportLines(report.Categories)
+
+	tenders, err := u.ZReportRepo.GetUnreportedTenders(branch.ID, report.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenders {
+		report.Tenders = append(report.Tenders, &domain.ZReportLine{
+			Kind:       domain.ZReportLineTender,
+			Key:        t.Method,
+			SalesCount: t.SalesCount,
+			Amount:     roundMoney(t.Amount),
+		})
+	}
+	sortZReportLines(report.Tenders)
+
+	report.RefundCount, report.Refunds, err = u.ZReportRepo.GetUnreportedRefunds(branch.ID, report.To)
+	if err != nil {
+		return nil, err
+	}
+
+	report.GrossSales = roundMoney(report.GrossSales)
+	report.Promotions = roundMoney(report.Promotions)
+	report.Discounts = roundMoney(report.Discounts)
+	report.NetSales = roundMoney(report.NetSales)
+	report.TaxAmount = roundMoney(report.TaxAmount)
+	report.CostOfGoods = roundMoney(report.CostOfGoods)
+	report.GrossProfit = roundMoney(kept - report.CostOfGoods)
+	report.Refunds = roundMoney(report.Refunds)
+	return report, nil
+}
+
+// sortZReportLines puts the biggest amounts first
+func sortZReportLines(lines []*domain.ZReportLine) {
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Amount != lines[j].Amount {
+			return lines[i].Amount > lines[j].Amount
+		}
+		return lines[i].Key < lines[j].Key
+	})
+}
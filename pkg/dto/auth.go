@@ -19,6 +19,8 @@ type RefreshTokenRequest struct {
 type RefreshTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
-	BusinessID   uint   `json:"business_id"` // Changed from string to uint
+	BusinessID   string `json:"business_id"`
+	BranchID     string `json:"branch_id,omitempty"`
+	UserType     string `json:"user_type"`
 	Role         string `json:"role"`
 }
@@ -24,10 +24,13 @@ type ProductResponse struct {
 	SellingPrice    float64 `json:"selling_price"`
 	CostPrice       float64 `json:"cost_price,omitempty"`
 	QuantityLeft    int     `json:"quantity_left"`
-	ProductImageURL *string `json:"product_image_url,omitempty"`
-	BranchID        string  `json:"branch_id,omitempty"`
-	BusinessID      string  `json:"business_id,omitempty"`
-	Message         string  `json:"message,omitempty"`
+	// Units on hand held by reservations, and what is left to sell
+	QuantityReserved  int     `json:"quantity_reserved"`
+	QuantityAvailable int     `json:"quantity_available"`
+	ProductImageURL   *string `json:"product_image_url,omitempty"`
+	BranchID          string  `json:"branch_id,omitempty"`
+	BusinessID        string  `json:"business_id,omitempty"`
+	Message           string  `json:"message,omitempty"`
 }
 
 // ProductListResponse represents a list of products
@@ -40,6 +40,8 @@ type StaffLoginRequest struct {
 type StaffLoginResponse struct {
 	StaffID      string `json:"staff_id"`
 	StaffName    string `json:"staff_name"`
+	BusinessID   string `json:"business_id"`
+	BranchID     string `json:"branch_id"`
 	Role         string `json:"role"`
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
@@ -15,15 +15,21 @@ func InitJWTKey() {
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID     string `json:"user_id"`
+	BusinessID string `json:"business_id"`
+	BranchID   string `json:"branch_id,omitempty"`
+	UserType   string `json:"user_type"`
+	Role       string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID, role string, duration time.Duration) (string, error) {
+func GenerateJWT(userID, businessID, branchID, userType, role string, duration time.Duration) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:     userID,
+		BusinessID: businessID,
+		BranchID:   branchID,
+		UserType:   userType,
+		Role:       role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 		},
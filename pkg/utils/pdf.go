@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDFLine is one line of monospaced text in a PDF
+type PDFLine struct {
+	Text string
+	Bold bool
+}
+
+const (
+	pdfFontSize  = 9.0
+	pdfLeading   = 11.0
+	pdfMargin    = 18.0
+	pdfCharWidth = 0.6 * pdfFontSize // Courier glyphs are 600/1000 em wide
+	pdfMinHeight = 144.0
+)
+
+// TextPDF renders lines of Courier text on a single page cols characters wide and as tall
+// as the text, like a till roll. Characters outside Latin-1 are printed as '?'.
+func TextPDF(lines []PDFLine, cols int) []byte {
+	width := float64(cols)*pdfCharWidth + 2*pdfMargin
+	height := max(float64(len(lines))*pdfLeading+2*pdfMargin, pdfMinHeight)
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n%.2f TL\n%.2f %.2f Td\n", pdfLeading, pdfMargin, height-pdfMargin-pdfFontSize)
+	bold := false
+	fmt.Fprintf(&content, "/F1 %.0f Tf\n", pdfFontSize)
+	for _, l := range lines {
+		if l.Bold != bold {
+			font := "/F1"
+			if l.Bold {
+				font = "/F2"
+			}
+			fmt.Fprintf(&content, "%s %.0f Tf\n", font, pdfFontSize)
+			bold = l.Bold
+		}
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(l.Text))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", width, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string in WinAnsi (Latin-1) encoding
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(c))
+		case c < 0x20 || c > 0xFF || (c >= 0x7F && c < 0xA0):
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(c))
+		}
+	}
+	return b.String()
+}